@@ -115,14 +115,38 @@ func main() {
 	}
 	log.Println("Script revision store initialized with indexes")
 
+	scheduleStore, err := store.NewMongoScheduleStore(mongoClient.Database())
+	if err != nil {
+		log.Fatalf("Failed to initialize schedule store: %v", err)
+	}
+	log.Println("Schedule store initialized with indexes")
+
+	clusterStore, err := store.NewMongoClusterStore(mongoClient.Database())
+	if err != nil {
+		log.Fatalf("Failed to initialize cluster store: %v", err)
+	}
+	log.Println("Cluster store initialized with indexes")
+
+	failureStore, err := store.NewMongoFailureStore(mongoClient.Database())
+	if err != nil {
+		log.Fatalf("Failed to initialize failure store: %v", err)
+	}
+	log.Println("Failure store initialized with indexes")
+
+	requestSampleStore, err := store.NewMongoRequestSampleStore(mongoClient.Database())
+	if err != nil {
+		log.Fatalf("Failed to initialize request sample store: %v", err)
+	}
+	log.Println("Request sample store initialized with indexes")
+
 	// Initialize orchestrator
-	orchestrator := service.NewOrchestrator(cfg, loadTestStore, loadTestRunStore, metricsStore)
+	orchestrator := service.NewOrchestrator(cfg, loadTestStore, loadTestRunStore, scriptRevisionStore, scheduleStore, clusterStore, metricsStore, failureStore, requestSampleStore)
 	orchestrator.Start()
 	log.Println("Orchestrator started")
 
 	// Initialize API handlers
-	handler := api.NewHandler(orchestrator, loadTestStore, loadTestRunStore, scriptRevisionStore, cfg)
-	visualizationHandler := api.NewVisualizationHandler(loadTestRunStore, metricsStore)
+	handler := api.NewHandler(orchestrator, loadTestStore, loadTestRunStore, scriptRevisionStore, scheduleStore, cfg)
+	visualizationHandler := api.NewVisualizationHandler(loadTestRunStore, metricsStore, requestSampleStore)
 
 	// Setup router
 	router := setupRouter(handler, visualizationHandler)
@@ -201,7 +225,17 @@ func setupRouter(handler *api.Handler, visualizationHandler *api.VisualizationHa
 	v1.HandleFunc("/load-tests/{id}/runs", handler.ListLoadTestRuns).Methods("GET")
 	v1.HandleFunc("/runs", handler.ListLoadTestRuns).Methods("GET")
 	v1.HandleFunc("/runs/{id}", handler.GetLoadTestRun).Methods("GET")
+	v1.HandleFunc("/runs/{id}", handler.UpdateLoadTestRun).Methods("PATCH")
 	v1.HandleFunc("/runs/{id}/stop", handler.StopLoadTestRun).Methods("POST")
+	v1.HandleFunc("/runs/{id}/events", handler.GetLoadTestRunEvents).Methods("GET")
+	v1.HandleFunc("/runs/{id}/failures", handler.GetLoadTestRunFailures).Methods("GET")
+
+	// Schedules
+	v1.HandleFunc("/load-tests/{id}/schedules", handler.CreateSchedule).Methods("POST")
+	v1.HandleFunc("/load-tests/{id}/schedules", handler.ListSchedules).Methods("GET")
+	v1.HandleFunc("/load-tests/{id}/schedules/{scheduleId}", handler.GetSchedule).Methods("GET")
+	v1.HandleFunc("/load-tests/{id}/schedules/{scheduleId}", handler.UpdateSchedule).Methods("PUT")
+	v1.HandleFunc("/load-tests/{id}/schedules/{scheduleId}", handler.DeleteSchedule).Methods("DELETE")
 
 	// Optimized visualization endpoints for dashboard UI
 	v1.HandleFunc("/runs/{id}/graph", visualizationHandler.GetRunGraph).Methods("GET")
@@ -213,6 +247,13 @@ func setupRouter(handler *api.Handler, visualizationHandler *api.VisualizationHa
 	v1.HandleFunc("/runs/{id}/metrics/scatter", visualizationHandler.GetScatterPlot).Methods("GET")
 	v1.HandleFunc("/runs/{id}/metrics/aggregate", visualizationHandler.GetAggregatedStats).Methods("GET")
 
+	// Locust cluster registry
+	v1.HandleFunc("/clusters", handler.CreateCluster).Methods("POST")
+	v1.HandleFunc("/clusters", handler.ListClusters).Methods("GET")
+	v1.HandleFunc("/clusters/{id}", handler.GetCluster).Methods("GET")
+	v1.HandleFunc("/clusters/{id}", handler.UpdateCluster).Methods("PUT")
+	v1.HandleFunc("/clusters/{id}", handler.DeleteCluster).Methods("DELETE")
+
 	// Swagger documentation
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
@@ -221,6 +262,7 @@ func setupRouter(handler *api.Handler, visualizationHandler *api.VisualizationHa
 	internal.HandleFunc("/test-start", handler.LocustCallbackTestStart).Methods("POST")
 	internal.HandleFunc("/test-stop", handler.LocustCallbackTestStop).Methods("POST")
 	internal.HandleFunc("/metrics", handler.LocustCallbackMetrics).Methods("POST")
+	internal.HandleFunc("/requests", handler.LocustCallbackRequests).Methods("POST")
 	internal.HandleFunc("/register-external", handler.RegisterExternalTest).Methods("POST")
 
 	// Log all registered routes
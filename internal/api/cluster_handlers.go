@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// CreateCluster godoc
+// @Summary Register a Locust cluster
+// @Description Registers a Locust cluster for an account, org, project and optional environment. The cluster is used for new runs right away
+// @Tags Clusters
+// @Accept json
+// @Produce json
+// @Param request body CreateClusterRequest true "Cluster definition"
+// @Success 201 {object} ClusterResponse "Cluster registered successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 409 {object} ErrorResponse "Cluster already exists"
+// @Failure 500 {object} ErrorResponse "Failed to register cluster"
+// @Router /clusters [post]
+func (h *Handler) CreateCluster(w http.ResponseWriter, r *http.Request) {
+	var req CreateClusterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	nowMillis := time.Now().UnixMilli()
+	cluster := &domain.LocustCluster{
+		ID:          req.ID,
+		Name:        req.Name,
+		BaseURL:     req.BaseURL,
+		Engine:      domain.EngineType(req.Engine),
+		MetricsMode: domain.MetricsMode(req.MetricsMode),
+		AccountID:   req.AccountID,
+		OrgID:       req.OrgID,
+		ProjectID:   req.ProjectID,
+		EnvID:       req.EnvID,
+		AuthToken:   req.AuthToken,
+		Source:      domain.ClusterSourceAPI,
+		CreatedAt:   nowMillis,
+		CreatedBy:   req.CreatedBy,
+		UpdatedAt:   nowMillis,
+		UpdatedBy:   req.CreatedBy,
+	}
+
+	if err := h.orchestrator.RegisterCluster(cluster); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCluster):
+			respondError(w, http.StatusBadRequest, "Invalid cluster", err)
+		case errors.Is(err, service.ErrClusterExists):
+			respondError(w, http.StatusConflict, "Cluster already exists", err)
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to register cluster", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, toClusterResponse(cluster))
+}
+
+// ListClusters godoc
+// @Summary List Locust clusters
+// @Description Returns every registered Locust cluster with its latest health probe
+// @Tags Clusters
+// @Produce json
+// @Success 200 {array} ClusterResponse "List of clusters"
+// @Router /clusters [get]
+func (h *Handler) ListClusters(w http.ResponseWriter, r *http.Request) {
+	clusters := h.orchestrator.ListClusters()
+
+	responses := make([]*ClusterResponse, len(clusters))
+	for i, cluster := range clusters {
+		responses[i] = toClusterResponse(cluster)
+	}
+
+	respondJSON(w, http.StatusOK, responses)
+}
+
+// GetCluster godoc
+// @Summary Get a Locust cluster
+// @Description Returns a registered Locust cluster with its latest health probe
+// @Tags Clusters
+// @Produce json
+// @Param id path string true "Cluster ID"
+// @Success 200 {object} ClusterResponse "Cluster details"
+// @Failure 404 {object} ErrorResponse "Cluster not found"
+// @Router /clusters/{id} [get]
+func (h *Handler) GetCluster(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	cluster, err := h.orchestrator.GetCluster(vars["id"])
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toClusterResponse(cluster))
+}
+
+// UpdateCluster godoc
+// @Summary Update a Locust cluster
+// @Description Updates a registered Locust cluster. Empty fields keep their current value; runs already on the cluster keep running
+// @Tags Clusters
+// @Accept json
+// @Produce json
+// @Param id path string true "Cluster ID"
+// @Param request body UpdateClusterRequest true "Fields to update"
+// @Success 200 {object} ClusterResponse "Cluster updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Cluster not found"
+// @Failure 500 {object} ErrorResponse "Failed to update cluster"
+// @Router /clusters/{id} [put]
+func (h *Handler) UpdateCluster(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	cluster, err := h.orchestrator.GetCluster(vars["id"])
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Cluster not found", err)
+		return
+	}
+
+	var req UpdateClusterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.Name != "" {
+		cluster.Name = req.Name
+	}
+	if req.BaseURL != "" {
+		cluster.BaseURL = req.BaseURL
+	}
+	if req.MetricsMode != "" {
+		cluster.MetricsMode = domain.MetricsMode(req.MetricsMode)
+	}
+	if req.AccountID != "" {
+		cluster.AccountID = req.AccountID
+	}
+	if req.OrgID != "" {
+		cluster.OrgID = req.OrgID
+	}
+	if req.ProjectID != "" {
+		cluster.ProjectID = req.ProjectID
+	}
+	if req.EnvID != "" {
+		cluster.EnvID = req.EnvID
+	}
+	if req.AuthToken != "" {
+		cluster.AuthToken = req.AuthToken
+	}
+	cluster.UpdatedAt = time.Now().UnixMilli()
+	cluster.UpdatedBy = req.UpdatedBy
+
+	if err := h.orchestrator.UpdateCluster(cluster); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCluster):
+			respondError(w, http.StatusBadRequest, "Invalid cluster", err)
+		case errors.Is(err, service.ErrClusterNotFound):
+			respondError(w, http.StatusNotFound, "Cluster not found", err)
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to update cluster", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toClusterResponse(cluster))
+}
+
+// DeleteCluster godoc
+// @Summary Delete a Locust cluster
+// @Description Removes an idle Locust cluster from the registry. Clusters running or queueing a run cannot be deleted
+// @Tags Clusters
+// @Produce json
+// @Param id path string true "Cluster ID"
+// @Success 200 {object} SuccessResponse "Cluster deleted successfully"
+// @Failure 404 {object} ErrorResponse "Cluster not found"
+// @Failure 409 {object} ErrorResponse "Cluster is in use"
+// @Failure 500 {object} ErrorResponse "Failed to delete cluster"
+// @Router /clusters/{id} [delete]
+func (h *Handler) DeleteCluster(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.orchestrator.DeleteCluster(vars["id"]); err != nil {
+		switch {
+		case errors.Is(err, service.ErrClusterNotFound):
+			respondError(w, http.StatusNotFound, "Cluster not found", err)
+		case errors.Is(err, service.ErrClusterInUse):
+			respondError(w, http.StatusConflict, "Cluster is in use", err)
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to delete cluster", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Cluster deleted successfully",
+	})
+}
@@ -9,38 +9,45 @@ import (
 
 // CreateLoadTestRequest represents the request body for creating a load test
 type CreateLoadTestRequest struct {
-	Name               string         `json:"name" binding:"required"`
-	Description        string         `json:"description,omitempty"`
-	Tags               []string       `json:"tags,omitempty"`
-	AccountID          string         `json:"accountId" binding:"required"`
-	OrgID              string         `json:"orgId" binding:"required"`
-	ProjectID          string         `json:"projectId" binding:"required"`
-	EnvID              string         `json:"envId,omitempty"`
-	LocustClusterID    string         `json:"locustClusterId" binding:"required"`
-	TargetURL          string         `json:"targetUrl" binding:"required"`
-	ScriptContent      string         `json:"scriptContent" binding:"required"` // Base64 encoded Python script
-	ScenarioID         string         `json:"scenarioId,omitempty"`
-	DefaultUsers       int            `json:"defaultUsers,omitempty"`
-	DefaultSpawnRate   float64        `json:"defaultSpawnRate,omitempty"`
-	DefaultDurationSec *int           `json:"defaultDurationSec,omitempty"`
-	MaxDurationSec     *int           `json:"maxDurationSec,omitempty"`
-	CreatedBy          string         `json:"createdBy" binding:"required"`
-	Metadata           map[string]any `json:"metadata,omitempty"`
+	Name               string              `json:"name" binding:"required"`
+	Description        string              `json:"description,omitempty"`
+	Tags               []string            `json:"tags,omitempty"`
+	AccountID          string              `json:"accountId" binding:"required"`
+	OrgID              string              `json:"orgId" binding:"required"`
+	ProjectID          string              `json:"projectId" binding:"required"`
+	EnvID              string              `json:"envId,omitempty"`
+	LocustClusterID    string              `json:"locustClusterId" binding:"required"`
+	Engine             domain.EngineType   `json:"engine,omitempty"` // Load engine executing the script (default: locust)
+	TargetURL          string              `json:"targetUrl" binding:"required"`
+	ScriptContent      string              `json:"scriptContent" binding:"required"` // Base64 encoded Python script
+	ScenarioID         string              `json:"scenarioId,omitempty"`
+	DefaultUsers       int                 `json:"defaultUsers,omitempty"`
+	DefaultSpawnRate   float64             `json:"defaultSpawnRate,omitempty"`
+	DefaultDurationSec *int                `json:"defaultDurationSec,omitempty"`
+	MaxDurationSec     *int                `json:"maxDurationSec,omitempty"`
+	LoadProfile        *domain.LoadProfile `json:"loadProfile,omitempty"` // Optional staged load profile
+	Thresholds         []domain.Threshold  `json:"thresholds,omitempty"`  // SLOs every run is judged against, e.g. p95ResponseMs < 300
+	AbortRules         []domain.AbortRule  `json:"abortRules,omitempty"`  // Live conditions that abort a run, e.g. errorRate > 20 for 60s
+	CreatedBy          string              `json:"createdBy" binding:"required"`
+	Metadata           map[string]any      `json:"metadata,omitempty"`
 }
 
 // UpdateLoadTestRequest represents the request body for updating a load test
 type UpdateLoadTestRequest struct {
-	Name               string         `json:"name,omitempty"`
-	Description        string         `json:"description,omitempty"`
-	Tags               []string       `json:"tags,omitempty"`
-	TargetURL          string         `json:"targetUrl,omitempty"`
-	ScenarioID         string         `json:"scenarioId,omitempty"`
-	DefaultUsers       int            `json:"defaultUsers,omitempty"`
-	DefaultSpawnRate   float64        `json:"defaultSpawnRate,omitempty"`
-	DefaultDurationSec *int           `json:"defaultDurationSec,omitempty"`
-	MaxDurationSec     *int           `json:"maxDurationSec,omitempty"`
-	UpdatedBy          string         `json:"updatedBy" binding:"required"`
-	Metadata           map[string]any `json:"metadata,omitempty"`
+	Name               string              `json:"name,omitempty"`
+	Description        string              `json:"description,omitempty"`
+	Tags               []string            `json:"tags,omitempty"`
+	TargetURL          string              `json:"targetUrl,omitempty"`
+	ScenarioID         string              `json:"scenarioId,omitempty"`
+	DefaultUsers       int                 `json:"defaultUsers,omitempty"`
+	DefaultSpawnRate   float64             `json:"defaultSpawnRate,omitempty"`
+	DefaultDurationSec *int                `json:"defaultDurationSec,omitempty"`
+	MaxDurationSec     *int                `json:"maxDurationSec,omitempty"`
+	LoadProfile        *domain.LoadProfile `json:"loadProfile,omitempty"`
+	Thresholds         []domain.Threshold  `json:"thresholds,omitempty"` // Replaces all thresholds; send [] to clear
+	AbortRules         []domain.AbortRule  `json:"abortRules,omitempty"` // Replaces all abort rules; send [] to clear
+	UpdatedBy          string              `json:"updatedBy" binding:"required"`
+	Metadata           map[string]any      `json:"metadata,omitempty"`
 }
 
 // UpdateScriptRequest represents the request body for updating a load test script (creates new revision)
@@ -52,29 +59,33 @@ type UpdateScriptRequest struct {
 
 // LoadTestResponse represents the response body for a load test
 type LoadTestResponse struct {
-	ID                 string                `json:"id"`
-	Name               string                `json:"name"`
-	Description        string                `json:"description,omitempty"`
-	Tags               []string              `json:"tags,omitempty"`
-	AccountID          string                `json:"accountId"`
-	OrgID              string                `json:"orgId"`
-	ProjectID          string                `json:"projectId"`
-	EnvID              string                `json:"envId,omitempty"`
-	LocustClusterID    string                `json:"locustClusterId"`
-	TargetURL          string                `json:"targetUrl"`
-	ScriptContent      string                `json:"scriptContent,omitempty"`      // Base64 encoded user script (without plugin)
-	LatestRevisionID   string                `json:"latestRevisionId,omitempty"`
-	ScenarioID         string                `json:"scenarioId,omitempty"`
-	DefaultUsers       int                   `json:"defaultUsers,omitempty"`
-	DefaultSpawnRate   float64               `json:"defaultSpawnRate,omitempty"`
-	DefaultDurationSec *int                  `json:"defaultDurationSec,omitempty"`
-	MaxDurationSec     *int                  `json:"maxDurationSec,omitempty"`
-	RecentRuns         []RecentRunResponse   `json:"recentRuns,omitempty"`         // Recent test runs
-	CreatedAt          string                `json:"createdAt"`
-	CreatedBy          string                `json:"createdBy"`
-	UpdatedAt          string                `json:"updatedAt"`
-	UpdatedBy          string                `json:"updatedBy"`
-	Metadata           map[string]any        `json:"metadata,omitempty"`
+	ID                 string              `json:"id"`
+	Name               string              `json:"name"`
+	Description        string              `json:"description,omitempty"`
+	Tags               []string            `json:"tags,omitempty"`
+	AccountID          string              `json:"accountId"`
+	OrgID              string              `json:"orgId"`
+	ProjectID          string              `json:"projectId"`
+	EnvID              string              `json:"envId,omitempty"`
+	LocustClusterID    string              `json:"locustClusterId"`
+	Engine             domain.EngineType   `json:"engine"`
+	TargetURL          string              `json:"targetUrl"`
+	ScriptContent      string              `json:"scriptContent,omitempty"` // Base64 encoded user script (without plugin)
+	LatestRevisionID   string              `json:"latestRevisionId,omitempty"`
+	ScenarioID         string              `json:"scenarioId,omitempty"`
+	DefaultUsers       int                 `json:"defaultUsers,omitempty"`
+	DefaultSpawnRate   float64             `json:"defaultSpawnRate,omitempty"`
+	DefaultDurationSec *int                `json:"defaultDurationSec,omitempty"`
+	MaxDurationSec     *int                `json:"maxDurationSec,omitempty"`
+	LoadProfile        *domain.LoadProfile `json:"loadProfile,omitempty"`
+	Thresholds         []domain.Threshold  `json:"thresholds,omitempty"`
+	AbortRules         []domain.AbortRule  `json:"abortRules,omitempty"`
+	RecentRuns         []RecentRunResponse `json:"recentRuns,omitempty"` // Recent test runs
+	CreatedAt          string              `json:"createdAt"`
+	CreatedBy          string              `json:"createdBy"`
+	UpdatedAt          string              `json:"updatedAt"`
+	UpdatedBy          string              `json:"updatedBy"`
+	Metadata           map[string]any      `json:"metadata,omitempty"`
 }
 
 // RecentRunResponse represents a summary of a recent test run
@@ -106,69 +117,238 @@ type ScriptRevisionResponse struct {
 
 // CreateLoadTestRunRequest represents the request body for creating/starting a load test run
 type CreateLoadTestRunRequest struct {
-	LoadTestID      string         `json:"loadTestId" binding:"required"`
-	Name            string         `json:"name,omitempty"`
-	TargetUsers     *int           `json:"targetUsers,omitempty"`     // Override from LoadTest
-	SpawnRate       *float64       `json:"spawnRate,omitempty"`       // Override from LoadTest
-	DurationSeconds *int           `json:"durationSeconds,omitempty"` // Override from LoadTest
-	CreatedBy       string         `json:"createdBy" binding:"required"`
-	Metadata        map[string]any `json:"metadata,omitempty"`
+	LoadTestID      string              `json:"loadTestId" binding:"required"`
+	Name            string              `json:"name,omitempty"`
+	TargetURL       string              `json:"targetUrl,omitempty"`       // Override host from LoadTest
+	TargetUsers     *int                `json:"targetUsers,omitempty"`     // Override from LoadTest
+	SpawnRate       *float64            `json:"spawnRate,omitempty"`       // Override from LoadTest
+	DurationSeconds *int                `json:"durationSeconds,omitempty"` // Override from LoadTest
+	LoadProfile     *domain.LoadProfile `json:"loadProfile,omitempty"`     // Override from LoadTest; first stage sets users and spawn rate
+	Shards          []domain.ShardSpec  `json:"shards,omitempty"`          // Distributes the run across several clusters by weight or explicit users
+	CreatedBy       string              `json:"createdBy" binding:"required"`
+	Metadata        map[string]any      `json:"metadata,omitempty"`
+}
+
+// UpdateLoadTestRunRequest represents the request body for adjusting the load of a running test
+type UpdateLoadTestRunRequest struct {
+	TargetUsers *int     `json:"targetUsers,omitempty"` // New number of users
+	SpawnRate   *float64 `json:"spawnRate,omitempty"`   // New spawn rate
+	UpdatedBy   string   `json:"updatedBy" binding:"required"`
+}
+
+// StopLoadTestRunRequest represents the optional request body for stopping a load test run
+type StopLoadTestRunRequest struct {
+	StoppedBy string `json:"stoppedBy,omitempty"` // Recorded as the actor in the run's timeline (default: "api")
 }
 
 // LoadTestRunResponse represents the response body for a load test run
 type LoadTestRunResponse struct {
-	ID              string                  `json:"id"`
-	LoadTestID      string                  `json:"loadTestId"`
-	Name            string                  `json:"name,omitempty"`
-	AccountID       string                  `json:"accountId"`
-	OrgID           string                  `json:"orgId"`
-	ProjectID       string                  `json:"projectId"`
-	EnvID           string                  `json:"envId,omitempty"`
-	TargetUsers     int                     `json:"targetUsers"`
-	SpawnRate       float64                 `json:"spawnRate"`
-	DurationSeconds *int                    `json:"durationSeconds,omitempty"`
-	Status          string                  `json:"status"`
-	StartedAt       *string                 `json:"startedAt,omitempty"`
-	FinishedAt      *string                 `json:"finishedAt,omitempty"`
-	CreatedAt       string                  `json:"createdAt"`
-	CreatedBy       string                  `json:"createdBy"`
-	UpdatedAt       string                  `json:"updatedAt"`
-	UpdatedBy       string                  `json:"updatedBy"`
-	Metadata        map[string]any          `json:"metadata,omitempty"`
-	LastMetrics     *MetricSnapshotResponse `json:"lastMetrics,omitempty"`
+	ID               string                   `json:"id"`
+	LoadTestID       string                   `json:"loadTestId"`
+	ScriptRevisionID string                   `json:"scriptRevisionId,omitempty"`
+	ScriptHash       string                   `json:"scriptHash,omitempty"` // SHA-256 of the script loaded by Locust
+	Name             string                   `json:"name,omitempty"`
+	AccountID        string                   `json:"accountId"`
+	OrgID            string                   `json:"orgId"`
+	ProjectID        string                   `json:"projectId"`
+	EnvID            string                   `json:"envId,omitempty"`
+	ClusterID        string                   `json:"clusterId,omitempty"`
+	MetricsMode      string                   `json:"metricsMode,omitempty"`     // "push" or "poll"
+	ClusterAttempts  []domain.ClusterAttempt  `json:"clusterAttempts,omitempty"` // Clusters the run was started on, including failovers
+	Shards           []domain.RunShard        `json:"shards,omitempty"`          // Per-cluster status of a distributed run
+	TargetURL        string                   `json:"targetUrl,omitempty"`
+	TargetUsers      int                      `json:"targetUsers"`
+	SpawnRate        float64                  `json:"spawnRate"`
+	DurationSeconds  *int                     `json:"durationSeconds,omitempty"`
+	LoadProfile      *domain.LoadProfile      `json:"loadProfile,omitempty"`
+	CurrentStage     int                      `json:"currentStage,omitempty"`
+	StageTransitions []domain.StageTransition `json:"stageTransitions,omitempty"`
+	LoadChanges      []domain.LoadChange      `json:"loadChanges,omitempty"`
+	ProfileOverride  bool                     `json:"profileOverride,omitempty"` // Set once a live adjustment took over from the load profile
+	Thresholds       []domain.Threshold       `json:"thresholds,omitempty"`
+	Verdict          *domain.Verdict          `json:"verdict,omitempty"` // Set once the run has ended and thresholds were evaluated
+	AbortRules       []domain.AbortRule       `json:"abortRules,omitempty"`
+	AbortedBy        *domain.AbortTrigger     `json:"abortedBy,omitempty"` // Set when an abort rule stopped the run
+	Status           string                   `json:"status"`
+	QueuePosition    int                      `json:"queuePosition,omitempty"` // 1-based position while waiting for a busy cluster
+	QueuedAt         *string                  `json:"queuedAt,omitempty"`
+	LastHeartbeatAt  *string                  `json:"lastHeartbeatAt,omitempty"` // Last metrics push or successful probe
+	FailureReason    string                   `json:"failureReason,omitempty"`
+	StartedAt        *string                  `json:"startedAt,omitempty"`
+	FinishedAt       *string                  `json:"finishedAt,omitempty"`
+	CreatedAt        string                   `json:"createdAt"`
+	CreatedBy        string                   `json:"createdBy"`
+	UpdatedAt        string                   `json:"updatedAt"`
+	UpdatedBy        string                   `json:"updatedBy"`
+	Metadata         map[string]any           `json:"metadata,omitempty"`
+	LastMetrics      *MetricSnapshotResponse  `json:"lastMetrics,omitempty"`
 }
 
 // MetricSnapshotResponse represents metrics data in API response
 type MetricSnapshotResponse struct {
-	Timestamp         string                  `json:"timestamp"`
-	TotalRPS          float64                 `json:"totalRps"`
-	TotalRequests     int64                   `json:"totalRequests"`
-	TotalFailures     int64                   `json:"totalFailures"`
-	ErrorRate         float64                 `json:"errorRate"`
-	AverageResponseMs float64                 `json:"avgResponseMs"`
-	P50ResponseMs     float64                 `json:"p50ResponseMs"`
-	P95ResponseMs     float64                 `json:"p95ResponseMs"`
-	P99ResponseMs     float64                 `json:"p99ResponseMs"`
-	CurrentUsers      int                     `json:"currentUsers"`
+	Timestamp         string                      `json:"timestamp"`
+	TotalRPS          float64                     `json:"totalRps"`
+	TotalRequests     int64                       `json:"totalRequests"`
+	TotalFailures     int64                       `json:"totalFailures"`
+	ErrorRate         float64                     `json:"errorRate"`
+	AverageResponseMs float64                     `json:"avgResponseMs"`
+	P50ResponseMs     float64                     `json:"p50ResponseMs"`
+	P90ResponseMs     float64                     `json:"p90ResponseMs"`
+	P95ResponseMs     float64                     `json:"p95ResponseMs"`
+	P99ResponseMs     float64                     `json:"p99ResponseMs"`
+	P999ResponseMs    float64                     `json:"p999ResponseMs"`
+	CurrentUsers      int                         `json:"currentUsers"`
+	ResponseTimes     domain.Histogram            `json:"responseTimes,omitempty"` // Response time bucket (ms) -> count since the test started
+	Interval          *domain.MetricInterval      `json:"interval,omitempty"`      // Requests since the previous snapshot; computed on ingestion, ignored when pushed
+	Failures          []domain.FailureGroup       `json:"failures,omitempty"`      // Pushed failure groups since the test started; stored apart from the metrics
+	Exceptions        []domain.ExceptionGroup     `json:"exceptions,omitempty"`    // Pushed exceptions since the test started; stored apart from the metrics
 	RequestStats      map[string]*ReqStatResponse `json:"requestStats,omitempty"`
 }
 
 // ReqStatResponse represents per-request statistics in API response
 type ReqStatResponse struct {
-	Method             string  `json:"method"`
-	Name               string  `json:"name"`
-	NumRequests        int64   `json:"numRequests"`
-	NumFailures        int64   `json:"numFailures"`
-	AvgResponseTime    float64 `json:"avgResponseTime"`
-	MinResponseTime    float64 `json:"minResponseTime"`
-	MaxResponseTime    float64 `json:"maxResponseTime"`
-	MedianResponseTime float64 `json:"medianResponseTime"`
-	RequestsPerSec     float64 `json:"requestsPerSec"`
+	Method             string           `json:"method"`
+	Name               string           `json:"name"`
+	NumRequests        int64            `json:"numRequests"`
+	NumFailures        int64            `json:"numFailures"`
+	AvgResponseTime    float64          `json:"avgResponseTime"`
+	MinResponseTime    float64          `json:"minResponseTime"`
+	MaxResponseTime    float64          `json:"maxResponseTime"`
+	MedianResponseTime float64          `json:"medianResponseTime"`
+	P50ResponseMs      float64          `json:"p50ResponseMs"`
+	P90ResponseMs      float64          `json:"p90ResponseMs"`
+	P95ResponseMs      float64          `json:"p95ResponseMs"`
+	P99ResponseMs      float64          `json:"p99ResponseMs"`
+	P999ResponseMs     float64          `json:"p999ResponseMs"`
+	ResponseTimes      domain.Histogram `json:"responseTimes,omitempty"` // Response time bucket (ms) -> count since the test started
+	RequestsPerSec     float64          `json:"requestsPerSec"`
+	IntervalRequests   int64            `json:"intervalRequests,omitempty"` // Requests since the previous snapshot
+	IntervalFailures   int64            `json:"intervalFailures,omitempty"` // Failures since the previous snapshot
+}
+
+// Schedule DTOs
+
+// CreateScheduleRequest represents the request body for creating a schedule
+type CreateScheduleRequest struct {
+	Name           string                       `json:"name,omitempty"`
+	CronExpression string                       `json:"cronExpression" binding:"required"` // Standard 5-field cron expression, e.g. "0 2 * * *"
+	Timezone       string                       `json:"timezone,omitempty"`                // IANA timezone, defaults to UTC
+	Overrides      *domain.ScheduleRunOverrides `json:"overrides,omitempty"`               // Run parameters overriding LoadTest defaults
+	Enabled        *bool                        `json:"enabled,omitempty"`                 // Defaults to true
+	CreatedBy      string                       `json:"createdBy" binding:"required"`
+}
+
+// UpdateScheduleRequest represents the request body for updating a schedule
+type UpdateScheduleRequest struct {
+	Name           *string                      `json:"name,omitempty"`
+	CronExpression *string                      `json:"cronExpression,omitempty"`
+	Timezone       *string                      `json:"timezone,omitempty"`
+	Overrides      *domain.ScheduleRunOverrides `json:"overrides,omitempty"`
+	Enabled        *bool                        `json:"enabled,omitempty"` // Set to false to pause the schedule
+	UpdatedBy      string                       `json:"updatedBy" binding:"required"`
+}
+
+// ScheduleResponse represents the response body for a schedule
+type ScheduleResponse struct {
+	ID             string                       `json:"id"`
+	LoadTestID     string                       `json:"loadTestId"`
+	Name           string                       `json:"name,omitempty"`
+	CronExpression string                       `json:"cronExpression"`
+	Timezone       string                       `json:"timezone"`
+	Overrides      *domain.ScheduleRunOverrides `json:"overrides,omitempty"`
+	Enabled        bool                         `json:"enabled"`
+	LastFireAt     string                       `json:"lastFireAt,omitempty"`
+	NextFireAt     string                       `json:"nextFireAt,omitempty"`
+	LastRunID      string                       `json:"lastRunId,omitempty"`
+	Firings        []ScheduleFiringResponse     `json:"firings"`
+	CreatedAt      string                       `json:"createdAt"`
+	CreatedBy      string                       `json:"createdBy"`
+	UpdatedAt      string                       `json:"updatedAt"`
+	UpdatedBy      string                       `json:"updatedBy"`
+}
+
+// ScheduleFiringResponse represents a single firing of a schedule
+type ScheduleFiringResponse struct {
+	FiredAt string `json:"firedAt"`
+	RunID   string `json:"runId,omitempty"` // Run created by this firing
+	Error   string `json:"error,omitempty"`
+}
+
+// Cluster DTOs
+
+// CreateClusterRequest represents the request body for registering a Locust cluster
+type CreateClusterRequest struct {
+	ID          string `json:"id" binding:"required"`
+	Name        string `json:"name,omitempty"`
+	BaseURL     string `json:"baseUrl,omitempty"`     // Locust master URL, e.g. "http://locust-master:8089"; not used by native clusters
+	Engine      string `json:"engine,omitempty"`      // Load engine the cluster runs: locust (default) or native
+	MetricsMode string `json:"metricsMode,omitempty"` // "push" or "poll"; empty uses orchestrator.metricsMode
+	AccountID   string `json:"accountId" binding:"required"`
+	OrgID       string `json:"orgId" binding:"required"`
+	ProjectID   string `json:"projectId" binding:"required"`
+	EnvID       string `json:"envId,omitempty"` // Empty serves every environment of the project
+	AuthToken   string `json:"authToken,omitempty"`
+	CreatedBy   string `json:"createdBy" binding:"required"`
+}
+
+// UpdateClusterRequest represents the request body for updating a Locust cluster
+// Empty fields keep their current value
+type UpdateClusterRequest struct {
+	Name        string `json:"name,omitempty"`
+	BaseURL     string `json:"baseUrl,omitempty"`
+	MetricsMode string `json:"metricsMode,omitempty"`
+	AccountID   string `json:"accountId,omitempty"`
+	OrgID       string `json:"orgId,omitempty"`
+	ProjectID   string `json:"projectId,omitempty"`
+	EnvID       string `json:"envId,omitempty"`
+	AuthToken   string `json:"authToken,omitempty"`
+	UpdatedBy   string `json:"updatedBy" binding:"required"`
+}
+
+// ClusterResponse represents the response body for a Locust cluster
+// The auth token is never returned
+type ClusterResponse struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name,omitempty"`
+	BaseURL      string                 `json:"baseUrl"`
+	Engine       string                 `json:"engine"`
+	MetricsMode  string                 `json:"metricsMode,omitempty"` // Empty when the cluster uses orchestrator.metricsMode
+	AccountID    string                 `json:"accountId"`
+	OrgID        string                 `json:"orgId"`
+	ProjectID    string                 `json:"projectId"`
+	EnvID        string                 `json:"envId,omitempty"`
+	HasAuthToken bool                   `json:"hasAuthToken"`
+	Source       string                 `json:"source"` // "config" or "api"
+	Health       *ClusterHealthResponse `json:"health,omitempty"`
+	CreatedAt    string                 `json:"createdAt,omitempty"`
+	CreatedBy    string                 `json:"createdBy,omitempty"`
+	UpdatedAt    string                 `json:"updatedAt,omitempty"`
+	UpdatedBy    string                 `json:"updatedBy,omitempty"`
+}
+
+// ClusterHealthResponse represents the latest health probe of a Locust cluster
+type ClusterHealthResponse struct {
+	Reachable     bool             `json:"reachable"`
+	State         string           `json:"state,omitempty"`
+	WorkerCount   int              `json:"workerCount"`
+	PluginVersion string           `json:"pluginVersion,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	CheckedAt     string           `json:"checkedAt"`
+	LastSeenAt    string           `json:"lastSeenAt,omitempty"`
+	Circuit       *CircuitResponse `json:"circuit,omitempty"` // Circuit breaker guarding calls to the master
+}
+
+// CircuitResponse represents the circuit breaker guarding calls to a Locust cluster
+type CircuitResponse struct {
+	State               string `json:"state"` // "closed", "open" or "half-open"
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	OpenedAt            string `json:"openedAt,omitempty"`
 }
 
 // LocustCallbackTestStartRequest represents the callback payload when test starts
 type LocustCallbackTestStartRequest struct {
 	RunID    string `json:"runId" binding:"required"`
+	ShardID  string `json:"shardId,omitempty"` // Cluster of the shard reporting, for distributed runs
 	TenantID string `json:"tenantId"`
 	EnvID    string `json:"envId"`
 }
@@ -176,6 +356,7 @@ type LocustCallbackTestStartRequest struct {
 // LocustCallbackTestStopRequest represents the callback payload when test stops
 type LocustCallbackTestStopRequest struct {
 	RunID        string                  `json:"runId" binding:"required"`
+	ShardID      string                  `json:"shardId,omitempty"` // Cluster of the shard reporting, for distributed runs
 	TenantID     string                  `json:"tenantId"`
 	EnvID        string                  `json:"envId"`
 	FinalMetrics *MetricSnapshotResponse `json:"finalMetrics,omitempty"`
@@ -185,9 +366,18 @@ type LocustCallbackTestStopRequest struct {
 // LocustCallbackMetricsRequest represents the callback payload for periodic metrics
 type LocustCallbackMetricsRequest struct {
 	RunID   string                  `json:"runId" binding:"required"`
+	ShardID string                  `json:"shardId,omitempty"` // Cluster of the shard reporting, for distributed runs
 	Metrics *MetricSnapshotResponse `json:"metrics" binding:"required"`
 }
 
+// LocustCallbackRequestsRequest represents the callback payload for a batch of sampled requests
+type LocustCallbackRequestsRequest struct {
+	RunID      string                 `json:"runId" binding:"required"`
+	ShardID    string                 `json:"shardId,omitempty"` // Cluster of the shard reporting, for distributed runs
+	SampleRate float64                `json:"sampleRate"`        // Fraction of requests sampled, between 0 and 1
+	Samples    []domain.RequestSample `json:"samples"`
+}
+
 // RegisterExternalTestRequest is used when a test is started directly from Locust UI
 type RegisterExternalTestRequest struct {
 	AccountID   string  `json:"accountId" binding:"required"`
@@ -211,6 +401,57 @@ type ErrorResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// RunEventResponse represents a single status transition of a load test run
+type RunEventResponse struct {
+	From   string `json:"from,omitempty"` // Empty for the run's initial status
+	To     string `json:"to"`
+	At     string `json:"at"`
+	Actor  string `json:"actor"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RunEventsResponse represents the status timeline of a load test run
+type RunEventsResponse struct {
+	RunID  string             `json:"runId"`
+	Status string             `json:"status"`
+	Events []RunEventResponse `json:"events"`
+}
+
+// RunFailuresResponse represents the most frequent failures and exceptions of a load test run
+type RunFailuresResponse struct {
+	RunID           string                   `json:"runId"`
+	TotalFailures   int64                    `json:"totalFailures"`       // Occurrences of every failure group
+	FailureGroups   int                      `json:"failureGroups"`       // Distinct failure groups
+	TotalExceptions int64                    `json:"totalExceptions"`     // Count of every exception group
+	ExceptionGroups int                      `json:"exceptionGroups"`     // Distinct exception groups
+	Failures        []FailureGroupResponse   `json:"failures"`            // Top failure groups, most frequent first
+	Exceptions      []ExceptionGroupResponse `json:"exceptions"`          // Top exception groups, most frequent first
+	Truncated       bool                     `json:"truncated,omitempty"` // Groups past the per-run cap were not recorded
+	UpdatedAt       string                   `json:"updatedAt,omitempty"`
+}
+
+// FailureGroupResponse represents the failures of one endpoint with the same error
+type FailureGroupResponse struct {
+	Method      string  `json:"method"`
+	Name        string  `json:"name"`
+	Error       string  `json:"error"`
+	Occurrences int64   `json:"occurrences"`
+	Percentage  float64 `json:"percentage"` // Share of all the run's failures
+	FirstSeenAt string  `json:"firstSeenAt,omitempty"`
+	LastSeenAt  string  `json:"lastSeenAt,omitempty"`
+}
+
+// ExceptionGroupResponse represents the exceptions raised with the same traceback
+type ExceptionGroupResponse struct {
+	Message     string   `json:"message"`
+	Traceback   string   `json:"traceback"`
+	Count       int64    `json:"count"`
+	Percentage  float64  `json:"percentage"` // Share of all the run's exceptions
+	Nodes       []string `json:"nodes,omitempty"`
+	FirstSeenAt string   `json:"firstSeenAt,omitempty"`
+	LastSeenAt  string   `json:"lastSeenAt,omitempty"`
+}
+
 // SuccessResponse represents a generic success response
 type SuccessResponse struct {
 	Success bool   `json:"success"`
@@ -241,7 +482,7 @@ func toLoadTestResponse(test *domain.LoadTest) *LoadTestResponse {
 			}
 		}
 	}
-	
+
 	return &LoadTestResponse{
 		ID:                 test.ID,
 		Name:               test.Name,
@@ -252,6 +493,7 @@ func toLoadTestResponse(test *domain.LoadTest) *LoadTestResponse {
 		ProjectID:          test.ProjectID,
 		EnvID:              test.EnvID,
 		LocustClusterID:    test.LocustClusterID,
+		Engine:             test.Engine.OrDefault(),
 		TargetURL:          test.TargetURL,
 		LatestRevisionID:   test.LatestRevisionID,
 		ScenarioID:         test.ScenarioID,
@@ -259,6 +501,9 @@ func toLoadTestResponse(test *domain.LoadTest) *LoadTestResponse {
 		DefaultSpawnRate:   test.DefaultSpawnRate,
 		DefaultDurationSec: test.DefaultDurationSec,
 		MaxDurationSec:     test.MaxDurationSec,
+		LoadProfile:        test.LoadProfile,
+		Thresholds:         test.Thresholds,
+		AbortRules:         test.AbortRules,
 		RecentRuns:         recentRuns,
 		CreatedAt:          time.UnixMilli(test.CreatedAt).Format("2006-01-02T15:04:05Z07:00"),
 		CreatedBy:          test.CreatedBy,
@@ -290,42 +535,211 @@ func toScriptRevisionResponse(revision *domain.ScriptRevision) *ScriptRevisionRe
 	}
 }
 
+// Schedule conversions
+
+func toScheduleResponse(schedule *domain.Schedule) *ScheduleResponse {
+	firings := make([]ScheduleFiringResponse, len(schedule.Firings))
+	for i, firing := range schedule.Firings {
+		firings[i] = ScheduleFiringResponse{
+			FiredAt: formatTimestamp(firing.FiredAt),
+			RunID:   firing.RunID,
+			Error:   firing.Error,
+		}
+	}
+
+	return &ScheduleResponse{
+		ID:             schedule.ID,
+		LoadTestID:     schedule.LoadTestID,
+		Name:           schedule.Name,
+		CronExpression: schedule.CronExpression,
+		Timezone:       schedule.Timezone,
+		Overrides:      schedule.Overrides,
+		Enabled:        schedule.Enabled,
+		LastFireAt:     formatTimestamp(schedule.LastFireAt),
+		NextFireAt:     formatTimestamp(schedule.NextFireAt),
+		LastRunID:      schedule.LastRunID,
+		Firings:        firings,
+		CreatedAt:      time.UnixMilli(schedule.CreatedAt).Format("2006-01-02T15:04:05Z07:00"),
+		CreatedBy:      schedule.CreatedBy,
+		UpdatedAt:      time.UnixMilli(schedule.UpdatedAt).Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedBy:      schedule.UpdatedBy,
+	}
+}
+
+// Cluster conversions
+
+func toClusterResponse(cluster *domain.LocustCluster) *ClusterResponse {
+	resp := &ClusterResponse{
+		ID:           cluster.ID,
+		Name:         cluster.Name,
+		BaseURL:      cluster.BaseURL,
+		Engine:       string(cluster.Engine.OrDefault()),
+		MetricsMode:  string(cluster.MetricsMode),
+		AccountID:    cluster.AccountID,
+		OrgID:        cluster.OrgID,
+		ProjectID:    cluster.ProjectID,
+		EnvID:        cluster.EnvID,
+		HasAuthToken: cluster.AuthToken != "",
+		Source:       cluster.Source,
+		CreatedAt:    formatTimestamp(cluster.CreatedAt),
+		CreatedBy:    cluster.CreatedBy,
+		UpdatedAt:    formatTimestamp(cluster.UpdatedAt),
+		UpdatedBy:    cluster.UpdatedBy,
+	}
+
+	if cluster.Health != nil {
+		resp.Health = &ClusterHealthResponse{
+			Reachable:     cluster.Health.Reachable,
+			State:         cluster.Health.State,
+			WorkerCount:   cluster.Health.WorkerCount,
+			PluginVersion: cluster.Health.PluginVersion,
+			Error:         cluster.Health.Error,
+			CheckedAt:     formatTimestamp(cluster.Health.CheckedAt),
+			LastSeenAt:    formatTimestamp(cluster.Health.LastSeenAt),
+		}
+		if circuit := cluster.Health.Circuit; circuit != nil {
+			resp.Health.Circuit = &CircuitResponse{
+				State:               circuit.State,
+				ConsecutiveFailures: circuit.ConsecutiveFailures,
+				OpenedAt:            formatTimestamp(circuit.OpenedAt),
+			}
+		}
+	}
+
+	return resp
+}
+
 // LoadTestRun conversions
 
 func toLoadTestRunResponse(run *domain.LoadTestRun) *LoadTestRunResponse {
 	resp := &LoadTestRunResponse{
-		ID:              run.ID,
-		LoadTestID:      run.LoadTestID,
-		Name:            run.Name,
-		AccountID:       run.AccountID,
-		OrgID:           run.OrgID,
-		ProjectID:       run.ProjectID,
-		EnvID:           run.EnvID,
-		TargetUsers:     run.TargetUsers,
-		SpawnRate:       run.SpawnRate,
-		DurationSeconds: run.DurationSeconds,
-		Status:          string(run.Status),
-		CreatedAt:       time.UnixMilli(run.CreatedAt).Format("2006-01-02T15:04:05Z07:00"),
-		CreatedBy:       run.CreatedBy,
-		UpdatedAt:       time.UnixMilli(run.UpdatedAt).Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedBy:       run.UpdatedBy,
-		Metadata:        run.Metadata,
+		ID:               run.ID,
+		LoadTestID:       run.LoadTestID,
+		ScriptRevisionID: run.ScriptRevisionID,
+		ScriptHash:       run.ScriptHash,
+		Name:             run.Name,
+		AccountID:        run.AccountID,
+		OrgID:            run.OrgID,
+		ProjectID:        run.ProjectID,
+		EnvID:            run.EnvID,
+		ClusterID:        run.ClusterID,
+		MetricsMode:      string(run.MetricsMode),
+		ClusterAttempts:  run.ClusterAttempts,
+		Shards:           run.Shards,
+		TargetURL:        run.TargetURL,
+		TargetUsers:      run.TargetUsers,
+		SpawnRate:        run.SpawnRate,
+		DurationSeconds:  run.DurationSeconds,
+		LoadProfile:      run.LoadProfile,
+		CurrentStage:     run.CurrentStage,
+		StageTransitions: run.StageTransitions,
+		LoadChanges:      run.LoadChanges,
+		ProfileOverride:  run.ProfileOverride,
+		Thresholds:       run.Thresholds,
+		Verdict:          run.Verdict,
+		AbortRules:       run.AbortRules,
+		AbortedBy:        run.AbortedBy,
+		Status:           string(run.Status),
+		FailureReason:    run.FailureReason,
+		CreatedAt:        time.UnixMilli(run.CreatedAt).Format("2006-01-02T15:04:05Z07:00"),
+		CreatedBy:        run.CreatedBy,
+		UpdatedAt:        time.UnixMilli(run.UpdatedAt).Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedBy:        run.UpdatedBy,
+		Metadata:         run.Metadata,
 	}
-	
+
+	if run.QueuedAt > 0 {
+		queuedAt := time.UnixMilli(run.QueuedAt).Format("2006-01-02T15:04:05Z07:00")
+		resp.QueuedAt = &queuedAt
+	}
+
 	if run.StartedAt > 0 {
 		startedAt := time.UnixMilli(run.StartedAt).Format("2006-01-02T15:04:05Z07:00")
 		resp.StartedAt = &startedAt
 	}
-	
+
+	if run.LastHeartbeatAt > 0 {
+		lastHeartbeatAt := time.UnixMilli(run.LastHeartbeatAt).Format("2006-01-02T15:04:05Z07:00")
+		resp.LastHeartbeatAt = &lastHeartbeatAt
+	}
+
 	if run.FinishedAt > 0 {
 		finishedAt := time.UnixMilli(run.FinishedAt).Format("2006-01-02T15:04:05Z07:00")
 		resp.FinishedAt = &finishedAt
 	}
-	
+
 	if run.LastMetrics != nil {
 		resp.LastMetrics = toMetricSnapshotResponse(run.LastMetrics)
 	}
-	
+
+	return resp
+}
+
+func toRunEventsResponse(run *domain.LoadTestRun) *RunEventsResponse {
+	events := make([]RunEventResponse, 0, len(run.Timeline))
+	for _, transition := range run.Timeline {
+		events = append(events, RunEventResponse{
+			From:   string(transition.From),
+			To:     string(transition.To),
+			At:     time.UnixMilli(transition.At).Format("2006-01-02T15:04:05Z07:00"),
+			Actor:  transition.Actor,
+			Reason: transition.Reason,
+		})
+	}
+
+	return &RunEventsResponse{
+		RunID:  run.ID,
+		Status: string(run.Status),
+		Events: events,
+	}
+}
+
+// toRunFailuresResponse keeps the limit most frequent failure and exception groups of a run
+func toRunFailuresResponse(runFailures *domain.RunFailures, limit int) *RunFailuresResponse {
+	totalFailures := runFailures.TotalFailures()
+	totalExceptions := runFailures.TotalExceptions()
+	share := func(count, total int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(count) / float64(total) * 100
+	}
+
+	resp := &RunFailuresResponse{
+		RunID:           runFailures.RunID,
+		TotalFailures:   totalFailures,
+		FailureGroups:   len(runFailures.Failures),
+		TotalExceptions: totalExceptions,
+		ExceptionGroups: len(runFailures.Exceptions),
+		Failures:        make([]FailureGroupResponse, 0, limit),
+		Exceptions:      make([]ExceptionGroupResponse, 0, limit),
+		Truncated:       runFailures.Truncated,
+		UpdatedAt:       formatTimestamp(runFailures.UpdatedAt),
+	}
+
+	for _, group := range runFailures.TopFailures(limit) {
+		resp.Failures = append(resp.Failures, FailureGroupResponse{
+			Method:      group.Method,
+			Name:        group.Name,
+			Error:       group.Error,
+			Occurrences: group.Occurrences,
+			Percentage:  share(group.Occurrences, totalFailures),
+			FirstSeenAt: formatTimestamp(group.FirstSeenAt),
+			LastSeenAt:  formatTimestamp(group.LastSeenAt),
+		})
+	}
+	for _, group := range runFailures.TopExceptions(limit) {
+		resp.Exceptions = append(resp.Exceptions, ExceptionGroupResponse{
+			Message:     group.Message,
+			Traceback:   group.Traceback,
+			Count:       group.Count,
+			Percentage:  share(group.Count, totalExceptions),
+			Nodes:       group.Nodes,
+			FirstSeenAt: formatTimestamp(group.FirstSeenAt),
+			LastSeenAt:  formatTimestamp(group.LastSeenAt),
+		})
+	}
+
 	return resp
 }
 
@@ -338,9 +752,13 @@ func toMetricSnapshotResponse(metrics *domain.MetricSnapshot) *MetricSnapshotRes
 		ErrorRate:         metrics.ErrorRate,
 		AverageResponseMs: metrics.AverageResponseMs,
 		P50ResponseMs:     metrics.P50ResponseMs,
+		P90ResponseMs:     metrics.P90ResponseMs,
 		P95ResponseMs:     metrics.P95ResponseMs,
 		P99ResponseMs:     metrics.P99ResponseMs,
+		P999ResponseMs:    metrics.P999ResponseMs,
 		CurrentUsers:      metrics.CurrentUsers,
+		ResponseTimes:     metrics.ResponseTimes,
+		Interval:          metrics.Interval,
 	}
 	
 	if metrics.RequestStats != nil {
@@ -356,7 +774,15 @@ func toMetricSnapshotResponse(metrics *domain.MetricSnapshot) *MetricSnapshotRes
 					MinResponseTime:    v.MinResponseTime,
 					MaxResponseTime:    v.MaxResponseTime,
 					MedianResponseTime: v.MedianResponseTime,
+					P50ResponseMs:      v.P50ResponseMs,
+					P90ResponseMs:      v.P90ResponseMs,
+					P95ResponseMs:      v.P95ResponseMs,
+					P99ResponseMs:      v.P99ResponseMs,
+					P999ResponseMs:     v.P999ResponseMs,
+					ResponseTimes:      v.ResponseTimes,
 					RequestsPerSec:     v.RequestsPerSec,
+					IntervalRequests:   v.IntervalRequests,
+					IntervalFailures:   v.IntervalFailures,
 				}
 			}
 		}
@@ -391,9 +817,14 @@ func toDomainMetricSnapshot(resp *MetricSnapshotResponse) *domain.MetricSnapshot
 		ErrorRate:         resp.ErrorRate,
 		AverageResponseMs: resp.AverageResponseMs,
 		P50ResponseMs:     resp.P50ResponseMs,
+		P90ResponseMs:     resp.P90ResponseMs,
 		P95ResponseMs:     resp.P95ResponseMs,
 		P99ResponseMs:     resp.P99ResponseMs,
+		P999ResponseMs:    resp.P999ResponseMs,
 		CurrentUsers:      resp.CurrentUsers,
+		ResponseTimes:     resp.ResponseTimes,
+		Failures:          resp.Failures,
+		Exceptions:        resp.Exceptions,
 	}
 	
 	if resp.RequestStats != nil {
@@ -409,6 +840,12 @@ func toDomainMetricSnapshot(resp *MetricSnapshotResponse) *domain.MetricSnapshot
 					MinResponseTime:    v.MinResponseTime,
 					MaxResponseTime:    v.MaxResponseTime,
 					MedianResponseTime: v.MedianResponseTime,
+					P50ResponseMs:      v.P50ResponseMs,
+					P90ResponseMs:      v.P90ResponseMs,
+					P95ResponseMs:      v.P95ResponseMs,
+					P99ResponseMs:      v.P99ResponseMs,
+					P999ResponseMs:     v.P999ResponseMs,
+					ResponseTimes:      v.ResponseTimes,
 					RequestsPerSec:     v.RequestsPerSec,
 				}
 			}
@@ -2,9 +2,11 @@ package api
 
 import (
 	"Load-manager-cli/internal/config"
+	"Load-manager-cli/internal/domain"
 	"Load-manager-cli/internal/service"
 	"Load-manager-cli/internal/store"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
@@ -12,20 +14,22 @@ import (
 
 // Handler contains all HTTP handlers for the API
 type Handler struct {
-	orchestrator         *service.Orchestrator
-	loadTestStore        store.LoadTestRepository
-	loadTestRunStore     store.LoadTestRunRepository
-	scriptRevisionStore  store.ScriptRevisionRepository
-	config               *config.Config
+	orchestrator        *service.Orchestrator
+	loadTestStore       store.LoadTestRepository
+	loadTestRunStore    store.LoadTestRunRepository
+	scriptRevisionStore store.ScriptRevisionRepository
+	scheduleStore       store.ScheduleRepository
+	config              *config.Config
 }
 
 // NewHandler creates a new API handler
-func NewHandler(orchestrator *service.Orchestrator, loadTestStore store.LoadTestRepository, loadTestRunStore store.LoadTestRunRepository, scriptRevisionStore store.ScriptRevisionRepository, config *config.Config) *Handler {
+func NewHandler(orchestrator *service.Orchestrator, loadTestStore store.LoadTestRepository, loadTestRunStore store.LoadTestRunRepository, scriptRevisionStore store.ScriptRevisionRepository, scheduleStore store.ScheduleRepository, config *config.Config) *Handler {
 	return &Handler{
 		orchestrator:        orchestrator,
 		loadTestStore:       loadTestStore,
 		loadTestRunStore:    loadTestRunStore,
 		scriptRevisionStore: scriptRevisionStore,
+		scheduleStore:       scheduleStore,
 		config:              config,
 	}
 }
@@ -48,6 +52,10 @@ func (h *Handler) LocustCallbackTestStart(w http.ResponseWriter, r *http.Request
 	
 	if err := h.orchestrator.HandleTestStart(req.RunID); err != nil {
 		log.Printf("Error handling test start callback: %v", err)
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			respondError(w, http.StatusConflict, "Test run can no longer be started", err)
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "Failed to handle test start", err)
 		return
 	}
@@ -77,7 +85,7 @@ func (h *Handler) LocustCallbackTestStop(w http.ResponseWriter, r *http.Request)
 	
 	finalMetrics := toDomainMetricSnapshot(req.FinalMetrics)
 	
-	if err := h.orchestrator.HandleTestStop(req.RunID, finalMetrics, req.AutoStopped); err != nil {
+	if err := h.orchestrator.HandleTestStop(req.RunID, req.ShardID, finalMetrics, req.AutoStopped); err != nil {
 		log.Printf("[API] Error handling test stop callback for runID %s: %v", req.RunID, err)
 		respondError(w, http.StatusInternalServerError, "Failed to handle test stop", err)
 		return
@@ -103,7 +111,7 @@ func (h *Handler) LocustCallbackMetrics(w http.ResponseWriter, r *http.Request)
 	
 	metrics := toDomainMetricSnapshot(req.Metrics)
 	
-	if err := h.orchestrator.UpdateMetrics(req.RunID, metrics); err != nil {
+	if err := h.orchestrator.UpdateMetrics(req.RunID, req.ShardID, metrics); err != nil {
 		log.Printf("Error updating metrics: %v", err)
 		respondError(w, http.StatusInternalServerError, "Failed to update metrics", err)
 		return
@@ -112,6 +120,34 @@ func (h *Handler) LocustCallbackMetrics(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, SuccessResponse{Success: true})
 }
 
+// LocustCallbackRequests handles POST /v1/internal/locust/requests
+// Called by Locust to push the requests it sampled since its previous batch
+func (h *Handler) LocustCallbackRequests(w http.ResponseWriter, r *http.Request) {
+	var req LocustCallbackRequestsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.RunID == "" {
+		respondError(w, http.StatusBadRequest, "runId is required", nil)
+		return
+	}
+
+	if req.SampleRate < 0 || req.SampleRate > 1 {
+		respondError(w, http.StatusBadRequest, "sampleRate must be between 0 and 1", nil)
+		return
+	}
+
+	if err := h.orchestrator.RecordRequestSamples(req.RunID, req.ShardID, req.Samples, req.SampleRate); err != nil {
+		log.Printf("[API] Error recording request samples for runID %s: %v", req.RunID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to record request samples", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Success: true})
+}
+
 // RegisterExternalTest handles POST /v1/internal/locust/register-external
 // Called by Locust when a test is started from the UI (not via API)
 func (h *Handler) RegisterExternalTest(w http.ResponseWriter, r *http.Request) {
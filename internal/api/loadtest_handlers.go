@@ -4,8 +4,12 @@ import (
 	"Load-manager-cli/internal/scriptprocessor"
 	"Load-manager-cli/internal/service"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"Load-manager-cli/internal/domain"
@@ -34,18 +38,40 @@ func (h *Handler) CreateLoadTest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.LoadProfile != nil {
+		if err := req.LoadProfile.Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid load profile", err)
+			return
+		}
+	}
+
+	if err := domain.ValidateThresholds(req.Thresholds); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid thresholds", err)
+		return
+	}
+
+	if err := domain.ValidateAbortRules(req.AbortRules); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid abort rules", err)
+		return
+	}
+
+	if !req.Engine.Known() {
+		respondError(w, http.StatusBadRequest, "Invalid engine", fmt.Errorf("unknown engine %q", req.Engine))
+		return
+	}
+
 	nowMillis := time.Now().UnixMilli()
 	testID := uuid.New().String()
 
-	// Automatically inject Harness plugin import into user script
-	log.Printf("[LoadTest] Injecting Harness plugin into script for test %s", testID)
-	enhancedScript, err := scriptprocessor.InjectHarnessPluginBase64(req.ScriptContent)
+	// Automatically inject Harness plugin import into Locust scripts, validate native scenarios
+	log.Printf("[LoadTest] Preparing %s script for test %s", req.Engine.OrDefault(), testID)
+	enhancedScript, err := prepareScript(req.Engine, req.ScriptContent)
 	if err != nil {
-		log.Printf("[LoadTest] Failed to inject plugin: %v", err)
+		log.Printf("[LoadTest] Failed to prepare script: %v", err)
 		respondError(w, http.StatusBadRequest, "Failed to process script", err)
 		return
 	}
-	log.Printf("[LoadTest] Plugin injection successful for test %s", testID)
+	log.Printf("[LoadTest] Script preparation successful for test %s", testID)
 
 	// Create initial script revision with enhanced script
 	revisionID := uuid.New().String()
@@ -75,6 +101,7 @@ func (h *Handler) CreateLoadTest(w http.ResponseWriter, r *http.Request) {
 		ProjectID:          req.ProjectID,
 		EnvID:              req.EnvID,
 		LocustClusterID:    req.LocustClusterID,
+		Engine:             req.Engine.OrDefault(),
 		TargetURL:          req.TargetURL,
 		LatestRevisionID:   revisionID,
 		ScenarioID:         req.ScenarioID,
@@ -82,6 +109,9 @@ func (h *Handler) CreateLoadTest(w http.ResponseWriter, r *http.Request) {
 		DefaultSpawnRate:   req.DefaultSpawnRate,
 		DefaultDurationSec: req.DefaultDurationSec,
 		MaxDurationSec:     req.MaxDurationSec,
+		LoadProfile:        req.LoadProfile,
+		Thresholds:         req.Thresholds,
+		AbortRules:         req.AbortRules,
 		RecentRuns:         []domain.RecentRun{},
 		CreatedAt:          nowMillis,
 		CreatedBy:          req.CreatedBy,
@@ -123,6 +153,9 @@ func (h *Handler) GetLoadTest(w http.ResponseWriter, r *http.Request) {
 		revision, err := h.scriptRevisionStore.Get(test.LatestRevisionID)
 		if err != nil {
 			log.Printf("[GetLoadTest] Failed to fetch script revision %s: %v", test.LatestRevisionID, err)
+		} else if test.Engine.OrDefault() != domain.EngineLocust {
+			// Only Locust scripts carry the plugin import
+			cleanScriptContent = revision.ScriptContent
 		} else {
 			// Strip plugin import to return clean user script
 			cleanScript, err := scriptprocessor.StripHarnessPluginBase64(revision.ScriptContent)
@@ -263,6 +296,27 @@ func (h *Handler) UpdateLoadTest(w http.ResponseWriter, r *http.Request) {
 	if req.MaxDurationSec != nil {
 		test.MaxDurationSec = req.MaxDurationSec
 	}
+	if req.LoadProfile != nil {
+		if err := req.LoadProfile.Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid load profile", err)
+			return
+		}
+		test.LoadProfile = req.LoadProfile
+	}
+	if req.Thresholds != nil {
+		if err := domain.ValidateThresholds(req.Thresholds); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid thresholds", err)
+			return
+		}
+		test.Thresholds = req.Thresholds
+	}
+	if req.AbortRules != nil {
+		if err := domain.ValidateAbortRules(req.AbortRules); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid abort rules", err)
+			return
+		}
+		test.AbortRules = req.AbortRules
+	}
 	if req.Metadata != nil {
 		test.Metadata = req.Metadata
 	}
@@ -306,15 +360,17 @@ func (h *Handler) DeleteLoadTest(w http.ResponseWriter, r *http.Request) {
 
 // CreateLoadTestRun godoc
 // @Summary Start a new load test run
-// @Description Creates and starts a new load test run using the latest script revision
+// @Description Creates and starts a new load test run using the latest script revision. If the Locust cluster is busy, the run is queued and started once the cluster frees up
 // @Tags Runs
 // @Accept json
 // @Produce json
 // @Param id path string true "Load Test ID"
 // @Param request body CreateLoadTestRunRequest true "Test run configuration"
 // @Success 201 {object} LoadTestRunResponse "Load test run started successfully"
+// @Success 202 {object} LoadTestRunResponse "Load test run queued until the cluster is free"
 // @Failure 400 {object} ErrorResponse "Invalid request or validation error"
 // @Failure 404 {object} ErrorResponse "Load test not found or no script available"
+// @Failure 409 {object} ErrorResponse "A cluster of the distributed run is running another run"
 // @Failure 500 {object} ErrorResponse "Failed to start load test run"
 // @Router /load-tests/{id}/runs [post]
 func (h *Handler) CreateLoadTestRun(w http.ResponseWriter, r *http.Request) {
@@ -334,86 +390,38 @@ func (h *Handler) CreateLoadTestRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Apply defaults from LoadTest, allow overrides
-	targetUsers := loadTest.DefaultUsers
-	if req.TargetUsers != nil {
-		targetUsers = *req.TargetUsers
-	}
-
-	spawnRate := loadTest.DefaultSpawnRate
-	if req.SpawnRate != nil {
-		spawnRate = *req.SpawnRate
-	}
-
-	var durationSeconds *int
-	if req.DurationSeconds != nil {
-		durationSeconds = req.DurationSeconds
-	} else if loadTest.DefaultDurationSec != nil {
-		durationSeconds = loadTest.DefaultDurationSec
-	}
-
-	// Validate against max duration if set
-	if loadTest.MaxDurationSec != nil && durationSeconds != nil && *durationSeconds > *loadTest.MaxDurationSec {
-		respondError(w, http.StatusBadRequest, "Duration exceeds maximum allowed duration", nil)
-		return
-	}
-
-	// Get the latest script revision
-	latestRevision, err := h.scriptRevisionStore.GetLatestByLoadTestID(loadTestID)
+	startedRun, err := h.orchestrator.LaunchLoadTestRun(loadTest, &service.LaunchRunRequest{
+		Name:            req.Name,
+		TargetURL:       req.TargetURL,
+		TargetUsers:     req.TargetUsers,
+		SpawnRate:       req.SpawnRate,
+		DurationSeconds: req.DurationSeconds,
+		LoadProfile:     req.LoadProfile,
+		Shards:          req.Shards,
+		CreatedBy:       req.CreatedBy,
+		Metadata:        req.Metadata,
+	})
 	if err != nil {
-		respondError(w, http.StatusNotFound, "No script found for this load test", err)
+		switch {
+		case errors.Is(err, service.ErrInvalidRunParameters):
+			respondError(w, http.StatusBadRequest, "Invalid run parameters", err)
+		case errors.Is(err, service.ErrNoScriptRevision):
+			respondError(w, http.StatusNotFound, "No script found for this load test", err)
+		case errors.Is(err, service.ErrShardClustersBusy):
+			respondError(w, http.StatusConflict, "Clusters of the distributed run are busy", err)
+		case errors.Is(err, service.ErrRunNotCreated):
+			respondError(w, http.StatusInternalServerError, "Failed to create load test run", err)
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to start load test", err)
+		}
 		return
 	}
 
-	nowMillis := time.Now().UnixMilli()
-	runID := uuid.New().String()
-	run := &domain.LoadTestRun{
-		ID:               runID,
-		LoadTestID:       loadTestID,
-		ScriptRevisionID: latestRevision.ID,
-		Name:             req.Name,
-		AccountID:        loadTest.AccountID,
-		OrgID:            loadTest.OrgID,
-		ProjectID:        loadTest.ProjectID,
-		EnvID:            loadTest.EnvID,
-		TargetUsers:      targetUsers,
-		SpawnRate:        spawnRate,
-		DurationSeconds:  durationSeconds,
-		Status:           domain.LoadTestRunStatusPending,
-		CreatedAt:        nowMillis,
-		CreatedBy:        req.CreatedBy,
-		UpdatedAt:        nowMillis,
-		UpdatedBy:        req.CreatedBy,
-		Metadata:         req.Metadata,
-	}
-
-	if err := h.loadTestRunStore.Create(run); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create load test run", err)
-		return
-	}
-
-	// Start the actual test via orchestrator
-	startReq := &service.CreateTestRunRequest{
-		LoadTestRunID:   runID,
-		AccountID:       loadTest.AccountID,
-		OrgID:           loadTest.OrgID,
-		ProjectID:       loadTest.ProjectID,
-		EnvID:           loadTest.EnvID,
-		ScriptContent:   latestRevision.ScriptContent, // Base64 encoded script
-		TargetURL:       loadTest.TargetURL,
-		TargetUsers:     targetUsers,
-		SpawnRate:       spawnRate,
-		DurationSeconds: durationSeconds,
-	}
-
-	startedRun, err := h.orchestrator.CreateTestRun(startReq)
-	if err != nil {
-		// Test creation failed, update run status to Failed
-		run.Status = domain.LoadTestRunStatusFailed
-		run.UpdatedAt = time.Now().UnixMilli()
-		h.loadTestRunStore.Update(run)
-
-		respondError(w, http.StatusInternalServerError, "Failed to start load test", err)
+	// The run is waiting for its cluster
+	if startedRun.Status == domain.LoadTestRunStatusPending {
+		response := toLoadTestRunResponse(startedRun)
+		response.QueuePosition = h.orchestrator.QueuePosition(startedRun.ID)
+		respondJSON(w, http.StatusAccepted, response)
 		return
 	}
 
@@ -439,7 +447,10 @@ func (h *Handler) GetLoadTestRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, toLoadTestRunResponse(run))
+	response := toLoadTestRunResponse(run)
+	response.QueuePosition = h.orchestrator.QueuePosition(run.ID)
+
+	respondJSON(w, http.StatusOK, response)
 }
 
 // ListLoadTestRuns godoc
@@ -452,7 +463,7 @@ func (h *Handler) GetLoadTestRun(w http.ResponseWriter, r *http.Request) {
 // @Param orgId query string false "Filter by organization ID"
 // @Param projectId query string false "Filter by project ID"
 // @Param name query string false "Filter by name (partial match)"
-// @Param status query string false "Filter by status (Pending, Running, Finished, Failed, Stopped)"
+// @Param status query string false "Filter by status (Pending, Running, Finished, Failed, Stopped, Aborted)"
 // @Param sortBy query string false "Sort by field: createdAt or updatedAt" default(createdAt)
 // @Param sortOrder query string false "Sort order: asc or desc" default(desc)
 // @Success 200 {array} LoadTestRunResponse "List of load test runs"
@@ -516,49 +527,160 @@ func (h *Handler) ListLoadTestRuns(w http.ResponseWriter, r *http.Request) {
 	responses := make([]*LoadTestRunResponse, len(runs))
 	for i, run := range runs {
 		responses[i] = toLoadTestRunResponse(run)
+		responses[i].QueuePosition = h.orchestrator.QueuePosition(run.ID)
 	}
 
 	respondJSON(w, http.StatusOK, responses)
 }
 
+// UpdateLoadTestRun godoc
+// @Summary Adjust the load of a running test
+// @Description Changes the number of users and/or spawn rate of a running load test without restarting it. Each change is recorded on the run
+// @Tags Runs
+// @Accept json
+// @Produce json
+// @Param id path string true "Load Test Run ID"
+// @Param request body UpdateLoadTestRunRequest true "New users and/or spawn rate"
+// @Success 200 {object} LoadTestRunResponse "Load adjusted successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Load test run not found"
+// @Failure 409 {object} ErrorResponse "Load test run is not running"
+// @Failure 500 {object} ErrorResponse "Failed to adjust load"
+// @Router /runs/{id} [patch]
+func (h *Handler) UpdateLoadTestRun(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	runID := vars["id"]
+
+	var req UpdateLoadTestRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	run, err := h.orchestrator.AdjustLoad(runID, &service.AdjustLoadRequest{
+		TargetUsers: req.TargetUsers,
+		SpawnRate:   req.SpawnRate,
+		ChangedBy:   req.UpdatedBy,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRunParameters):
+			respondError(w, http.StatusBadRequest, "Invalid run parameters", err)
+		case errors.Is(err, service.ErrRunNotFound):
+			respondError(w, http.StatusNotFound, "Load test run not found", err)
+		case errors.Is(err, service.ErrRunNotRunning):
+			respondError(w, http.StatusConflict, "Can only adjust running tests", err)
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to adjust load", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toLoadTestRunResponse(run))
+}
+
 // StopLoadTestRun godoc
 // @Summary Stop a running load test
-// @Description Stops a currently running load test run
+// @Description Stops a running load test run on Locust, or cancels a run still waiting in its cluster's queue
 // @Tags Runs
+// @Accept json
 // @Produce json
 // @Param id path string true "Load Test Run ID"
+// @Param request body StopLoadTestRunRequest false "Who is stopping the run"
 // @Success 200 {object} SuccessResponse "Load test run stopped successfully"
 // @Failure 404 {object} ErrorResponse "Load test run not found"
+// @Failure 409 {object} ErrorResponse "Load test run is not running"
 // @Failure 500 {object} ErrorResponse "Failed to stop load test run"
 // @Router /runs/{id}/stop [post]
 func (h *Handler) StopLoadTestRun(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	runID := vars["id"]
 
+	// The body is optional
+	var req StopLoadTestRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.StoppedBy == "" {
+		req.StoppedBy = "api"
+	}
+
+	if err := h.orchestrator.StopTestRun(runID, req.StoppedBy); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRunNotFound):
+			respondError(w, http.StatusNotFound, "Load test run not found", err)
+		case errors.Is(err, service.ErrRunNotRunning), errors.Is(err, domain.ErrInvalidTransition):
+			respondError(w, http.StatusConflict, "Can only stop pending or running tests", err)
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to stop load test run", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Load test run stopped",
+	})
+}
+
+// GetLoadTestRunEvents godoc
+// @Summary Get the status timeline of a load test run
+// @Description Returns every status transition of a run with who made it and why, oldest first
+// @Tags Runs
+// @Produce json
+// @Param id path string true "Load Test Run ID"
+// @Success 200 {object} RunEventsResponse
+// @Failure 404 {object} ErrorResponse "Load test run not found"
+// @Router /runs/{id}/events [get]
+func (h *Handler) GetLoadTestRunEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	runID := vars["id"]
+
 	run, err := h.loadTestRunStore.Get(runID)
 	if err != nil {
 		respondError(w, http.StatusNotFound, "Load test run not found", err)
 		return
 	}
 
-	if run.Status != domain.LoadTestRunStatusRunning {
-		respondError(w, http.StatusBadRequest, "Can only stop running tests", nil)
+	respondJSON(w, http.StatusOK, toRunEventsResponse(run))
+}
+
+// GetLoadTestRunFailures godoc
+// @Summary Get the failures and exceptions of a load test run
+// @Description Returns the most frequent failure groups (endpoint and error) and exceptions of a run with their occurrences and first/last-seen times
+// @Tags Runs
+// @Produce json
+// @Param id path string true "Load Test Run ID"
+// @Param limit query int false "Number of failure and exception groups to return (default: 10, max: 500)"
+// @Success 200 {object} RunFailuresResponse
+// @Failure 404 {object} ErrorResponse "Load test run not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /runs/{id}/failures [get]
+func (h *Handler) GetLoadTestRunFailures(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	runID := vars["id"]
+
+	if _, err := h.loadTestRunStore.Get(runID); err != nil {
+		respondError(w, http.StatusNotFound, "Load test run not found", err)
 		return
 	}
 
-	run.Status = domain.LoadTestRunStatusStopping
-	run.UpdatedAt = time.Now().UnixMilli()
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+			if limit > domain.MaxFailureGroups {
+				limit = domain.MaxFailureGroups
+			}
+		}
+	}
 
-	if err := h.loadTestRunStore.Update(run); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update load test run", err)
+	runFailures, err := h.orchestrator.GetRunFailures(runID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get run failures", err)
 		return
 	}
 
-	// TODO: Send stop command to Locust via orchestrator
-	// h.orchestrator.StopLoadTestRun(runID)
-
-	respondJSON(w, http.StatusOK, SuccessResponse{
-		Success: true,
-		Message: "Load test run stopping",
-	})
+	respondJSON(w, http.StatusOK, toRunFailuresResponse(runFailures, limit))
 }
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CreateSchedule godoc
+// @Summary Create a schedule for a load test
+// @Description Creates a cron schedule that starts runs of the load test, optionally overriding its run defaults
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Load Test ID"
+// @Param request body CreateScheduleRequest true "Schedule configuration"
+// @Success 201 {object} ScheduleResponse "Schedule created successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request or cron expression"
+// @Failure 404 {object} ErrorResponse "Load test not found"
+// @Failure 500 {object} ErrorResponse "Failed to create schedule"
+// @Router /load-tests/{id}/schedules [post]
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	loadTestID := vars["id"]
+
+	if _, err := h.loadTestStore.Get(loadTestID); err != nil {
+		respondError(w, http.StatusNotFound, "Load test not found", err)
+		return
+	}
+
+	var req CreateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	now := time.Now()
+	schedule := &domain.Schedule{
+		ID:             uuid.New().String(),
+		LoadTestID:     loadTestID,
+		Name:           req.Name,
+		CronExpression: req.CronExpression,
+		Timezone:       timezone,
+		Overrides:      req.Overrides,
+		Enabled:        enabled,
+		CreatedAt:      now.UnixMilli(),
+		CreatedBy:      req.CreatedBy,
+		UpdatedAt:      now.UnixMilli(),
+		UpdatedBy:      req.CreatedBy,
+	}
+
+	if err := planSchedule(schedule, now); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid schedule", err)
+		return
+	}
+
+	if err := h.scheduleStore.Create(schedule); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create schedule", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, toScheduleResponse(schedule))
+}
+
+// ListSchedules godoc
+// @Summary List schedules of a load test
+// @Description Returns all schedules attached to a load test
+// @Tags Schedules
+// @Produce json
+// @Param id path string true "Load Test ID"
+// @Success 200 {array} ScheduleResponse "List of schedules"
+// @Failure 404 {object} ErrorResponse "Load test not found"
+// @Failure 500 {object} ErrorResponse "Failed to list schedules"
+// @Router /load-tests/{id}/schedules [get]
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	loadTestID := vars["id"]
+
+	if _, err := h.loadTestStore.Get(loadTestID); err != nil {
+		respondError(w, http.StatusNotFound, "Load test not found", err)
+		return
+	}
+
+	schedules, err := h.scheduleStore.ListByLoadTestID(loadTestID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list schedules", err)
+		return
+	}
+
+	responses := make([]*ScheduleResponse, len(schedules))
+	for i, schedule := range schedules {
+		responses[i] = toScheduleResponse(schedule)
+	}
+
+	respondJSON(w, http.StatusOK, responses)
+}
+
+// GetSchedule godoc
+// @Summary Get a schedule
+// @Description Returns a schedule with its last and next fire times and recent firings
+// @Tags Schedules
+// @Produce json
+// @Param id path string true "Load Test ID"
+// @Param scheduleId path string true "Schedule ID"
+// @Success 200 {object} ScheduleResponse "Schedule details"
+// @Failure 404 {object} ErrorResponse "Schedule not found"
+// @Router /load-tests/{id}/schedules/{scheduleId} [get]
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.getScheduleForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toScheduleResponse(schedule))
+}
+
+// UpdateSchedule godoc
+// @Summary Update a schedule
+// @Description Updates the cron expression, timezone, overrides or enabled state of a schedule. Set enabled to false to pause it
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Load Test ID"
+// @Param scheduleId path string true "Schedule ID"
+// @Param request body UpdateScheduleRequest true "Fields to update"
+// @Success 200 {object} ScheduleResponse "Schedule updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request or cron expression"
+// @Failure 404 {object} ErrorResponse "Schedule not found"
+// @Failure 500 {object} ErrorResponse "Failed to update schedule"
+// @Router /load-tests/{id}/schedules/{scheduleId} [put]
+func (h *Handler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.getScheduleForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.Name != nil {
+		schedule.Name = *req.Name
+	}
+	if req.CronExpression != nil {
+		schedule.CronExpression = *req.CronExpression
+	}
+	if req.Timezone != nil {
+		schedule.Timezone = *req.Timezone
+		if schedule.Timezone == "" {
+			schedule.Timezone = "UTC"
+		}
+	}
+	if req.Overrides != nil {
+		schedule.Overrides = req.Overrides
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+
+	now := time.Now()
+	schedule.UpdatedAt = now.UnixMilli()
+	schedule.UpdatedBy = req.UpdatedBy
+
+	if err := planSchedule(schedule, now); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid schedule", err)
+		return
+	}
+
+	if err := h.scheduleStore.Update(schedule); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update schedule", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toScheduleResponse(schedule))
+}
+
+// DeleteSchedule godoc
+// @Summary Delete a schedule
+// @Description Deletes a schedule; runs it already created are kept
+// @Tags Schedules
+// @Produce json
+// @Param id path string true "Load Test ID"
+// @Param scheduleId path string true "Schedule ID"
+// @Success 200 {object} SuccessResponse "Schedule deleted successfully"
+// @Failure 404 {object} ErrorResponse "Schedule not found"
+// @Failure 500 {object} ErrorResponse "Failed to delete schedule"
+// @Router /load-tests/{id}/schedules/{scheduleId} [delete]
+func (h *Handler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.getScheduleForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.scheduleStore.Delete(schedule.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete schedule", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Schedule deleted successfully",
+	})
+}
+
+// getScheduleForRequest loads the schedule addressed by the request and checks it belongs to the load test
+// Writes a 404 response and returns false if it does not
+func (h *Handler) getScheduleForRequest(w http.ResponseWriter, r *http.Request) (*domain.Schedule, bool) {
+	vars := mux.Vars(r)
+	loadTestID := vars["id"]
+	scheduleID := vars["scheduleId"]
+
+	schedule, err := h.scheduleStore.Get(scheduleID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Schedule not found", err)
+		return nil, false
+	}
+
+	if schedule.LoadTestID != loadTestID {
+		respondError(w, http.StatusNotFound, "Schedule not found",
+			fmt.Errorf("schedule %s does not belong to load test %s", scheduleID, loadTestID))
+		return nil, false
+	}
+
+	return schedule, true
+}
+
+// planSchedule validates a schedule and computes its next fire time
+// Paused schedules have no next fire time
+func planSchedule(schedule *domain.Schedule, now time.Time) error {
+	if schedule.CronExpression == "" {
+		return fmt.Errorf("cronExpression is required")
+	}
+
+	if schedule.Overrides != nil && schedule.Overrides.LoadProfile != nil {
+		if err := schedule.Overrides.LoadProfile.Validate(); err != nil {
+			return fmt.Errorf("invalid load profile: %w", err)
+		}
+	}
+
+	next, err := service.NextScheduleFire(schedule.CronExpression, schedule.Timezone, now)
+	if err != nil {
+		return err
+	}
+
+	if schedule.Enabled {
+		schedule.NextFireAt = next.UnixMilli()
+	} else {
+		schedule.NextFireAt = 0
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/loadgen"
 	"Load-manager-cli/internal/scriptprocessor"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -54,15 +55,15 @@ func (h *Handler) UpdateScript(w http.ResponseWriter, r *http.Request) {
 		nextRevisionNumber = latestRevision.RevisionNumber + 1
 	}
 
-	// Automatically inject Harness plugin import into user script
-	log.Printf("[Script] Injecting Harness plugin into script revision %d for test %s", nextRevisionNumber, testID)
-	enhancedScript, err := scriptprocessor.InjectHarnessPluginBase64(req.ScriptContent)
+	// Automatically inject Harness plugin import into Locust scripts, validate native scenarios
+	log.Printf("[Script] Preparing %s script revision %d for test %s", loadTest.Engine.OrDefault(), nextRevisionNumber, testID)
+	enhancedScript, err := prepareScript(loadTest.Engine, req.ScriptContent)
 	if err != nil {
-		log.Printf("[Script] Failed to inject plugin: %v", err)
+		log.Printf("[Script] Failed to prepare script: %v", err)
 		respondError(w, http.StatusBadRequest, "Failed to process script", err)
 		return
 	}
-	log.Printf("[Script] Plugin injection successful for revision %d", nextRevisionNumber)
+	log.Printf("[Script] Script preparation successful for revision %d", nextRevisionNumber)
 
 	// Create new revision with enhanced script
 	nowMillis := time.Now().UnixMilli()
@@ -176,3 +177,17 @@ func (h *Handler) ListScriptRevisions(w http.ResponseWriter, r *http.Request) {
 
 	respondJSON(w, http.StatusOK, responses)
 }
+
+// prepareScript turns a base64 encoded user script into the content stored for the given engine
+// Locust scripts get the Harness plugin import, native scenarios are stored as-is once they parse
+func prepareScript(engineType domain.EngineType, scriptContent string) (string, error) {
+	switch engineType.OrDefault() {
+	case domain.EngineNative:
+		if _, err := loadgen.ParseScenarioBase64(scriptContent); err != nil {
+			return "", err
+		}
+		return scriptContent, nil
+	default:
+		return scriptprocessor.InjectHarnessPluginBase64(scriptContent)
+	}
+}
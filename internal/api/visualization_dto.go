@@ -1,34 +1,47 @@
 package api
 
 import (
+	"Load-manager-cli/internal/domain"
 	"time"
 )
 
 // TimeseriesDataPoint represents a single point in time-series chart
+// The P*ResponseMs percentiles cover the test so far; the Window* ones only the responses since the previous
+// point, and are only set when the points carry response time histograms
 type TimeseriesDataPoint struct {
-	Timestamp     time.Time `json:"timestamp"`
-	TotalRPS      float64   `json:"totalRps"`
-	CurrentUsers  int       `json:"currentUsers"`
-	P50ResponseMs float64   `json:"p50ResponseMs"`
-	P95ResponseMs float64   `json:"p95ResponseMs"`
-	P99ResponseMs float64   `json:"p99ResponseMs"`
-	ErrorRate     float64   `json:"errorRate"`
+	Timestamp           time.Time `json:"timestamp"`
+	TotalRPS            float64   `json:"totalRps"`
+	CurrentUsers        int       `json:"currentUsers"`
+	P50ResponseMs       float64   `json:"p50ResponseMs"`
+	P95ResponseMs       float64   `json:"p95ResponseMs"`
+	P99ResponseMs       float64   `json:"p99ResponseMs"`
+	WindowP50ResponseMs float64   `json:"windowP50ResponseMs,omitempty"`
+	WindowP95ResponseMs float64   `json:"windowP95ResponseMs,omitempty"`
+	WindowP99ResponseMs float64   `json:"windowP99ResponseMs,omitempty"`
+	ErrorRate           float64   `json:"errorRate"`
+	IntervalRequests    int64     `json:"intervalRequests,omitempty"` // Requests since the previous point
+	IntervalFailures    int64     `json:"intervalFailures,omitempty"` // Failures since the previous point
+	WindowRPS           float64   `json:"windowRps,omitempty"`        // Requests per second since the previous point
+	WindowErrorRate     float64   `json:"windowErrorRate,omitempty"`  // Error rate since the previous point
 }
 
 // TimeseriesChartResponse is for line charts (RPS, latency over time)
 type TimeseriesChartResponse struct {
-	TestRunID  string                `json:"testRunId"`
-	DataPoints []TimeseriesDataPoint `json:"dataPoints"`
-	Summary    AggregatedSummary     `json:"summary"`
+	TestRunID   string                `json:"testRunId"`
+	DataPoints  []TimeseriesDataPoint `json:"dataPoints"`
+	Annotations []GraphAnnotation     `json:"annotations"` // Moments when the load was changed
+	Summary     AggregatedSummary     `json:"summary"`
 }
 
-// ScatterDataPoint represents a single request for scatter plot
+// ScatterDataPoint represents a single sampled request for scatter plot
 type ScatterDataPoint struct {
 	Timestamp      time.Time `json:"timestamp"`
 	Endpoint       string    `json:"endpoint"`
 	Method         string    `json:"method"`
 	ResponseTimeMs float64   `json:"responseTimeMs"`
+	StatusCode     int       `json:"statusCode"` // 0 if no response was received
 	Success        bool      `json:"success"`
+	ShardID        string    `json:"shardId,omitempty"`
 }
 
 // ScatterPlotResponse is for scatter plots (response time distribution)
@@ -36,6 +49,7 @@ type ScatterPlotResponse struct {
 	TestRunID  string             `json:"testRunId"`
 	DataPoints []ScatterDataPoint `json:"dataPoints"`
 	Endpoints  []string           `json:"endpoints"`
+	SampleRate float64            `json:"sampleRate"` // Fraction of requests sampled, to scale counts back up
 }
 
 // AggregatedSummary provides aggregated statistics
@@ -43,10 +57,15 @@ type AggregatedSummary struct {
 	AvgRPS           float64 `json:"avgRps"`
 	MaxRPS           float64 `json:"maxRps"`
 	MinRPS           float64 `json:"minRps"`
-	AvgP50Latency    float64 `json:"avgP50Latency"`
-	AvgP95Latency    float64 `json:"avgP95Latency"`
-	AvgP99Latency    float64 `json:"avgP99Latency"`
+	AvgP50Latency    float64 `json:"avgP50Latency"` // Deprecated: average of the points' percentiles, kept for existing dashboards; use p50Latency
+	AvgP95Latency    float64 `json:"avgP95Latency"` // Deprecated: use p95Latency
+	AvgP99Latency    float64 `json:"avgP99Latency"` // Deprecated: use p99Latency
 	MaxP95Latency    float64 `json:"maxP95Latency"`
+	P50Latency       float64 `json:"p50Latency,omitempty"` // Whole-run percentiles, see store.AggregatedMetrics
+	P90Latency       float64 `json:"p90Latency,omitempty"`
+	P95Latency       float64 `json:"p95Latency,omitempty"`
+	P99Latency       float64 `json:"p99Latency,omitempty"`
+	P999Latency      float64 `json:"p999Latency,omitempty"`
 	TotalRequests    int64   `json:"totalRequests"`
 	TotalFailures    int64   `json:"totalFailures"`
 	OverallErrorRate float64 `json:"overallErrorRate"`
@@ -66,6 +85,7 @@ type EndpointStatsResponse struct {
 	MaxResponseTimeMs float64 `json:"maxResponseTimeMs"`
 	P50ResponseMs     float64 `json:"p50ResponseMs"`
 	P95ResponseMs     float64 `json:"p95ResponseMs"`
+	P99ResponseMs     float64 `json:"p99ResponseMs"`
 	AvgRPS            float64 `json:"avgRps"`
 }
 
@@ -89,11 +109,21 @@ type GraphDataPoint struct {
 
 // RunGraphResponse returns minimal graph data for plotting
 type RunGraphResponse struct {
-	RunID      string            `json:"runId"`
-	RunName    string            `json:"runName"`
-	Status     string            `json:"status"`
-	StartedAt  string            `json:"startedAt"`
-	DataPoints []GraphDataPoint  `json:"dataPoints"`
+	RunID       string            `json:"runId"`
+	RunName     string            `json:"runName"`
+	Status      string            `json:"status"`
+	StartedAt   string            `json:"startedAt"`
+	DataPoints  []GraphDataPoint  `json:"dataPoints"`
+	Annotations []GraphAnnotation `json:"annotations"` // Moments when the load was changed
+}
+
+// GraphAnnotation marks a moment on a graph when the load of the run changed
+type GraphAnnotation struct {
+	Timestamp int64   `json:"timestamp"` // Unix milliseconds
+	Type      string  `json:"type"`      // "load_change" (manual adjustment) or "stage" (load profile stage)
+	Label     string  `json:"label"`
+	Users     int     `json:"users"`
+	SpawnRate float64 `json:"spawnRate"`
 }
 
 // RunSummaryResponse returns the 4 key metrics for the summary cards
@@ -106,28 +136,34 @@ type RunSummaryResponse struct {
 	Duration        string  `json:"duration"`
 	TotalRequests   int64   `json:"totalRequests"`
 	RequestsPerSec  float64 `json:"requestsPerSec"`
-	ErrorRate       float64 `json:"errorRate"`        // Percentage
+	ErrorRate       float64 `json:"errorRate"`       // Percentage
 	AvgResponseTime float64 `json:"avgResponseTime"` // In seconds
 	// Test configuration
 	TargetUsers     int     `json:"targetUsers"`
 	SpawnRate       float64 `json:"spawnRate"`
 	DurationSeconds *int    `json:"durationSeconds,omitempty"`
+	// Threshold evaluation, set once the run has ended
+	Verdict *domain.Verdict `json:"verdict,omitempty"`
 }
 
-// RequestLogEntry represents a single request in the live log
+// RequestLogEntry represents a single sampled request in the live log
 type RequestLogEntry struct {
-	Timestamp      int64   `json:"timestamp"`      // Unix milliseconds
-	RequestType    string  `json:"requestType"`    // GET, POST, etc.
-	ResponseTime   float64 `json:"responseTime"`   // In milliseconds
+	Timestamp      int64   `json:"timestamp"`    // Unix milliseconds
+	RequestType    string  `json:"requestType"`  // GET, POST, etc.
+	ResponseTime   float64 `json:"responseTime"` // In milliseconds
 	URL            string  `json:"url"`
-	ResponseLength int64   `json:"responseLength"`
+	StatusCode     int     `json:"statusCode"`     // 0 if no response was received
+	ResponseLength int64   `json:"responseLength"` // Bytes
 	Success        bool    `json:"success"`
+	Exception      string  `json:"exception,omitempty"` // Why the request failed
+	ShardID        string  `json:"shardId,omitempty"`
 }
 
 // LiveRequestLogResponse returns recent individual requests
 type LiveRequestLogResponse struct {
-	RunID    string             `json:"runId"`
-	Requests []RequestLogEntry  `json:"requests"`
-	Total    int                `json:"total"`    // Total requests in the log
-	Limit    int                `json:"limit"`    // Number of requests returned
+	RunID      string            `json:"runId"`
+	Requests   []RequestLogEntry `json:"requests"`
+	Total      int               `json:"total"`      // Sampled requests matching the filters
+	Limit      int               `json:"limit"`      // Maximum number of requests returned
+	SampleRate float64           `json:"sampleRate"` // Fraction of requests sampled
 }
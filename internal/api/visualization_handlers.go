@@ -3,24 +3,30 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
+	"Load-manager-cli/internal/domain"
 	"Load-manager-cli/internal/store"
 	"github.com/gorilla/mux"
 )
 
 // VisualizationHandler handles visualization API endpoints
 type VisualizationHandler struct {
-	loadTestRunStore *store.MongoLoadTestRunStore
-	metricsStore     *store.MongoMetricsStore
+	loadTestRunStore   *store.MongoLoadTestRunStore
+	metricsStore       *store.MongoMetricsStore
+	requestSampleStore *store.MongoRequestSampleStore
 }
 
 // NewVisualizationHandler creates a new visualization handler
-func NewVisualizationHandler(loadTestRunStore *store.MongoLoadTestRunStore, metricsStore *store.MongoMetricsStore) *VisualizationHandler {
+func NewVisualizationHandler(loadTestRunStore *store.MongoLoadTestRunStore, metricsStore *store.MongoMetricsStore, requestSampleStore *store.MongoRequestSampleStore) *VisualizationHandler {
 	return &VisualizationHandler{
-		loadTestRunStore: loadTestRunStore,
-		metricsStore:     metricsStore,
+		loadTestRunStore:   loadTestRunStore,
+		metricsStore:       metricsStore,
+		requestSampleStore: requestSampleStore,
 	}
 }
 
@@ -32,6 +38,7 @@ func NewVisualizationHandler(loadTestRunStore *store.MongoLoadTestRunStore, metr
 // @Param id path string true "Load Test Run ID"
 // @Param from query string false "Start time in RFC3339 format"
 // @Param to query string false "End time in RFC3339 format"
+// @Param shardId query string false "Cluster ID of one shard of a distributed run; defaults to the merged run"
 // @Success 200 {object} TimeseriesChartResponse "Detailed timeseries metrics"
 // @Failure 404 {object} ErrorResponse "Load test run not found"
 // @Failure 500 {object} ErrorResponse "Failed to fetch timeseries data"
@@ -59,7 +66,7 @@ func (h *VisualizationHandler) GetTimeseriesChart(w http.ResponseWriter, r *http
 		toMillis = toTime.UnixMilli()
 	}
 
-	metrics, err := h.metricsStore.GetMetricsTimeseries(ctx, loadTestRunID, fromMillis, toMillis)
+	metrics, err := h.getMetricsTimeseries(ctx, r, loadTestRunID, fromMillis, toMillis)
 	if err != nil {
 		http.Error(w, "Failed to fetch metrics: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -70,18 +77,7 @@ func (h *VisualizationHandler) GetTimeseriesChart(w http.ResponseWriter, r *http
 		aggMetrics = &store.AggregatedMetrics{}
 	}
 
-	dataPoints := make([]TimeseriesDataPoint, len(metrics))
-	for i, m := range metrics {
-		dataPoints[i] = TimeseriesDataPoint{
-			Timestamp:     m.Timestamp,
-			TotalRPS:      m.TotalRPS,
-			CurrentUsers:  m.CurrentUsers,
-			P50ResponseMs: m.P50ResponseMs,
-			P95ResponseMs: m.P95ResponseMs,
-			P99ResponseMs: m.P99ResponseMs,
-			ErrorRate:     m.ErrorRate,
-		}
-	}
+	dataPoints := toTimeseriesDataPoints(metrics, fromMillis == 0)
 
 	duration := "N/A"
 	if loadTestRun.StartedAt > 0 && loadTestRun.FinishedAt > 0 {
@@ -91,22 +87,10 @@ func (h *VisualizationHandler) GetTimeseriesChart(w http.ResponseWriter, r *http
 	}
 
 	response := TimeseriesChartResponse{
-		TestRunID:  loadTestRunID,
-		DataPoints: dataPoints,
-		Summary: AggregatedSummary{
-			AvgRPS:           aggMetrics.AvgRPS,
-			MaxRPS:           aggMetrics.MaxRPS,
-			MinRPS:           aggMetrics.MinRPS,
-			AvgP50Latency:    aggMetrics.AvgP50,
-			AvgP95Latency:    aggMetrics.AvgP95,
-			AvgP99Latency:    aggMetrics.AvgP99,
-			MaxP95Latency:    aggMetrics.MaxP95,
-			TotalRequests:    aggMetrics.TotalRequests,
-			TotalFailures:    aggMetrics.TotalFailures,
-			OverallErrorRate: calculateErrorRate(aggMetrics.TotalRequests, aggMetrics.TotalFailures),
-			DataPoints:       aggMetrics.DataPoints,
-			Duration:         duration,
-		},
+		TestRunID:   loadTestRunID,
+		DataPoints:  dataPoints,
+		Annotations: loadAnnotations(loadTestRun, fromMillis, toMillis),
+		Summary:     toAggregatedSummary(aggMetrics, duration),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -115,12 +99,18 @@ func (h *VisualizationHandler) GetTimeseriesChart(w http.ResponseWriter, r *http
 
 // GetScatterPlot godoc
 // @Summary Get scatter plot data
-// @Description Returns scatter plot data for response time distribution analysis
+// @Description Returns the sampled requests of a run, one point per request, for response time distribution analysis
 // @Tags Visualization
 // @Produce json
 // @Param id path string true "Load Test Run ID"
 // @Param from query string false "Start time in RFC3339 format"
 // @Param to query string false "End time in RFC3339 format"
+// @Param shardId query string false "Cluster ID of one shard of a distributed run; defaults to every shard"
+// @Param method query string false "Only requests with this method"
+// @Param name query string false "Only requests to this endpoint name"
+// @Param status query string false "Only successful or failed requests" Enums(success, failure)
+// @Param statusCode query int false "Only requests with this HTTP status code"
+// @Param minResponseMs query number false "Only requests at least this slow"
 // @Success 200 {object} ScatterPlotResponse "Scatter plot data points"
 // @Failure 404 {object} ErrorResponse "Load test run not found"
 // @Failure 500 {object} ErrorResponse "Failed to fetch scatter plot data"
@@ -129,51 +119,46 @@ func (h *VisualizationHandler) GetScatterPlot(w http.ResponseWriter, r *http.Req
 	vars := mux.Vars(r)
 	loadTestRunID := vars["id"]
 
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
-
-	fromTime, toTime := parseTimeRange(r)
-	// Convert time.Time to Unix milliseconds
-	var fromMillis, toMillis int64
-	if !fromTime.IsZero() {
-		fromMillis = fromTime.UnixMilli()
-	}
-	if !toTime.IsZero() {
-		toMillis = toTime.UnixMilli()
+	if _, err := h.loadTestRunStore.Get(loadTestRunID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
 	}
 
-	metrics, err := h.metricsStore.GetMetricsTimeseries(ctx, loadTestRunID, fromMillis, toMillis)
+	sampleLog, err := h.requestSampleStore.Get(loadTestRunID)
 	if err != nil {
-		http.Error(w, "Failed to fetch metrics: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to fetch request samples: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	var dataPoints []ScatterDataPoint
+	samples := sampleLog.Filter(parseRequestSampleFilter(r))
+	dataPoints := make([]ScatterDataPoint, 0, len(samples))
 	endpointsMap := make(map[string]bool)
 
-	for _, m := range metrics {
-		for _, stat := range m.RequestStats {
-			dataPoints = append(dataPoints, ScatterDataPoint{
-				Timestamp:      m.Timestamp,
-				Endpoint:       stat.Name,
-				Method:         stat.Method,
-				ResponseTimeMs: stat.AvgResponseTimeMs,
-				Success:        stat.NumFailures == 0,
-			})
-
-			endpointsMap[stat.Method+" "+stat.Name] = true
-		}
+	for _, sample := range samples {
+		dataPoints = append(dataPoints, ScatterDataPoint{
+			Timestamp:      time.UnixMilli(sample.Timestamp).UTC(),
+			Endpoint:       sample.Name,
+			Method:         sample.Method,
+			ResponseTimeMs: sample.ResponseTimeMs,
+			StatusCode:     sample.StatusCode,
+			Success:        sample.Success(),
+			ShardID:        sample.ShardID,
+		})
+
+		endpointsMap[sample.Method+" "+sample.Name] = true
 	}
 
 	endpoints := make([]string, 0, len(endpointsMap))
 	for endpoint := range endpointsMap {
 		endpoints = append(endpoints, endpoint)
 	}
+	sort.Strings(endpoints)
 
 	response := ScatterPlotResponse{
 		TestRunID:  loadTestRunID,
 		DataPoints: dataPoints,
 		Endpoints:  endpoints,
+		SampleRate: sampleLog.SampleRate,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -216,91 +201,191 @@ func (h *VisualizationHandler) GetAggregatedStats(w http.ResponseWriter, r *http
 		aggMetrics = &store.AggregatedMetrics{}
 	}
 
-	timeseriesPoints := make([]TimeseriesDataPoint, len(metrics))
-	for i, m := range metrics {
-		timeseriesPoints[i] = TimeseriesDataPoint{
-			Timestamp:     m.Timestamp,
-			TotalRPS:      m.TotalRPS,
-			CurrentUsers:  m.CurrentUsers,
-			P50ResponseMs: m.P50ResponseMs,
-			P95ResponseMs: m.P95ResponseMs,
-			P99ResponseMs: m.P99ResponseMs,
-			ErrorRate:     m.ErrorRate,
-		}
+	timeseriesPoints := toTimeseriesDataPoints(metrics, true)
+	endpointStats := aggregateEndpointStats(metrics, loadTestRun.StartedAt)
+
+	duration := "N/A"
+	if loadTestRun.StartedAt > 0 && loadTestRun.FinishedAt > 0 {
+		startTime := time.UnixMilli(loadTestRun.StartedAt)
+		endTime := time.UnixMilli(loadTestRun.FinishedAt)
+		duration = endTime.Sub(startTime).String()
+	}
+
+	response := VisualizationSummaryResponse{
+		TestRunID:     loadTestRunID,
+		Status:        string(loadTestRun.Status),
+		Timeseries:    timeseriesPoints,
+		EndpointStats: endpointStats,
+		Summary:       toAggregatedSummary(aggMetrics, duration),
 	}
 
-	endpointStatsMap := make(map[string]*EndpointStatsResponse)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// endpointTotals accumulates an endpoint's stats over a run's points
+type endpointTotals struct {
+	stats          *EndpointStatsResponse
+	last           store.RequestStatDocument // The endpoint's cumulative stats at the latest point
+	bankedRequests int64                     // Requests counted before the latest stats reset
+	bankedTimeMs   float64                   // Total response time of those requests
+}
 
+// aggregateEndpointStats summarizes each endpoint over a run's points, oldest first
+// Counts and rates add up the points' intervals; points stored before intervals were leave their cumulative
+// counters for the last one to set. The average response time is the latest cumulative one, weighted with the
+// averages the stats had before any reset. Percentiles come from the merged histograms, or are the latest
+// cumulative ones if the points carry no histograms
+func aggregateEndpointStats(metrics []store.MetricsDocument, startedAt int64) []EndpointStatsResponse {
+	totals := make(map[string]*endpointTotals)
+	var keys []string
+	var intervalMs, lastTimestamp int64
 	for _, m := range metrics {
+		if m.Interval != nil {
+			intervalMs += m.Interval.DurationMs()
+		}
+		lastTimestamp = m.Timestamp.UnixMilli()
+
 		for _, stat := range m.RequestStats {
 			key := stat.Method + ":" + stat.Name
+			requests, failures := stat.IntervalRequests, stat.IntervalFailures
+			if m.Interval == nil {
+				requests, failures = stat.NumRequests, stat.NumFailures
+			}
 
-			if existing, ok := endpointStatsMap[key]; ok {
-				existing.TotalRequests += stat.NumRequests
-				existing.TotalFailures += stat.NumFailures
-				existing.AvgResponseTimeMs = (existing.AvgResponseTimeMs + stat.AvgResponseTimeMs) / 2
-				if stat.MinResponseTimeMs < existing.MinResponseTimeMs {
-					existing.MinResponseTimeMs = stat.MinResponseTimeMs
-				}
-				if stat.MaxResponseTimeMs > existing.MaxResponseTimeMs {
-					existing.MaxResponseTimeMs = stat.MaxResponseTimeMs
-				}
-				existing.P50ResponseMs = (existing.P50ResponseMs + stat.P50ResponseMs) / 2
-				existing.P95ResponseMs = (existing.P95ResponseMs + stat.P95ResponseMs) / 2
-				existing.AvgRPS = (existing.AvgRPS + stat.RequestsPerSec) / 2
-			} else {
-				endpointStatsMap[key] = &EndpointStatsResponse{
+			t, ok := totals[key]
+			if !ok {
+				t = &endpointTotals{stats: &EndpointStatsResponse{
 					Endpoint:          stat.Name,
 					Method:            stat.Method,
-					TotalRequests:     stat.NumRequests,
-					TotalFailures:     stat.NumFailures,
-					AvgResponseTimeMs: stat.AvgResponseTimeMs,
 					MinResponseTimeMs: stat.MinResponseTimeMs,
-					MaxResponseTimeMs: stat.MaxResponseTimeMs,
-					P50ResponseMs:     stat.P50ResponseMs,
-					P95ResponseMs:     stat.P95ResponseMs,
-					AvgRPS:            stat.RequestsPerSec,
-				}
+				}}
+				totals[key] = t
+				keys = append(keys, key)
+			}
+
+			if m.Interval != nil {
+				t.stats.TotalRequests += requests
+				t.stats.TotalFailures += failures
+			} else {
+				t.stats.TotalRequests, t.stats.TotalFailures = requests, failures
+			}
+			if stat.MinResponseTimeMs < t.stats.MinResponseTimeMs {
+				t.stats.MinResponseTimeMs = stat.MinResponseTimeMs
 			}
+			if stat.MaxResponseTimeMs > t.stats.MaxResponseTimeMs {
+				t.stats.MaxResponseTimeMs = stat.MaxResponseTimeMs
+			}
+
+			if stat.NumRequests < t.last.NumRequests {
+				t.bankedRequests += t.last.NumRequests
+				t.bankedTimeMs += t.last.AvgResponseTimeMs * float64(t.last.NumRequests)
+			}
+			t.last = stat
 		}
 	}
 
-	endpointStats := make([]EndpointStatsResponse, 0, len(endpointStatsMap))
-	for _, stat := range endpointStatsMap {
+	histograms := store.FoldHistograms(metrics)
+	endpointStats := make([]EndpointStatsResponse, 0, len(keys))
+	for _, key := range keys {
+		t := totals[key]
+		stat := t.stats
+
+		if requests := t.bankedRequests + t.last.NumRequests; requests > 0 {
+			stat.AvgResponseTimeMs = (t.bankedTimeMs + t.last.AvgResponseTimeMs*float64(t.last.NumRequests)) / float64(requests)
+		}
+
+		if histogram := histograms.Endpoints[key]; histogram.Count() > 0 {
+			stat.P50ResponseMs = histogram.Percentile(0.50)
+			stat.P95ResponseMs = histogram.Percentile(0.95)
+			stat.P99ResponseMs = histogram.Percentile(0.99)
+		} else {
+			stat.P50ResponseMs = t.last.P50ResponseMs
+			stat.P95ResponseMs = t.last.P95ResponseMs
+			stat.P99ResponseMs = t.last.P99ResponseMs
+		}
+
 		stat.ErrorRate = calculateErrorRate(stat.TotalRequests, stat.TotalFailures)
+		switch {
+		case intervalMs > 0:
+			stat.AvgRPS = float64(stat.TotalRequests) * 1000 / float64(intervalMs)
+		case startedAt > 0 && lastTimestamp > startedAt:
+			stat.AvgRPS = float64(stat.TotalRequests) * 1000 / float64(lastTimestamp-startedAt)
+		default:
+			stat.AvgRPS = t.last.RequestsPerSec
+		}
+
 		endpointStats = append(endpointStats, *stat)
 	}
+	return endpointStats
+}
 
-	duration := "N/A"
-	if loadTestRun.StartedAt > 0 && loadTestRun.FinishedAt > 0 {
-		startTime := time.UnixMilli(loadTestRun.StartedAt)
-		endTime := time.UnixMilli(loadTestRun.FinishedAt)
-		duration = endTime.Sub(startTime).String()
+// toTimeseriesDataPoints converts metric points to chart points
+// Window percentiles come from the responses each point recorded since the previous one; for points stored
+// with cumulative histograms, the first point's window is only known if the points start with the test.
+// Window rates come from the points' intervals
+func toTimeseriesDataPoints(metrics []store.MetricsDocument, fromStart bool) []TimeseriesDataPoint {
+	dataPoints := make([]TimeseriesDataPoint, len(metrics))
+	windows := store.PointHistograms(metrics)
+	for i, m := range metrics {
+		dataPoints[i] = TimeseriesDataPoint{
+			Timestamp:     m.Timestamp,
+			TotalRPS:      m.TotalRPS,
+			CurrentUsers:  m.CurrentUsers,
+			P50ResponseMs: m.P50ResponseMs,
+			P95ResponseMs: m.P95ResponseMs,
+			P99ResponseMs: m.P99ResponseMs,
+			ErrorRate:     m.ErrorRate,
+		}
+		if m.Interval != nil {
+			dataPoints[i].IntervalRequests = m.Interval.Requests
+			dataPoints[i].IntervalFailures = m.Interval.Failures
+			if durationMs := m.Interval.DurationMs(); durationMs > 0 {
+				dataPoints[i].WindowRPS = float64(m.Interval.Requests) * 1000 / float64(durationMs)
+			}
+			dataPoints[i].WindowErrorRate = calculateErrorRate(m.Interval.Requests, m.Interval.Failures)
+		}
+
+		window := windows[i].Total
+		if window == nil || (i == 0 && !fromStart && m.ResponseTimes != nil) {
+			continue
+		}
+		dataPoints[i].WindowP50ResponseMs = window.Percentile(0.50)
+		dataPoints[i].WindowP95ResponseMs = window.Percentile(0.95)
+		dataPoints[i].WindowP99ResponseMs = window.Percentile(0.99)
 	}
+	return dataPoints
+}
 
-	response := VisualizationSummaryResponse{
-		TestRunID:     loadTestRunID,
-		Status:        string(loadTestRun.Status),
-		Timeseries:    timeseriesPoints,
-		EndpointStats: endpointStats,
-		Summary: AggregatedSummary{
-			AvgRPS:           aggMetrics.AvgRPS,
-			MaxRPS:           aggMetrics.MaxRPS,
-			MinRPS:           aggMetrics.MinRPS,
-			AvgP50Latency:    aggMetrics.AvgP50,
-			AvgP95Latency:    aggMetrics.AvgP95,
-			AvgP99Latency:    aggMetrics.AvgP99,
-			MaxP95Latency:    aggMetrics.MaxP95,
-			TotalRequests:    aggMetrics.TotalRequests,
-			TotalFailures:    aggMetrics.TotalFailures,
-			OverallErrorRate: calculateErrorRate(aggMetrics.TotalRequests, aggMetrics.TotalFailures),
-			DataPoints:       aggMetrics.DataPoints,
-			Duration:         duration,
-		},
+// toAggregatedSummary converts a run's aggregated metrics to the chart summary
+func toAggregatedSummary(aggMetrics *store.AggregatedMetrics, duration string) AggregatedSummary {
+	return AggregatedSummary{
+		AvgRPS:           aggMetrics.AvgRPS,
+		MaxRPS:           aggMetrics.MaxRPS,
+		MinRPS:           aggMetrics.MinRPS,
+		AvgP50Latency:    aggMetrics.AvgP50,
+		AvgP95Latency:    aggMetrics.AvgP95,
+		AvgP99Latency:    aggMetrics.AvgP99,
+		MaxP95Latency:    aggMetrics.MaxP95,
+		P50Latency:       aggMetrics.P50,
+		P90Latency:       aggMetrics.P90,
+		P95Latency:       aggMetrics.P95,
+		P99Latency:       aggMetrics.P99,
+		P999Latency:      aggMetrics.P999,
+		TotalRequests:    aggMetrics.TotalRequests,
+		TotalFailures:    aggMetrics.TotalFailures,
+		OverallErrorRate: calculateErrorRate(aggMetrics.TotalRequests, aggMetrics.TotalFailures),
+		DataPoints:       aggMetrics.DataPoints,
+		Duration:         duration,
 	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// getMetricsTimeseries fetches a run's merged timeseries, or one shard's when the shardId query parameter is set
+func (h *VisualizationHandler) getMetricsTimeseries(ctx context.Context, r *http.Request, runID string, fromMillis, toMillis int64) ([]store.MetricsDocument, error) {
+	if shardID := r.URL.Query().Get("shardId"); shardID != "" {
+		return h.metricsStore.GetShardMetricsTimeseries(ctx, runID, shardID, fromMillis, toMillis)
+	}
+	return h.metricsStore.GetMetricsTimeseries(ctx, runID, fromMillis, toMillis)
 }
 
 func parseTimeRange(r *http.Request) (time.Time, time.Time) {
@@ -321,6 +406,51 @@ func parseTimeRange(r *http.Request) (time.Time, time.Time) {
 	return fromTime, toTime
 }
 
+// loadAnnotations lists the moments a run's load changed within an optional time range (Unix milliseconds, 0 = unbounded)
+// Includes load profile stage transitions after the first stage and live load adjustments, in time order
+func loadAnnotations(run *domain.LoadTestRun, fromMillis, toMillis int64) []GraphAnnotation {
+	annotations := []GraphAnnotation{}
+	inRange := func(ts int64) bool {
+		return (fromMillis == 0 || ts >= fromMillis) && (toMillis == 0 || ts <= toMillis)
+	}
+
+	for _, transition := range run.StageTransitions {
+		if transition.StageIndex == 0 || !inRange(transition.StartedAt) {
+			continue
+		}
+		label := fmt.Sprintf("Stage %d", transition.StageIndex)
+		if transition.Name != "" {
+			label = fmt.Sprintf("%s: %s", label, transition.Name)
+		}
+		annotations = append(annotations, GraphAnnotation{
+			Timestamp: transition.StartedAt,
+			Type:      "stage",
+			Label:     label,
+			Users:     transition.TargetUsers,
+			SpawnRate: transition.SpawnRate,
+		})
+	}
+
+	for _, change := range run.LoadChanges {
+		if !inRange(change.Timestamp) {
+			continue
+		}
+		annotations = append(annotations, GraphAnnotation{
+			Timestamp: change.Timestamp,
+			Type:      "load_change",
+			Label:     fmt.Sprintf("Users %d -> %d", change.PreviousUsers, change.TargetUsers),
+			Users:     change.TargetUsers,
+			SpawnRate: change.SpawnRate,
+		})
+	}
+
+	sort.Slice(annotations, func(i, j int) bool {
+		return annotations[i].Timestamp < annotations[j].Timestamp
+	})
+
+	return annotations
+}
+
 func calculateErrorRate(total, failures int64) float64 {
 	if total == 0 {
 		return 0.0
@@ -336,6 +466,7 @@ func calculateErrorRate(total, failures int64) float64 {
 // @Param id path string true "Load Test Run ID"
 // @Param from query string false "Start time in RFC3339 format"
 // @Param to query string false "End time in RFC3339 format"
+// @Param shardId query string false "Cluster ID of one shard of a distributed run; defaults to the merged run"
 // @Success 200 {object} RunGraphResponse "Graph data for visualization"
 // @Failure 404 {object} ErrorResponse "Load test run not found"
 // @Failure 500 {object} ErrorResponse "Failed to fetch graph data"
@@ -365,7 +496,7 @@ func (h *VisualizationHandler) GetRunGraph(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Fetch metrics from store
-	metrics, err := h.metricsStore.GetMetricsTimeseries(ctx, runID, fromMillis, toMillis)
+	metrics, err := h.getMetricsTimeseries(ctx, r, runID, fromMillis, toMillis)
 	if err != nil {
 		http.Error(w, "Failed to fetch metrics: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -396,11 +527,12 @@ func (h *VisualizationHandler) GetRunGraph(w http.ResponseWriter, r *http.Reques
 	}
 
 	response := RunGraphResponse{
-		RunID:      runID,
-		RunName:    run.Name,
-		Status:     string(run.Status),
-		StartedAt:  startedAt,
-		DataPoints: dataPoints,
+		RunID:       runID,
+		RunName:     run.Name,
+		Status:      string(run.Status),
+		StartedAt:   startedAt,
+		DataPoints:  dataPoints,
+		Annotations: loadAnnotations(run, fromMillis, toMillis),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -461,7 +593,7 @@ func (h *VisualizationHandler) GetRunSummary(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Calculate average response time in seconds
-	avgResponseTime := aggMetrics.AvgP50 / 1000.0
+	avgResponseTime := aggMetrics.P50 / 1000.0
 
 	response := RunSummaryResponse{
 		RunID:           runID,
@@ -477,6 +609,7 @@ func (h *VisualizationHandler) GetRunSummary(w http.ResponseWriter, r *http.Requ
 		TargetUsers:     run.TargetUsers,
 		SpawnRate:       run.SpawnRate,
 		DurationSeconds: run.DurationSeconds,
+		Verdict:         run.Verdict,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -484,13 +617,21 @@ func (h *VisualizationHandler) GetRunSummary(w http.ResponseWriter, r *http.Requ
 }
 
 // GetLiveRequestLog godoc
-// @Summary Get live request statistics
-// @Description Returns endpoint statistics in a log-like format showing performance per endpoint
+// @Summary Get the sampled request log
+// @Description Returns the most recent requests the load generators sampled, newest first
 // @Tags Visualization
 // @Produce json
 // @Param id path string true "Load Test Run ID"
 // @Param limit query int false "Maximum number of entries to return" default(100)
-// @Success 200 {array} RequestLogEntry "List of request statistics per endpoint"
+// @Param from query string false "Start time in RFC3339 format"
+// @Param to query string false "End time in RFC3339 format"
+// @Param shardId query string false "Cluster ID of one shard of a distributed run; defaults to every shard"
+// @Param method query string false "Only requests with this method"
+// @Param name query string false "Only requests to this endpoint name"
+// @Param status query string false "Only successful or failed requests" Enums(success, failure)
+// @Param statusCode query int false "Only requests with this HTTP status code"
+// @Param minResponseMs query number false "Only requests at least this slow"
+// @Success 200 {object} LiveRequestLogResponse "Sampled requests"
 // @Failure 404 {object} ErrorResponse "Load test run not found"
 // @Failure 500 {object} ErrorResponse "Failed to fetch request log"
 // @Router /runs/{id}/requests [get]
@@ -498,9 +639,6 @@ func (h *VisualizationHandler) GetLiveRequestLog(w http.ResponseWriter, r *http.
 	vars := mux.Vars(r)
 	runID := vars["id"]
 
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
-
 	// Parse limit parameter (default 100, max 500)
 	limit := 100
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
@@ -512,54 +650,81 @@ func (h *VisualizationHandler) GetLiveRequestLog(w http.ResponseWriter, r *http.
 		}
 	}
 
-	// Get recent metrics (last few data points)
-	fromTime, toTime := parseTimeRange(r)
-	var fromMillis, toMillis int64
-	if !fromTime.IsZero() {
-		fromMillis = fromTime.UnixMilli()
-	}
-	if !toTime.IsZero() {
-		toMillis = toTime.UnixMilli()
+	if _, err := h.loadTestRunStore.Get(runID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
 	}
 
-	metrics, err := h.metricsStore.GetMetricsTimeseries(ctx, runID, fromMillis, toMillis)
+	sampleLog, err := h.requestSampleStore.Get(runID)
 	if err != nil {
-		http.Error(w, "Failed to fetch metrics: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to fetch request samples: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Collect endpoint data from recent metrics as log entries
-	var logEntries []RequestLogEntry
-	
-	// Take the most recent metrics first (reverse order)
-	for i := len(metrics) - 1; i >= 0 && len(logEntries) < limit; i-- {
-		m := metrics[i]
-		
-		for _, stat := range m.RequestStats {
-			if len(logEntries) >= limit {
-				break
-			}
-			
-			// Create a log entry for each endpoint stat
-			// Note: This is aggregated data, not individual requests
-			logEntries = append(logEntries, RequestLogEntry{
-				Timestamp:      m.Timestamp.UnixMilli(),
-				RequestType:    stat.Method,
-				ResponseTime:   stat.AvgResponseTimeMs,
-				URL:            stat.Name,
-				ResponseLength: 0, // Content length not tracked in aggregated stats
-				Success:        stat.NumFailures == 0,
-			})
-		}
+	samples := sampleLog.Filter(parseRequestSampleFilter(r))
+
+	// Take the most recent samples first (reverse order)
+	logEntries := make([]RequestLogEntry, 0, limit)
+	for i := len(samples) - 1; i >= 0 && len(logEntries) < limit; i-- {
+		sample := samples[i]
+		logEntries = append(logEntries, RequestLogEntry{
+			Timestamp:      sample.Timestamp,
+			RequestType:    sample.Method,
+			ResponseTime:   sample.ResponseTimeMs,
+			URL:            sample.Name,
+			StatusCode:     sample.StatusCode,
+			ResponseLength: sample.ResponseLength,
+			Success:        sample.Success(),
+			Exception:      sample.Exception,
+			ShardID:        sample.ShardID,
+		})
 	}
 
 	response := LiveRequestLogResponse{
-		RunID:    runID,
-		Requests: logEntries,
-		Total:    len(logEntries),
-		Limit:    limit,
+		RunID:      runID,
+		Requests:   logEntries,
+		Total:      len(samples),
+		Limit:      limit,
+		SampleRate: sampleLog.SampleRate,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// parseRequestSampleFilter reads the request sample filters of the request log and scatter endpoints
+// Malformed values are ignored, like malformed time ranges
+func parseRequestSampleFilter(r *http.Request) domain.RequestSampleFilter {
+	query := r.URL.Query()
+	filter := domain.RequestSampleFilter{
+		Method:  query.Get("method"),
+		Name:    query.Get("name"),
+		ShardID: query.Get("shardId"),
+	}
+
+	switch query.Get("status") {
+	case "success":
+		success := true
+		filter.Success = &success
+	case "failure":
+		success := false
+		filter.Success = &success
+	}
+
+	if statusCode, err := strconv.Atoi(query.Get("statusCode")); err == nil {
+		filter.StatusCode = statusCode
+	}
+	if minResponseMs, err := strconv.ParseFloat(query.Get("minResponseMs"), 64); err == nil {
+		filter.MinResponseMs = minResponseMs
+	}
+
+	fromTime, toTime := parseTimeRange(r)
+	if !fromTime.IsZero() {
+		filter.FromMs = fromTime.UnixMilli()
+	}
+	if !toTime.IsZero() {
+		filter.ToMs = toTime.UnixMilli()
+	}
+
+	return filter
+}
@@ -11,11 +11,12 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	Server         ServerConfig         `yaml:"server" json:"server"`
-	LocustClusters []ClusterConfig      `yaml:"locustClusters" json:"locustClusters"`
-	Security       SecurityConfig       `yaml:"security" json:"security"`
-	Orchestrator   OrchestratorConfig   `yaml:"orchestrator" json:"orchestrator"`
-	MongoDB        MongoDBConfig        `yaml:"mongodb" json:"mongodb"`
+	Server         ServerConfig       `yaml:"server" json:"server"`
+	LocustClusters []ClusterConfig    `yaml:"locustClusters" json:"locustClusters"`
+	Security       SecurityConfig     `yaml:"security" json:"security"`
+	Orchestrator   OrchestratorConfig `yaml:"orchestrator" json:"orchestrator"`
+	LocustClient   LocustClientConfig `yaml:"locustClient" json:"locustClient"`
+	MongoDB        MongoDBConfig      `yaml:"mongodb" json:"mongodb"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -26,13 +27,15 @@ type ServerConfig struct {
 
 // ClusterConfig represents a Locust cluster configuration
 type ClusterConfig struct {
-	ID        string `yaml:"id" json:"id"`
-	BaseURL   string `yaml:"baseUrl" json:"baseUrl"`
-	AccountID string `yaml:"accountId" json:"accountId"`
-	OrgID     string `yaml:"orgId" json:"orgId"`
-	ProjectID string `yaml:"projectId" json:"projectId"`
-	EnvID     string `yaml:"envId,omitempty" json:"envId,omitempty"`
-	AuthToken string `yaml:"authToken,omitempty" json:"authToken,omitempty"`
+	ID          string             `yaml:"id" json:"id"`
+	BaseURL     string             `yaml:"baseUrl" json:"baseUrl"`
+	Engine      domain.EngineType  `yaml:"engine,omitempty" json:"engine,omitempty"`           // Load engine the cluster runs (default: locust)
+	MetricsMode domain.MetricsMode `yaml:"metricsMode,omitempty" json:"metricsMode,omitempty"` // "push" or "poll" (default: orchestrator.metricsMode)
+	AccountID   string             `yaml:"accountId" json:"accountId"`
+	OrgID       string             `yaml:"orgId" json:"orgId"`
+	ProjectID   string             `yaml:"projectId" json:"projectId"`
+	EnvID       string             `yaml:"envId,omitempty" json:"envId,omitempty"`
+	AuthToken   string             `yaml:"authToken,omitempty" json:"authToken,omitempty"`
 }
 
 // SecurityConfig holds security-related configuration
@@ -44,10 +47,39 @@ type SecurityConfig struct {
 }
 
 // OrchestratorConfig holds orchestrator behavior configuration
-// Note: Metrics collection is now push-based (Locust sends metrics to control plane)
+// Note: Metrics collection is push-based (Locust sends metrics to control plane) unless a cluster polls
 type OrchestratorConfig struct {
-	// Deprecated: No longer used - metrics are pushed from Locust
+	// How runs report metrics on clusters that do not choose: "push" (harness plugin callbacks) or "poll"
+	MetricsMode domain.MetricsMode `yaml:"metricsMode,omitempty" json:"metricsMode,omitempty"`
+	// How often runs in poll mode have their Locust master's stats polled
 	MetricsPollIntervalSeconds int `yaml:"metricsPollIntervalSeconds,omitempty" json:"metricsPollIntervalSeconds,omitempty"`
+	// Extra time after a run's duration before the control plane stops it itself,
+	// giving the Locust plugin a chance to stop the test and report first
+	DurationGraceSeconds int `yaml:"durationGraceSeconds,omitempty" json:"durationGraceSeconds,omitempty"`
+	// Silence after which a running run is probed on its Locust cluster and failed or re-attached
+	HeartbeatTimeoutSeconds int `yaml:"heartbeatTimeoutSeconds,omitempty" json:"heartbeatTimeoutSeconds,omitempty"`
+	// How often the stale run reaper looks for silent runs
+	ReaperIntervalSeconds int `yaml:"reaperIntervalSeconds,omitempty" json:"reaperIntervalSeconds,omitempty"`
+	// How often every registered Locust cluster is probed for reachability, state, workers and plugin version
+	ClusterHealthIntervalSeconds int `yaml:"clusterHealthIntervalSeconds,omitempty" json:"clusterHealthIntervalSeconds,omitempty"`
+}
+
+// LocustClientConfig holds how the control plane calls Locust masters
+// Idempotent calls (set-context, load-script, stop, stats) are retried when the master does not answer;
+// swarms never are. Each cluster has a circuit breaker failing calls fast while its master is down
+type LocustClientConfig struct {
+	// Timeout of a single request to a Locust master
+	TimeoutSeconds int `yaml:"timeoutSeconds,omitempty" json:"timeoutSeconds,omitempty"`
+	// Attempts of an idempotent call, including the first
+	MaxAttempts int `yaml:"maxAttempts,omitempty" json:"maxAttempts,omitempty"`
+	// Wait before the first retry, doubled for each further retry and jittered
+	InitialBackoffMillis int `yaml:"initialBackoffMillis,omitempty" json:"initialBackoffMillis,omitempty"`
+	// Upper bound of the wait between retries
+	MaxBackoffMillis int `yaml:"maxBackoffMillis,omitempty" json:"maxBackoffMillis,omitempty"`
+	// Consecutive unanswered requests that open a cluster's circuit breaker
+	BreakerFailureThreshold int `yaml:"breakerFailureThreshold,omitempty" json:"breakerFailureThreshold,omitempty"`
+	// How long an open circuit breaker fails calls fast before letting a probe through
+	BreakerOpenSeconds int `yaml:"breakerOpenSeconds,omitempty" json:"breakerOpenSeconds,omitempty"`
 }
 
 // MongoDBConfig holds MongoDB connection configuration
@@ -81,29 +113,83 @@ func LoadFromFile(path string) (*Config, error) {
 	if cfg.Server.Port == 0 {
 		cfg.Server.Port = 8080
 	}
-	// MetricsPollIntervalSeconds is deprecated - no longer used
+	if cfg.Orchestrator.MetricsMode == "" {
+		cfg.Orchestrator.MetricsMode = domain.MetricsModePush
+	}
+	if !cfg.Orchestrator.MetricsMode.Known() {
+		return nil, fmt.Errorf("unknown orchestrator metrics mode %q", cfg.Orchestrator.MetricsMode)
+	}
+	if cfg.Orchestrator.MetricsPollIntervalSeconds == 0 {
+		cfg.Orchestrator.MetricsPollIntervalSeconds = 10
+	}
+	if cfg.Orchestrator.DurationGraceSeconds == 0 {
+		cfg.Orchestrator.DurationGraceSeconds = 30
+	}
+	if cfg.Orchestrator.HeartbeatTimeoutSeconds == 0 {
+		cfg.Orchestrator.HeartbeatTimeoutSeconds = 120
+	}
+	if cfg.Orchestrator.ReaperIntervalSeconds == 0 {
+		cfg.Orchestrator.ReaperIntervalSeconds = 30
+	}
+	if cfg.Orchestrator.ClusterHealthIntervalSeconds == 0 {
+		cfg.Orchestrator.ClusterHealthIntervalSeconds = 30
+	}
+	if cfg.LocustClient.TimeoutSeconds == 0 {
+		cfg.LocustClient.TimeoutSeconds = 30
+	}
+	if cfg.LocustClient.MaxAttempts == 0 {
+		cfg.LocustClient.MaxAttempts = 3
+	}
+	if cfg.LocustClient.InitialBackoffMillis == 0 {
+		cfg.LocustClient.InitialBackoffMillis = 500
+	}
+	if cfg.LocustClient.MaxBackoffMillis == 0 {
+		cfg.LocustClient.MaxBackoffMillis = 5000
+	}
+	if cfg.LocustClient.BreakerFailureThreshold == 0 {
+		cfg.LocustClient.BreakerFailureThreshold = 5
+	}
+	if cfg.LocustClient.BreakerOpenSeconds == 0 {
+		cfg.LocustClient.BreakerOpenSeconds = 30
+	}
 
 	return &cfg, nil
 }
 
-// GetLocustCluster returns the Locust cluster for a given account, org, project, and optional environment
-func (c *Config) GetLocustCluster(accountID, orgID, projectID, envID string) (*domain.LocustCluster, error) {
-	for _, cluster := range c.LocustClusters {
-		// Match account, org, project and optionally env
-		if cluster.AccountID == accountID && cluster.OrgID == orgID && cluster.ProjectID == projectID {
-			// If envID is specified, must match; if not specified in query, match any
-			if envID == "" || cluster.EnvID == "" || cluster.EnvID == envID {
-				return &domain.LocustCluster{
-					ID:        cluster.ID,
-					BaseURL:   cluster.BaseURL,
-					AccountID: cluster.AccountID,
-					OrgID:     cluster.OrgID,
-					ProjectID: cluster.ProjectID,
-					EnvID:     cluster.EnvID,
-					AuthToken: cluster.AuthToken,
-				}, nil
-			}
+// GetLocustClusters returns the pool of configured Locust clusters for a given account, org, project, and optional environment
+// Clusters dedicated to the environment come first
+// At runtime clusters are resolved from the orchestrator's cluster registry, which is seeded from this list
+func (c *Config) GetLocustClusters(accountID, orgID, projectID, envID string) []*domain.LocustCluster {
+	var dedicated, shared []*domain.LocustCluster
+	for _, clusterCfg := range c.LocustClusters {
+		cluster := &domain.LocustCluster{
+			ID:          clusterCfg.ID,
+			BaseURL:     clusterCfg.BaseURL,
+			Engine:      clusterCfg.Engine,
+			MetricsMode: clusterCfg.MetricsMode,
+			AccountID:   clusterCfg.AccountID,
+			OrgID:       clusterCfg.OrgID,
+			ProjectID:   clusterCfg.ProjectID,
+			EnvID:       clusterCfg.EnvID,
+			AuthToken:   clusterCfg.AuthToken,
+		}
+		if !cluster.Matches(accountID, orgID, projectID, envID) {
+			continue
 		}
+		if envID != "" && cluster.EnvID == envID {
+			dedicated = append(dedicated, cluster)
+		} else {
+			shared = append(shared, cluster)
+		}
+	}
+	return append(dedicated, shared...)
+}
+
+// GetLocustCluster returns the preferred configured Locust cluster for a given account, org, project, and optional environment
+func (c *Config) GetLocustCluster(accountID, orgID, projectID, envID string) (*domain.LocustCluster, error) {
+	clusters := c.GetLocustClusters(accountID, orgID, projectID, envID)
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("no Locust cluster found for account=%s, org=%s, project=%s, env=%s", accountID, orgID, projectID, envID)
 	}
-	return nil, fmt.Errorf("no Locust cluster found for account=%s, org=%s, project=%s, env=%s", accountID, orgID, projectID, envID)
+	return clusters[0], nil
 }
@@ -0,0 +1,72 @@
+package domain
+
+import "fmt"
+
+// AbortRule stops a run early when a live metric breaches a limit, e.g. errorRate > 20 for 60 seconds
+// A rule trips once the breach has lasted ForSeconds or ConsecutiveSnapshots pushed snapshots;
+// with neither set, the first breaching snapshot trips it
+type AbortRule struct {
+	Name                 string            `json:"name,omitempty"`
+	Metric               ThresholdMetric   `json:"metric"`
+	Operator             ThresholdOperator `json:"operator"` // Breach condition, e.g. ">" aborts when the metric goes above Value
+	Value                float64           `json:"value"`
+	ForSeconds           int               `json:"forSeconds,omitempty"`           // How long the breach must last
+	ConsecutiveSnapshots int               `json:"consecutiveSnapshots,omitempty"` // How many snapshots in a row must breach
+	Endpoint             string            `json:"endpoint,omitempty"`             // Optional request name (or "METHOD name") to scope the rule to
+}
+
+// AbortTrigger records the rule that aborted a run and the breach that tripped it
+type AbortTrigger struct {
+	Rule          AbortRule `json:"rule"`
+	Actual        float64   `json:"actual"`        // Metric value of the snapshot that tripped the rule
+	BreachedSince int64     `json:"breachedSince"` // Unix milliseconds of the first breaching snapshot
+	Snapshots     int       `json:"snapshots"`     // Number of consecutive breaching snapshots
+	TriggeredAt   int64     `json:"triggeredAt"`   // Unix milliseconds
+}
+
+// Validate checks that the abort rule can be evaluated
+func (r *AbortRule) Validate() error {
+	condition := Threshold{Metric: r.Metric, Operator: r.Operator, Value: r.Value}
+	if err := condition.Validate(); err != nil {
+		return err
+	}
+
+	if r.ForSeconds < 0 || r.ConsecutiveSnapshots < 0 {
+		return fmt.Errorf("forSeconds and consecutiveSnapshots must not be negative")
+	}
+	if r.ForSeconds > 0 && r.ConsecutiveSnapshots > 0 {
+		return fmt.Errorf("only one of forSeconds and consecutiveSnapshots may be set")
+	}
+
+	return nil
+}
+
+// Breached reports whether a measured value meets the rule's breach condition
+func (r *AbortRule) Breached(actual float64) bool {
+	condition := Threshold{Operator: r.Operator, Value: r.Value}
+	return condition.Compare(actual)
+}
+
+// String returns a readable form of the rule, e.g. "errorRate > 20 for 60s [GET /api]"
+func (r *AbortRule) String() string {
+	s := fmt.Sprintf("%s %s %g", r.Metric, r.Operator, r.Value)
+	if r.ForSeconds > 0 {
+		s += fmt.Sprintf(" for %ds", r.ForSeconds)
+	} else if r.ConsecutiveSnapshots > 0 {
+		s += fmt.Sprintf(" for %d consecutive snapshots", r.ConsecutiveSnapshots)
+	}
+	if r.Endpoint != "" {
+		s += fmt.Sprintf(" [%s]", r.Endpoint)
+	}
+	return s
+}
+
+// ValidateAbortRules validates every abort rule in a list
+func ValidateAbortRules(rules []AbortRule) error {
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return fmt.Errorf("abort rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
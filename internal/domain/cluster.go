@@ -0,0 +1,78 @@
+package domain
+
+import "fmt"
+
+// Cluster sources
+const (
+	ClusterSourceConfig = "config" // Seeded from the config file on startup
+	ClusterSourceAPI    = "api"    // Registered through the clusters API
+)
+
+// ClusterHealth is the result of probing a Locust cluster
+type ClusterHealth struct {
+	Reachable     bool           `json:"reachable" bson:"reachable"`
+	State         string         `json:"state,omitempty" bson:"state,omitempty"`                 // Locust runner state: "ready", "running", "stopped", ...
+	WorkerCount   int            `json:"workerCount" bson:"workerCount"`                         // Workers connected to the master
+	PluginVersion string         `json:"pluginVersion,omitempty" bson:"pluginVersion,omitempty"` // Version of the harness plugin, empty if it is not installed
+	Error         string         `json:"error,omitempty" bson:"error,omitempty"`
+	CheckedAt     int64          `json:"checkedAt" bson:"checkedAt"`                       // Unix milliseconds
+	LastSeenAt    int64          `json:"lastSeenAt,omitempty" bson:"lastSeenAt,omitempty"` // Unix milliseconds of the last successful probe
+	Circuit       *CircuitStatus `json:"circuit,omitempty" bson:"circuit,omitempty"`       // Circuit breaker guarding calls to the master
+}
+
+// Circuit breaker states of a Locust cluster
+const (
+	CircuitClosed   = "closed"    // Calls go through
+	CircuitOpen     = "open"      // The master failed repeatedly, calls fail fast
+	CircuitHalfOpen = "half-open" // A single probe call decides whether the breaker closes or opens again
+)
+
+// CircuitStatus is the state of the circuit breaker guarding calls to a Locust cluster
+type CircuitStatus struct {
+	State               string `json:"state" bson:"state"`
+	ConsecutiveFailures int    `json:"consecutiveFailures" bson:"consecutiveFailures"` // Calls in a row the master did not answer
+	OpenedAt            int64  `json:"openedAt,omitempty" bson:"openedAt,omitempty"`   // Unix milliseconds the breaker last opened, unless closed
+}
+
+// ClusterAttempt records a Locust cluster a run was started on
+// A failed attempt is followed by a failover to another cluster of the run's pool, if one is idle
+type ClusterAttempt struct {
+	ClusterID string `json:"clusterId"`
+	At        int64  `json:"at"`              // Unix milliseconds
+	Error     string `json:"error,omitempty"` // Why the cluster failed to start the run, empty for the cluster that started it
+}
+
+// Runs reports whether the cluster runs the given engine
+func (c *LocustCluster) Runs(engineType EngineType) bool {
+	return c.Engine.OrDefault() == engineType.OrDefault()
+}
+
+// Matches reports whether the cluster serves the given account, org, project and optional environment
+// A cluster without an environment serves every environment of its project
+func (c *LocustCluster) Matches(accountID, orgID, projectID, envID string) bool {
+	if c.AccountID != accountID || c.OrgID != orgID || c.ProjectID != projectID {
+		return false
+	}
+	return envID == "" || c.EnvID == "" || c.EnvID == envID
+}
+
+// Validate checks that the cluster can be registered
+// Native clusters run inside the control plane and need no base URL
+func (c *LocustCluster) Validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if !c.Engine.Known() {
+		return fmt.Errorf("unknown engine %q", c.Engine)
+	}
+	if !c.MetricsMode.Known() {
+		return fmt.Errorf("unknown metrics mode %q", c.MetricsMode)
+	}
+	if c.BaseURL == "" && c.Engine.OrDefault() != EngineNative {
+		return fmt.Errorf("baseUrl is required")
+	}
+	if c.AccountID == "" || c.OrgID == "" || c.ProjectID == "" {
+		return fmt.Errorf("accountId, orgId and projectId are required")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package domain
+
+// EngineType identifies the load generation engine that executes a load test
+type EngineType string
+
+const (
+	EngineLocust EngineType = "locust" // Locust master driven over its web API and the harness plugin
+	EngineNative EngineType = "native" // In-process Go generator running YAML scenarios, see internal/loadgen
+)
+
+// OrDefault returns the engine type, or Locust for load tests and clusters that predate engine selection
+func (t EngineType) OrDefault() EngineType {
+	if t == "" {
+		return EngineLocust
+	}
+	return t
+}
+
+// Known reports whether the engine type is one the control plane knows; empty means Locust
+func (t EngineType) Known() bool {
+	switch t.OrDefault() {
+	case EngineLocust, EngineNative:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// MaxFailureGroups caps the failure groups and the exceptions kept per run; error messages that embed
+// request-specific values would otherwise grow a run's failures without bound
+const MaxFailureGroups = 500
+
+// runFailureSource keys the counts reported by a run that is not distributed; stored map keys can't be empty
+const runFailureSource = "_run"
+
+// FailureGroup counts the failed requests of one endpoint that failed with the same error, like a row of
+// Locust's failures table. Sources report cumulative occurrences; a run's stored groups add them up
+type FailureGroup struct {
+	Method      string           `json:"method" bson:"method"`
+	Name        string           `json:"name" bson:"name"`
+	Error       string           `json:"error" bson:"error"`
+	Occurrences int64            `json:"occurrences" bson:"occurrences"`
+	FirstSeenAt int64            `json:"firstSeenAt,omitempty" bson:"firstSeenAt,omitempty"` // Unix milliseconds, set when stored
+	LastSeenAt  int64            `json:"lastSeenAt,omitempty" bson:"lastSeenAt,omitempty"`   // Unix milliseconds of the last new occurrence
+	Reported    map[string]int64 `json:"-" bson:"reported,omitempty"`                        // Source -> occurrences it last reported
+}
+
+// ExceptionGroup counts the exceptions raised with the same traceback in user code, like a row of
+// Locust's exceptions table. Sources report cumulative counts; a run's stored groups add them up
+type ExceptionGroup struct {
+	Message     string           `json:"msg" bson:"msg"`
+	Traceback   string           `json:"traceback" bson:"traceback"`
+	Count       int64            `json:"count" bson:"count"`
+	Nodes       []string         `json:"nodes,omitempty" bson:"nodes,omitempty"`             // Workers the exception was raised on
+	FirstSeenAt int64            `json:"firstSeenAt,omitempty" bson:"firstSeenAt,omitempty"` // Unix milliseconds, set when stored
+	LastSeenAt  int64            `json:"lastSeenAt,omitempty" bson:"lastSeenAt,omitempty"`   // Unix milliseconds of the last new occurrence
+	Reported    map[string]int64 `json:"-" bson:"reported,omitempty"`                        // Source -> count it last reported
+}
+
+// RunFailures holds the failures and exceptions reported over a run
+type RunFailures struct {
+	RunID      string            `json:"runId" bson:"runId"`
+	Failures   []*FailureGroup   `json:"failures" bson:"failures"`
+	Exceptions []*ExceptionGroup `json:"exceptions" bson:"exceptions"`
+	Truncated  bool              `json:"truncated,omitempty" bson:"truncated,omitempty"` // New groups were dropped past MaxFailureGroups
+	UpdatedAt  int64             `json:"updatedAt" bson:"updatedAt"`                     // Unix milliseconds
+}
+
+// Record adds what the run, or the shard shardID of a distributed run, reported at a time
+// Sources report cumulative counts, so only the increase since the source's previous report is added;
+// a count lower than before means the source's stats were reset, after which all of it is new
+func (r *RunFailures) Record(shardID string, failures []FailureGroup, exceptions []ExceptionGroup, at int64) {
+	source := shardID
+	if source == "" {
+		source = runFailureSource
+	}
+
+	for _, reported := range failures {
+		group := r.failureGroup(reported.Method, reported.Name, reported.Error)
+		if group == nil {
+			continue
+		}
+		if added := increase(group.Reported, source, reported.Occurrences); added > 0 {
+			group.Occurrences += added
+			group.LastSeenAt = at
+			if group.FirstSeenAt == 0 {
+				group.FirstSeenAt = at
+			}
+		}
+		group.Reported[source] = reported.Occurrences
+	}
+
+	for _, reported := range exceptions {
+		group := r.exceptionGroup(reported.Message, reported.Traceback)
+		if group == nil {
+			continue
+		}
+		if added := increase(group.Reported, source, reported.Count); added > 0 {
+			group.Count += added
+			group.LastSeenAt = at
+			if group.FirstSeenAt == 0 {
+				group.FirstSeenAt = at
+			}
+		}
+		group.Reported[source] = reported.Count
+		group.Nodes = mergeNodes(group.Nodes, reported.Nodes)
+	}
+
+	r.UpdatedAt = at
+}
+
+// TotalFailures returns the occurrences of every failure group
+func (r *RunFailures) TotalFailures() int64 {
+	var total int64
+	for _, group := range r.Failures {
+		total += group.Occurrences
+	}
+	return total
+}
+
+// TotalExceptions returns the count of every exception group
+func (r *RunFailures) TotalExceptions() int64 {
+	var total int64
+	for _, group := range r.Exceptions {
+		total += group.Count
+	}
+	return total
+}
+
+// TopFailures returns up to limit failure groups, most frequent first; a limit of 0 returns them all
+func (r *RunFailures) TopFailures(limit int) []*FailureGroup {
+	top := make([]*FailureGroup, len(r.Failures))
+	copy(top, r.Failures)
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Occurrences > top[j].Occurrences })
+	if limit > 0 && len(top) > limit {
+		top = top[:limit]
+	}
+	return top
+}
+
+// TopExceptions returns up to limit exception groups, most frequent first; a limit of 0 returns them all
+func (r *RunFailures) TopExceptions(limit int) []*ExceptionGroup {
+	top := make([]*ExceptionGroup, len(r.Exceptions))
+	copy(top, r.Exceptions)
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Count > top[j].Count })
+	if limit > 0 && len(top) > limit {
+		top = top[:limit]
+	}
+	return top
+}
+
+// failureGroup returns the run's group for a failure, adding it if there is room, nil otherwise
+func (r *RunFailures) failureGroup(method, name, message string) *FailureGroup {
+	for _, group := range r.Failures {
+		if group.Method == method && group.Name == name && group.Error == message {
+			if group.Reported == nil {
+				group.Reported = make(map[string]int64)
+			}
+			return group
+		}
+	}
+	if len(r.Failures) >= MaxFailureGroups {
+		r.Truncated = true
+		return nil
+	}
+	group := &FailureGroup{Method: method, Name: name, Error: message, Reported: make(map[string]int64)}
+	r.Failures = append(r.Failures, group)
+	return group
+}
+
+// exceptionGroup returns the run's group for an exception, adding it if there is room, nil otherwise
+func (r *RunFailures) exceptionGroup(message, traceback string) *ExceptionGroup {
+	for _, group := range r.Exceptions {
+		if group.Message == message && group.Traceback == traceback {
+			if group.Reported == nil {
+				group.Reported = make(map[string]int64)
+			}
+			return group
+		}
+	}
+	if len(r.Exceptions) >= MaxFailureGroups {
+		r.Truncated = true
+		return nil
+	}
+	group := &ExceptionGroup{Message: message, Traceback: traceback, Reported: make(map[string]int64)}
+	r.Exceptions = append(r.Exceptions, group)
+	return group
+}
+
+// increase returns what a cumulative count adds to the count a source reported before
+func increase(reported map[string]int64, source string, count int64) int64 {
+	before, ok := reported[source]
+	if !ok || count < before {
+		return count
+	}
+	return count - before
+}
+
+// mergeNodes adds the nodes that are not listed yet
+func mergeNodes(nodes, added []string) []string {
+	for _, node := range added {
+		node = strings.TrimSpace(node)
+		if node == "" {
+			continue
+		}
+		found := false
+		for _, existing := range nodes {
+			if existing == node {
+				found = true
+				break
+			}
+		}
+		if !found {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
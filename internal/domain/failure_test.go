@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRunFailuresRecordAddsCumulativeIncreases(t *testing.T) {
+	failures := &RunFailures{RunID: "run-1"}
+	timeout := func(occurrences int64) []FailureGroup {
+		return []FailureGroup{{Method: "GET", Name: "/", Error: "timeout", Occurrences: occurrences}}
+	}
+
+	failures.Record("", timeout(3), nil, 1000)
+	failures.Record("", timeout(5), nil, 2000)
+	failures.Record("", timeout(5), nil, 3000) // Nothing new since the last report
+	failures.Record("", timeout(2), nil, 4000) // Stats were reset, all of it is new
+
+	if len(failures.Failures) != 1 {
+		t.Fatalf("failure groups = %d, want 1", len(failures.Failures))
+	}
+	group := failures.Failures[0]
+	if group.Occurrences != 7 {
+		t.Errorf("occurrences = %d, want 7", group.Occurrences)
+	}
+	if group.FirstSeenAt != 1000 || group.LastSeenAt != 4000 {
+		t.Errorf("seen at %d..%d, want 1000..4000", group.FirstSeenAt, group.LastSeenAt)
+	}
+	if failures.UpdatedAt != 4000 {
+		t.Errorf("updated at = %d, want 4000", failures.UpdatedAt)
+	}
+}
+
+func TestRunFailuresRecordAddsUpShards(t *testing.T) {
+	failures := &RunFailures{RunID: "run-1"}
+	boom := func(count int64, node string) []ExceptionGroup {
+		return []ExceptionGroup{{Message: "boom", Traceback: "tb", Count: count, Nodes: []string{node}}}
+	}
+
+	failures.Record("cluster-1", nil, boom(4, "worker-a"), 1000)
+	failures.Record("cluster-2", nil, boom(2, "worker-b"), 1000)
+	failures.Record("cluster-1", nil, boom(6, "worker-a"), 2000)
+
+	if len(failures.Exceptions) != 1 {
+		t.Fatalf("exception groups = %d, want 1", len(failures.Exceptions))
+	}
+	group := failures.Exceptions[0]
+	if group.Count != 8 {
+		t.Errorf("count = %d, want 8", group.Count)
+	}
+	if len(group.Nodes) != 2 || group.Nodes[0] != "worker-a" || group.Nodes[1] != "worker-b" {
+		t.Errorf("nodes = %v, want [worker-a worker-b]", group.Nodes)
+	}
+	if total := failures.TotalExceptions(); total != 8 {
+		t.Errorf("total exceptions = %d, want 8", total)
+	}
+}
+
+func TestRunFailuresRecordCapsGroups(t *testing.T) {
+	failures := &RunFailures{RunID: "run-1"}
+
+	reported := make([]FailureGroup, MaxFailureGroups+1)
+	for i := range reported {
+		reported[i] = FailureGroup{Method: "GET", Name: "/", Error: fmt.Sprintf("error %d", i), Occurrences: 1}
+	}
+	failures.Record("", reported, nil, 1000)
+
+	if len(failures.Failures) != MaxFailureGroups {
+		t.Errorf("failure groups = %d, want %d", len(failures.Failures), MaxFailureGroups)
+	}
+	if !failures.Truncated {
+		t.Error("failures are not marked truncated")
+	}
+
+	// Groups already kept still count their new occurrences
+	failures.Record("", []FailureGroup{{Method: "GET", Name: "/", Error: "error 0", Occurrences: 4}}, nil, 2000)
+	if top := failures.TopFailures(1); len(top) != 1 || top[0].Error != "error 0" || top[0].Occurrences != 4 {
+		t.Errorf("top failure = %+v, want error 0 with 4 occurrences", top)
+	}
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"math"
+	"sort"
+)
+
+// Histogram counts responses by response time bucket in milliseconds, like Locust's response_times dict
+// Response times are rounded the way Locust rounds them, so histograms pushed by Locust and built by
+// the native generator line up bucket by bucket and merge by adding counts
+type Histogram map[int64]int64
+
+// HistogramBucket rounds a response time in milliseconds to its histogram bucket:
+// exact below 100ms, then to 2 significant digits up to 10s and to whole seconds above
+func HistogramBucket(responseMs float64) int64 {
+	switch {
+	case responseMs < 100:
+		return int64(math.Round(responseMs))
+	case responseMs < 1000:
+		return int64(math.Round(responseMs/10)) * 10
+	case responseMs < 10000:
+		return int64(math.Round(responseMs/100)) * 100
+	default:
+		return int64(math.Round(responseMs/1000)) * 1000
+	}
+}
+
+// Record adds a response to the histogram
+func (h Histogram) Record(responseMs float64) {
+	h[HistogramBucket(responseMs)]++
+}
+
+// Count returns the number of responses in the histogram
+func (h Histogram) Count() int64 {
+	var count int64
+	for _, n := range h {
+		count += n
+	}
+	return count
+}
+
+// Merge adds the responses of another histogram to this one
+func (h Histogram) Merge(other Histogram) {
+	for b, n := range other {
+		h[b] += n
+	}
+}
+
+// Clone returns a copy of the histogram, nil for a nil histogram
+func (h Histogram) Clone() Histogram {
+	if h == nil {
+		return nil
+	}
+	clone := make(Histogram, len(h))
+	for b, n := range h {
+		clone[b] = n
+	}
+	return clone
+}
+
+// Since returns the responses recorded after an earlier snapshot of the same cumulative histogram was taken
+// A bucket holding fewer responses than before means the stats were reset in between, in which case
+// every response of the histogram is newer than the earlier snapshot
+func (h Histogram) Since(earlier Histogram) Histogram {
+	for b, n := range earlier {
+		if h[b] < n {
+			return h.Clone()
+		}
+	}
+
+	delta := make(Histogram)
+	for b, n := range h {
+		if d := n - earlier[b]; d > 0 {
+			delta[b] = d
+		}
+	}
+	return delta
+}
+
+// Percentile returns the response time below which the given fraction of the responses fall, 0 if there are none
+// Computed the way Locust computes its percentiles from response_times, so pushed and merged values agree
+func (h Histogram) Percentile(fraction float64) float64 {
+	total := h.Count()
+	if total == 0 {
+		return 0
+	}
+
+	buckets := make([]int64, 0, len(h))
+	for b := range h {
+		buckets = append(buckets, b)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] > buckets[j] })
+
+	threshold := int64(float64(total) * fraction)
+	var processed int64
+	for _, b := range buckets {
+		processed += h[b]
+		if total-processed <= threshold {
+			return float64(b)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHistogramBucket(t *testing.T) {
+	tests := []struct {
+		responseMs float64
+		want       int64
+	}{
+		{responseMs: 0.4, want: 0},
+		{responseMs: 42.5, want: 43},
+		{responseMs: 99.4, want: 99},
+		{responseMs: 147, want: 150},
+		{responseMs: 999, want: 1000},
+		{responseMs: 1234, want: 1200},
+		{responseMs: 12345, want: 12000},
+	}
+
+	for _, tt := range tests {
+		if got := HistogramBucket(tt.responseMs); got != tt.want {
+			t.Errorf("HistogramBucket(%g) = %d, want %d", tt.responseMs, got, tt.want)
+		}
+	}
+}
+
+func TestHistogramSince(t *testing.T) {
+	tests := []struct {
+		name    string
+		current Histogram
+		earlier Histogram
+		want    Histogram
+	}{
+		{
+			name:    "no earlier snapshot",
+			current: Histogram{10: 3, 20: 1},
+			want:    Histogram{10: 3, 20: 1},
+		},
+		{
+			name:    "responses added",
+			current: Histogram{10: 5, 20: 1, 30: 2},
+			earlier: Histogram{10: 3, 20: 1},
+			want:    Histogram{10: 2, 30: 2},
+		},
+		{
+			name:    "nothing added",
+			current: Histogram{10: 3},
+			earlier: Histogram{10: 3},
+			want:    Histogram{},
+		},
+		{
+			name:    "stats reset in between",
+			current: Histogram{10: 1, 40: 4},
+			earlier: Histogram{10: 3, 20: 1},
+			want:    Histogram{10: 1, 40: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.current.Since(tt.earlier)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Since = %v, want %v", got, tt.want)
+			}
+
+			// The result never aliases the current histogram
+			got[999] = 1
+			if _, ok := tt.current[999]; ok {
+				t.Error("Since returned the current histogram itself")
+			}
+		})
+	}
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	histogram := Histogram{10: 50, 20: 40, 100: 10}
+
+	tests := []struct {
+		fraction float64
+		want     float64
+	}{
+		{fraction: 0.4, want: 10},
+		{fraction: 0.5, want: 20},
+		{fraction: 0.9, want: 100},
+		{fraction: 0.99, want: 100},
+		{fraction: 1, want: 100},
+	}
+
+	for _, tt := range tests {
+		if got := histogram.Percentile(tt.fraction); got != tt.want {
+			t.Errorf("Percentile(%g) = %g, want %g", tt.fraction, got, tt.want)
+		}
+	}
+
+	if got := (Histogram{}).Percentile(0.5); got != 0 {
+		t.Errorf("empty Percentile(0.5) = %g, want 0", got)
+	}
+	if got := Histogram(nil).Percentile(0.5); got != 0 {
+		t.Errorf("nil Percentile(0.5) = %g, want 0", got)
+	}
+}
+
+func TestHistogramMergeMatchesCombinedRecording(t *testing.T) {
+	first, second, combined := make(Histogram), make(Histogram), make(Histogram)
+	for _, ms := range []float64{5, 12, 12, 230, 1840} {
+		first.Record(ms)
+		combined.Record(ms)
+	}
+	for _, ms := range []float64{12, 95, 230, 15200} {
+		second.Record(ms)
+		combined.Record(ms)
+	}
+
+	merged := first.Clone()
+	merged.Merge(second)
+	if !reflect.DeepEqual(merged, combined) {
+		t.Errorf("merged = %v, want %v", merged, combined)
+	}
+	if merged.Count() != 9 {
+		t.Errorf("count = %d, want 9", merged.Count())
+	}
+	if first.Count() != 5 {
+		t.Errorf("merging into a clone changed the original: count = %d, want 5", first.Count())
+	}
+}
@@ -0,0 +1,78 @@
+package domain
+
+import "fmt"
+
+// LoadProfileType describes the shape of a staged load profile
+type LoadProfileType string
+
+const (
+	LoadProfileTypeRamp   LoadProfileType = "ramp"   // Gradually increasing users
+	LoadProfileTypeStep   LoadProfileType = "step"   // Discrete plateaus of increasing users
+	LoadProfileTypeSpike  LoadProfileType = "spike"  // Sudden burst followed by recovery
+	LoadProfileTypeSoak   LoadProfileType = "soak"   // Long hold at a steady load
+	LoadProfileTypeCustom LoadProfileType = "custom" // Any other sequence of stages
+)
+
+// LoadStage is a single step of a load profile
+type LoadStage struct {
+	Name        string  `json:"name,omitempty"`
+	TargetUsers int     `json:"targetUsers"`
+	SpawnRate   float64 `json:"spawnRate"`
+	HoldSeconds int     `json:"holdSeconds"` // Time spent in this stage before moving to the next one
+}
+
+// LoadProfile is an ordered list of stages the control plane walks through during a run
+type LoadProfile struct {
+	Type   LoadProfileType `json:"type,omitempty"`
+	Stages []LoadStage     `json:"stages"`
+}
+
+// StageTransition records when a run entered a stage of its load profile
+type StageTransition struct {
+	StageIndex  int     `json:"stageIndex"`
+	Name        string  `json:"name,omitempty"`
+	TargetUsers int     `json:"targetUsers"`
+	SpawnRate   float64 `json:"spawnRate"`
+	StartedAt   int64   `json:"startedAt"`       // Unix milliseconds
+	Error       string  `json:"error,omitempty"` // Set if the swarm call for this stage failed
+}
+
+// LoadChange records a live adjustment of users or spawn rate on a running run
+type LoadChange struct {
+	Timestamp         int64   `json:"timestamp"` // Unix milliseconds
+	PreviousUsers     int     `json:"previousUsers"`
+	PreviousSpawnRate float64 `json:"previousSpawnRate"`
+	TargetUsers       int     `json:"targetUsers"`
+	SpawnRate         float64 `json:"spawnRate"`
+	ChangedBy         string  `json:"changedBy,omitempty"`
+}
+
+// Validate checks that the profile can be executed
+func (p *LoadProfile) Validate() error {
+	if len(p.Stages) == 0 {
+		return fmt.Errorf("load profile must have at least one stage")
+	}
+
+	for i, stage := range p.Stages {
+		if stage.TargetUsers < 0 {
+			return fmt.Errorf("stage %d: targetUsers must not be negative", i)
+		}
+		if stage.SpawnRate <= 0 {
+			return fmt.Errorf("stage %d: spawnRate must be greater than zero", i)
+		}
+		if stage.HoldSeconds <= 0 {
+			return fmt.Errorf("stage %d: holdSeconds must be greater than zero", i)
+		}
+	}
+
+	return nil
+}
+
+// TotalDurationSeconds returns the sum of all stage hold times
+func (p *LoadProfile) TotalDurationSeconds() int {
+	total := 0
+	for _, stage := range p.Stages {
+		total += stage.HoldSeconds
+	}
+	return total
+}
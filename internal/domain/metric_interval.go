@@ -0,0 +1,186 @@
+package domain
+
+// MetricInterval holds the requests a metric snapshot adds to the previous snapshot of the same source
+// Locust's counters are cumulative, so adding intervals up is what gives a run's totals and rates
+type MetricInterval struct {
+	StartMs  int64 `json:"startMs"` // Unix milliseconds of the previous snapshot, or of the test start for the first one
+	EndMs    int64 `json:"endMs"`   // Unix milliseconds of this snapshot
+	Requests int64 `json:"requests"`
+	Failures int64 `json:"failures"`
+	Reset    bool  `json:"reset,omitempty"` // Counters went backwards in between (e.g. Locust stats were reset)
+	// Responses recorded over the interval, if the source reports response time histograms
+	ResponseTimes Histogram `json:"responseTimes,omitempty"`
+}
+
+// DurationMs returns the length of the interval, 0 if its bounds are out of order
+func (i *MetricInterval) DurationMs() int64 {
+	if i.EndMs <= i.StartMs {
+		return 0
+	}
+	return i.EndMs - i.StartMs
+}
+
+// SetInterval computes what the snapshot adds to the previous snapshot of the same source, or to
+// the test start at startMs if there is none, for the whole snapshot and each of its endpoints
+// A counter lower than before means the stats were reset in between; the requests since the reset,
+// which are the snapshot's own counts, are then all the interval can account for.
+// Response time histograms are diffed the same way with Histogram.Since
+func (s *MetricSnapshot) SetInterval(previous *MetricSnapshot, startMs int64) {
+	if startMs == 0 {
+		startMs = s.Timestamp // Unknown test start: the first interval has no duration
+	}
+	interval := &MetricInterval{
+		StartMs:  startMs,
+		EndMs:    s.Timestamp,
+		Requests: s.TotalRequests,
+		Failures: s.TotalFailures,
+	}
+	if previous != nil {
+		interval.StartMs = previous.Timestamp
+		if s.TotalRequests < previous.TotalRequests || s.TotalFailures < previous.TotalFailures {
+			interval.Reset = true
+			previous = nil
+		} else {
+			interval.Requests -= previous.TotalRequests
+			interval.Failures -= previous.TotalFailures
+		}
+	}
+	if s.ResponseTimes != nil {
+		var before Histogram
+		if previous != nil {
+			before = previous.ResponseTimes
+		}
+		interval.ResponseTimes = s.ResponseTimes.Since(before)
+	}
+	s.Interval = interval
+
+	for key, stat := range s.RequestStats {
+		if stat == nil {
+			continue
+		}
+		stat.IntervalRequests = stat.NumRequests
+		stat.IntervalFailures = stat.NumFailures
+		var before *ReqStat
+		if previous != nil {
+			before = previous.RequestStats[key]
+		}
+		if before != nil && stat.NumRequests >= before.NumRequests && stat.NumFailures >= before.NumFailures {
+			stat.IntervalRequests -= before.NumRequests
+			stat.IntervalFailures -= before.NumFailures
+		} else {
+			before = nil
+		}
+
+		stat.IntervalResponseTimes = nil
+		if stat.ResponseTimes != nil {
+			var histogram Histogram
+			if before != nil {
+				histogram = before.ResponseTimes
+			}
+			stat.IntervalResponseTimes = stat.ResponseTimes.Since(histogram)
+		}
+	}
+}
+
+// PendingInterval accumulates the intervals the shards of a distributed run pushed since its last merged point
+// Response time histograms are only kept while every shard interval with requests carried one
+type PendingInterval struct {
+	StartMs       int64                        `json:"startMs"` // Unix milliseconds of the last merged point, or of the test start
+	Requests      int64                        `json:"requests"`
+	Failures      int64                        `json:"failures"`
+	Reset         bool                         `json:"reset,omitempty"`
+	ResponseTimes Histogram                    `json:"responseTimes,omitempty"`
+	NoHistogram   bool                         `json:"noHistogram,omitempty"` // A shard interval with requests carried no histogram
+	Endpoints     map[string]*EndpointInterval `json:"endpoints,omitempty"`   // Keyed like MetricSnapshot.RequestStats
+}
+
+// EndpointInterval holds the requests of one endpoint over an interval
+type EndpointInterval struct {
+	Requests      int64     `json:"requests"`
+	Failures      int64     `json:"failures"`
+	ResponseTimes Histogram `json:"responseTimes,omitempty"`
+	NoHistogram   bool      `json:"noHistogram,omitempty"`
+}
+
+// NewPendingInterval starts accumulating shard intervals from startMs
+func NewPendingInterval(startMs int64) *PendingInterval {
+	return &PendingInterval{StartMs: startMs, Endpoints: make(map[string]*EndpointInterval)}
+}
+
+// Add accumulates the interval of a shard snapshot
+func (p *PendingInterval) Add(snapshot *MetricSnapshot) {
+	if snapshot == nil || snapshot.Interval == nil {
+		return
+	}
+	p.Requests += snapshot.Interval.Requests
+	p.Failures += snapshot.Interval.Failures
+	p.Reset = p.Reset || snapshot.Interval.Reset
+	p.ResponseTimes, p.NoHistogram = addHistogram(p.ResponseTimes, p.NoHistogram, snapshot.Interval.ResponseTimes, snapshot.Interval.Requests)
+
+	if p.Endpoints == nil {
+		p.Endpoints = make(map[string]*EndpointInterval)
+	}
+	for key, stat := range snapshot.RequestStats {
+		if stat == nil || (stat.IntervalRequests == 0 && stat.IntervalFailures == 0) {
+			continue
+		}
+		endpoint := p.Endpoints[key]
+		if endpoint == nil {
+			endpoint = &EndpointInterval{}
+			p.Endpoints[key] = endpoint
+		}
+		endpoint.Requests += stat.IntervalRequests
+		endpoint.Failures += stat.IntervalFailures
+		endpoint.ResponseTimes, endpoint.NoHistogram = addHistogram(endpoint.ResponseTimes, endpoint.NoHistogram, stat.IntervalResponseTimes, stat.IntervalRequests)
+	}
+}
+
+// addHistogram merges the histogram of a shard interval into an accumulated one
+// An interval with requests but no histogram leaves the accumulated histogram incomplete for good
+func addHistogram(accumulated Histogram, incomplete bool, histogram Histogram, requests int64) (Histogram, bool) {
+	if incomplete || (histogram == nil && requests > 0) {
+		return nil, true
+	}
+	if histogram == nil {
+		return accumulated, false
+	}
+	if accumulated == nil {
+		accumulated = make(Histogram)
+	}
+	accumulated.Merge(histogram)
+	return accumulated, false
+}
+
+// Empty reports whether no requests were accumulated
+func (p *PendingInterval) Empty() bool {
+	return p.Requests == 0 && p.Failures == 0 && !p.Reset
+}
+
+// Apply sets the accumulated intervals on a merged snapshot, which closes them at the snapshot's timestamp
+func (p *PendingInterval) Apply(merged *MetricSnapshot) {
+	startMs := p.StartMs
+	if startMs == 0 {
+		startMs = merged.Timestamp // Unknown test start, as in SetInterval
+	}
+	merged.Interval = &MetricInterval{
+		StartMs:  startMs,
+		EndMs:    merged.Timestamp,
+		Requests: p.Requests,
+		Failures: p.Failures,
+		Reset:    p.Reset,
+	}
+	if !p.NoHistogram {
+		merged.Interval.ResponseTimes = p.ResponseTimes
+	}
+	for key, stat := range merged.RequestStats {
+		stat.IntervalRequests, stat.IntervalFailures = 0, 0
+		stat.IntervalResponseTimes = nil
+		if endpoint := p.Endpoints[key]; endpoint != nil {
+			stat.IntervalRequests = endpoint.Requests
+			stat.IntervalFailures = endpoint.Failures
+			if !endpoint.NoHistogram {
+				stat.IntervalResponseTimes = endpoint.ResponseTimes
+			}
+		}
+	}
+}
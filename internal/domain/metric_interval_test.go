@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetIntervalWithoutPreviousSnapshot(t *testing.T) {
+	tests := []struct {
+		name      string
+		startMs   int64
+		wantStart int64
+	}{
+		{name: "known test start", startMs: 1000, wantStart: 1000},
+		{name: "unknown test start", startMs: 0, wantStart: 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := &MetricSnapshot{Timestamp: 5000, TotalRequests: 40, TotalFailures: 4}
+			snapshot.SetInterval(nil, tt.startMs)
+
+			want := &MetricInterval{StartMs: tt.wantStart, EndMs: 5000, Requests: 40, Failures: 4}
+			if !reflect.DeepEqual(snapshot.Interval, want) {
+				t.Errorf("interval = %+v, want %+v", snapshot.Interval, want)
+			}
+		})
+	}
+}
+
+func TestSetIntervalDiffsPreviousSnapshot(t *testing.T) {
+	previous := &MetricSnapshot{
+		Timestamp:     2000,
+		TotalRequests: 30,
+		TotalFailures: 3,
+		ResponseTimes: Histogram{10: 20, 20: 10},
+		RequestStats: map[string]*ReqStat{
+			"GET:/a": {NumRequests: 20, NumFailures: 1, ResponseTimes: Histogram{10: 20}},
+			"GET:/b": {NumRequests: 10, NumFailures: 2, ResponseTimes: Histogram{20: 10}},
+		},
+	}
+	snapshot := &MetricSnapshot{
+		Timestamp:     4000,
+		TotalRequests: 50,
+		TotalFailures: 4,
+		ResponseTimes: Histogram{10: 25, 20: 10, 30: 15},
+		RequestStats: map[string]*ReqStat{
+			"GET:/a": {NumRequests: 25, NumFailures: 1, ResponseTimes: Histogram{10: 25}},
+			"GET:/b": {NumRequests: 10, NumFailures: 2, ResponseTimes: Histogram{20: 10}},
+			"GET:/c": {NumRequests: 15, NumFailures: 1, ResponseTimes: Histogram{30: 15}},
+		},
+	}
+	snapshot.SetInterval(previous, 1000)
+
+	want := &MetricInterval{
+		StartMs:       2000,
+		EndMs:         4000,
+		Requests:      20,
+		Failures:      1,
+		ResponseTimes: Histogram{10: 5, 30: 15},
+	}
+	if !reflect.DeepEqual(snapshot.Interval, want) {
+		t.Errorf("interval = %+v, want %+v", snapshot.Interval, want)
+	}
+	if d := snapshot.Interval.DurationMs(); d != 2000 {
+		t.Errorf("duration = %d, want 2000", d)
+	}
+
+	endpoints := []struct {
+		key           string
+		requests      int64
+		failures      int64
+		responseTimes Histogram
+	}{
+		{key: "GET:/a", requests: 5, failures: 0, responseTimes: Histogram{10: 5}},
+		{key: "GET:/b", requests: 0, failures: 0, responseTimes: Histogram{}},
+		{key: "GET:/c", requests: 15, failures: 1, responseTimes: Histogram{30: 15}}, // New endpoint: all of its requests
+	}
+	for _, e := range endpoints {
+		stat := snapshot.RequestStats[e.key]
+		if stat.IntervalRequests != e.requests || stat.IntervalFailures != e.failures {
+			t.Errorf("%s interval = %d requests, %d failures, want %d, %d",
+				e.key, stat.IntervalRequests, stat.IntervalFailures, e.requests, e.failures)
+		}
+		if !reflect.DeepEqual(stat.IntervalResponseTimes, e.responseTimes) {
+			t.Errorf("%s interval response times = %v, want %v", e.key, stat.IntervalResponseTimes, e.responseTimes)
+		}
+	}
+}
+
+func TestSetIntervalAfterCounterReset(t *testing.T) {
+	previous := &MetricSnapshot{
+		Timestamp:     2000,
+		TotalRequests: 100,
+		TotalFailures: 10,
+		ResponseTimes: Histogram{10: 100},
+		RequestStats: map[string]*ReqStat{
+			"GET:/a": {NumRequests: 100, NumFailures: 10, ResponseTimes: Histogram{10: 100}},
+		},
+	}
+	snapshot := &MetricSnapshot{
+		Timestamp:     4000,
+		TotalRequests: 8,
+		TotalFailures: 1,
+		ResponseTimes: Histogram{10: 6, 20: 2},
+		RequestStats: map[string]*ReqStat{
+			"GET:/a": {NumRequests: 8, NumFailures: 1, ResponseTimes: Histogram{10: 6, 20: 2}},
+		},
+	}
+	snapshot.SetInterval(previous, 1000)
+
+	// The requests since the reset are the snapshot's own counts
+	want := &MetricInterval{
+		StartMs:       2000,
+		EndMs:         4000,
+		Requests:      8,
+		Failures:      1,
+		Reset:         true,
+		ResponseTimes: Histogram{10: 6, 20: 2},
+	}
+	if !reflect.DeepEqual(snapshot.Interval, want) {
+		t.Errorf("interval = %+v, want %+v", snapshot.Interval, want)
+	}
+
+	stat := snapshot.RequestStats["GET:/a"]
+	if stat.IntervalRequests != 8 || stat.IntervalFailures != 1 {
+		t.Errorf("endpoint interval = %d requests, %d failures, want 8, 1", stat.IntervalRequests, stat.IntervalFailures)
+	}
+	if !reflect.DeepEqual(stat.IntervalResponseTimes, Histogram{10: 6, 20: 2}) {
+		t.Errorf("endpoint interval response times = %v, want %v", stat.IntervalResponseTimes, Histogram{10: 6, 20: 2})
+	}
+}
+
+func TestSetIntervalResetsOnlyFailures(t *testing.T) {
+	previous := &MetricSnapshot{Timestamp: 2000, TotalRequests: 10, TotalFailures: 5}
+	snapshot := &MetricSnapshot{Timestamp: 4000, TotalRequests: 12, TotalFailures: 1}
+	snapshot.SetInterval(previous, 1000)
+
+	if !snapshot.Interval.Reset || snapshot.Interval.Requests != 12 || snapshot.Interval.Failures != 1 {
+		t.Errorf("interval = %+v, want a reset with 12 requests and 1 failure", snapshot.Interval)
+	}
+}
+
+func TestPendingIntervalApply(t *testing.T) {
+	shard := func(requests, failures int64, histogram Histogram) *MetricSnapshot {
+		return &MetricSnapshot{
+			Interval: &MetricInterval{Requests: requests, Failures: failures, ResponseTimes: histogram},
+			RequestStats: map[string]*ReqStat{
+				"GET:/a": {IntervalRequests: requests, IntervalFailures: failures, IntervalResponseTimes: histogram},
+			},
+		}
+	}
+
+	t.Run("every shard carries histograms", func(t *testing.T) {
+		pending := NewPendingInterval(1000)
+		pending.Add(shard(10, 1, Histogram{10: 10}))
+		pending.Add(shard(5, 0, Histogram{10: 2, 20: 3}))
+
+		merged := &MetricSnapshot{Timestamp: 3000, RequestStats: map[string]*ReqStat{"GET:/a": {}, "GET:/b": {IntervalRequests: 7}}}
+		pending.Apply(merged)
+
+		want := &MetricInterval{StartMs: 1000, EndMs: 3000, Requests: 15, Failures: 1, ResponseTimes: Histogram{10: 12, 20: 3}}
+		if !reflect.DeepEqual(merged.Interval, want) {
+			t.Errorf("interval = %+v, want %+v", merged.Interval, want)
+		}
+		a := merged.RequestStats["GET:/a"]
+		if a.IntervalRequests != 15 || a.IntervalFailures != 1 || !reflect.DeepEqual(a.IntervalResponseTimes, Histogram{10: 12, 20: 3}) {
+			t.Errorf("GET:/a interval = %d requests, %d failures, %v", a.IntervalRequests, a.IntervalFailures, a.IntervalResponseTimes)
+		}
+		if b := merged.RequestStats["GET:/b"]; b.IntervalRequests != 0 {
+			t.Errorf("GET:/b interval requests = %d, want 0", b.IntervalRequests)
+		}
+	})
+
+	t.Run("a shard without histograms", func(t *testing.T) {
+		pending := NewPendingInterval(1000)
+		pending.Add(shard(10, 1, Histogram{10: 10}))
+		pending.Add(shard(5, 0, nil))
+		pending.Add(shard(3, 0, Histogram{10: 3}))
+
+		merged := &MetricSnapshot{Timestamp: 3000, RequestStats: map[string]*ReqStat{"GET:/a": {}}}
+		pending.Apply(merged)
+
+		if merged.Interval.Requests != 18 || merged.Interval.ResponseTimes != nil {
+			t.Errorf("interval = %+v, want 18 requests and no histogram", merged.Interval)
+		}
+		if a := merged.RequestStats["GET:/a"]; a.IntervalRequests != 18 || a.IntervalResponseTimes != nil {
+			t.Errorf("GET:/a interval = %d requests, %v, want 18 requests and no histogram", a.IntervalRequests, a.IntervalResponseTimes)
+		}
+	})
+}
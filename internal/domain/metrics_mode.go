@@ -0,0 +1,27 @@
+package domain
+
+// MetricsMode is how the control plane gets the metrics of the runs on a cluster
+type MetricsMode string
+
+const (
+	MetricsModePush MetricsMode = "push" // The harness plugin pushes metrics and the test stop to the control plane
+	MetricsModePoll MetricsMode = "poll" // The control plane polls the Locust master's stats, for scripts without the plugin or masters that cannot reach it
+)
+
+// OrDefault returns the metrics mode, or fallback if it is not set
+func (m MetricsMode) OrDefault(fallback MetricsMode) MetricsMode {
+	if m == "" {
+		return fallback
+	}
+	return m
+}
+
+// Known reports whether the metrics mode is one the control plane knows; empty means the configured default
+func (m MetricsMode) Known() bool {
+	switch m {
+	case "", MetricsModePush, MetricsModePoll:
+		return true
+	default:
+		return false
+	}
+}
@@ -10,17 +10,27 @@ const (
 	LoadTestRunStatusStopped  LoadTestRunStatus = "Stopped"  // Manual stop
 	LoadTestRunStatusFinished LoadTestRunStatus = "Finished" // Auto-completed
 	LoadTestRunStatusFailed   LoadTestRunStatus = "Failed"
+	LoadTestRunStatusAborted  LoadTestRunStatus = "Aborted" // Stopped by an abort rule
 )
 
-// LocustCluster represents a Locust master cluster configuration
+// LocustCluster represents a Locust master cluster registered with the control plane
 type LocustCluster struct {
-	ID        string `json:"id"`
-	BaseURL   string `json:"baseUrl"`   // e.g., "http://locust-master:8089"
-	AccountID string `json:"accountId"`
-	OrgID     string `json:"orgId"`
-	ProjectID string `json:"projectId"`
-	EnvID     string `json:"envId,omitempty"` // Optional environment
-	AuthToken string `json:"authToken"`       // Optional API key/token for Locust master
+	ID          string         `json:"id" bson:"id"`
+	Name        string         `json:"name,omitempty" bson:"name,omitempty"`
+	BaseURL     string         `json:"baseUrl" bson:"baseUrl"`                             // e.g., "http://locust-master:8089"
+	Engine      EngineType     `json:"engine,omitempty" bson:"engine,omitempty"`           // Engine the cluster runs (default: locust)
+	MetricsMode MetricsMode    `json:"metricsMode,omitempty" bson:"metricsMode,omitempty"` // How runs on the cluster report metrics (default: orchestrator.metricsMode)
+	AccountID   string         `json:"accountId" bson:"accountId"`
+	OrgID       string         `json:"orgId" bson:"orgId"`
+	ProjectID   string         `json:"projectId" bson:"projectId"`
+	EnvID       string         `json:"envId,omitempty" bson:"envId,omitempty"`         // Optional environment
+	AuthToken   string         `json:"authToken" bson:"authToken,omitempty"`           // Optional API key/token for Locust master
+	Source      string         `json:"source,omitempty" bson:"source,omitempty"`       // "config" if seeded from the config file, "api" if registered at runtime
+	Health      *ClusterHealth `json:"health,omitempty" bson:"health,omitempty"`       // Result of the latest health probe
+	CreatedAt   int64          `json:"createdAt,omitempty" bson:"createdAt,omitempty"` // Unix milliseconds
+	CreatedBy   string         `json:"createdBy,omitempty" bson:"createdBy,omitempty"`
+	UpdatedAt   int64          `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"` // Unix milliseconds
+	UpdatedBy   string         `json:"updatedBy,omitempty" bson:"updatedBy,omitempty"`
 }
 
 // ScriptRevision represents a version of a Locust test script
@@ -50,52 +60,77 @@ type RecentRun struct {
 
 // LoadTest represents a load test definition/template
 type LoadTest struct {
-	ID              string         `json:"id"`
-	Name            string         `json:"name"`
-	Description     string         `json:"description,omitempty"`
-	Tags            []string       `json:"tags,omitempty"`
-	AccountID       string         `json:"accountId"`
-	OrgID           string         `json:"orgId"`
-	ProjectID       string         `json:"projectId"`
-	EnvID           string         `json:"envId,omitempty"`     // Optional environment
-	LocustClusterID string         `json:"locustClusterId"`
-	TargetURL       string         `json:"targetUrl"`
-	LatestRevisionID string        `json:"latestRevisionId,omitempty"` // Reference to the latest script revision
-	ScenarioID      string         `json:"scenarioId,omitempty"` // Optional scenario/tag within locustfile
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description,omitempty"`
+	Tags             []string   `json:"tags,omitempty"`
+	AccountID        string     `json:"accountId"`
+	OrgID            string     `json:"orgId"`
+	ProjectID        string     `json:"projectId"`
+	EnvID            string     `json:"envId,omitempty"` // Optional environment
+	LocustClusterID  string     `json:"locustClusterId"`
+	Engine           EngineType `json:"engine,omitempty"` // Engine executing the load test's script (default: locust)
+	TargetURL        string     `json:"targetUrl"`
+	LatestRevisionID string     `json:"latestRevisionId,omitempty"` // Reference to the latest script revision
+	ScenarioID       string     `json:"scenarioId,omitempty"`       // Optional scenario/tag within locustfile
 	// Default runtime parameters
-	DefaultUsers         int     `json:"defaultUsers,omitempty"`
-	DefaultSpawnRate     float64 `json:"defaultSpawnRate,omitempty"`
-	DefaultDurationSec   *int    `json:"defaultDurationSec,omitempty"`
-	MaxDurationSec       *int    `json:"maxDurationSec,omitempty"` // Maximum allowed duration
+	DefaultUsers       int          `json:"defaultUsers,omitempty"`
+	DefaultSpawnRate   float64      `json:"defaultSpawnRate,omitempty"`
+	DefaultDurationSec *int         `json:"defaultDurationSec,omitempty"`
+	MaxDurationSec     *int         `json:"maxDurationSec,omitempty"` // Maximum allowed duration
+	LoadProfile        *LoadProfile `json:"loadProfile,omitempty"`    // Optional staged load profile
+	Thresholds         []Threshold  `json:"thresholds,omitempty"`     // SLOs every run is judged against
+	AbortRules         []AbortRule  `json:"abortRules,omitempty"`     // Live conditions that stop a run early
 	// Recent runs (up to 10 most recent)
 	RecentRuns []RecentRun `json:"recentRuns,omitempty"`
 	// Audit fields (Unix milliseconds)
-	CreatedAt  int64  `json:"createdAt"`
-	CreatedBy  string `json:"createdBy"`
-	UpdatedAt  int64  `json:"updatedAt"`
-	UpdatedBy  string `json:"updatedBy"`
-	Metadata   map[string]any `json:"metadata,omitempty"` // Additional metadata
+	CreatedAt int64          `json:"createdAt"`
+	CreatedBy string         `json:"createdBy"`
+	UpdatedAt int64          `json:"updatedAt"`
+	UpdatedBy string         `json:"updatedBy"`
+	Metadata  map[string]any `json:"metadata,omitempty"` // Additional metadata
 }
 
 // LoadTestRun represents an actual execution of a load test
 type LoadTestRun struct {
-	ID               string `json:"id"`
-	LoadTestID       string `json:"loadTestId"` // Reference to the LoadTest
-	ScriptRevisionID string `json:"scriptRevisionId"` // Reference to the script revision used for this run
-	Name             string `json:"name,omitempty"` // Optional run name
-	AccountID        string `json:"accountId"`
-	OrgID            string `json:"orgId"`
-	ProjectID        string `json:"projectId"`
-	EnvID            string `json:"envId,omitempty"` // Optional environment
+	ID               string      `json:"id"`
+	LoadTestID       string      `json:"loadTestId"`           // Reference to the LoadTest
+	ScriptRevisionID string      `json:"scriptRevisionId"`     // Reference to the script revision used for this run
+	ScriptHash       string      `json:"scriptHash,omitempty"` // SHA-256 of the script confirmed as loaded by Locust
+	Name             string      `json:"name,omitempty"`       // Optional run name
+	AccountID        string      `json:"accountId"`
+	OrgID            string      `json:"orgId"`
+	ProjectID        string      `json:"projectId"`
+	EnvID            string      `json:"envId,omitempty"`       // Optional environment
+	ClusterID        string      `json:"clusterId,omitempty"`   // Locust cluster the run was admitted to
+	Engine           EngineType  `json:"engine,omitempty"`      // Engine executing the run, copied from the LoadTest
+	MetricsMode      MetricsMode `json:"metricsMode,omitempty"` // How the run's metrics reach the control plane, chosen from its cluster at start
 	// Runtime parameters (can override LoadTest defaults)
-	TargetUsers     int     `json:"targetUsers"`
-	SpawnRate       float64 `json:"spawnRate"`
-	DurationSeconds *int    `json:"durationSeconds,omitempty"`
+	TargetURL       string       `json:"targetUrl,omitempty"` // Host the run was pointed at
+	TargetUsers     int          `json:"targetUsers"`
+	SpawnRate       float64      `json:"spawnRate"`
+	DurationSeconds *int         `json:"durationSeconds,omitempty"`
+	LoadProfile     *LoadProfile `json:"loadProfile,omitempty"` // Staged profile driven by the control plane
+	Thresholds      []Threshold  `json:"thresholds,omitempty"`  // SLOs copied from the LoadTest when the run was created
+	AbortRules      []AbortRule  `json:"abortRules,omitempty"`  // Abort rules copied from the LoadTest when the run was created
 	// Execution state
-	Status       LoadTestRunStatus `json:"status"`
-	StartedAt    int64             `json:"startedAt,omitempty"`  // Unix milliseconds
-	FinishedAt   int64             `json:"finishedAt,omitempty"` // Unix milliseconds
-	LastMetrics  *MetricSnapshot   `json:"lastMetrics,omitempty"`
+	Status           LoadTestRunStatus  `json:"status"`
+	Timeline         []StatusTransition `json:"timeline,omitempty"`        // Every status change, oldest first
+	QueuedAt         int64              `json:"queuedAt,omitempty"`        // Unix milliseconds, set while waiting for a busy cluster
+	ClusterAttempts  []ClusterAttempt   `json:"clusterAttempts,omitempty"` // Clusters the run was started on, including failovers
+	Shards           []RunShard         `json:"shards,omitempty"`          // Per-cluster shards of a distributed run
+	StartedAt        int64              `json:"startedAt,omitempty"`       // Unix milliseconds
+	FinishedAt       int64              `json:"finishedAt,omitempty"`      // Unix milliseconds
+	LastMetrics      *MetricSnapshot    `json:"lastMetrics,omitempty"`
+	PendingInterval  *PendingInterval   `json:"pendingInterval,omitempty"`  // Shard intervals not yet in a merged point of a distributed run
+	LastHeartbeatAt  int64              `json:"lastHeartbeatAt,omitempty"`  // Unix milliseconds of the last sign of life from Locust
+	FailureReason    string             `json:"failureReason,omitempty"`    // Why the control plane failed the run
+	CurrentStage     int                `json:"currentStage,omitempty"`     // Index of the active load profile stage
+	StageTransitions []StageTransition  `json:"stageTransitions,omitempty"` // When each profile stage was entered
+	LoadChanges      []LoadChange       `json:"loadChanges,omitempty"`      // Live adjustments of users and spawn rate
+	ProfileOverride  bool               `json:"profileOverride,omitempty"`  // A live adjustment took over from the load profile
+	Verdict          *Verdict           `json:"verdict,omitempty"`          // Threshold evaluation recorded when the run ends
+	AbortedBy        *AbortTrigger      `json:"abortedBy,omitempty"`        // The abort rule that stopped the run
 	// Audit fields (Unix milliseconds)
 	CreatedAt int64          `json:"createdAt"`
 	CreatedBy string         `json:"createdBy"`
@@ -106,36 +141,52 @@ type LoadTestRun struct {
 
 // MetricSnapshot represents aggregated metrics from Locust at a point in time
 type MetricSnapshot struct {
-	Timestamp         int64              `json:"timestamp"` // Unix milliseconds
-	TotalRPS          float64            `json:"totalRps"`
-	TotalRequests     int64              `json:"totalRequests"`
-	TotalFailures     int64              `json:"totalFailures"`
-	ErrorRate         float64            `json:"errorRate"` // Percentage
-	AverageResponseMs float64            `json:"avgResponseMs"`
-	MinResponseMs     float64            `json:"minResponseMs"`
-	MaxResponseMs     float64            `json:"maxResponseMs"`
-	AvgResponseMs     float64            `json:"avgResponseMs"` // Alias for AverageResponseMs
-	P50ResponseMs     float64            `json:"p50ResponseMs"`
-	P95ResponseMs     float64            `json:"p95ResponseMs"`
-	P99ResponseMs     float64            `json:"p99ResponseMs"`
-	CurrentUsers      int                `json:"currentUsers"`
-	RequestStats      map[string]*ReqStat `json:"requestStats,omitempty"` // Per-endpoint stats
+	Timestamp         int64               `json:"timestamp"` // Unix milliseconds
+	TotalRPS          float64             `json:"totalRps"`
+	TotalRequests     int64               `json:"totalRequests"`
+	TotalFailures     int64               `json:"totalFailures"`
+	ErrorRate         float64             `json:"errorRate"` // Percentage
+	AverageResponseMs float64             `json:"avgResponseMs"`
+	MinResponseMs     float64             `json:"minResponseMs"`
+	MaxResponseMs     float64             `json:"maxResponseMs"`
+	AvgResponseMs     float64             `json:"-"` // Alias for AverageResponseMs
+	P50ResponseMs     float64             `json:"p50ResponseMs"`
+	P90ResponseMs     float64             `json:"p90ResponseMs"`
+	P95ResponseMs     float64             `json:"p95ResponseMs"`
+	P99ResponseMs     float64             `json:"p99ResponseMs"`
+	P999ResponseMs    float64             `json:"p999ResponseMs"`
+	CurrentUsers      int                 `json:"currentUsers"`
+	RunnerState       string              `json:"runnerState,omitempty"`   // Locust runner state when polled: "running", "stopped", ...
+	WorkerCount       int                 `json:"workerCount,omitempty"`   // Connected workers when polled from a master
+	ResponseTimes     Histogram           `json:"responseTimes,omitempty"` // Response times since the test started, if the source reports them
+	Interval          *MetricInterval     `json:"interval,omitempty"`      // Requests since the previous snapshot, set by the control plane on ingestion
+	Failures          []FailureGroup      `json:"failures,omitempty"`      // Failure groups since the test started, if the source reports them; moved to the run's failures on ingestion
+	Exceptions        []ExceptionGroup    `json:"exceptions,omitempty"`    // Exceptions since the test started, if the source reports them; moved like Failures
+	RequestStats      map[string]*ReqStat `json:"requestStats,omitempty"`  // Per-endpoint stats
 }
 
 // ReqStat represents statistics for a specific request/endpoint
 type ReqStat struct {
-	Method             string  `json:"method"`
-	Name               string  `json:"name"`
-	NumRequests        int64   `json:"numRequests"`
-	NumFailures        int64   `json:"numFailures"`
-	AvgResponseTime    float64 `json:"avgResponseTime"`
-	AvgResponseTimeMs  float64 `json:"avgResponseTimeMs"` // In milliseconds
-	MinResponseTime    float64 `json:"minResponseTime"`
-	MinResponseTimeMs  float64 `json:"minResponseTimeMs"` // In milliseconds
-	MaxResponseTime    float64 `json:"maxResponseTime"`
-	MaxResponseTimeMs  float64 `json:"maxResponseTimeMs"` // In milliseconds
-	MedianResponseTime float64 `json:"medianResponseTime"`
-	P50ResponseMs      float64 `json:"p50ResponseMs"` // 50th percentile
-	P95ResponseMs      float64 `json:"p95ResponseMs"` // 95th percentile
-	RequestsPerSec     float64 `json:"requestsPerSec"`
+	Method             string    `json:"method"`
+	Name               string    `json:"name"`
+	NumRequests        int64     `json:"numRequests"`
+	NumFailures        int64     `json:"numFailures"`
+	AvgResponseTime    float64   `json:"avgResponseTime"`
+	AvgResponseTimeMs  float64   `json:"avgResponseTimeMs"` // In milliseconds
+	MinResponseTime    float64   `json:"minResponseTime"`
+	MinResponseTimeMs  float64   `json:"minResponseTimeMs"` // In milliseconds
+	MaxResponseTime    float64   `json:"maxResponseTime"`
+	MaxResponseTimeMs  float64   `json:"maxResponseTimeMs"` // In milliseconds
+	MedianResponseTime float64   `json:"medianResponseTime"`
+	P50ResponseMs      float64   `json:"p50ResponseMs"`           // 50th percentile
+	P90ResponseMs      float64   `json:"p90ResponseMs"`           // 90th percentile
+	P95ResponseMs      float64   `json:"p95ResponseMs"`           // 95th percentile
+	P99ResponseMs      float64   `json:"p99ResponseMs"`           // 99th percentile
+	P999ResponseMs     float64   `json:"p999ResponseMs"`          // 99.9th percentile
+	ResponseTimes      Histogram `json:"responseTimes,omitempty"` // Response times since the test started, if the source reports them
+	RequestsPerSec     float64   `json:"requestsPerSec"`
+	IntervalRequests   int64     `json:"intervalRequests,omitempty"` // Requests since the previous snapshot, see MetricSnapshot.Interval
+	IntervalFailures   int64     `json:"intervalFailures,omitempty"` // Failures since the previous snapshot
+	// Response times since the previous snapshot, if the source reports them
+	IntervalResponseTimes Histogram `json:"intervalResponseTimes,omitempty"`
 }
@@ -0,0 +1,86 @@
+package domain
+
+import "unicode/utf8"
+
+// MaxRequestSamples caps the request samples kept per run; older samples are dropped first
+const MaxRequestSamples = 10000
+
+// MaxSampleExceptionBytes caps the exception kept per sample, so a run's samples stay well within a MongoDB document
+const MaxSampleExceptionBytes = 256
+
+// RequestSample is one request a load generator sampled from its request events
+type RequestSample struct {
+	Timestamp      int64   `json:"timestamp" bson:"timestamp"` // Unix milliseconds the request started
+	Method         string  `json:"method" bson:"method"`
+	Name           string  `json:"name" bson:"name"`
+	StatusCode     int     `json:"statusCode" bson:"statusCode"` // 0 if no response was received
+	ResponseTimeMs float64 `json:"responseTimeMs" bson:"responseTimeMs"`
+	ResponseLength int64   `json:"responseLength" bson:"responseLength"`           // Bytes
+	Exception      string  `json:"exception,omitempty" bson:"exception,omitempty"` // Why the request failed, empty if it succeeded
+	ShardID        string  `json:"shardId,omitempty" bson:"shardId,omitempty"`     // Shard that sent the request, for distributed runs
+}
+
+// Success reports whether the request succeeded; the load generator sets an exception on every failure
+func (s *RequestSample) Success() bool {
+	return s.Exception == ""
+}
+
+// TruncateException shortens the sample's exception to MaxSampleExceptionBytes without splitting a character
+func (s *RequestSample) TruncateException() {
+	if len(s.Exception) <= MaxSampleExceptionBytes {
+		return
+	}
+	cut := MaxSampleExceptionBytes
+	for cut > 0 && !utf8.RuneStart(s.Exception[cut]) {
+		cut--
+	}
+	s.Exception = s.Exception[:cut]
+}
+
+// RequestSampleLog holds the request samples kept for a run, oldest first
+type RequestSampleLog struct {
+	RunID      string          `json:"runId" bson:"runId"`
+	SampleRate float64         `json:"sampleRate" bson:"sampleRate"` // Fraction of requests sampled, as last reported
+	Received   int64           `json:"received" bson:"received"`     // Samples received over the run, including dropped ones
+	Samples    []RequestSample `json:"samples" bson:"samples"`
+	UpdatedAt  int64           `json:"updatedAt" bson:"updatedAt"` // Unix milliseconds
+}
+
+// RequestSampleFilter selects request samples; zero values match everything
+type RequestSampleFilter struct {
+	Method        string
+	Name          string
+	ShardID       string
+	Success       *bool // Only successful, or only failed, requests
+	StatusCode    int
+	MinResponseMs float64
+	FromMs        int64 // Unix milliseconds, inclusive
+	ToMs          int64 // Unix milliseconds, inclusive
+}
+
+// Matches reports whether a sample passes the filter
+func (f *RequestSampleFilter) Matches(sample *RequestSample) bool {
+	switch {
+	case f.Method != "" && sample.Method != f.Method,
+		f.Name != "" && sample.Name != f.Name,
+		f.ShardID != "" && sample.ShardID != f.ShardID,
+		f.Success != nil && sample.Success() != *f.Success,
+		f.StatusCode != 0 && sample.StatusCode != f.StatusCode,
+		sample.ResponseTimeMs < f.MinResponseMs,
+		f.FromMs != 0 && sample.Timestamp < f.FromMs,
+		f.ToMs != 0 && sample.Timestamp > f.ToMs:
+		return false
+	}
+	return true
+}
+
+// Filter returns the samples of the log that pass the filter, oldest first
+func (l *RequestSampleLog) Filter(filter RequestSampleFilter) []RequestSample {
+	var samples []RequestSample
+	for i := range l.Samples {
+		if filter.Matches(&l.Samples[i]) {
+			samples = append(samples, l.Samples[i])
+		}
+	}
+	return samples
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRequestSampleTruncateException(t *testing.T) {
+	tests := []struct {
+		name      string
+		exception string
+		want      int
+	}{
+		{name: "short", exception: "timeout", want: len("timeout")},
+		{name: "ascii", exception: strings.Repeat("x", MaxSampleExceptionBytes+10), want: MaxSampleExceptionBytes},
+		// A 3-byte rune straddling the cap is dropped rather than cut in half
+		{name: "multibyte", exception: strings.Repeat("x", MaxSampleExceptionBytes-1) + "€", want: MaxSampleExceptionBytes - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample := RequestSample{Exception: tt.exception}
+			sample.TruncateException()
+			if len(sample.Exception) != tt.want {
+				t.Errorf("exception length = %d, want %d", len(sample.Exception), tt.want)
+			}
+			if !utf8.ValidString(sample.Exception) {
+				t.Error("truncated exception is not valid UTF-8")
+			}
+		})
+	}
+}
+
+func TestRequestSampleLogFilter(t *testing.T) {
+	log := &RequestSampleLog{Samples: []RequestSample{
+		{Timestamp: 1000, Method: "GET", Name: "/", StatusCode: 200, ResponseTimeMs: 20},
+		{Timestamp: 2000, Method: "POST", Name: "/login", StatusCode: 500, ResponseTimeMs: 300, Exception: "HTTP 500", ShardID: "cluster-2"},
+		{Timestamp: 3000, Method: "GET", Name: "/", StatusCode: 0, ResponseTimeMs: 5000, Exception: "timeout"},
+	}}
+	failed := false
+
+	tests := []struct {
+		name   string
+		filter RequestSampleFilter
+		want   []int64
+	}{
+		{name: "everything", filter: RequestSampleFilter{}, want: []int64{1000, 2000, 3000}},
+		{name: "method and name", filter: RequestSampleFilter{Method: "GET", Name: "/"}, want: []int64{1000, 3000}},
+		{name: "shard", filter: RequestSampleFilter{ShardID: "cluster-2"}, want: []int64{2000}},
+		{name: "failed", filter: RequestSampleFilter{Success: &failed}, want: []int64{2000, 3000}},
+		{name: "status code", filter: RequestSampleFilter{StatusCode: 500}, want: []int64{2000}},
+		{name: "slow", filter: RequestSampleFilter{MinResponseMs: 300}, want: []int64{2000, 3000}},
+		{name: "time range", filter: RequestSampleFilter{FromMs: 1500, ToMs: 2000}, want: []int64{2000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := log.Filter(tt.filter)
+			var got []int64
+			for _, sample := range samples {
+				got = append(got, sample.Timestamp)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("samples = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("samples = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTransition is returned when a run is moved to a status its current status cannot reach
+var ErrInvalidTransition = errors.New("invalid run status transition")
+
+// Actors recorded on status transitions that are not made on behalf of a user
+const (
+	RunActorControlPlane = "control-plane" // Watchdogs, reaper, abort rules and the orchestrator itself
+	RunActorLocust       = "locust"        // Callbacks from the Locust plugin
+)
+
+// runTransitions is the run state machine: the statuses each status may move to
+// Terminal statuses have no outgoing transitions
+var runTransitions = map[LoadTestRunStatus][]LoadTestRunStatus{
+	LoadTestRunStatusPending: {
+		LoadTestRunStatusRunning,
+		LoadTestRunStatusStopped, // Cancelled while queued
+		LoadTestRunStatusFailed,
+	},
+	LoadTestRunStatusRunning: {
+		LoadTestRunStatusStopping,
+		LoadTestRunStatusStopped,
+		LoadTestRunStatusFinished,
+		LoadTestRunStatusFailed,
+		LoadTestRunStatusAborted,
+	},
+	LoadTestRunStatusStopping: {
+		LoadTestRunStatusStopped,
+		LoadTestRunStatusFinished,
+		LoadTestRunStatusFailed,
+		LoadTestRunStatusAborted,
+	},
+}
+
+// StatusTransition records a change of a run's status
+type StatusTransition struct {
+	From   LoadTestRunStatus `json:"from,omitempty"` // Empty for the run's initial status
+	To     LoadTestRunStatus `json:"to"`
+	At     int64             `json:"at"`    // Unix milliseconds
+	Actor  string            `json:"actor"` // User, RunActorControlPlane or RunActorLocust
+	Reason string            `json:"reason,omitempty"`
+}
+
+// IsTerminal reports whether a run in this status is over
+func (s LoadTestRunStatus) IsTerminal() bool {
+	switch s {
+	case LoadTestRunStatusStopped, LoadTestRunStatusFinished, LoadTestRunStatusFailed, LoadTestRunStatusAborted:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether the state machine allows moving from this status to another
+func (s LoadTestRunStatus) CanTransitionTo(to LoadTestRunStatus) bool {
+	for _, allowed := range runTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// InitTimeline sets the initial status of a new run and records it as the first timeline entry
+func (r *LoadTestRun) InitTimeline(status LoadTestRunStatus, actor, reason string, at int64) {
+	r.Status = status
+	r.Timeline = []StatusTransition{{To: status, At: at, Actor: actor, Reason: reason}}
+}
+
+// Transition moves the run to a new status and records it in the timeline
+// Returns ErrInvalidTransition if the state machine does not allow the move
+func (r *LoadTestRun) Transition(to LoadTestRunStatus, actor, reason string, at int64) error {
+	if !r.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, r.Status, to)
+	}
+
+	r.Timeline = append(r.Timeline, StatusTransition{
+		From:   r.Status,
+		To:     to,
+		At:     at,
+		Actor:  actor,
+		Reason: reason,
+	})
+	r.Status = to
+	r.UpdatedAt = at
+	return nil
+}
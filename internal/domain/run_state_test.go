@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+var allRunStatuses = []LoadTestRunStatus{
+	LoadTestRunStatusPending,
+	LoadTestRunStatusRunning,
+	LoadTestRunStatusStopping,
+	LoadTestRunStatusStopped,
+	LoadTestRunStatusFinished,
+	LoadTestRunStatusFailed,
+	LoadTestRunStatusAborted,
+}
+
+func TestCanTransitionTo(t *testing.T) {
+	allowed := map[[2]LoadTestRunStatus]bool{
+		{LoadTestRunStatusPending, LoadTestRunStatusRunning}:   true,
+		{LoadTestRunStatusPending, LoadTestRunStatusStopped}:   true,
+		{LoadTestRunStatusPending, LoadTestRunStatusFailed}:    true,
+		{LoadTestRunStatusRunning, LoadTestRunStatusStopping}:  true,
+		{LoadTestRunStatusRunning, LoadTestRunStatusStopped}:   true,
+		{LoadTestRunStatusRunning, LoadTestRunStatusFinished}:  true,
+		{LoadTestRunStatusRunning, LoadTestRunStatusFailed}:    true,
+		{LoadTestRunStatusRunning, LoadTestRunStatusAborted}:   true,
+		{LoadTestRunStatusStopping, LoadTestRunStatusStopped}:  true,
+		{LoadTestRunStatusStopping, LoadTestRunStatusFinished}: true,
+		{LoadTestRunStatusStopping, LoadTestRunStatusFailed}:   true,
+		{LoadTestRunStatusStopping, LoadTestRunStatusAborted}:  true,
+	}
+
+	for _, from := range allRunStatuses {
+		for _, to := range allRunStatuses {
+			want := allowed[[2]LoadTestRunStatus{from, to}]
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s allowed = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestTerminalStatusesHaveNoTransitions(t *testing.T) {
+	for _, from := range allRunStatuses {
+		if !from.IsTerminal() {
+			continue
+		}
+		for _, to := range allRunStatuses {
+			if from.CanTransitionTo(to) {
+				t.Errorf("terminal status %s may move to %s", from, to)
+			}
+		}
+	}
+
+	for _, status := range []LoadTestRunStatus{LoadTestRunStatusPending, LoadTestRunStatusRunning, LoadTestRunStatusStopping} {
+		if status.IsTerminal() {
+			t.Errorf("%s is terminal, want it in flight", status)
+		}
+	}
+}
+
+func TestTransitionRecordsTimeline(t *testing.T) {
+	run := &LoadTestRun{}
+	run.InitTimeline(LoadTestRunStatusPending, "alice", "created", 1000)
+
+	if err := run.Transition(LoadTestRunStatusRunning, RunActorLocust, "test started", 2000); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	if err := run.Transition(LoadTestRunStatusStopping, "bob", "stop requested", 3000); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+
+	if run.Status != LoadTestRunStatusStopping || run.UpdatedAt != 3000 {
+		t.Errorf("status = %s updated at %d, want %s at 3000", run.Status, run.UpdatedAt, LoadTestRunStatusStopping)
+	}
+
+	want := []StatusTransition{
+		{To: LoadTestRunStatusPending, At: 1000, Actor: "alice", Reason: "created"},
+		{From: LoadTestRunStatusPending, To: LoadTestRunStatusRunning, At: 2000, Actor: RunActorLocust, Reason: "test started"},
+		{From: LoadTestRunStatusRunning, To: LoadTestRunStatusStopping, At: 3000, Actor: "bob", Reason: "stop requested"},
+	}
+	if len(run.Timeline) != len(want) {
+		t.Fatalf("timeline = %+v, want %+v", run.Timeline, want)
+	}
+	for i := range want {
+		if run.Timeline[i] != want[i] {
+			t.Errorf("timeline[%d] = %+v, want %+v", i, run.Timeline[i], want[i])
+		}
+	}
+}
+
+func TestTransitionRejectsInvalidMove(t *testing.T) {
+	run := &LoadTestRun{}
+	run.InitTimeline(LoadTestRunStatusPending, "alice", "created", 1000)
+	if err := run.Transition(LoadTestRunStatusRunning, RunActorControlPlane, "", 2000); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	if err := run.Transition(LoadTestRunStatusFinished, RunActorLocust, "", 3000); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+
+	err := run.Transition(LoadTestRunStatusRunning, "bob", "restart", 4000)
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Transition error = %v, want ErrInvalidTransition", err)
+	}
+	if run.Status != LoadTestRunStatusFinished || run.UpdatedAt != 3000 || len(run.Timeline) != 3 {
+		t.Errorf("rejected transition changed the run: status %s, updated at %d, %d timeline entries",
+			run.Status, run.UpdatedAt, len(run.Timeline))
+	}
+}
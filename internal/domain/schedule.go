@@ -0,0 +1,56 @@
+package domain
+
+// MaxScheduleFirings is the number of most recent firings kept on a schedule
+const MaxScheduleFirings = 20
+
+// Schedule triggers runs of a LoadTest on a cron expression
+type Schedule struct {
+	ID             string                `json:"id" bson:"id"`
+	LoadTestID     string                `json:"loadTestId" bson:"loadTestId"` // Reference to the LoadTest
+	Name           string                `json:"name,omitempty" bson:"name,omitempty"`
+	CronExpression string                `json:"cronExpression" bson:"cronExpression"` // Standard 5-field cron expression
+	Timezone       string                `json:"timezone" bson:"timezone"`             // IANA timezone the expression is evaluated in
+	Overrides      *ScheduleRunOverrides `json:"overrides,omitempty" bson:"overrides,omitempty"`
+	Enabled        bool                  `json:"enabled" bson:"enabled"` // Paused schedules are kept but never fire
+	// Firing state (Unix milliseconds)
+	LastFireAt int64            `json:"lastFireAt,omitempty" bson:"lastFireAt,omitempty"`
+	NextFireAt int64            `json:"nextFireAt,omitempty" bson:"nextFireAt,omitempty"`
+	LastRunID  string           `json:"lastRunId,omitempty" bson:"lastRunId,omitempty"`
+	Firings    []ScheduleFiring `json:"firings,omitempty" bson:"firings,omitempty"` // Most recent firings, newest first
+	// Audit fields (Unix milliseconds)
+	CreatedAt int64  `json:"createdAt" bson:"createdAt"`
+	CreatedBy string `json:"createdBy" bson:"createdBy"`
+	UpdatedAt int64  `json:"updatedAt" bson:"updatedAt"`
+	UpdatedBy string `json:"updatedBy" bson:"updatedBy"`
+}
+
+// ScheduleRunOverrides replaces LoadTest defaults for runs created by a schedule
+type ScheduleRunOverrides struct {
+	Name            string         `json:"name,omitempty" bson:"name,omitempty"`
+	TargetURL       string         `json:"targetUrl,omitempty" bson:"targetUrl,omitempty"`
+	TargetUsers     *int           `json:"targetUsers,omitempty" bson:"targetUsers,omitempty"`
+	SpawnRate       *float64       `json:"spawnRate,omitempty" bson:"spawnRate,omitempty"`
+	DurationSeconds *int           `json:"durationSeconds,omitempty" bson:"durationSeconds,omitempty"`
+	LoadProfile     *LoadProfile   `json:"loadProfile,omitempty" bson:"loadProfile,omitempty"`
+	Metadata        map[string]any `json:"metadata,omitempty" bson:"metadata,omitempty"`
+}
+
+// ScheduleFiring records a single firing of a schedule and the run it produced
+type ScheduleFiring struct {
+	FiredAt int64  `json:"firedAt" bson:"firedAt"`                 // Unix milliseconds
+	RunID   string `json:"runId,omitempty" bson:"runId,omitempty"` // Empty if the run could not be created
+	Error   string `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// RecordFiring prepends a firing to the schedule's history and keeps only the most recent ones
+func (s *Schedule) RecordFiring(firing ScheduleFiring) {
+	s.LastFireAt = firing.FiredAt
+	if firing.RunID != "" {
+		s.LastRunID = firing.RunID
+	}
+
+	s.Firings = append([]ScheduleFiring{firing}, s.Firings...)
+	if len(s.Firings) > MaxScheduleFirings {
+		s.Firings = s.Firings[:MaxScheduleFirings]
+	}
+}
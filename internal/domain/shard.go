@@ -0,0 +1,343 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ShardSpec requests a share of a distributed run's load on one Locust cluster
+// A run's shards either all carry a weight or all carry an explicit number of users
+type ShardSpec struct {
+	ClusterID string  `json:"clusterId"`
+	Weight    float64 `json:"weight,omitempty"` // Share of the run's users relative to the other shards
+	Users     int     `json:"users,omitempty"`  // Explicit number of users; the run's users are the sum over its shards
+}
+
+// RunShard is the part of a distributed run generated by one Locust cluster
+// The cluster ID doubles as the shard ID the harness plugin reports in its callbacks
+type RunShard struct {
+	ClusterID       string            `json:"clusterId"`
+	Users           int               `json:"users"`     // Users at start; later load changes are split in the same proportions
+	SpawnRate       float64           `json:"spawnRate"` // Spawn rate at start
+	Status          LoadTestRunStatus `json:"status"`
+	StartedAt       int64             `json:"startedAt,omitempty"`       // Unix milliseconds
+	FinishedAt      int64             `json:"finishedAt,omitempty"`      // Unix milliseconds
+	LastMetrics     *MetricSnapshot   `json:"lastMetrics,omitempty"`     // Latest snapshot pushed by the shard
+	LastHeartbeatAt int64             `json:"lastHeartbeatAt,omitempty"` // Unix milliseconds of the shard's last metrics push
+	Error           string            `json:"error,omitempty"`           // Why the shard failed to start
+}
+
+// PlanShards validates the shards requested for a run and splits the run's users and spawn rate across them
+// Weights split totalUsers; explicit user counts are used as given
+func PlanShards(specs []ShardSpec, totalUsers int, spawnRate float64) ([]RunShard, error) {
+	if len(specs) < 2 {
+		return nil, fmt.Errorf("a distributed run needs at least 2 shards, got %d", len(specs))
+	}
+
+	explicit := specs[0].Users > 0
+	seen := make(map[string]bool, len(specs))
+	weights := make([]float64, len(specs))
+	for i, spec := range specs {
+		if spec.ClusterID == "" {
+			return nil, fmt.Errorf("shard %d: clusterId is required", i)
+		}
+		if seen[spec.ClusterID] {
+			return nil, fmt.Errorf("shard %d: cluster %s is used by more than one shard", i, spec.ClusterID)
+		}
+		seen[spec.ClusterID] = true
+
+		if spec.Users < 0 || spec.Weight < 0 {
+			return nil, fmt.Errorf("shard %d: users and weight must not be negative", i)
+		}
+		if (spec.Users > 0) != explicit || (spec.Users > 0 && spec.Weight > 0) {
+			return nil, fmt.Errorf("shard %d: either every shard sets users or every shard sets a weight", i)
+		}
+		if !explicit && spec.Weight == 0 {
+			return nil, fmt.Errorf("shard %d: weight must be greater than 0", i)
+		}
+
+		weights[i] = spec.Weight
+		if explicit {
+			weights[i] = float64(spec.Users)
+		}
+	}
+
+	users := make([]int, len(specs))
+	if explicit {
+		totalUsers = 0
+		for i, spec := range specs {
+			users[i] = spec.Users
+			totalUsers += spec.Users
+		}
+	} else {
+		users = SplitUsers(totalUsers, weights)
+	}
+
+	shards := make([]RunShard, len(specs))
+	for i, spec := range specs {
+		shards[i] = RunShard{
+			ClusterID: spec.ClusterID,
+			Users:     users[i],
+			SpawnRate: splitRate(spawnRate, users[i], totalUsers, len(specs)),
+			Status:    LoadTestRunStatusPending,
+		}
+	}
+
+	return shards, nil
+}
+
+// SplitUsers splits users across shards in proportion to their weights
+// Remainders go to the shards with the largest fractional share, so the split always adds up to users
+func SplitUsers(users int, weights []float64) []int {
+	split := make([]int, len(weights))
+	if len(weights) == 0 {
+		return split
+	}
+
+	var totalWeight float64
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+	if totalWeight <= 0 {
+		weights = make([]float64, len(split))
+		for i := range weights {
+			weights[i] = 1
+		}
+		totalWeight = float64(len(weights))
+	}
+
+	remainders := make([]int, len(weights))
+	assigned := 0
+	for i, weight := range weights {
+		share := float64(users) * weight / totalWeight
+		split[i] = int(math.Floor(share))
+		assigned += split[i]
+		remainders[i] = i
+	}
+
+	sort.SliceStable(remainders, func(a, b int) bool {
+		shareA := float64(users) * weights[remainders[a]] / totalWeight
+		shareB := float64(users) * weights[remainders[b]] / totalWeight
+		return shareA-math.Floor(shareA) > shareB-math.Floor(shareB)
+	})
+	for i := 0; assigned < users; i++ {
+		split[remainders[i%len(remainders)]]++
+		assigned++
+	}
+
+	return split
+}
+
+// SplitSpawnRate splits a spawn rate across shards in proportion to the users each shard was given
+func SplitSpawnRate(spawnRate float64, users []int) []float64 {
+	total := 0
+	for _, shardUsers := range users {
+		total += shardUsers
+	}
+
+	rates := make([]float64, len(users))
+	for i, shardUsers := range users {
+		rates[i] = splitRate(spawnRate, shardUsers, total, len(users))
+	}
+	return rates
+}
+
+// splitRate returns a shard's part of a spawn rate, evenly split if the run has no users
+func splitRate(spawnRate float64, shardUsers, totalUsers, shards int) float64 {
+	if totalUsers == 0 {
+		return spawnRate / float64(shards)
+	}
+	return spawnRate * float64(shardUsers) / float64(totalUsers)
+}
+
+// IsSharded reports whether the run is distributed across several Locust clusters
+func (r *LoadTestRun) IsSharded() bool {
+	return len(r.Shards) > 0
+}
+
+// Shard returns the run's shard on the given cluster, or nil if the run has none there
+func (r *LoadTestRun) Shard(clusterID string) *RunShard {
+	for i := range r.Shards {
+		if r.Shards[i].ClusterID == clusterID {
+			return &r.Shards[i]
+		}
+	}
+	return nil
+}
+
+// ClusterIDs returns every cluster the run was admitted to
+func (r *LoadTestRun) ClusterIDs() []string {
+	if !r.IsSharded() {
+		if r.ClusterID == "" {
+			return nil
+		}
+		return []string{r.ClusterID}
+	}
+
+	clusterIDs := make([]string, len(r.Shards))
+	for i, shard := range r.Shards {
+		clusterIDs[i] = shard.ClusterID
+	}
+	return clusterIDs
+}
+
+// MergedShardMetrics merges the latest snapshot of every shard into one snapshot of the whole run
+// Returns nil if no shard reported metrics yet
+func (r *LoadTestRun) MergedShardMetrics() *MetricSnapshot {
+	snapshots := make([]*MetricSnapshot, 0, len(r.Shards))
+	for _, shard := range r.Shards {
+		if shard.LastMetrics != nil {
+			snapshots = append(snapshots, shard.LastMetrics)
+		}
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+	return MergeMetricSnapshots(snapshots)
+}
+
+// MergeMetricSnapshots combines snapshots taken on different Locust clusters into one snapshot
+// Counts, rates and users add up and response times are weighted by each snapshot's requests.
+// Percentiles are computed exactly from the merged response time histograms when every snapshot carries
+// one; summaries alone cannot be merged exactly, so they are request-weighted approximations otherwise
+func MergeMetricSnapshots(snapshots []*MetricSnapshot) *MetricSnapshot {
+	merged := &MetricSnapshot{RequestStats: make(map[string]*ReqStat)}
+	histogram := make(Histogram)
+	histogramComplete := true
+
+	var weightSum, avgSum, p50Sum, p90Sum, p95Sum, p99Sum, p999Sum float64
+	for _, snapshot := range snapshots {
+		if snapshot == nil {
+			continue
+		}
+
+		if snapshot.Timestamp > merged.Timestamp {
+			merged.Timestamp = snapshot.Timestamp
+		}
+		merged.TotalRPS += snapshot.TotalRPS
+		merged.TotalRequests += snapshot.TotalRequests
+		merged.TotalFailures += snapshot.TotalFailures
+		merged.CurrentUsers += snapshot.CurrentUsers
+		merged.WorkerCount += snapshot.WorkerCount
+		merged.RunnerState = mergeRunnerState(merged.RunnerState, snapshot.RunnerState)
+
+		if snapshot.TotalRequests > 0 {
+			weight := float64(snapshot.TotalRequests)
+			weightSum += weight
+			avgSum += snapshot.AverageResponseMs * weight
+			p50Sum += snapshot.P50ResponseMs * weight
+			p90Sum += snapshot.P90ResponseMs * weight
+			p95Sum += snapshot.P95ResponseMs * weight
+			p99Sum += snapshot.P99ResponseMs * weight
+			p999Sum += snapshot.P999ResponseMs * weight
+
+			if snapshot.ResponseTimes == nil {
+				histogramComplete = false
+			}
+			histogram.Merge(snapshot.ResponseTimes)
+
+			if merged.MinResponseMs == 0 || snapshot.MinResponseMs < merged.MinResponseMs {
+				merged.MinResponseMs = snapshot.MinResponseMs
+			}
+			if snapshot.MaxResponseMs > merged.MaxResponseMs {
+				merged.MaxResponseMs = snapshot.MaxResponseMs
+			}
+		}
+
+		for key, stat := range snapshot.RequestStats {
+			if stat != nil {
+				merged.RequestStats[key] = mergeReqStat(merged.RequestStats[key], stat)
+			}
+		}
+	}
+
+	if weightSum > 0 {
+		merged.AverageResponseMs = avgSum / weightSum
+		merged.P50ResponseMs = p50Sum / weightSum
+		merged.P90ResponseMs = p90Sum / weightSum
+		merged.P95ResponseMs = p95Sum / weightSum
+		merged.P99ResponseMs = p99Sum / weightSum
+		merged.P999ResponseMs = p999Sum / weightSum
+	}
+	if histogramComplete && histogram.Count() > 0 {
+		merged.ResponseTimes = histogram
+		merged.P50ResponseMs = histogram.Percentile(0.50)
+		merged.P90ResponseMs = histogram.Percentile(0.90)
+		merged.P95ResponseMs = histogram.Percentile(0.95)
+		merged.P99ResponseMs = histogram.Percentile(0.99)
+		merged.P999ResponseMs = histogram.Percentile(0.999)
+	}
+	merged.AvgResponseMs = merged.AverageResponseMs
+	if merged.TotalRequests > 0 {
+		merged.ErrorRate = float64(merged.TotalFailures) / float64(merged.TotalRequests) * 100
+	}
+
+	return merged
+}
+
+// mergeReqStat adds an endpoint's stats from one cluster to the stats merged so far
+func mergeReqStat(merged, stat *ReqStat) *ReqStat {
+	if merged != nil && stat.NumRequests == 0 {
+		return merged
+	}
+	if merged == nil || merged.NumRequests == 0 {
+		result := *stat
+		result.ResponseTimes = stat.ResponseTimes.Clone()
+		result.IntervalRequests, result.IntervalFailures = 0, 0 // Shard intervals don't line up, see PendingInterval
+		result.IntervalResponseTimes = nil
+		return &result
+	}
+
+	total := merged.NumRequests + stat.NumRequests
+	weighted := func(a, b float64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return (a*float64(merged.NumRequests) + b*float64(stat.NumRequests)) / float64(total)
+	}
+
+	result := &ReqStat{
+		Method:             merged.Method,
+		Name:               merged.Name,
+		NumRequests:        total,
+		NumFailures:        merged.NumFailures + stat.NumFailures,
+		AvgResponseTime:    weighted(merged.AvgResponseTime, stat.AvgResponseTime),
+		AvgResponseTimeMs:  weighted(merged.AvgResponseTimeMs, stat.AvgResponseTimeMs),
+		MinResponseTime:    math.Min(merged.MinResponseTime, stat.MinResponseTime),
+		MinResponseTimeMs:  math.Min(merged.MinResponseTimeMs, stat.MinResponseTimeMs),
+		MaxResponseTime:    math.Max(merged.MaxResponseTime, stat.MaxResponseTime),
+		MaxResponseTimeMs:  math.Max(merged.MaxResponseTimeMs, stat.MaxResponseTimeMs),
+		MedianResponseTime: weighted(merged.MedianResponseTime, stat.MedianResponseTime),
+		P50ResponseMs:      weighted(merged.P50ResponseMs, stat.P50ResponseMs),
+		P90ResponseMs:      weighted(merged.P90ResponseMs, stat.P90ResponseMs),
+		P95ResponseMs:      weighted(merged.P95ResponseMs, stat.P95ResponseMs),
+		P99ResponseMs:      weighted(merged.P99ResponseMs, stat.P99ResponseMs),
+		P999ResponseMs:     weighted(merged.P999ResponseMs, stat.P999ResponseMs),
+		RequestsPerSec:     merged.RequestsPerSec + stat.RequestsPerSec,
+	}
+
+	if merged.ResponseTimes != nil && stat.ResponseTimes != nil {
+		result.ResponseTimes = merged.ResponseTimes.Clone()
+		result.ResponseTimes.Merge(stat.ResponseTimes)
+		result.P50ResponseMs = result.ResponseTimes.Percentile(0.50)
+		result.P90ResponseMs = result.ResponseTimes.Percentile(0.90)
+		result.P95ResponseMs = result.ResponseTimes.Percentile(0.95)
+		result.P99ResponseMs = result.ResponseTimes.Percentile(0.99)
+		result.P999ResponseMs = result.ResponseTimes.Percentile(0.999)
+	}
+	return result
+}
+
+// mergeRunnerState reports the most active of two Locust runner states
+func mergeRunnerState(a, b string) string {
+	for _, state := range []string{"spawning", "running"} {
+		if a == state || b == state {
+			return state
+		}
+	}
+	if a != "" {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanShards(t *testing.T) {
+	tests := []struct {
+		name       string
+		specs      []ShardSpec
+		users      int
+		wantUsers  []int
+		wantRates  []float64
+		wantErrors bool
+	}{
+		{
+			name:      "equal weights",
+			specs:     []ShardSpec{{ClusterID: "a", Weight: 1}, {ClusterID: "b", Weight: 1}},
+			users:     10,
+			wantUsers: []int{5, 5},
+			wantRates: []float64{1, 1},
+		},
+		{
+			name:      "uneven weights",
+			specs:     []ShardSpec{{ClusterID: "a", Weight: 3}, {ClusterID: "b", Weight: 1}},
+			users:     10,
+			wantUsers: []int{8, 2},
+			wantRates: []float64{1.6, 0.4},
+		},
+		{
+			name:      "explicit users",
+			specs:     []ShardSpec{{ClusterID: "a", Users: 6}, {ClusterID: "b", Users: 14}},
+			users:     10,
+			wantUsers: []int{6, 14},
+			wantRates: []float64{0.6, 1.4},
+		},
+		{name: "single shard", specs: []ShardSpec{{ClusterID: "a", Weight: 1}}, wantErrors: true},
+		{name: "same cluster twice", specs: []ShardSpec{{ClusterID: "a", Weight: 1}, {ClusterID: "a", Weight: 1}}, wantErrors: true},
+		{name: "missing cluster", specs: []ShardSpec{{ClusterID: "a", Weight: 1}, {Weight: 1}}, wantErrors: true},
+		{name: "users and weights mixed", specs: []ShardSpec{{ClusterID: "a", Users: 5}, {ClusterID: "b", Weight: 1}}, wantErrors: true},
+		{name: "zero weight", specs: []ShardSpec{{ClusterID: "a", Weight: 1}, {ClusterID: "b"}}, wantErrors: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shards, err := PlanShards(tt.specs, tt.users, 2)
+			if tt.wantErrors {
+				if err == nil {
+					t.Fatalf("PlanShards = %+v, want an error", shards)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanShards: %v", err)
+			}
+
+			users := make([]int, len(shards))
+			rates := make([]float64, len(shards))
+			for i, shard := range shards {
+				users[i], rates[i] = shard.Users, shard.SpawnRate
+				if shard.ClusterID != tt.specs[i].ClusterID || shard.Status != LoadTestRunStatusPending {
+					t.Errorf("shard %d = %+v, want a Pending shard on %s", i, shard, tt.specs[i].ClusterID)
+				}
+			}
+			if !reflect.DeepEqual(users, tt.wantUsers) {
+				t.Errorf("users = %v, want %v", users, tt.wantUsers)
+			}
+			if !reflect.DeepEqual(rates, tt.wantRates) {
+				t.Errorf("spawn rates = %v, want %v", rates, tt.wantRates)
+			}
+		})
+	}
+}
+
+func TestSplitUsersAddsUp(t *testing.T) {
+	tests := []struct {
+		users   int
+		weights []float64
+		want    []int
+	}{
+		{users: 10, weights: []float64{1, 1, 1}, want: []int{4, 3, 3}},
+		{users: 7, weights: []float64{2, 1}, want: []int{5, 2}},
+		{users: 1, weights: []float64{1, 1}, want: []int{1, 0}},
+		{users: 4, weights: []float64{0, 0}, want: []int{2, 2}},
+	}
+
+	for _, tt := range tests {
+		if got := SplitUsers(tt.users, tt.weights); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitUsers(%d, %v) = %v, want %v", tt.users, tt.weights, got, tt.want)
+		}
+	}
+}
+
+func TestMergeMetricSnapshots(t *testing.T) {
+	first := &MetricSnapshot{
+		Timestamp: 1_000, TotalRPS: 10, TotalRequests: 100, TotalFailures: 10, CurrentUsers: 5,
+		AverageResponseMs: 100, MinResponseMs: 20, MaxResponseMs: 300, P95ResponseMs: 200,
+		RequestStats: map[string]*ReqStat{"GET /": {Method: "GET", Name: "/", NumRequests: 100, NumFailures: 10, AvgResponseTime: 100, RequestsPerSec: 10}},
+	}
+	second := &MetricSnapshot{
+		Timestamp: 2_000, TotalRPS: 30, TotalRequests: 300, TotalFailures: 0, CurrentUsers: 15,
+		AverageResponseMs: 200, MinResponseMs: 10, MaxResponseMs: 900, P95ResponseMs: 400,
+		RequestStats: map[string]*ReqStat{"GET /": {Method: "GET", Name: "/", NumRequests: 300, AvgResponseTime: 200, RequestsPerSec: 30}},
+	}
+
+	merged := MergeMetricSnapshots([]*MetricSnapshot{first, second, nil})
+
+	if merged.Timestamp != 2_000 || merged.TotalRequests != 400 || merged.TotalFailures != 10 || merged.CurrentUsers != 20 || merged.TotalRPS != 40 {
+		t.Errorf("merged counts = %+v, want the shards' sums at the latest timestamp", merged)
+	}
+	if merged.ErrorRate != 2.5 {
+		t.Errorf("error rate = %g, want 2.5", merged.ErrorRate)
+	}
+	if merged.AverageResponseMs != 175 || merged.P95ResponseMs != 350 {
+		t.Errorf("avg = %g, p95 = %g, want the request-weighted 175 and 350", merged.AverageResponseMs, merged.P95ResponseMs)
+	}
+	if merged.MinResponseMs != 10 || merged.MaxResponseMs != 900 {
+		t.Errorf("min = %g, max = %g, want 10 and 900", merged.MinResponseMs, merged.MaxResponseMs)
+	}
+
+	stat := merged.RequestStats["GET /"]
+	if stat == nil || stat.NumRequests != 400 || stat.NumFailures != 10 || stat.AvgResponseTime != 175 || stat.RequestsPerSec != 40 {
+		t.Errorf("merged endpoint = %+v, want 400 requests, 10 failures, 175ms avg at 40 rps", stat)
+	}
+}
+
+func TestMergeMetricSnapshotsUsesHistograms(t *testing.T) {
+	// Shard summaries would average p95 to 30ms; the merged histogram shows the slowest 5% took 90ms
+	fast := &MetricSnapshot{TotalRequests: 15, P95ResponseMs: 10, ResponseTimes: Histogram{10: 15}}
+	slow := &MetricSnapshot{TotalRequests: 5, P95ResponseMs: 90, ResponseTimes: Histogram{90: 5}}
+
+	merged := MergeMetricSnapshots([]*MetricSnapshot{fast, slow})
+	if merged.P95ResponseMs != 90 || merged.P50ResponseMs != 10 {
+		t.Errorf("p50 = %g, p95 = %g, want 10 and 90 from the merged histogram", merged.P50ResponseMs, merged.P95ResponseMs)
+	}
+	if merged.ResponseTimes.Count() != 20 {
+		t.Errorf("merged histogram holds %d requests, want 20", merged.ResponseTimes.Count())
+	}
+
+	// Without a histogram from every shard, percentiles fall back to the weighted summaries
+	slow.ResponseTimes = nil
+	merged = MergeMetricSnapshots([]*MetricSnapshot{fast, slow})
+	if merged.P95ResponseMs != 30 || merged.ResponseTimes != nil {
+		t.Errorf("p95 = %g with histogram %v, want the weighted 30 and no histogram", merged.P95ResponseMs, merged.ResponseTimes)
+	}
+}
@@ -0,0 +1,128 @@
+package domain
+
+import "fmt"
+
+// ThresholdMetric identifies the metric a threshold is evaluated against
+type ThresholdMetric string
+
+const (
+	ThresholdMetricP50ResponseMs ThresholdMetric = "p50ResponseMs"
+	ThresholdMetricP95ResponseMs ThresholdMetric = "p95ResponseMs"
+	ThresholdMetricP99ResponseMs ThresholdMetric = "p99ResponseMs"
+	ThresholdMetricAvgResponseMs ThresholdMetric = "avgResponseMs"
+	ThresholdMetricErrorRate     ThresholdMetric = "errorRate" // Percentage
+	ThresholdMetricRPS           ThresholdMetric = "rps"
+)
+
+// ThresholdOperator compares the measured value against the threshold value
+type ThresholdOperator string
+
+const (
+	ThresholdOperatorLessThan       ThresholdOperator = "<"
+	ThresholdOperatorLessOrEqual    ThresholdOperator = "<="
+	ThresholdOperatorGreaterThan    ThresholdOperator = ">"
+	ThresholdOperatorGreaterOrEqual ThresholdOperator = ">="
+)
+
+// ThresholdAggregation reduces the run's timeseries to the single value that is compared
+// Percentile metrics are computed from response time histograms where the run has them: avg and last then give
+// the whole run's percentile, min and max the lowest or highest percentile of a single snapshot's responses.
+// Error rates and RPS are computed over the snapshots' intervals the same way: avg gives the whole run's rate
+type ThresholdAggregation string
+
+const (
+	ThresholdAggregationAvg  ThresholdAggregation = "avg" // Default
+	ThresholdAggregationMin  ThresholdAggregation = "min"
+	ThresholdAggregationMax  ThresholdAggregation = "max"
+	ThresholdAggregationLast ThresholdAggregation = "last"
+)
+
+// Threshold is a service level objective a run must meet to pass, e.g. p95ResponseMs < 300
+type Threshold struct {
+	Name        string               `json:"name,omitempty"`
+	Metric      ThresholdMetric      `json:"metric"`
+	Operator    ThresholdOperator    `json:"operator"`
+	Value       float64              `json:"value"`
+	Aggregation ThresholdAggregation `json:"aggregation,omitempty"` // How snapshots are combined (default: avg)
+	Endpoint    string               `json:"endpoint,omitempty"`    // Optional request name (or "METHOD name") to scope the threshold to
+}
+
+// ThresholdResult is the outcome of evaluating a single threshold
+type ThresholdResult struct {
+	Threshold Threshold `json:"threshold"`
+	Actual    float64   `json:"actual"`
+	Samples   int       `json:"samples"` // Number of snapshots the actual value was computed from
+	Passed    bool      `json:"passed"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// Verdict records whether a run met all of its thresholds
+type Verdict struct {
+	Passed      bool              `json:"passed"`
+	EvaluatedAt int64             `json:"evaluatedAt"` // Unix milliseconds
+	Results     []ThresholdResult `json:"results"`
+}
+
+// Validate checks that the threshold can be evaluated
+func (t *Threshold) Validate() error {
+	switch t.Metric {
+	case ThresholdMetricP50ResponseMs, ThresholdMetricP95ResponseMs, ThresholdMetricP99ResponseMs,
+		ThresholdMetricAvgResponseMs, ThresholdMetricErrorRate, ThresholdMetricRPS:
+	default:
+		return fmt.Errorf("unknown threshold metric %q", t.Metric)
+	}
+
+	switch t.Operator {
+	case ThresholdOperatorLessThan, ThresholdOperatorLessOrEqual,
+		ThresholdOperatorGreaterThan, ThresholdOperatorGreaterOrEqual:
+	default:
+		return fmt.Errorf("unknown threshold operator %q", t.Operator)
+	}
+
+	switch t.Aggregation {
+	case "", ThresholdAggregationAvg, ThresholdAggregationMin, ThresholdAggregationMax, ThresholdAggregationLast:
+	default:
+		return fmt.Errorf("unknown threshold aggregation %q", t.Aggregation)
+	}
+
+	return nil
+}
+
+// Compare reports whether a measured value satisfies the threshold
+func (t *Threshold) Compare(actual float64) bool {
+	switch t.Operator {
+	case ThresholdOperatorLessThan:
+		return actual < t.Value
+	case ThresholdOperatorLessOrEqual:
+		return actual <= t.Value
+	case ThresholdOperatorGreaterThan:
+		return actual > t.Value
+	case ThresholdOperatorGreaterOrEqual:
+		return actual >= t.Value
+	}
+	return false
+}
+
+// String returns a readable form of the threshold, e.g. "avg(p95ResponseMs) < 300 [GET /api]"
+func (t *Threshold) String() string {
+	aggregation := t.Aggregation
+	if aggregation == "" {
+		aggregation = ThresholdAggregationAvg
+	}
+
+	s := fmt.Sprintf("%s(%s) %s %g", aggregation, t.Metric, t.Operator, t.Value)
+	if t.Endpoint != "" {
+		s += fmt.Sprintf(" [%s]", t.Endpoint)
+	}
+	return s
+}
+
+// ValidateThresholds validates every threshold in a list
+func ValidateThresholds(thresholds []Threshold) error {
+	for i := range thresholds {
+		if err := thresholds[i].Validate(); err != nil {
+			return fmt.Errorf("threshold %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/loadgen"
+	"Load-manager-cli/internal/locustclient"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedEngine is returned for an engine type the control plane has no executor for
+var ErrUnsupportedEngine = errors.New("unsupported load engine")
+
+// Executor drives a load generation engine on behalf of the control plane
+// A run is prepared with its script, attached to the engine's reporting, started, scaled while it runs and stopped
+type Executor interface {
+	// Prepare loads a script revision on the engine and reports what the engine loaded
+	Prepare(ctx context.Context, script Script) (*Prepared, error)
+	// Attach tells the engine which run it executes, so that its reports are attributed to the run
+	Attach(ctx context.Context, run RunContext) error
+	// Start begins generating load; a non-empty host overrides the host configured in the script
+	Start(ctx context.Context, users int, spawnRate float64, host string) error
+	// Scale changes the load of the running test, keeping its host
+	Scale(ctx context.Context, users int, spawnRate float64) error
+	// Stop stops generating load
+	Stop(ctx context.Context) error
+	// Stats returns the engine's current statistics
+	Stats(ctx context.Context) (*domain.MetricSnapshot, error)
+	// State returns the run and script the engine currently holds
+	State(ctx context.Context) (*State, error)
+}
+
+// Script is a script revision to load on an engine
+type Script struct {
+	RevisionID string
+	Content    string // Base64 encoded script
+}
+
+// Prepared describes the script an engine reports as loaded
+type Prepared struct {
+	RevisionID     string
+	SHA256         string            // Hex-encoded SHA-256 of the decoded script
+	Workers        int               // Number of load generators the script was distributed to
+	WorkerAcks     map[string]string // Worker -> SHA-256 of the script it reports loaded, empty if it failed to load it
+	MissingWorkers []string          // Workers the script was distributed to that never acknowledged it
+}
+
+// RunContext identifies the run an engine executes
+type RunContext struct {
+	RunID           string
+	ShardID         string // Set when the engine executes one shard of a distributed run
+	TenantID        string
+	EnvID           string
+	DurationSeconds *int // Remaining duration after which the engine stops by itself; nil for no limit
+}
+
+// State is the run and script an engine currently holds
+type State struct {
+	RunID            string
+	ShardID          string
+	ScriptRevisionID string
+	ScriptSHA256     string
+	AgentVersion     string // Version of the control plane's agent in the engine, empty if it is not installed
+}
+
+// FailureSource is implemented by executors whose engine details the failures of its test
+type FailureSource interface {
+	// Failures returns the failure groups and exceptions the engine recorded since the test started
+	Failures(ctx context.Context) ([]domain.FailureGroup, []domain.ExceptionGroup, error)
+}
+
+// CircuitReporter is implemented by executors whose calls to their cluster go through a circuit breaker
+type CircuitReporter interface {
+	// Circuit returns the state of the breaker, nil if the cluster is not guarded by one
+	Circuit() *domain.CircuitStatus
+}
+
+// New creates the executor driving a cluster with the engine it runs
+// Locust masters are called with clientOpts; native generators report their metrics and stops to
+// reporter instead of calling back over HTTP
+func New(cluster *domain.LocustCluster, reporter loadgen.Reporter, clientOpts locustclient.Options) (Executor, error) {
+	switch cluster.Engine.OrDefault() {
+	case domain.EngineLocust:
+		return NewLocustExecutor(locustclient.NewHTTPClientWithOptions(cluster.BaseURL, cluster.AuthToken, clientOpts)), nil
+	case domain.EngineNative:
+		return NewNativeExecutor(loadgen.New(reporter, loadgen.Options{})), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEngine, cluster.Engine)
+	}
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locustclient"
+	"errors"
+	"testing"
+)
+
+func TestNewPicksClusterEngine(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine domain.EngineType
+		check  func(executor Executor) bool
+	}{
+		{name: "default", check: func(executor Executor) bool { _, ok := executor.(*LocustExecutor); return ok }},
+		{name: "locust", engine: domain.EngineLocust, check: func(executor Executor) bool { _, ok := executor.(*LocustExecutor); return ok }},
+		{name: "native", engine: domain.EngineNative, check: func(executor Executor) bool { _, ok := executor.(*NativeExecutor); return ok }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &domain.LocustCluster{ID: "c1", BaseURL: "http://locust.example", Engine: tt.engine}
+			executor, err := New(cluster, nil, locustclient.Options{})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if !tt.check(executor) {
+				t.Errorf("executor = %T, want the %s engine's", executor, tt.engine.OrDefault())
+			}
+		})
+	}
+}
+
+func TestNewRejectsUnknownEngine(t *testing.T) {
+	cluster := &domain.LocustCluster{ID: "c1", BaseURL: "http://k6.example", Engine: "k6"}
+	if executor, err := New(cluster, nil, locustclient.Options{}); !errors.Is(err, ErrUnsupportedEngine) {
+		t.Errorf("New = %T, %v, want %v", executor, err, ErrUnsupportedEngine)
+	}
+}
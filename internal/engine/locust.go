@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locustclient"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Workers acknowledge a script asynchronously; Prepare polls the plugin for their acks until the timeout
+// Variables so tests can shorten them
+var (
+	workerAckTimeout      = 15 * time.Second
+	workerAckPollInterval = 250 * time.Millisecond
+)
+
+// LocustExecutor drives a Locust master through its web API and the harness plugin's control plane endpoints
+type LocustExecutor struct {
+	client locustclient.Client
+}
+
+// NewLocustExecutor creates an executor for the Locust master behind client
+func NewLocustExecutor(client locustclient.Client) *LocustExecutor {
+	return &LocustExecutor{client: client}
+}
+
+// Prepare pushes the script to the master, which loads it and forwards it to its workers, and waits
+// for every worker to acknowledge the script it loaded
+func (e *LocustExecutor) Prepare(ctx context.Context, script Script) (*Prepared, error) {
+	prepared, err := e.load(ctx, script)
+	if err != nil || prepared.Workers == 0 {
+		return prepared, err
+	}
+	if err := e.awaitWorkerAcks(ctx, prepared); err != nil {
+		return nil, err
+	}
+	return prepared, nil
+}
+
+// load pushes the script to the master
+func (e *LocustExecutor) load(ctx context.Context, script Script) (*Prepared, error) {
+	result, err := e.client.LoadScript(ctx, script.RevisionID, script.Content)
+	if err != nil {
+		return nil, err
+	}
+	return &Prepared{RevisionID: result.RevisionID, SHA256: result.SHA256, Workers: result.Workers}, nil
+}
+
+// awaitWorkerAcks polls the plugin until every worker the script was forwarded to acknowledged it, or until
+// workerAckTimeout elapses; the acks received and the workers still missing are recorded on prepared
+func (e *LocustExecutor) awaitWorkerAcks(ctx context.Context, prepared *Prepared) error {
+	waitCtx, cancel := context.WithTimeout(ctx, workerAckTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(workerAckPollInterval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, err := e.client.GetRunContext(waitCtx)
+		if err != nil && (ctx.Err() != nil || waitCtx.Err() == nil) {
+			return err
+		}
+		if err == nil {
+			if runCtx.ScriptRevisionID != prepared.RevisionID {
+				return fmt.Errorf("script revision %s was replaced by %s before its workers acknowledged it",
+					prepared.RevisionID, runCtx.ScriptRevisionID)
+			}
+			if recordWorkerAcks(prepared, runCtx) {
+				return nil
+			}
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordWorkerAcks records the acks the plugin holds for the workers the script was forwarded to, and
+// reports whether none is missing; plugins that don't name those workers are checked by count
+func recordWorkerAcks(prepared *Prepared, runCtx *locustclient.RunContext) bool {
+	prepared.WorkerAcks = make(map[string]string, prepared.Workers)
+	prepared.MissingWorkers = nil
+
+	if runCtx.ScriptWorkers == nil {
+		for worker, sha := range runCtx.WorkerAcks {
+			prepared.WorkerAcks[worker] = sha
+		}
+		for i := len(prepared.WorkerAcks); i < prepared.Workers; i++ {
+			prepared.MissingWorkers = append(prepared.MissingWorkers, fmt.Sprintf("unnamed worker %d", i+1))
+		}
+		return len(prepared.MissingWorkers) == 0
+	}
+
+	for _, worker := range runCtx.ScriptWorkers {
+		sha, ok := runCtx.WorkerAcks[worker]
+		if !ok {
+			prepared.MissingWorkers = append(prepared.MissingWorkers, worker)
+			continue
+		}
+		prepared.WorkerAcks[worker] = sha
+	}
+	return len(prepared.MissingWorkers) == 0
+}
+
+// Attach sets the run context the harness plugin reports callbacks and metrics under
+func (e *LocustExecutor) Attach(ctx context.Context, run RunContext) error {
+	return e.client.SetRunContext(ctx, run.RunID, run.ShardID, run.TenantID, run.EnvID, run.DurationSeconds)
+}
+
+// Start swarms the master's users
+func (e *LocustExecutor) Start(ctx context.Context, users int, spawnRate float64, host string) error {
+	return e.client.Swarm(ctx, users, spawnRate, host)
+}
+
+// Scale swarms again with the new load; Locust keeps the host when none is sent
+func (e *LocustExecutor) Scale(ctx context.Context, users int, spawnRate float64) error {
+	return e.client.Swarm(ctx, users, spawnRate, "")
+}
+
+// Stop stops the master's users
+func (e *LocustExecutor) Stop(ctx context.Context) error {
+	return e.client.Stop(ctx)
+}
+
+// Stats returns the master's aggregated request statistics
+func (e *LocustExecutor) Stats(ctx context.Context) (*domain.MetricSnapshot, error) {
+	return e.client.GetStats(ctx)
+}
+
+// Failures returns the master's failures table and the exceptions raised in its users' code
+func (e *LocustExecutor) Failures(ctx context.Context) ([]domain.FailureGroup, []domain.ExceptionGroup, error) {
+	failures, err := e.client.GetFailures(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	exceptions, err := e.client.GetExceptions(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return failures, exceptions, nil
+}
+
+// State returns the run context and script held by the harness plugin
+func (e *LocustExecutor) State(ctx context.Context) (*State, error) {
+	runCtx, err := e.client.GetRunContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &State{
+		RunID:            runCtx.RunID,
+		ShardID:          runCtx.ShardID,
+		ScriptRevisionID: runCtx.ScriptRevisionID,
+		ScriptSHA256:     runCtx.ScriptSHA256,
+		AgentVersion:     runCtx.PluginVersion,
+	}, nil
+}
+
+// Circuit returns the state of the circuit breaker guarding the master, nil if its client has none
+func (e *LocustExecutor) Circuit() *domain.CircuitStatus {
+	if guarded, ok := e.client.(CircuitReporter); ok {
+		return guarded.Circuit()
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"Load-manager-cli/internal/locustclient"
+	"Load-manager-cli/internal/locusttest"
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+// newTestExecutor returns an executor driving a fake master with the given workers
+func newTestExecutor(t *testing.T, workers int) (*LocustExecutor, *locusttest.Master) {
+	t.Helper()
+	master := locusttest.NewMaster(locusttest.Options{Workers: workers})
+	t.Cleanup(master.Close)
+	client := locustclient.NewHTTPClientWithOptions(master.URL(), "", locustclient.Options{Timeout: 5 * time.Second, MaxAttempts: 1})
+	return NewLocustExecutor(client), master
+}
+
+// shortenWorkerAckTimeout makes Prepare give up on missing acks quickly for the rest of the test
+func shortenWorkerAckTimeout(t *testing.T) {
+	t.Helper()
+	timeout, interval := workerAckTimeout, workerAckPollInterval
+	workerAckTimeout, workerAckPollInterval = 300*time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { workerAckTimeout, workerAckPollInterval = timeout, interval })
+}
+
+var testScript = Script{RevisionID: "rev-1", Content: base64.StdEncoding.EncodeToString([]byte("print('hello')\n"))}
+
+func TestPrepareRecordsEveryWorkerAck(t *testing.T) {
+	executor, master := newTestExecutor(t, 2)
+
+	prepared, err := executor.Prepare(context.Background(), testScript)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+
+	_, digest := master.Script()
+	if prepared.SHA256 != digest || prepared.Workers != 2 {
+		t.Errorf("prepared = %+v, want hash %s on 2 workers", prepared, digest)
+	}
+	if len(prepared.MissingWorkers) != 0 {
+		t.Errorf("missing workers = %v, want none", prepared.MissingWorkers)
+	}
+	for i := 1; i <= 2; i++ {
+		if ack := prepared.WorkerAcks[locusttest.WorkerID(i)]; ack != digest {
+			t.Errorf("ack of %s = %q, want %s", locusttest.WorkerID(i), ack, digest)
+		}
+	}
+}
+
+func TestPrepareReportsWorkerWithoutAck(t *testing.T) {
+	shortenWorkerAckTimeout(t)
+	executor, master := newTestExecutor(t, 2)
+	master.SetWorkerAck(locusttest.WorkerID(2), locusttest.WorkerAck{Missing: true})
+
+	prepared, err := executor.Prepare(context.Background(), testScript)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+
+	if len(prepared.MissingWorkers) != 1 || prepared.MissingWorkers[0] != locusttest.WorkerID(2) {
+		t.Errorf("missing workers = %v, want [%s]", prepared.MissingWorkers, locusttest.WorkerID(2))
+	}
+	if _, ok := prepared.WorkerAcks[locusttest.WorkerID(1)]; !ok {
+		t.Errorf("acks = %v, want the ack of %s", prepared.WorkerAcks, locusttest.WorkerID(1))
+	}
+}
+
+func TestPrepareReportsWorkerThatFailedToLoad(t *testing.T) {
+	executor, master := newTestExecutor(t, 2)
+	master.SetWorkerAck(locusttest.WorkerID(1), locusttest.WorkerAck{Failed: true})
+
+	prepared, err := executor.Prepare(context.Background(), testScript)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+
+	if ack, ok := prepared.WorkerAcks[locusttest.WorkerID(1)]; !ok || ack != "" {
+		t.Errorf("ack of %s = %q (present %v), want an empty hash", locusttest.WorkerID(1), ack, ok)
+	}
+}
+
+func TestLocustExecutorDrivesMaster(t *testing.T) {
+	executor, master := newTestExecutor(t, 1)
+	ctx := context.Background()
+
+	duration := 60
+	if err := executor.Attach(ctx, RunContext{RunID: "run-1", ShardID: "c1", TenantID: "acc", DurationSeconds: &duration}); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if runCtx := master.RunContext(); runCtx.RunID != "run-1" || runCtx.ShardID != "c1" || runCtx.DurationSeconds != "60" {
+		t.Errorf("run context = %+v, want run-1 as shard c1 for 60s", runCtx)
+	}
+
+	if err := executor.Start(ctx, 10, 2, "http://target.example"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := executor.Scale(ctx, 20, 4); err != nil {
+		t.Fatalf("Scale: %v", err)
+	}
+	if users, spawnRate := master.Users(); users != 20 || spawnRate != 4 {
+		t.Errorf("swarm users = %d at %g/s, want 20 at 4/s", users, spawnRate)
+	}
+	if host := master.Host(); host != "http://target.example" {
+		t.Errorf("host after scaling = %q, want the start's http://target.example", host)
+	}
+
+	stats, err := executor.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.RunnerState != locusttest.StateRunning {
+		t.Errorf("runner state = %s, want %s", stats.RunnerState, locusttest.StateRunning)
+	}
+
+	state, err := executor.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+	if state.RunID != "run-1" || state.ShardID != "c1" || state.AgentVersion != locusttest.PluginVersion {
+		t.Errorf("state = %+v, want run-1 as shard c1 with the plugin's version", state)
+	}
+
+	if err := executor.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if state := master.State(); state != locusttest.StateStopped {
+		t.Errorf("master state = %s, want %s", state, locusttest.StateStopped)
+	}
+}
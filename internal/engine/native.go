@@ -0,0 +1,22 @@
+package engine
+
+import (
+	"Load-manager-cli/internal/loadgen"
+	"context"
+)
+
+// NativeExecutor runs YAML scenarios on the control plane's in-process Go generator
+// The generator speaks the same client interface as a Locust master, so the Locust executor drives it
+type NativeExecutor struct {
+	*LocustExecutor
+}
+
+// NewNativeExecutor creates an executor for a native generator
+func NewNativeExecutor(generator *loadgen.Generator) *NativeExecutor {
+	return &NativeExecutor{LocustExecutor: NewLocustExecutor(generator)}
+}
+
+// Prepare loads the scenario on the generator; it runs in-process, so there are no workers to acknowledge it
+func (e *NativeExecutor) Prepare(ctx context.Context, script Script) (*Prepared, error) {
+	return e.load(ctx, script)
+}
@@ -0,0 +1,545 @@
+package loadgen
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locustclient"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the generator's agent version, reported where Locust clusters report their harness plugin version
+const Version = "native-1.0.0"
+
+// Runner states, named like Locust's so the control plane treats both engines alike
+const (
+	StateReady    = "ready"
+	StateSpawning = "spawning"
+	StateRunning  = "running"
+	StateStopped  = "stopped"
+)
+
+// maxBodyBytes caps how much of a response body is kept for assertions
+const maxBodyBytes = 1 << 20
+
+// Reporter receives the metrics and stop events of the runs a generator executes
+// The orchestrator implements it, so metrics take the same path as the harness plugin's callbacks
+type Reporter interface {
+	UpdateMetrics(runID, shardID string, metrics *domain.MetricSnapshot) error
+	HandleTestStop(runID, shardID string, finalMetrics *domain.MetricSnapshot, autoStopped bool) error
+	RecordRequestSamples(runID, shardID string, samples []domain.RequestSample, sampleRate float64) error
+}
+
+// defaultSampleRate is the fraction of requests sampled for request logs, as the harness plugin samples by default
+const defaultSampleRate = 0.01
+
+// Options configure a Generator
+type Options struct {
+	ReportInterval time.Duration // How often metrics are reported while a test runs (default: 10s, like the harness plugin)
+	HTTPClient     *http.Client  // Client virtual users send requests with (default: 30s timeout)
+	SampleRate     float64       // Fraction of requests sampled for the run's request log (default: 0.01; negative disables sampling)
+}
+
+// Generator is an in-process load generator running YAML scenarios with virtual users
+// It implements locustclient.Client, so the control plane drives it exactly like a Locust master
+type Generator struct {
+	reporter       Reporter
+	reportInterval time.Duration
+	httpClient     *http.Client
+	sampleRate     float64
+
+	mu              sync.Mutex
+	runID           string
+	shardID         string
+	tenantID        string
+	envID           string
+	durationSeconds *int
+	revisionID      string
+	scriptSHA256    string
+	scenario        *Scenario
+	host            string
+	test            *test // Current or last test, nil before the first swarm
+}
+
+var _ locustclient.Client = (*Generator)(nil)
+
+// test is one swarm of a generator, from its start until it is stopped
+type test struct {
+	runID       string
+	shardID     string
+	ctx         context.Context
+	cancel      context.CancelFunc
+	stats       *stats
+	users       []context.CancelFunc // One per virtual user, oldest first
+	spawnCancel context.CancelFunc   // Cancels the spawner still ramping users up, if any
+	spawning    bool
+	timer       *time.Timer // Stops the test when its duration elapses
+	vus         sync.WaitGroup
+}
+
+// New creates a generator reporting to reporter
+func New(reporter Reporter, opts Options) *Generator {
+	if opts.ReportInterval <= 0 {
+		opts.ReportInterval = 10 * time.Second
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.SampleRate == 0 {
+		opts.SampleRate = defaultSampleRate
+	}
+
+	return &Generator{
+		reporter:       reporter,
+		reportInterval: opts.ReportInterval,
+		httpClient:     opts.HTTPClient,
+		sampleRate:     opts.SampleRate,
+	}
+}
+
+// SetRunContext sets the run the generator reports metrics and stops under
+// A new duration applies to the running test, counted from now
+func (g *Generator) SetRunContext(ctx context.Context, runID, shardID, tenantID, envID string, durationSeconds *int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.runID = runID
+	g.shardID = shardID
+	g.tenantID = tenantID
+	g.envID = envID
+	g.durationSeconds = durationSeconds
+
+	if t := g.test; t != nil && t.ctx.Err() == nil {
+		t.runID = runID
+		t.shardID = shardID
+		g.armDuration(t)
+	}
+	return nil
+}
+
+// LoadScript parses a base64 encoded YAML scenario and makes it the scenario of the next test
+func (g *Generator) LoadScript(ctx context.Context, revisionID, scriptContent string) (*locustclient.ScriptLoadResult, error) {
+	data, err := base64.StdEncoding.DecodeString(scriptContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode scenario: %w", err)
+	}
+	scenario, err := ParseScenario(data)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if t := g.test; t != nil && t.ctx.Err() == nil {
+		return nil, fmt.Errorf("cannot load scenario %s while a test is running", revisionID)
+	}
+
+	g.revisionID = revisionID
+	g.scriptSHA256 = hex.EncodeToString(sum[:])
+	g.scenario = scenario
+
+	log.Printf("[Native Generator] Loaded scenario revision %s (%d requests)", revisionID, len(scenario.Requests))
+	return &locustclient.ScriptLoadResult{RevisionID: revisionID, SHA256: g.scriptSHA256, Workers: 1}, nil
+}
+
+// Swarm starts a test with the given users, or changes the users of the running test
+// If host is non-empty it overrides the scenario's host
+func (g *Generator) Swarm(ctx context.Context, users int, spawnRate float64, host string) error {
+	if users < 0 {
+		return fmt.Errorf("users must not be negative")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.scenario == nil {
+		return fmt.Errorf("no scenario loaded")
+	}
+	if host != "" {
+		g.host = host
+	}
+	if g.host == "" && g.scenario.Host == "" && g.scenario.needsHost() {
+		return fmt.Errorf("no host: the scenario has relative paths and neither it nor the run sets a host")
+	}
+
+	t := g.test
+	if t == nil || t.ctx.Err() != nil {
+		t = g.startTest()
+	}
+
+	log.Printf("[Native Generator] Swarming %d users at %.2f/s for run %s", users, spawnRate, t.runID)
+	g.scale(t, users, spawnRate)
+	return nil
+}
+
+// Stop stops the running test and reports its final metrics
+func (g *Generator) Stop(ctx context.Context) error {
+	g.stop(false)
+	return nil
+}
+
+// GetStats returns the statistics of the current or last test
+func (g *Generator) GetStats(ctx context.Context) (*domain.MetricSnapshot, error) {
+	g.mu.Lock()
+	t := g.test
+	state := g.state()
+	users := 0
+	if t != nil && t.ctx.Err() == nil {
+		users = len(t.users)
+	}
+	g.mu.Unlock()
+
+	snapshot := &domain.MetricSnapshot{Timestamp: time.Now().UnixMilli()}
+	if t != nil {
+		snapshot = t.stats.snapshot(time.Now())
+	}
+	snapshot.CurrentUsers = users
+	snapshot.RunnerState = state
+	snapshot.WorkerCount = 1
+	return snapshot, nil
+}
+
+// GetFailures returns the failures of the current or last test, grouped by request and error
+func (g *Generator) GetFailures(ctx context.Context) ([]domain.FailureGroup, error) {
+	g.mu.Lock()
+	t := g.test
+	g.mu.Unlock()
+
+	if t == nil {
+		return nil, nil
+	}
+	return t.stats.failureGroups(), nil
+}
+
+// GetExceptions returns no exceptions: scenarios run no user code that could raise any
+func (g *Generator) GetExceptions(ctx context.Context) ([]domain.ExceptionGroup, error) {
+	return nil, nil
+}
+
+// GetRunContext returns the run context and scenario the generator holds
+func (g *Generator) GetRunContext(ctx context.Context) (*locustclient.RunContext, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	runCtx := &locustclient.RunContext{
+		RunID:            g.runID,
+		ShardID:          g.shardID,
+		TenantID:         g.tenantID,
+		EnvID:            g.envID,
+		ScriptRevisionID: g.revisionID,
+		ScriptSHA256:     g.scriptSHA256,
+		PluginVersion:    Version,
+	}
+	if g.durationSeconds != nil {
+		runCtx.DurationSeconds = strconv.Itoa(*g.durationSeconds)
+	}
+	return runCtx, nil
+}
+
+// state reports the runner state; callers must hold g.mu
+func (g *Generator) state() string {
+	switch {
+	case g.test == nil:
+		return StateReady
+	case g.test.ctx.Err() != nil:
+		return StateStopped
+	case g.test.spawning:
+		return StateSpawning
+	default:
+		return StateRunning
+	}
+}
+
+// startTest begins a new test with fresh stats; callers must hold g.mu
+func (g *Generator) startTest() *test {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &test{
+		runID:   g.runID,
+		shardID: g.shardID,
+		ctx:     ctx,
+		cancel:  cancel,
+		stats:   newStats(time.Now(), g.sampleRate),
+	}
+	g.test = t
+
+	g.armDuration(t)
+	go g.report(t)
+
+	log.Printf("[Native Generator] Test started for run %s", t.runID)
+	return t
+}
+
+// armDuration (re)arms the timer stopping a test when the run's duration elapses; callers must hold g.mu
+func (g *Generator) armDuration(t *test) {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	if g.durationSeconds == nil {
+		return
+	}
+
+	t.timer = time.AfterFunc(time.Duration(*g.durationSeconds)*time.Second, func() {
+		log.Printf("[Native Generator] Duration of run %s elapsed, stopping test (auto-stop)", t.runID)
+		g.stopTest(t, true)
+	})
+}
+
+// scale moves a test to the given users: extra users stop right away, missing users are spawned
+// at spawnRate per second; callers must hold g.mu
+func (g *Generator) scale(t *test, users int, spawnRate float64) {
+	if t.spawnCancel != nil {
+		t.spawnCancel()
+		t.spawnCancel = nil
+	}
+	t.spawning = false
+
+	for len(t.users) > users {
+		last := len(t.users) - 1
+		t.users[last]()
+		t.users = t.users[:last]
+	}
+
+	missing := users - len(t.users)
+	if missing == 0 {
+		return
+	}
+
+	spawnCtx, spawnCancel := context.WithCancel(t.ctx)
+	t.spawnCancel = spawnCancel
+	t.spawning = true
+	go g.spawn(spawnCtx, t, missing, spawnRate)
+}
+
+// spawn starts count virtual users, spawnRate per second, or all at once if spawnRate is not positive
+func (g *Generator) spawn(ctx context.Context, t *test, count int, spawnRate float64) {
+	var interval time.Duration
+	if spawnRate > 0 {
+		interval = time.Duration(float64(time.Second) / spawnRate)
+	}
+
+	for i := 0; i < count; i++ {
+		if i > 0 && interval > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+
+		g.mu.Lock()
+		if ctx.Err() != nil {
+			g.mu.Unlock()
+			return
+		}
+		userCtx, userCancel := context.WithCancel(t.ctx)
+		t.users = append(t.users, userCancel)
+		scenario, host := g.scenario, g.host
+		t.vus.Add(1)
+		g.mu.Unlock()
+
+		go g.runUser(userCtx, t, scenario, host)
+	}
+
+	g.mu.Lock()
+	if ctx.Err() == nil {
+		t.spawning = false
+	}
+	g.mu.Unlock()
+}
+
+// runUser is the loop of one virtual user: pick a request, send it, think, until the user is stopped
+func (g *Generator) runUser(ctx context.Context, t *test, scenario *Scenario, host string) {
+	defer t.vus.Done()
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	if host == "" {
+		host = scenario.Host
+	}
+
+	for ctx.Err() == nil {
+		req := scenario.pick(rng)
+		g.send(ctx, t, scenario, req, host)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(scenario.pause(req, rng)):
+		}
+	}
+}
+
+// send executes one request and records its outcome
+func (g *Generator) send(ctx context.Context, t *test, scenario *Scenario, req *Request, host string) {
+	target := req.Path
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimRight(host, "/") + target
+	}
+
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target, body)
+	if err != nil {
+		t.stats.record(req.Method, req.Name, 0, 0, 0, err.Error(), time.Now())
+		return
+	}
+	for key, value := range scenario.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	start := time.Now()
+	resp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		// Requests cut short by the user being stopped are not responses
+		if ctx.Err() != nil {
+			return
+		}
+		t.stats.record(req.Method, req.Name, msSince(start), 0, 0, err.Error(), time.Now())
+		return
+	}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	discarded, _ := io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	responseMs := msSince(start)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	var failure string
+	if err != nil {
+		failure = err.Error()
+	} else {
+		failure = req.Assert.check(resp.StatusCode, respBody, responseMs)
+	}
+	t.stats.record(req.Method, req.Name, responseMs, resp.StatusCode, int64(len(respBody))+discarded, failure, time.Now())
+}
+
+// report pushes the test's metrics to the reporter every report interval until the test stops
+func (g *Generator) report(t *test) {
+	ticker := time.NewTicker(g.reportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		runID, shardID, snapshot := g.testSnapshot(t)
+		if runID == "" {
+			continue
+		}
+		if err := g.reporter.UpdateMetrics(runID, shardID, snapshot); err != nil {
+			log.Printf("[Native Generator] Failed to report metrics for run %s: %v", runID, err)
+		}
+		g.reportSamples(t, runID, shardID)
+	}
+}
+
+// reportSamples sends the requests the test sampled since its previous report
+func (g *Generator) reportSamples(t *test, runID, shardID string) {
+	samples := t.stats.drainSamples()
+	if len(samples) == 0 {
+		return
+	}
+	if err := g.reporter.RecordRequestSamples(runID, shardID, samples, t.stats.sampleRate); err != nil {
+		log.Printf("[Native Generator] Failed to report request samples for run %s: %v", runID, err)
+	}
+}
+
+// testSnapshot returns the test's run and its current metrics
+func (g *Generator) testSnapshot(t *test) (string, string, *domain.MetricSnapshot) {
+	g.mu.Lock()
+	runID, shardID, users := t.runID, t.shardID, len(t.users)
+	state := StateRunning
+	if t.spawning {
+		state = StateSpawning
+	}
+	g.mu.Unlock()
+
+	snapshot := t.stats.snapshot(time.Now())
+	snapshot.CurrentUsers = users
+	snapshot.RunnerState = state
+	snapshot.WorkerCount = 1
+	return runID, shardID, snapshot
+}
+
+// stop stops the current test, if one is running
+func (g *Generator) stop(autoStopped bool) {
+	g.mu.Lock()
+	t := g.test
+	g.mu.Unlock()
+
+	if t != nil {
+		g.stopTest(t, autoStopped)
+	}
+}
+
+// stopTest stops a test's users and reports its final metrics once they all returned
+// The report is sent in the background, like the harness plugin's test_stop callback, since the
+// control plane may stop the test while holding the run it reports to
+func (g *Generator) stopTest(t *test, autoStopped bool) {
+	g.mu.Lock()
+	if t.ctx.Err() != nil {
+		g.mu.Unlock()
+		return
+	}
+	t.cancel()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.users = nil
+	t.spawning = false
+	runID, shardID := t.runID, t.shardID
+	g.mu.Unlock()
+
+	log.Printf("[Native Generator] Test stopped for run %s (autoStopped=%v)", runID, autoStopped)
+
+	go func() {
+		t.vus.Wait()
+		if runID == "" {
+			return
+		}
+
+		g.reportSamples(t, runID, shardID)
+
+		final := t.stats.snapshot(time.Now())
+		final.RunnerState = StateStopped
+		final.WorkerCount = 1
+		if err := g.reporter.HandleTestStop(runID, shardID, final, autoStopped); err != nil {
+			log.Printf("[Native Generator] Failed to report stop of run %s: %v", runID, err)
+		}
+	}()
+}
+
+// needsHost reports whether some request of the scenario is relative to a host
+func (s *Scenario) needsHost() bool {
+	for _, req := range s.Requests {
+		if strings.HasPrefix(req.Path, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
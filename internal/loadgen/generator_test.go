@@ -0,0 +1,235 @@
+package loadgen
+
+import (
+	"Load-manager-cli/internal/domain"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stop is a test stop the generator reported
+type stop struct {
+	runID       string
+	final       *domain.MetricSnapshot
+	autoStopped bool
+}
+
+// recordingReporter records what a generator reports
+type recordingReporter struct {
+	mu      sync.Mutex
+	metrics []*domain.MetricSnapshot
+	stops   []stop
+	samples int
+}
+
+func (r *recordingReporter) UpdateMetrics(runID, shardID string, metrics *domain.MetricSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, metrics)
+	return nil
+}
+
+func (r *recordingReporter) HandleTestStop(runID, shardID string, finalMetrics *domain.MetricSnapshot, autoStopped bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stops = append(r.stops, stop{runID: runID, final: finalMetrics, autoStopped: autoStopped})
+	return nil
+}
+
+func (r *recordingReporter) RecordRequestSamples(runID, shardID string, samples []domain.RequestSample, sampleRate float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples += len(samples)
+	return nil
+}
+
+// waitForStop waits until the generator reported a test stop, failing the test if it does not within a few seconds
+func (r *recordingReporter) waitForStop(t *testing.T) stop {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.mu.Lock()
+		stops := r.stops
+		r.mu.Unlock()
+		if len(stops) > 0 {
+			return stops[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("generator never reported the test stop")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newTestTarget serves /ok with 200 and /fail with 500
+func newTestTarget(t *testing.T) *httptest.Server {
+	t.Helper()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(target.Close)
+	return target
+}
+
+// newLoadedGenerator returns a generator reporting every 50ms that loaded the scenario
+func newLoadedGenerator(t *testing.T, scenario string) (*Generator, *recordingReporter) {
+	t.Helper()
+
+	reporter := &recordingReporter{}
+	generator := New(reporter, Options{ReportInterval: 50 * time.Millisecond, SampleRate: 1})
+	t.Cleanup(func() { generator.Stop(context.Background()) })
+
+	if _, err := generator.LoadScript(context.Background(), "rev-1", base64.StdEncoding.EncodeToString([]byte(scenario))); err != nil {
+		t.Fatalf("LoadScript: %v", err)
+	}
+	return generator, reporter
+}
+
+const testScenario = `
+thinkTime: {min: 5ms}
+requests:
+  - path: /ok
+  - path: /fail
+`
+
+func TestGeneratorRunsScenario(t *testing.T) {
+	target := newTestTarget(t)
+	generator, reporter := newLoadedGenerator(t, testScenario)
+	ctx := context.Background()
+
+	if err := generator.SetRunContext(ctx, "run-1", "", "acc", "", nil); err != nil {
+		t.Fatalf("SetRunContext: %v", err)
+	}
+	if err := generator.Swarm(ctx, 3, 100, target.URL); err != nil {
+		t.Fatalf("Swarm: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats, err := generator.GetStats(ctx)
+		if err != nil {
+			t.Fatalf("GetStats: %v", err)
+		}
+		if stats.RunnerState == StateRunning && stats.CurrentUsers == 3 && stats.TotalFailures > 0 && stats.TotalRequests > stats.TotalFailures {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stats = %+v, want 3 running users sending requests to both paths", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Scaling down stops users right away
+	if err := generator.Swarm(ctx, 1, 100, ""); err != nil {
+		t.Fatalf("Swarm: %v", err)
+	}
+	if stats, _ := generator.GetStats(ctx); stats.CurrentUsers != 1 {
+		t.Errorf("users after scaling down = %d, want 1", stats.CurrentUsers)
+	}
+
+	failures, err := generator.GetFailures(ctx)
+	if err != nil {
+		t.Fatalf("GetFailures: %v", err)
+	}
+	if len(failures) != 1 || failures[0].Name != "/fail" || !strings.Contains(failures[0].Error, "500") {
+		t.Errorf("failures = %+v, want one group for /fail with HTTP 500", failures)
+	}
+
+	// Metrics are pushed every report interval while the test runs
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		reporter.mu.Lock()
+		pushed := len(reporter.metrics)
+		reporter.mu.Unlock()
+		if pushed > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("generator never pushed metrics")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := generator.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	stopped := reporter.waitForStop(t)
+	if stopped.runID != "run-1" || stopped.autoStopped {
+		t.Errorf("stop = %+v, want a manual stop of run-1", stopped)
+	}
+	if stopped.final.RunnerState != StateStopped || stopped.final.TotalRequests == 0 {
+		t.Errorf("final metrics = %+v, want the stopped test's requests", stopped.final)
+	}
+
+	reporter.mu.Lock()
+	samples := reporter.samples
+	reporter.mu.Unlock()
+	if samples == 0 {
+		t.Error("no request samples were reported")
+	}
+}
+
+func TestGeneratorStopsWhenDurationElapses(t *testing.T) {
+	target := newTestTarget(t)
+	generator, reporter := newLoadedGenerator(t, testScenario)
+	ctx := context.Background()
+
+	duration := 1
+	if err := generator.SetRunContext(ctx, "run-1", "shard-1", "acc", "", &duration); err != nil {
+		t.Fatalf("SetRunContext: %v", err)
+	}
+	if err := generator.Swarm(ctx, 1, 10, target.URL); err != nil {
+		t.Fatalf("Swarm: %v", err)
+	}
+
+	stopped := reporter.waitForStop(t)
+	if !stopped.autoStopped {
+		t.Errorf("stop = %+v, want an auto-stop", stopped)
+	}
+	if stats, _ := generator.GetStats(ctx); stats.RunnerState != StateStopped || stats.CurrentUsers != 0 {
+		t.Errorf("stats after the duration = %s with %d users, want stopped with none", stats.RunnerState, stats.CurrentUsers)
+	}
+
+	runCtx, err := generator.GetRunContext(ctx)
+	if err != nil {
+		t.Fatalf("GetRunContext: %v", err)
+	}
+	if runCtx.RunID != "run-1" || runCtx.ShardID != "shard-1" || runCtx.DurationSeconds != "1" || runCtx.PluginVersion != Version {
+		t.Errorf("run context = %+v, want run-1 as shard-1 for 1s", runCtx)
+	}
+}
+
+func TestGeneratorRejectsInvalidSwarms(t *testing.T) {
+	ctx := context.Background()
+
+	empty := New(&recordingReporter{}, Options{})
+	if err := empty.Swarm(ctx, 1, 1, "http://target.example"); err == nil {
+		t.Error("swarm without a scenario succeeded")
+	}
+
+	generator, _ := newLoadedGenerator(t, "requests:\n  - path: /ok\n")
+	if err := generator.Swarm(ctx, -1, 1, "http://target.example"); err == nil {
+		t.Error("swarm with negative users succeeded")
+	}
+	if err := generator.Swarm(ctx, 1, 1, ""); err == nil || !strings.Contains(err.Error(), "no host") {
+		t.Errorf("swarm of relative paths without a host error = %v, want a missing host", err)
+	}
+
+	// The scenario of a running test cannot change
+	if err := generator.Swarm(ctx, 1, 1, newTestTarget(t).URL); err != nil {
+		t.Fatalf("Swarm: %v", err)
+	}
+	if _, err := generator.LoadScript(ctx, "rev-2", base64.StdEncoding.EncodeToString([]byte(testScenario))); err == nil {
+		t.Error("loading a scenario during a test succeeded")
+	}
+}
@@ -0,0 +1,191 @@
+package loadgen
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a declarative HTTP load test executed by the native load generator
+//
+//	host: https://shop.example.com
+//	thinkTime: {min: 1s, max: 3s}
+//	requests:
+//	  - name: home
+//	    path: /
+//	    weight: 3
+//	  - name: checkout
+//	    method: POST
+//	    path: /api/checkout
+//	    headers: {Content-Type: application/json}
+//	    body: '{"cart": "demo"}'
+//	    assert: {status: [200, 201], maxResponseMs: 800}
+type Scenario struct {
+	Host      string            `yaml:"host,omitempty"`      // Default host; the run's target URL overrides it
+	Headers   map[string]string `yaml:"headers,omitempty"`   // Sent with every request
+	ThinkTime ThinkTime         `yaml:"thinkTime,omitempty"` // Pause of a virtual user between two requests
+	Requests  []Request         `yaml:"requests"`
+
+	totalWeight int
+}
+
+// Request is one HTTP request of a scenario, picked by virtual users in proportion to its weight
+type Request struct {
+	Name      string            `yaml:"name,omitempty"`   // Name the request's stats are reported under (default: path)
+	Method    string            `yaml:"method,omitempty"` // Default: GET
+	Path      string            `yaml:"path"`             // Path appended to the host, or an absolute URL
+	Headers   map[string]string `yaml:"headers,omitempty"`
+	Body      string            `yaml:"body,omitempty"`
+	Weight    int               `yaml:"weight,omitempty"`    // Relative frequency (default: 1)
+	ThinkTime *ThinkTime        `yaml:"thinkTime,omitempty"` // Overrides the scenario's think time after this request
+	Assert    Assertions        `yaml:"assert,omitempty"`
+}
+
+// ThinkTime is a pause drawn uniformly between Min and Max
+type ThinkTime struct {
+	Min time.Duration `yaml:"min,omitempty"`
+	Max time.Duration `yaml:"max,omitempty"`
+}
+
+// Assertions decide whether a response counts as a failure
+type Assertions struct {
+	Status        []int   `yaml:"status,omitempty"`        // Accepted status codes (default: any 2xx or 3xx)
+	BodyContains  string  `yaml:"bodyContains,omitempty"`  // Text the response body must contain
+	MaxResponseMs float64 `yaml:"maxResponseMs,omitempty"` // Slower responses fail
+}
+
+// ParseScenario parses and validates a YAML scenario
+func ParseScenario(data []byte) (*Scenario, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var scenario Scenario
+	if err := decoder.Decode(&scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if err := scenario.validate(); err != nil {
+		return nil, err
+	}
+	return &scenario, nil
+}
+
+// ParseScenarioBase64 parses and validates a base64 encoded YAML scenario, as scripts are stored
+func ParseScenarioBase64(content string) (*Scenario, error) {
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode scenario: %w", err)
+	}
+	return ParseScenario(data)
+}
+
+// validate checks the scenario and fills in defaults
+func (s *Scenario) validate() error {
+	if len(s.Requests) == 0 {
+		return fmt.Errorf("scenario has no requests")
+	}
+	if err := s.ThinkTime.validate(); err != nil {
+		return fmt.Errorf("thinkTime: %w", err)
+	}
+
+	s.totalWeight = 0
+	for i := range s.Requests {
+		req := &s.Requests[i]
+		if req.Path == "" {
+			return fmt.Errorf("request %d: path is required", i)
+		}
+		if !strings.HasPrefix(req.Path, "http://") && !strings.HasPrefix(req.Path, "https://") && !strings.HasPrefix(req.Path, "/") {
+			return fmt.Errorf("request %d: path must start with / or be an absolute URL", i)
+		}
+		if req.Method == "" {
+			req.Method = http.MethodGet
+		}
+		req.Method = strings.ToUpper(req.Method)
+		if req.Name == "" {
+			req.Name = req.Path
+		}
+		if req.Weight < 0 {
+			return fmt.Errorf("request %d: weight must not be negative", i)
+		}
+		if req.Weight == 0 {
+			req.Weight = 1
+		}
+		if req.ThinkTime != nil {
+			if err := req.ThinkTime.validate(); err != nil {
+				return fmt.Errorf("request %d: thinkTime: %w", i, err)
+			}
+		}
+		if req.Assert.MaxResponseMs < 0 {
+			return fmt.Errorf("request %d: maxResponseMs must not be negative", i)
+		}
+		s.totalWeight += req.Weight
+	}
+
+	return nil
+}
+
+// validate checks that the think time is a valid range
+func (t ThinkTime) validate() error {
+	if t.Min < 0 || t.Max < 0 {
+		return fmt.Errorf("min and max must not be negative")
+	}
+	if t.Max > 0 && t.Max < t.Min {
+		return fmt.Errorf("max must not be less than min")
+	}
+	return nil
+}
+
+// pick draws a request in proportion to the requests' weights
+func (s *Scenario) pick(rng *rand.Rand) *Request {
+	n := rng.Intn(s.totalWeight)
+	for i := range s.Requests {
+		n -= s.Requests[i].Weight
+		if n < 0 {
+			return &s.Requests[i]
+		}
+	}
+	return &s.Requests[len(s.Requests)-1]
+}
+
+// pause returns how long a virtual user thinks after req
+func (s *Scenario) pause(req *Request, rng *rand.Rand) time.Duration {
+	thinkTime := s.ThinkTime
+	if req.ThinkTime != nil {
+		thinkTime = *req.ThinkTime
+	}
+	if thinkTime.Max <= thinkTime.Min {
+		return thinkTime.Min
+	}
+	return thinkTime.Min + time.Duration(rng.Int63n(int64(thinkTime.Max-thinkTime.Min)))
+}
+
+// check applies the request's assertions to a response and returns why it failed, or "" if it passed
+func (a Assertions) check(status int, body []byte, responseMs float64) string {
+	if len(a.Status) > 0 {
+		accepted := false
+		for _, code := range a.Status {
+			if status == code {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			return fmt.Sprintf("unexpected status %d", status)
+		}
+	} else if status >= 400 {
+		return fmt.Sprintf("HTTP %d", status)
+	}
+
+	if a.BodyContains != "" && !bytes.Contains(body, []byte(a.BodyContains)) {
+		return fmt.Sprintf("response body does not contain %q", a.BodyContains)
+	}
+	if a.MaxResponseMs > 0 && responseMs > a.MaxResponseMs {
+		return fmt.Sprintf("response took %.0fms, more than %.0fms", responseMs, a.MaxResponseMs)
+	}
+	return ""
+}
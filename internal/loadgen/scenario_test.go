@@ -0,0 +1,94 @@
+package loadgen
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScenarioFillsDefaults(t *testing.T) {
+	scenario, err := ParseScenario([]byte(`
+host: https://shop.example
+thinkTime: {min: 1s, max: 3s}
+requests:
+  - path: /
+  - name: checkout
+    method: post
+    path: /api/checkout
+    weight: 3
+    thinkTime: {min: 500ms}
+`))
+	if err != nil {
+		t.Fatalf("ParseScenario: %v", err)
+	}
+
+	home, checkout := scenario.Requests[0], scenario.Requests[1]
+	if home.Method != "GET" || home.Name != "/" || home.Weight != 1 {
+		t.Errorf("home = %s %s weight %d, want GET / weight 1", home.Method, home.Name, home.Weight)
+	}
+	if checkout.Method != "POST" || checkout.Name != "checkout" || checkout.Weight != 3 {
+		t.Errorf("checkout = %s %s weight %d, want POST checkout weight 3", checkout.Method, checkout.Name, checkout.Weight)
+	}
+	if scenario.ThinkTime.Min != time.Second || scenario.ThinkTime.Max != 3*time.Second {
+		t.Errorf("think time = %+v, want 1s to 3s", scenario.ThinkTime)
+	}
+	if scenario.totalWeight != 4 {
+		t.Errorf("total weight = %d, want 4", scenario.totalWeight)
+	}
+	if pause := scenario.pause(&checkout, nil); pause != 500*time.Millisecond {
+		t.Errorf("pause after checkout = %s, want its own 500ms", pause)
+	}
+}
+
+func TestParseScenarioRejectsInvalidScenarios(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		want     string
+	}{
+		{name: "no requests", scenario: "host: https://shop.example\n", want: "no requests"},
+		{name: "missing path", scenario: "requests:\n  - name: home\n", want: "path is required"},
+		{name: "relative path", scenario: "requests:\n  - path: api\n", want: "must start with /"},
+		{name: "negative weight", scenario: "requests:\n  - path: /\n    weight: -1\n", want: "weight"},
+		{name: "inverted think time", scenario: "thinkTime: {min: 2s, max: 1s}\nrequests:\n  - path: /\n", want: "thinkTime"},
+		{name: "unknown field", scenario: "requests:\n  - path: /\n    retries: 3\n", want: "retries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScenario([]byte(tt.scenario))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseScenario error = %v, want one about %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssertionsCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		assert     Assertions
+		status     int
+		body       string
+		responseMs float64
+		wantFailed bool
+	}{
+		{name: "2xx by default", status: 204},
+		{name: "4xx by default", status: 404, wantFailed: true},
+		{name: "accepted status", assert: Assertions{Status: []int{404}}, status: 404},
+		{name: "unexpected status", assert: Assertions{Status: []int{201}}, status: 200, wantFailed: true},
+		{name: "body contains", assert: Assertions{BodyContains: "ok"}, status: 200, body: `{"status":"ok"}`},
+		{name: "body missing text", assert: Assertions{BodyContains: "ok"}, status: 200, body: `{"status":"down"}`, wantFailed: true},
+		{name: "fast enough", assert: Assertions{MaxResponseMs: 100}, status: 200, responseMs: 80},
+		{name: "too slow", assert: Assertions{MaxResponseMs: 100}, status: 200, responseMs: 120, wantFailed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := tt.assert.check(tt.status, []byte(tt.body), tt.responseMs)
+			if (failure != "") != tt.wantFailed {
+				t.Errorf("check = %q, want failed %v", failure, tt.wantFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package loadgen
+
+import (
+	"Load-manager-cli/internal/domain"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// rpsWindowSeconds is the window current requests per second are averaged over, as Locust does
+const rpsWindowSeconds = 10
+
+// maxBufferedSamples caps the request samples held between two reports, like the harness plugin's buffer
+const maxBufferedSamples = 1000
+
+// entryStats accumulates the responses of one request name, or of all requests for the total
+type entryStats struct {
+	method      string
+	name        string
+	numRequests int64
+	numFailures int64
+	totalMs     float64
+	minMs       float64
+	maxMs       float64
+	histogram   domain.Histogram // Response times rounded the way Locust rounds them, which keeps percentiles within a few percent
+	perSecond   map[int64]int64  // Unix second -> responses, for the current requests per second
+}
+
+func newEntryStats(method, name string) *entryStats {
+	return &entryStats{method: method, name: name, histogram: make(domain.Histogram), perSecond: make(map[int64]int64)}
+}
+
+// record adds a response to the entry
+func (e *entryStats) record(responseMs float64, failed bool, second int64) {
+	e.numRequests++
+	if failed {
+		e.numFailures++
+	}
+	e.totalMs += responseMs
+	if e.numRequests == 1 || responseMs < e.minMs {
+		e.minMs = responseMs
+	}
+	if responseMs > e.maxMs {
+		e.maxMs = responseMs
+	}
+	e.histogram.Record(responseMs)
+
+	e.perSecond[second]++
+	for s := range e.perSecond {
+		if s <= second-rpsWindowSeconds-1 {
+			delete(e.perSecond, s)
+		}
+	}
+}
+
+// currentRPS averages the responses of the last complete seconds of the window
+func (e *entryStats) currentRPS(now, startedAt int64) float64 {
+	window := now - startedAt
+	if window > rpsWindowSeconds {
+		window = rpsWindowSeconds
+	}
+	if window < 1 {
+		return 0
+	}
+
+	var count int64
+	for s := now - window; s < now; s++ {
+		count += e.perSecond[s]
+	}
+	return float64(count) / float64(window)
+}
+
+func (e *entryStats) avgMs() float64 {
+	if e.numRequests == 0 {
+		return 0
+	}
+	return e.totalMs / float64(e.numRequests)
+}
+
+// failureKey groups failures like Locust's failures table: by request and error
+type failureKey struct {
+	method string
+	name   string
+	error  string
+}
+
+// stats accumulates the responses of one test
+type stats struct {
+	mu         sync.Mutex
+	startedAt  int64 // Unix seconds
+	total      *entryStats
+	entries    map[string]*entryStats // Keyed like the harness plugin keys its request stats: "method:name"
+	failures   map[failureKey]int64   // Occurrences of each failure, up to domain.MaxFailureGroups distinct ones
+	sampleRate float64                // Fraction of responses sampled for the run's request log
+	samples    []domain.RequestSample // Sampled since the last report, oldest first
+}
+
+func newStats(now time.Time, sampleRate float64) *stats {
+	return &stats{
+		startedAt:  now.Unix(),
+		total:      newEntryStats("", "Aggregated"),
+		entries:    make(map[string]*entryStats),
+		failures:   make(map[failureKey]int64),
+		sampleRate: sampleRate,
+	}
+}
+
+// record adds a response of a request to its entry and to the total, and samples it at the sample rate
+// statusCode is 0 if no response was received; failure is why the request failed, empty if it succeeded
+func (s *stats) record(method, name string, responseMs float64, statusCode int, responseLength int64, failure string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rand.Float64() < s.sampleRate {
+		if len(s.samples) >= maxBufferedSamples {
+			s.samples = s.samples[1:]
+		}
+		s.samples = append(s.samples, domain.RequestSample{
+			Timestamp:      at.UnixMilli() - int64(responseMs),
+			Method:         method,
+			Name:           name,
+			StatusCode:     statusCode,
+			ResponseTimeMs: responseMs,
+			ResponseLength: responseLength,
+			Exception:      failure,
+		})
+	}
+
+	failed := failure != ""
+	if failed {
+		key := failureKey{method: method, name: name, error: failure}
+		if _, ok := s.failures[key]; ok || len(s.failures) < domain.MaxFailureGroups {
+			s.failures[key]++
+		}
+	}
+
+	key := method + ":" + name
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = newEntryStats(method, name)
+		s.entries[key] = entry
+	}
+
+	second := at.Unix()
+	entry.record(responseMs, failed, second)
+	s.total.record(responseMs, failed, second)
+}
+
+// snapshot reports the stats as the harness plugin reports Locust's
+func (s *stats) snapshot(now time.Time) *domain.MetricSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	second := now.Unix()
+	total := s.total
+	snapshot := &domain.MetricSnapshot{
+		Timestamp:         now.UnixMilli(),
+		TotalRPS:          total.currentRPS(second, s.startedAt),
+		TotalRequests:     total.numRequests,
+		TotalFailures:     total.numFailures,
+		AverageResponseMs: total.avgMs(),
+		MinResponseMs:     total.minMs,
+		MaxResponseMs:     total.maxMs,
+		P50ResponseMs:     total.histogram.Percentile(0.50),
+		P90ResponseMs:     total.histogram.Percentile(0.90),
+		P95ResponseMs:     total.histogram.Percentile(0.95),
+		P99ResponseMs:     total.histogram.Percentile(0.99),
+		P999ResponseMs:    total.histogram.Percentile(0.999),
+		ResponseTimes:     total.histogram.Clone(),
+		Failures:          s.failureGroupsLocked(),
+		RequestStats:      make(map[string]*domain.ReqStat, len(s.entries)),
+	}
+	snapshot.AvgResponseMs = snapshot.AverageResponseMs
+	if total.numRequests > 0 {
+		snapshot.ErrorRate = float64(total.numFailures) / float64(total.numRequests) * 100
+	}
+
+	for key, entry := range s.entries {
+		p50 := entry.histogram.Percentile(0.50)
+		snapshot.RequestStats[key] = &domain.ReqStat{
+			Method:             entry.method,
+			Name:               entry.name,
+			NumRequests:        entry.numRequests,
+			NumFailures:        entry.numFailures,
+			AvgResponseTime:    entry.avgMs(),
+			AvgResponseTimeMs:  entry.avgMs(),
+			MinResponseTime:    entry.minMs,
+			MinResponseTimeMs:  entry.minMs,
+			MaxResponseTime:    entry.maxMs,
+			MaxResponseTimeMs:  entry.maxMs,
+			MedianResponseTime: p50,
+			P50ResponseMs:      p50,
+			P90ResponseMs:      entry.histogram.Percentile(0.90),
+			P95ResponseMs:      entry.histogram.Percentile(0.95),
+			P99ResponseMs:      entry.histogram.Percentile(0.99),
+			P999ResponseMs:     entry.histogram.Percentile(0.999),
+			ResponseTimes:      entry.histogram.Clone(),
+			RequestsPerSec:     entry.currentRPS(second, s.startedAt),
+		}
+	}
+
+	return snapshot
+}
+
+// drainSamples returns the requests sampled since the previous call
+func (s *stats) drainSamples() []domain.RequestSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples := s.samples
+	s.samples = nil
+	return samples
+}
+
+// failureGroups reports the failures as rows of Locust's failures table
+func (s *stats) failureGroups() []domain.FailureGroup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failureGroupsLocked()
+}
+
+func (s *stats) failureGroupsLocked() []domain.FailureGroup {
+	if len(s.failures) == 0 {
+		return nil
+	}
+	groups := make([]domain.FailureGroup, 0, len(s.failures))
+	for key, occurrences := range s.failures {
+		groups = append(groups, domain.FailureGroup{Method: key.method, Name: key.name, Error: key.error, Occurrences: occurrences})
+	}
+	return groups
+}
@@ -0,0 +1,94 @@
+package locustclient
+
+import (
+	"Load-manager-cli/internal/domain"
+	"sync"
+	"time"
+)
+
+// breaker is a circuit breaker guarding one Locust master
+// It opens after consecutive requests failed to get an answer, fails calls fast while open, and
+// lets a single probe through once the open duration elapsed: the probe closes it again or re-opens it
+type breaker struct {
+	threshold    int
+	openDuration time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int       // Consecutive failures
+	openedAt time.Time // When the breaker last opened
+	probing  bool      // Whether the half-open probe is in flight
+}
+
+func newBreaker(threshold int, openDuration time.Duration) *breaker {
+	return &breaker{threshold: threshold, openDuration: openDuration, state: domain.CircuitClosed}
+}
+
+// allow reports whether a request may be sent, moving an open breaker to half-open once its open duration elapsed
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case domain.CircuitOpen:
+		if time.Since(b.openedAt) < b.openDuration {
+			return false
+		}
+		b.state = domain.CircuitHalfOpen
+		b.probing = true
+		return true
+	case domain.CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success records a request the master answered, closing the breaker
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = domain.CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// failure records a request that got no answer, opening the breaker at the threshold or when the probe failed
+// Returns true if the breaker opened
+func (b *breaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == domain.CircuitHalfOpen || (b.state == domain.CircuitClosed && b.failures >= b.threshold) {
+		b.state = domain.CircuitOpen
+		b.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+// abandon records a request whose caller gave up before it was answered, letting another probe through if it was the probe
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// status returns the breaker's state for the cluster health view
+func (b *breaker) status() *domain.CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := &domain.CircuitStatus{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != domain.CircuitClosed {
+		status.OpenedAt = b.openedAt.UnixMilli()
+	}
+	return status
+}
@@ -0,0 +1,159 @@
+package locustclient
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAtThreshold(t *testing.T) {
+	b := newBreaker(3, time.Hour)
+
+	for i := 1; i <= 2; i++ {
+		if b.failure() {
+			t.Fatalf("breaker opened after %d failures, want 3", i)
+		}
+		if !b.allow() {
+			t.Fatalf("closed breaker refused a request after %d failures", i)
+		}
+	}
+	if !b.failure() {
+		t.Fatal("breaker did not open at the threshold")
+	}
+	if b.allow() {
+		t.Error("open breaker let a request through")
+	}
+
+	status := b.status()
+	if status.State != domain.CircuitOpen || status.ConsecutiveFailures != 3 || status.OpenedAt == 0 {
+		t.Errorf("status = %+v, want open after 3 failures", status)
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	b := newBreaker(2, time.Hour)
+
+	b.failure()
+	b.success()
+	if b.failure() {
+		t.Error("breaker opened on a failure following a success")
+	}
+	if status := b.status(); status.State != domain.CircuitClosed || status.ConsecutiveFailures != 1 {
+		t.Errorf("status = %+v, want closed with 1 failure", status)
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name      string
+		probeOK   bool
+		wantState string
+	}{
+		{name: "probe succeeds", probeOK: true, wantState: domain.CircuitClosed},
+		{name: "probe fails", probeOK: false, wantState: domain.CircuitOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(1, 10*time.Millisecond)
+			b.failure()
+			time.Sleep(20 * time.Millisecond)
+
+			if !b.allow() {
+				t.Fatal("breaker refused the probe after its open duration")
+			}
+			if state := b.status().State; state != domain.CircuitHalfOpen {
+				t.Fatalf("state = %s, want %s", state, domain.CircuitHalfOpen)
+			}
+			if b.allow() {
+				t.Fatal("half-open breaker let a second request through while probing")
+			}
+
+			if tt.probeOK {
+				b.success()
+			} else if !b.failure() {
+				t.Error("failed probe did not re-open the breaker")
+			}
+			if state := b.status().State; state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestBreakerAbandonedProbe(t *testing.T) {
+	b := newBreaker(1, 10*time.Millisecond)
+	b.failure()
+	time.Sleep(20 * time.Millisecond)
+
+	if !b.allow() {
+		t.Fatal("breaker refused the probe after its open duration")
+	}
+	b.abandon()
+	if !b.allow() {
+		t.Error("breaker refused another probe after the first was abandoned")
+	}
+	if state := b.status().State; state != domain.CircuitHalfOpen {
+		t.Errorf("state = %s, want %s", state, domain.CircuitHalfOpen)
+	}
+}
+
+func TestClientFailsFastWhileBreakerOpen(t *testing.T) {
+	master := locusttest.NewMaster(locusttest.Options{})
+	defer master.Close()
+	master.InjectFault(locusttest.EndpointStop, locusttest.Fault{Status: http.StatusServiceUnavailable})
+
+	client := NewHTTPClientWithOptions(master.URL(), "", Options{
+		Timeout:          time.Second,
+		MaxAttempts:      1,
+		BreakerThreshold: 2,
+		BreakerOpen:      50 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := client.Stop(ctx); !errors.Is(err, ErrUnreachable) {
+			t.Fatalf("Stop error = %v, want ErrUnreachable", err)
+		}
+	}
+	if state := client.Circuit().State; state != domain.CircuitOpen {
+		t.Fatalf("state = %s, want %s", state, domain.CircuitOpen)
+	}
+
+	if err := client.Stop(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Stop error = %v, want ErrCircuitOpen", err)
+	}
+	if calls := len(master.Calls(locusttest.EndpointStop)); calls != 2 {
+		t.Errorf("stop calls = %d, want 2: the open breaker sends nothing", calls)
+	}
+
+	// Once the master recovers, the probe closes the breaker
+	master.ClearFaults()
+	time.Sleep(60 * time.Millisecond)
+	if err := client.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if state := client.Circuit().State; state != domain.CircuitClosed {
+		t.Errorf("state = %s, want %s", state, domain.CircuitClosed)
+	}
+}
+
+func TestClientRejectionDoesNotOpenBreaker(t *testing.T) {
+	master := locusttest.NewMaster(locusttest.Options{})
+	defer master.Close()
+	master.InjectFault(locusttest.EndpointStop, locusttest.Fault{Status: http.StatusBadRequest})
+
+	client := NewHTTPClientWithOptions(master.URL(), "", Options{MaxAttempts: 1, BreakerThreshold: 1})
+	for i := 0; i < 3; i++ {
+		if err := client.Stop(context.Background()); !errors.Is(err, ErrRejected) {
+			t.Fatalf("Stop error = %v, want ErrRejected", err)
+		}
+	}
+	if state := client.Circuit().State; state != domain.CircuitClosed {
+		t.Errorf("state = %s, want %s", state, domain.CircuitClosed)
+	}
+}
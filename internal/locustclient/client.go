@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -16,37 +15,52 @@ import (
 
 // Client is an interface for interacting with Locust master HTTP API
 type Client interface {
-	SetRunContext(ctx context.Context, runID, tenantID, envID string, durationSeconds *int) error
-	Swarm(ctx context.Context, users int, spawnRate float64) error
+	SetRunContext(ctx context.Context, runID, shardID, tenantID, envID string, durationSeconds *int) error
+	LoadScript(ctx context.Context, revisionID, scriptContent string) (*ScriptLoadResult, error)
+	Swarm(ctx context.Context, users int, spawnRate float64, host string) error
 	Stop(ctx context.Context) error
 	GetStats(ctx context.Context) (*domain.MetricSnapshot, error)
+	GetFailures(ctx context.Context) ([]domain.FailureGroup, error)
+	GetExceptions(ctx context.Context) ([]domain.ExceptionGroup, error)
+	GetRunContext(ctx context.Context) (*RunContext, error)
 }
 
 // HTTPClient implements the Client interface using HTTP calls to Locust master
+// Idempotent calls are retried with backoff when the master does not answer, and a circuit breaker
+// fails calls fast while the master is down
 type HTTPClient struct {
 	baseURL    string
 	authToken  string
+	opts       Options
 	httpClient *http.Client
+	breaker    *breaker
 }
 
-// NewHTTPClient creates a new Locust HTTP client
+// NewHTTPClient creates a new Locust HTTP client with the default options
 func NewHTTPClient(baseURL, authToken string) *HTTPClient {
+	return NewHTTPClientWithOptions(baseURL, authToken, Options{})
+}
+
+// NewHTTPClientWithOptions creates a new Locust HTTP client with the given timeout, retry and circuit breaker options
+func NewHTTPClientWithOptions(baseURL, authToken string, opts Options) *HTTPClient {
+	opts = opts.withDefaults()
 	return &HTTPClient{
 		baseURL:   baseURL,
 		authToken: authToken,
+		opts:      opts,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: opts.Timeout,
 		},
+		breaker: newBreaker(opts.BreakerThreshold, opts.BreakerOpen),
 	}
 }
 
 // SetRunContext sets the run context in Locust before starting a test
+// shardID identifies the master's share of a distributed run in its callbacks; empty for single-cluster runs
 // Calls the custom /controlplane/set-context endpoint
-func (c *HTTPClient) SetRunContext(ctx context.Context, runID, tenantID, envID string, durationSeconds *int) error {
-	endpoint := fmt.Sprintf("%s/controlplane/set-context", c.baseURL)
-	
-	log.Printf("[Locust Client] Setting run context: runID=%s, tenantID=%s, envID=%s, duration=%v",
-		runID, tenantID, envID, durationSeconds)
+func (c *HTTPClient) SetRunContext(ctx context.Context, runID, shardID, tenantID, envID string, durationSeconds *int) error {
+	log.Printf("[Locust Client] Setting run context: runID=%s, shardID=%s, tenantID=%s, envID=%s, duration=%v",
+		runID, shardID, tenantID, envID, durationSeconds)
 	
 	payload := map[string]interface{}{
 		"runId":    runID,
@@ -54,6 +68,10 @@ func (c *HTTPClient) SetRunContext(ctx context.Context, runID, tenantID, envID s
 		"envId":    envID,
 	}
 	
+	if shardID != "" {
+		payload["shardId"] = shardID
+	}
+
 	if durationSeconds != nil {
 		payload["durationSeconds"] = *durationSeconds
 	}
@@ -63,71 +81,156 @@ func (c *HTTPClient) SetRunContext(ctx context.Context, runID, tenantID, envID s
 		return fmt.Errorf("failed to marshal context payload: %w", err)
 	}
 	
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(string(jsonData)))
+	// Setting the same context twice is harmless, so the call is retried
+	body, err := c.send(ctx, request{
+		op:          "set-context",
+		method:      http.MethodPost,
+		path:        "/controlplane/set-context",
+		contentType: "application/json",
+		body:        jsonData,
+		idempotent:  true,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create set-context request: %w", err)
+		log.Printf("[Locust Client] Set context failed: %v", err)
+		return err
 	}
 	
-	req.Header.Set("Content-Type", "application/json")
-	
-	if c.authToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.authToken))
+	log.Printf("[Locust Client] Run context set successfully. Response: %s", string(body))
+	return nil
+}
+
+// ScriptLoadResult describes the script the Locust master reports as loaded
+type ScriptLoadResult struct {
+	RevisionID string `json:"revisionId"`
+	SHA256     string `json:"sha256"`  // Hex-encoded SHA-256 of the decoded script
+	Workers    int    `json:"workers"` // Number of workers the script was forwarded to
+}
+
+// LoadScript pushes a script revision to the Locust master, which loads it and forwards it to its workers
+// Calls the custom /controlplane/load-script endpoint
+func (c *HTTPClient) LoadScript(ctx context.Context, revisionID, scriptContent string) (*ScriptLoadResult, error) {
+	log.Printf("[Locust Client] Loading script revision %s", revisionID)
+
+	payload := map[string]interface{}{
+		"revisionId":    revisionID,
+		"scriptContent": scriptContent, // Base64 encoded Python script
 	}
-	
-	resp, err := c.httpClient.Do(req)
+
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("[Locust Client] Set context request failed: %v", err)
-		return fmt.Errorf("failed to execute set-context request: %w", err)
+		return nil, fmt.Errorf("failed to marshal load-script payload: %w", err)
 	}
-	defer resp.Body.Close()
-	
-	body, _ := io.ReadAll(resp.Body)
-	
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("[Locust Client] Set context failed with status %d: %s", resp.StatusCode, string(body))
-		return fmt.Errorf("set-context request failed with status %d: %s", resp.StatusCode, string(body))
+
+	// Loading the same revision again replaces it with itself, so the call is retried
+	body, err := c.send(ctx, request{
+		op:          "load-script",
+		method:      http.MethodPost,
+		path:        "/controlplane/load-script",
+		contentType: "application/json",
+		body:        jsonData,
+		idempotent:  true,
+	})
+	if err != nil {
+		log.Printf("[Locust Client] Load script failed: %v", err)
+		return nil, err
 	}
-	
-	log.Printf("[Locust Client] Run context set successfully. Response: %s", string(body))
-	return nil
+
+	var result ScriptLoadResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode load-script response: %w", err)
+	}
+
+	log.Printf("[Locust Client] Script revision %s loaded (sha256=%s, workers=%d)", result.RevisionID, result.SHA256, result.Workers)
+	return &result, nil
+}
+
+// RunContext is the run context and script the Locust plugin currently holds
+type RunContext struct {
+	RunID            string
+	ShardID          string // Empty unless the master drives a shard of a distributed run
+	TenantID         string
+	EnvID            string
+	DurationSeconds  string // As sent in set-context; empty if the run has no duration
+	ScriptRevisionID string
+	ScriptSHA256     string
+	ScriptWorkers    []string          // Workers the master forwarded the script to; nil for plugins that don't report them
+	WorkerAcks       map[string]string // Worker -> SHA-256 of the script it loaded, empty if it failed to load it
+	PluginVersion    string            // Empty for plugins that predate version reporting
+}
+
+// GetRunContext retrieves the run context the Locust plugin currently holds
+// Calls the custom /controlplane/get-context endpoint
+func (c *HTTPClient) GetRunContext(ctx context.Context) (*RunContext, error) {
+	body, err := c.send(ctx, request{
+		op:         "get-context",
+		method:     http.MethodGet,
+		path:       "/controlplane/get-context",
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The plugin reports its globals as they are named in Python
+	var result struct {
+		Context struct {
+			RunID           string `json:"run_id"`
+			ShardID         string `json:"shard_id"`
+			TenantID        string `json:"tenant_id"`
+			EnvID           string `json:"env_id"`
+			DurationSeconds string `json:"duration_seconds"`
+		} `json:"context"`
+		Script struct {
+			RevisionID string            `json:"revision_id"`
+			SHA256     string            `json:"sha256"`
+			Workers    []string          `json:"workers"`
+			WorkerAcks map[string]string `json:"worker_acks"`
+		} `json:"script"`
+		PluginVersion string `json:"pluginVersion"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode get-context response: %w", err)
+	}
+
+	return &RunContext{
+		RunID:            result.Context.RunID,
+		ShardID:          result.Context.ShardID,
+		TenantID:         result.Context.TenantID,
+		EnvID:            result.Context.EnvID,
+		DurationSeconds:  result.Context.DurationSeconds,
+		ScriptRevisionID: result.Script.RevisionID,
+		ScriptSHA256:     result.Script.SHA256,
+		ScriptWorkers:    result.Script.Workers,
+		WorkerAcks:       result.Script.WorkerAcks,
+		PluginVersion:    result.PluginVersion,
+	}, nil
 }
 
 // Swarm starts a load test with specified users and spawn rate
+// If host is non-empty it overrides the host configured in the locustfile
 // Calls the /swarm endpoint on Locust master
-func (c *HTTPClient) Swarm(ctx context.Context, users int, spawnRate float64) error {
-	endpoint := fmt.Sprintf("%s/swarm", c.baseURL)
-	
-	log.Printf("[Locust Client] Starting swarm: users=%d, spawnRate=%.2f, endpoint=%s", users, spawnRate, endpoint)
+func (c *HTTPClient) Swarm(ctx context.Context, users int, spawnRate float64, host string) error {
+	log.Printf("[Locust Client] Starting swarm: users=%d, spawnRate=%.2f, host=%s, endpoint=%s/swarm", users, spawnRate, host, c.baseURL)
 	
 	// Locust /swarm endpoint expects form-encoded data
 	formData := url.Values{}
 	formData.Set("user_count", strconv.Itoa(users))
 	formData.Set("spawn_rate", fmt.Sprintf("%.2f", spawnRate))
-	
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(formData.Encode()))
-	if err != nil {
-		return fmt.Errorf("failed to create swarm request: %w", err)
-	}
-	
-	// Set proper content type for form data
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	
-	if c.authToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.authToken))
+	if host != "" {
+		formData.Set("host", host)
 	}
 	
-	resp, err := c.httpClient.Do(req)
+	// A swarm that reached the master may have started the test, so it is never retried
+	body, err := c.send(ctx, request{
+		op:          "swarm",
+		method:      http.MethodPost,
+		path:        "/swarm",
+		contentType: "application/x-www-form-urlencoded",
+		body:        []byte(formData.Encode()),
+	})
 	if err != nil {
-		log.Printf("[Locust Client] Swarm request failed: %v", err)
-		return fmt.Errorf("failed to execute swarm request: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	body, _ := io.ReadAll(resp.Body)
-	
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("[Locust Client] Swarm failed with status %d: %s", resp.StatusCode, string(body))
-		return fmt.Errorf("swarm request failed with status %d: %s", resp.StatusCode, string(body))
+		log.Printf("[Locust Client] Swarm failed: %v", err)
+		return err
 	}
 	
 	log.Printf("[Locust Client] Swarm successful. Response: %s", string(body))
@@ -137,60 +240,26 @@ func (c *HTTPClient) Swarm(ctx context.Context, users int, spawnRate float64) er
 // Stop stops the current load test
 // Calls the /stop endpoint on Locust master
 func (c *HTTPClient) Stop(ctx context.Context) error {
-	endpoint := fmt.Sprintf("%s/stop", c.baseURL)
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create stop request: %w", err)
-	}
-	
-	if c.authToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.authToken))
-	}
-	
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to execute stop request: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("stop request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-	
-	return nil
+	_, err := c.send(ctx, request{
+		op:         "stop",
+		method:     http.MethodGet,
+		path:       "/stop",
+		idempotent: true,
+	})
+	return err
 }
 
 // GetStats retrieves current statistics from Locust master
 // Calls the /stats/requests endpoint
 func (c *HTTPClient) GetStats(ctx context.Context) (*domain.MetricSnapshot, error) {
-	endpoint := fmt.Sprintf("%s/stats/requests", c.baseURL)
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stats request: %w", err)
-	}
-	
-	if c.authToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.authToken))
-	}
-	
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute stats request: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("stats request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-	
-	// Read body for both parsing and debugging
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.send(ctx, request{
+		op:         "stats",
+		method:     http.MethodGet,
+		path:       "/stats/requests",
+		idempotent: true,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
 	var statsResponse LocustStatsResponse
@@ -204,39 +273,101 @@ func (c *HTTPClient) GetStats(ctx context.Context) (*domain.MetricSnapshot, erro
 // LocustStatsResponse represents the response from Locust /stats/requests endpoint
 // Note: Field names are based on Locust's actual API response format
 type LocustStatsResponse struct {
-	Stats []struct {
-		Method             string  `json:"method"`
-		Name               string  `json:"name"`
-		NumRequests        int64   `json:"num_requests"`
-		NumFailures        int64   `json:"num_failures"`
-		AvgResponseTime    float64 `json:"avg_response_time"`
-		MinResponseTime    float64 `json:"min_response_time"`
-		MaxResponseTime    float64 `json:"max_response_time"`
-		MedianResponseTime float64 `json:"median_response_time"`
-		CurrentRps         float64 `json:"current_rps"`
-		CurrentFailPerSec  float64 `json:"current_fail_per_sec"`
-	} `json:"stats"`
+	Stats []LocustStat `json:"stats"`
 	// Top-level aggregated fields
-	TotalRps          float64 `json:"total_rps"`           // May also be "current_rps_total"
-	FailRatio         float64 `json:"fail_ratio"`          // Decimal 0-1, not percentage
-	CurrentUserCount  int     `json:"user_count"`          // Active users
-	State             string  `json:"state"`               // "running", "stopped", etc.
+	TotalRps             float64 `json:"total_rps"`               // May also be "current_rps_total"
+	FailRatio            float64 `json:"fail_ratio"`              // Decimal 0-1, not percentage
+	CurrentUserCount     int     `json:"user_count"`              // Active users
+	State                string  `json:"state"`                   // "running", "stopped", etc.
+	WorkerCount          int     `json:"worker_count"`            // Connected workers, only reported by a master
 	TotalAvgResponseTime float64 `json:"total_avg_response_time"` // Average across all requests
 }
 
+// LocustStat is one entry of the /stats/requests response, an endpoint or the "Aggregated" total
+type LocustStat struct {
+	Method                  string  `json:"method"`
+	Name                    string  `json:"name"`
+	NumRequests             int64   `json:"num_requests"`
+	NumFailures             int64   `json:"num_failures"`
+	AvgResponseTime         float64 `json:"avg_response_time"`
+	MinResponseTime         float64 `json:"min_response_time"`
+	MaxResponseTime         float64 `json:"max_response_time"`
+	MedianResponseTime      float64 `json:"median_response_time"`
+	NinetiethResponseTime   float64 `json:"ninetieth_response_time"`    // Reported by Locust 2.x alongside the chart percentiles
+	NinetyNinthResponseTime float64 `json:"ninety_ninth_response_time"` // Reported by Locust 2.x alongside the chart percentiles
+	CurrentRps              float64 `json:"current_rps"`
+	CurrentFailPerSec       float64 `json:"current_fail_per_sec"`
+	// Response time percentiles by fraction, from Locust's response_time_percentile_<fraction> fields;
+	// which fractions are reported depends on the master's PERCENTILES_TO_CHART setting
+	Percentiles map[float64]float64 `json:"-"`
+}
+
+// percentileFieldPrefix starts the name of the percentile fields of a stats entry, e.g. response_time_percentile_0.95
+const percentileFieldPrefix = "response_time_percentile_"
+
+// UnmarshalJSON decodes a stats entry, collecting its response_time_percentile_* fields
+func (s *LocustStat) UnmarshalJSON(data []byte) error {
+	type plain LocustStat
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for key, raw := range fields {
+		suffix, ok := strings.CutPrefix(key, percentileFieldPrefix)
+		if !ok {
+			continue
+		}
+		fraction, err := strconv.ParseFloat(suffix, 64)
+		if err != nil {
+			continue
+		}
+		var value *float64
+		if err := json.Unmarshal(raw, &value); err != nil || value == nil {
+			continue
+		}
+		if s.Percentiles == nil {
+			s.Percentiles = make(map[float64]float64)
+		}
+		s.Percentiles[fraction] = *value
+	}
+	return nil
+}
+
+// percentile returns a response time percentile of the entry, falling back to the fixed fields
+// Locust reports for the median, 90th and 99th percentiles; 0 if the master did not report it
+func (s *LocustStat) percentile(fraction float64) float64 {
+	if value, ok := s.Percentiles[fraction]; ok {
+		return value
+	}
+	switch fraction {
+	case 0.50:
+		return s.MedianResponseTime
+	case 0.90:
+		return s.NinetiethResponseTime
+	case 0.99:
+		return s.NinetyNinthResponseTime
+	}
+	return 0
+}
+
 // convertToMetricSnapshot converts Locust stats response to our domain MetricSnapshot
 func convertToMetricSnapshot(stats *LocustStatsResponse) *domain.MetricSnapshot {
 	snapshot := &domain.MetricSnapshot{
-		Timestamp:     time.Now().UnixMilli(), // Unix milliseconds
-		TotalRPS:      stats.TotalRps,
-		ErrorRate:     stats.FailRatio * 100, // Convert to percentage
-		CurrentUsers:  stats.CurrentUserCount,
-		RequestStats:  make(map[string]*domain.ReqStat),
+		Timestamp:    time.Now().UnixMilli(), // Unix milliseconds
+		TotalRPS:     stats.TotalRps,
+		ErrorRate:    stats.FailRatio * 100, // Convert to percentage
+		CurrentUsers: stats.CurrentUserCount,
+		RunnerState:  stats.State,
+		WorkerCount:  stats.WorkerCount,
+		RequestStats: make(map[string]*domain.ReqStat),
 	}
 
 	var totalRequests, totalFailures int64
 	var sumAvgResponseTime float64
-	var p50 float64
 	var numValidStats int
 
 	// Aggregate stats from individual endpoints
@@ -248,7 +379,11 @@ func convertToMetricSnapshot(stats *LocustStatsResponse) *domain.MetricSnapshot
 				snapshot.TotalRequests = stat.NumRequests
 				snapshot.TotalFailures = stat.NumFailures
 				snapshot.AverageResponseMs = stat.AvgResponseTime
-				snapshot.P50ResponseMs = stat.MedianResponseTime
+				snapshot.P50ResponseMs = stat.percentile(0.50)
+				snapshot.P90ResponseMs = stat.percentile(0.90)
+				snapshot.P95ResponseMs = stat.percentile(0.95)
+				snapshot.P99ResponseMs = stat.percentile(0.99)
+				snapshot.P999ResponseMs = stat.percentile(0.999)
 			}
 			continue
 		}
@@ -260,11 +395,6 @@ func convertToMetricSnapshot(stats *LocustStatsResponse) *domain.MetricSnapshot
 		if stat.NumRequests > 0 {
 			sumAvgResponseTime += stat.AvgResponseTime
 			numValidStats++
-
-			// Track highest median for percentile approximation
-			if stat.MedianResponseTime > p50 {
-				p50 = stat.MedianResponseTime
-			}
 		}
 
 		// Store per-request stats
@@ -278,6 +408,11 @@ func convertToMetricSnapshot(stats *LocustStatsResponse) *domain.MetricSnapshot
 			MinResponseTime:    stat.MinResponseTime,
 			MaxResponseTime:    stat.MaxResponseTime,
 			MedianResponseTime: stat.MedianResponseTime,
+			P50ResponseMs:      stat.percentile(0.50),
+			P90ResponseMs:      stat.percentile(0.90),
+			P95ResponseMs:      stat.percentile(0.95),
+			P99ResponseMs:      stat.percentile(0.99),
+			P999ResponseMs:     stat.percentile(0.999),
 			RequestsPerSec:     stat.CurrentRps,
 		}
 	}
@@ -292,15 +427,7 @@ func convertToMetricSnapshot(stats *LocustStatsResponse) *domain.MetricSnapshot
 		snapshot.AverageResponseMs = sumAvgResponseTime / float64(numValidStats)
 	}
 
-	if snapshot.P50ResponseMs == 0 {
-		snapshot.P50ResponseMs = p50
-	}
-
-	// Approximations for P95 and P99 if not provided
-	if snapshot.P50ResponseMs > 0 {
-		snapshot.P95ResponseMs = snapshot.P50ResponseMs * 1.5
-		snapshot.P99ResponseMs = snapshot.P50ResponseMs * 2.0
-	}
+	// Run-wide percentiles cannot be derived from endpoint percentiles: without an "Aggregated" entry they stay 0
 
 	// Use TotalAvgResponseTime if available
 	if stats.TotalAvgResponseTime > 0 {
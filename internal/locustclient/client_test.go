@@ -0,0 +1,93 @@
+package locustclient
+
+import (
+	"Load-manager-cli/internal/locusttest"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLocustStatDecodesPercentileFields(t *testing.T) {
+	var stat LocustStat
+	data := `{
+		"name": "/", "num_requests": 10, "median_response_time": 100, "ninetieth_response_time": 200,
+		"response_time_percentile_0.5": null,
+		"response_time_percentile_0.95": 250,
+		"response_time_percentile_0.999": 900,
+		"response_time_percentile_p99": 999
+	}`
+	if err := json.Unmarshal([]byte(data), &stat); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	tests := []struct {
+		fraction float64
+		want     float64
+	}{
+		{fraction: 0.50, want: 100}, // Null percentile field, falls back to the median
+		{fraction: 0.90, want: 200}, // Not charted, falls back to ninetieth_response_time
+		{fraction: 0.95, want: 250},
+		{fraction: 0.99, want: 0}, // Neither charted nor reported
+		{fraction: 0.999, want: 900},
+	}
+	for _, tt := range tests {
+		if got := stat.percentile(tt.fraction); got != tt.want {
+			t.Errorf("percentile(%g) = %g, want %g", tt.fraction, got, tt.want)
+		}
+	}
+	if stat.NumRequests != 10 || stat.MedianResponseTime != 100 {
+		t.Errorf("stat = %+v, want its regular fields decoded too", stat)
+	}
+}
+
+func TestConvertToMetricSnapshotLeavesPercentilesUnsetWithoutAggregated(t *testing.T) {
+	stats := &LocustStatsResponse{Stats: []LocustStat{
+		{Method: "GET", Name: "/fast", NumRequests: 90, AvgResponseTime: 10, Percentiles: map[float64]float64{0.95: 20}},
+		{Method: "GET", Name: "/slow", NumRequests: 10, AvgResponseTime: 500, Percentiles: map[float64]float64{0.95: 800}},
+	}}
+
+	snapshot := convertToMetricSnapshot(stats)
+
+	if snapshot.TotalRequests != 100 {
+		t.Errorf("total requests = %d, want the endpoints' 100", snapshot.TotalRequests)
+	}
+	if snapshot.P95ResponseMs != 0 {
+		t.Errorf("run p95 = %g, want 0 without an Aggregated entry", snapshot.P95ResponseMs)
+	}
+	if slow := snapshot.RequestStats["GET_/slow"]; slow == nil || slow.P95ResponseMs != 800 {
+		t.Errorf("/slow stats = %+v, want its own p95 of 800", slow)
+	}
+}
+
+func TestGetStatsReportsMasterPercentiles(t *testing.T) {
+	master := locusttest.NewMaster(locusttest.Options{Workers: 2})
+	t.Cleanup(master.Close)
+	master.SetStats(locusttest.Stats{
+		Entries: []locusttest.Stat{
+			{Method: "GET", Name: "/", NumRequests: 100, NumFailures: 5, AvgResponseTime: 50, MedianResponseTime: 40, P95ResponseTime: 120},
+		},
+		P90ResponseMs:  90,
+		P95ResponseMs:  150,
+		P99ResponseMs:  300,
+		P999ResponseMs: 700,
+	})
+
+	client := NewHTTPClientWithOptions(master.URL(), "", Options{Timeout: 5 * time.Second, MaxAttempts: 1})
+	snapshot, err := client.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+
+	if snapshot.P50ResponseMs != 40 || snapshot.P90ResponseMs != 90 || snapshot.P95ResponseMs != 150 ||
+		snapshot.P99ResponseMs != 300 || snapshot.P999ResponseMs != 700 {
+		t.Errorf("percentiles = %g/%g/%g/%g/%g, want the Aggregated entry's 40/90/150/300/700",
+			snapshot.P50ResponseMs, snapshot.P90ResponseMs, snapshot.P95ResponseMs, snapshot.P99ResponseMs, snapshot.P999ResponseMs)
+	}
+	if snapshot.TotalRequests != 100 || snapshot.ErrorRate != 5 || snapshot.WorkerCount != 2 {
+		t.Errorf("snapshot = %+v, want 100 requests at 5%% errors from 2 workers", snapshot)
+	}
+	if endpoint := snapshot.RequestStats["GET_/"]; endpoint == nil || endpoint.P95ResponseMs != 120 {
+		t.Errorf("endpoint stats = %+v, want its p95 of 120", endpoint)
+	}
+}
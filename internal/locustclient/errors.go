@@ -0,0 +1,47 @@
+package locustclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Kinds of failed Locust requests; every error the HTTP client returns for a failed request wraps one of them
+var (
+	ErrUnreachable = errors.New("locust master unreachable")              // No response: connection refused or reset, DNS failure, or a gateway error in front of the master
+	ErrTimeout     = errors.New("locust master timed out")                // The request did not complete within its timeout
+	ErrRejected    = errors.New("locust master rejected the request")     // The master answered with an error status
+	ErrCircuitOpen = errors.New("circuit breaker open for locust master") // The master failed repeatedly and is not called until its breaker lets a probe through
+)
+
+// IsClusterFailure reports whether an error means the master itself is down or too slow,
+// rather than the request being refused: worth moving the work to another cluster
+func IsClusterFailure(err error) bool {
+	return errors.Is(err, ErrUnreachable) || errors.Is(err, ErrTimeout) || errors.Is(err, ErrCircuitOpen)
+}
+
+// classifyTransportError returns the kind of a request that got no response
+func classifyTransportError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTimeout
+	}
+	return ErrUnreachable
+}
+
+// classifyStatus returns the kind of a request answered with a non-200 status
+// Gateway errors come from a proxy in front of the master, not from Locust
+func classifyStatus(status int) error {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return ErrUnreachable
+	case http.StatusGatewayTimeout:
+		return ErrTimeout
+	default:
+		return ErrRejected
+	}
+}
@@ -0,0 +1,117 @@
+package locustclient
+
+import (
+	"Load-manager-cli/internal/domain"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GetFailures retrieves the failures table of the current test: one group per endpoint and error
+// Calls the /stats/failures/csv endpoint, the only form Locust serves the table in besides its web UI
+func (c *HTTPClient) GetFailures(ctx context.Context) ([]domain.FailureGroup, error) {
+	body, err := c.send(ctx, request{
+		op:         "failures",
+		method:     http.MethodGet,
+		path:       "/stats/failures/csv",
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode failures response: %w", err)
+	}
+
+	// Columns are looked up by name: Method, Name, Error, Occurrences
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var failures []domain.FailureGroup
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode failures response: %w", err)
+		}
+		occurrences, err := strconv.ParseInt(field(record, "occurrences"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode failures response: invalid occurrences %q", field(record, "occurrences"))
+		}
+		failures = append(failures, domain.FailureGroup{
+			Method:      field(record, "method"),
+			Name:        field(record, "name"),
+			Error:       field(record, "error"),
+			Occurrences: occurrences,
+		})
+	}
+	return failures, nil
+}
+
+// GetExceptions retrieves the exceptions raised in user code during the current test, grouped by traceback
+// Calls the /exceptions endpoint
+func (c *HTTPClient) GetExceptions(ctx context.Context) ([]domain.ExceptionGroup, error) {
+	body, err := c.send(ctx, request{
+		op:         "exceptions",
+		method:     http.MethodGet,
+		path:       "/exceptions",
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Locust escapes messages and tracebacks for its web UI and joins the nodes into one string
+	var result struct {
+		Exceptions []struct {
+			Count     int64  `json:"count"`
+			Msg       string `json:"msg"`
+			Traceback string `json:"traceback"`
+			Nodes     string `json:"nodes"`
+		} `json:"exceptions"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode exceptions response: %w", err)
+	}
+
+	exceptions := make([]domain.ExceptionGroup, 0, len(result.Exceptions))
+	for _, exception := range result.Exceptions {
+		var nodes []string
+		for _, node := range strings.Split(exception.Nodes, ",") {
+			if node = strings.TrimSpace(node); node != "" {
+				nodes = append(nodes, node)
+			}
+		}
+		exceptions = append(exceptions, domain.ExceptionGroup{
+			Message:   html.UnescapeString(exception.Msg),
+			Traceback: html.UnescapeString(exception.Traceback),
+			Count:     exception.Count,
+			Nodes:     nodes,
+		})
+	}
+	return exceptions, nil
+}
@@ -0,0 +1,169 @@
+package locustclient
+
+import (
+	"Load-manager-cli/internal/domain"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Options configure how an HTTP client calls its Locust master
+// Zero values take the defaults
+type Options struct {
+	Timeout          time.Duration // Timeout of each attempt (default: 30s)
+	MaxAttempts      int           // Attempts of idempotent calls, including the first (default: 3)
+	InitialBackoff   time.Duration // Wait before the first retry, doubled for each further retry (default: 500ms)
+	MaxBackoff       time.Duration // Upper bound of the wait between retries (default: 5s)
+	BreakerThreshold int           // Consecutive unanswered requests that open the circuit breaker (default: 5)
+	BreakerOpen      time.Duration // How long an open breaker fails calls fast before letting a probe through (default: 30s)
+}
+
+// withDefaults returns the options with zero values replaced by the defaults
+func (o Options) withDefaults() Options {
+	if o.Timeout <= 0 {
+		o.Timeout = 30 * time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = 500 * time.Millisecond
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Second
+	}
+	if o.BreakerThreshold <= 0 {
+		o.BreakerThreshold = 5
+	}
+	if o.BreakerOpen <= 0 {
+		o.BreakerOpen = 30 * time.Second
+	}
+	return o
+}
+
+// request describes one call to the Locust master
+type request struct {
+	op          string // Name used in errors and logs, e.g. "swarm"
+	method      string
+	path        string
+	contentType string
+	body        []byte
+	idempotent  bool // Whether the call is safe to retry after an attempt that got no answer
+}
+
+// Circuit returns the state of the circuit breaker guarding the master
+func (c *HTTPClient) Circuit() *domain.CircuitStatus {
+	return c.breaker.status()
+}
+
+// send performs a call, retrying idempotent calls that got no answer with exponential backoff and jitter
+// Returns the response body of a 200 answer; errors wrap ErrUnreachable, ErrTimeout, ErrRejected or ErrCircuitOpen
+func (c *HTTPClient) send(ctx context.Context, req request) ([]byte, error) {
+	attempts := 1
+	if req.idempotent {
+		attempts = c.opts.MaxAttempts
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			wait := c.backoff(attempt - 1)
+			log.Printf("[Locust Client] Retrying %s in %v (attempt %d/%d): %v", req.op, wait, attempt, attempts, err)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%w: %s request abandoned while retrying: %w", ErrTimeout, req.op, err)
+			case <-time.After(wait):
+			}
+		}
+
+		var body []byte
+		body, err = c.attempt(ctx, req)
+		if err == nil {
+			return body, nil
+		}
+		if !IsClusterFailure(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		if !c.breakerAllows() {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// attempt sends a call once through the circuit breaker
+func (c *HTTPClient) attempt(ctx context.Context, req request) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.baseURL+req.path, bytes.NewReader(req.body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", req.op, err)
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if c.authToken != "" {
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.authToken))
+	}
+
+	if !c.breaker.allow() {
+		return nil, fmt.Errorf("%w: %s request not sent to %s", ErrCircuitOpen, req.op, c.baseURL)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		// A call its caller gave up on says nothing about the master
+		if errors.Is(ctx.Err(), context.Canceled) {
+			c.breaker.abandon()
+			return nil, fmt.Errorf("%s request cancelled: %w", req.op, err)
+		}
+		c.recordFailure()
+		return nil, fmt.Errorf("%w: failed to execute %s request: %w", classifyTransportError(err), req.op, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.recordFailure()
+		return nil, fmt.Errorf("%w: failed to read %s response: %w", classifyTransportError(err), req.op, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		kind := classifyStatus(resp.StatusCode)
+		if kind == ErrRejected {
+			c.breaker.success()
+		} else {
+			c.recordFailure()
+		}
+		return nil, fmt.Errorf("%w: %s request failed with status %d: %s", kind, req.op, resp.StatusCode, string(body))
+	}
+
+	c.breaker.success()
+	return body, nil
+}
+
+// recordFailure counts an unanswered request against the circuit breaker
+func (c *HTTPClient) recordFailure() {
+	if c.breaker.failure() {
+		log.Printf("[Locust Client] Circuit breaker opened for %s", c.baseURL)
+	}
+}
+
+// breakerAllows reports whether a retry would reach the master; no point in waiting out a backoff otherwise
+func (c *HTTPClient) breakerAllows() bool {
+	return c.breaker.status().State != domain.CircuitOpen
+}
+
+// backoff returns the wait before the given retry: exponential, capped, with the upper half jittered
+func (c *HTTPClient) backoff(retry int) time.Duration {
+	wait := c.opts.InitialBackoff << (retry - 1)
+	if wait <= 0 || wait > c.opts.MaxBackoff {
+		wait = c.opts.MaxBackoff
+	}
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,224 @@
+package locusttest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Callbacks the harness plugin sends to the control plane's /v1/internal/locust/* endpoints
+const (
+	CallbackTestStart = "test-start"
+	CallbackTestStop  = "test-stop"
+	CallbackMetrics   = "metrics"
+	CallbackRequests  = "requests"
+)
+
+// SentCallback is a callback the fake master sent, or dropped by an injected fault
+type SentCallback struct {
+	Kind    string
+	Payload []byte
+	Status  int   // Status the control plane answered with, 0 if the callback failed or was dropped
+	Err     error // Transport error or non-200 status
+	Dropped bool
+	At      time.Time
+}
+
+// Step is one callback of a scripted sequence
+type Step struct {
+	After       time.Duration // Wait before the callback, counted from the previous step
+	Stats       *Stats        // Replaces the reported stats before the callback, if set
+	Callback    string        // CallbackTestStart, CallbackMetrics, CallbackRequests or CallbackTestStop
+	AutoStopped bool          // Reported by a test-stop callback
+}
+
+// Sent returns the callbacks of a kind the fake master sent or dropped, oldest first
+// An empty kind returns the callbacks of every kind
+func (m *Master) Sent(kind string) []SentCallback {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sent []SentCallback
+	for _, callback := range m.sent {
+		if kind == "" || callback.Kind == kind {
+			sent = append(sent, callback)
+		}
+	}
+	return sent
+}
+
+// EmitTestStart sends a test-start callback for the current run context
+func (m *Master) EmitTestStart() error {
+	return m.emit(CallbackTestStart, false)
+}
+
+// EmitMetrics sends a metrics callback with the current stats
+func (m *Master) EmitMetrics() error {
+	return m.emit(CallbackMetrics, false)
+}
+
+// EmitRequests sends a requests callback with the stats' samples, which it clears like the plugin drains its buffer
+func (m *Master) EmitRequests() error {
+	return m.emit(CallbackRequests, false)
+}
+
+// EmitTestStop sends a test-stop callback with the current stats as final metrics
+// The runner state is left alone: use the /stop endpoint to stop the test itself
+func (m *Master) EmitTestStop(autoStopped bool) error {
+	return m.emit(CallbackTestStop, autoStopped)
+}
+
+// Play sends a scripted sequence of callbacks, stopping at the first failed callback or when ctx is done
+// Dropped callbacks are not failures
+func (m *Master) Play(ctx context.Context, steps []Step) error {
+	for i, step := range steps {
+		if step.After > 0 {
+			timer := time.NewTimer(step.After)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if step.Stats != nil {
+			m.SetStats(*step.Stats)
+		}
+		if err := m.emit(step.Callback, step.AutoStopped); err != nil {
+			return fmt.Errorf("step %d (%s) failed: %w", i, step.Callback, err)
+		}
+	}
+	return nil
+}
+
+// emitAsync sends a callback in the background, like the plugin does from Locust's event handlers
+func (m *Master) emitAsync(kind string, autoStopped bool) {
+	if m.opts.ControlPlaneURL == "" {
+		return
+	}
+
+	m.callbacks.Add(1)
+	go func() {
+		defer m.callbacks.Done()
+		if err := m.emit(kind, autoStopped); err != nil {
+			log.Printf("[Fake Locust] Failed to send %s callback: %v", kind, err)
+		}
+	}()
+}
+
+// emit builds a callback the way the plugin does and sends it, applying any injected callback fault
+func (m *Master) emit(kind string, autoStopped bool) error {
+	if m.opts.ControlPlaneURL == "" {
+		return fmt.Errorf("no control plane URL configured")
+	}
+
+	m.mu.Lock()
+	payload, err := m.callbackPayload(kind, autoStopped)
+	fault := m.takeFault(m.callbackFaults, kind)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	callback := SentCallback{Kind: kind, Payload: payload}
+
+	if fault != nil {
+		if !fault.wait(context.Background(), m.closed) {
+			return fmt.Errorf("master closed before the %s callback was sent", kind)
+		}
+		if fault.Drop {
+			callback.Dropped = true
+			callback.At = time.Now()
+			m.record(callback)
+			return nil
+		}
+	}
+
+	callback.Status, callback.Err = m.post(kind, payload)
+	callback.At = time.Now()
+	m.record(callback)
+	return callback.Err
+}
+
+// callbackPayload builds the JSON body of a callback; callers must hold m.mu
+func (m *Master) callbackPayload(kind string, autoStopped bool) ([]byte, error) {
+	users := 0
+	if m.state == StateRunning || m.state == StateSpawning {
+		users = m.users
+	}
+
+	var payload map[string]any
+	switch kind {
+	case CallbackTestStart:
+		payload = map[string]any{
+			"runId":    m.runContext.RunID,
+			"shardId":  m.runContext.ShardID,
+			"tenantId": m.runContext.TenantID,
+			"envId":    m.runContext.EnvID,
+		}
+	case CallbackMetrics:
+		payload = map[string]any{
+			"runId":   m.runContext.RunID,
+			"shardId": m.runContext.ShardID,
+			"metrics": m.stats.pluginMetrics(users),
+		}
+	case CallbackRequests:
+		payload = map[string]any{
+			"runId":      m.runContext.RunID,
+			"shardId":    m.runContext.ShardID,
+			"sampleRate": m.stats.SampleRate,
+			"samples":    m.stats.pluginSamples(),
+		}
+		m.stats.Samples = nil
+	case CallbackTestStop:
+		payload = map[string]any{
+			"runId":        m.runContext.RunID,
+			"shardId":      m.runContext.ShardID,
+			"tenantId":     m.runContext.TenantID,
+			"envId":        m.runContext.EnvID,
+			"finalMetrics": m.stats.pluginMetrics(users),
+			"autoStopped":  autoStopped,
+		}
+	default:
+		return nil, fmt.Errorf("unknown callback %q", kind)
+	}
+
+	return json.Marshal(payload)
+}
+
+// post sends a callback to the control plane
+func (m *Master) post(kind string, payload []byte) (int, error) {
+	endpoint := strings.TrimRight(m.opts.ControlPlaneURL, "/") + "/v1/internal/locust/" + kind
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s callback: %w", kind, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Locust-Token", m.opts.CallbackToken)
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send %s callback: %w", kind, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("%s callback failed with status %d: %s", kind, resp.StatusCode, string(body))
+	}
+	return resp.StatusCode, nil
+}
+
+func (m *Master) record(callback SentCallback) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, callback)
+}
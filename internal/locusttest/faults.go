@@ -0,0 +1,77 @@
+package locusttest
+
+import (
+	"context"
+	"time"
+)
+
+// Fault is a failure injected into an endpoint of the fake master or into one of its callbacks
+//
+// On an endpoint, Delay holds the response (longer than the client's timeout makes the request time out),
+// then Drop closes the connection without a response, or Status answers with that status instead of
+// handling the request. On a callback, Delay holds the callback and Drop discards it; Status is ignored.
+type Fault struct {
+	Status int           // HTTP status to answer with, e.g. 500; 0 handles the request normally after Delay
+	Delay  time.Duration // How long to wait before responding or sending the callback
+	Drop   bool          // Close the connection without a response, or never send the callback
+	Times  int           // Number of requests or callbacks the fault applies to; 0 applies it until cleared
+
+	used int
+}
+
+// InjectFault makes the fault apply to the next requests on an endpoint, replacing any previous fault on it
+func (m *Master) InjectFault(endpoint string, fault Fault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults[endpoint] = &fault
+}
+
+// InjectCallbackFault makes the fault apply to the next callbacks of a kind, replacing any previous fault on it
+func (m *Master) InjectCallbackFault(callback string, fault Fault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callbackFaults[callback] = &fault
+}
+
+// ClearFaults removes every injected fault
+func (m *Master) ClearFaults() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = make(map[string]*Fault)
+	m.callbackFaults = make(map[string]*Fault)
+}
+
+// takeFault returns the fault applying to the next use of key, counting it; callers must hold m.mu
+func (m *Master) takeFault(faults map[string]*Fault, key string) *Fault {
+	fault, ok := faults[key]
+	if !ok {
+		return nil
+	}
+
+	fault.used++
+	if fault.Times > 0 && fault.used >= fault.Times {
+		delete(faults, key)
+	}
+
+	applied := *fault
+	return &applied
+}
+
+// wait sleeps for the fault's delay; it returns false if the request was cancelled or the master closed first
+func (f *Fault) wait(ctx context.Context, closed <-chan struct{}) bool {
+	if f.Delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(f.Delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-closed:
+		return false
+	}
+}
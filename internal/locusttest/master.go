@@ -0,0 +1,571 @@
+// Package locusttest provides a fake Locust master, with the harness plugin installed, for tests
+// against the control plane without a real Locust deployment
+//
+// A Master serves the Locust web API and the plugin's /controlplane/* endpoints from an httptest
+// server, and sends the plugin's callbacks to the control plane's /v1/internal/locust/* endpoints.
+// Faults can be injected on both sides: slow or failing endpoints, and delayed or dropped callbacks.
+//
+//	master := locusttest.NewMaster(locusttest.Options{ControlPlaneURL: controlPlane.URL})
+//	defer master.Close()
+//	master.SetStats(locusttest.Stats{Entries: []locusttest.Stat{{Method: "GET", Name: "/", NumRequests: 100}}})
+//	master.InjectFault(locusttest.EndpointSwarm, locusttest.Fault{Status: http.StatusInternalServerError, Times: 1})
+package locusttest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PluginVersion is the harness plugin version the fake master reports
+const PluginVersion = "test"
+
+// Endpoints of the fake master, used to inject faults and inspect calls
+const (
+	EndpointSwarm      = "/swarm"
+	EndpointStop       = "/stop"
+	EndpointStats      = "/stats/requests"
+	EndpointFailures   = "/stats/failures/csv"
+	EndpointExceptions = "/exceptions"
+	EndpointSetContext = "/controlplane/set-context"
+	EndpointLoadScript = "/controlplane/load-script"
+	EndpointGetContext = "/controlplane/get-context"
+)
+
+// Runner states, as Locust reports them
+const (
+	StateReady    = "ready"
+	StateRunning  = "running"
+	StateStopped  = "stopped"
+	StateSpawning = "spawning"
+)
+
+// Options configure a fake master
+type Options struct {
+	ControlPlaneURL string        // Control plane the callbacks go to; empty disables callbacks, like the plugin
+	CallbackToken   string        // Sent as X-Locust-Token with every callback
+	Workers         int           // Workers reported as connected (default: 1)
+	MetricsInterval time.Duration // How often metrics are pushed while running; 0 only pushes them on EmitMetrics
+	CallbackTimeout time.Duration // Timeout of callback requests (default: 5s, like the plugin)
+}
+
+// RunContext is the run context the fake plugin holds, as set through /controlplane/set-context
+type RunContext struct {
+	RunID           string
+	ShardID         string
+	TenantID        string
+	EnvID           string
+	DurationSeconds string // Empty if the run has no duration
+}
+
+// WorkerAck overrides how a fake worker acknowledges the scripts the master forwards to it
+type WorkerAck struct {
+	SHA256  string // Hash the worker reports; empty reports the hash of the script it was sent
+	Failed  bool   // The worker reports it failed to load the script
+	Missing bool   // The worker never acknowledges the script
+}
+
+// WorkerID returns the client ID of the fake master's i-th worker, counted from 1
+func WorkerID(i int) string {
+	return fmt.Sprintf("worker-%d", i)
+}
+
+// Call is a request the fake master received
+type Call struct {
+	Endpoint string
+	Method   string
+	Form     url.Values // Form values of /swarm
+	Body     []byte     // JSON body of the /controlplane/* endpoints
+	At       time.Time
+}
+
+// Master is a fake Locust master with the harness plugin installed
+type Master struct {
+	server     *httptest.Server
+	opts       Options
+	httpClient *http.Client
+
+	mu             sync.Mutex
+	state          string
+	users          int
+	spawnRate      float64
+	host           string
+	runContext     RunContext
+	revisionID     string
+	scriptSHA256   string
+	workerAcks     map[string]string    // Worker -> hash it acknowledged for the script loaded last
+	ackOverrides   map[string]WorkerAck // Worker -> how it acknowledges scripts, if not like the master
+	stats          Stats
+	autoStopped    bool
+	faults         map[string]*Fault
+	callbackFaults map[string]*Fault
+	calls          []Call
+	sent           []SentCallback
+	stopTimers     chan struct{} // Closed when the running test stops, ending its pusher and duration timer
+
+	closed    chan struct{} // Closed by Close, releasing handlers and callbacks held by delay faults
+	closeOnce sync.Once
+	callbacks sync.WaitGroup
+}
+
+// NewMaster starts a fake master; callers must Close it
+func NewMaster(opts Options) *Master {
+	if opts.Workers == 0 {
+		opts.Workers = 1
+	}
+	if opts.CallbackTimeout == 0 {
+		opts.CallbackTimeout = 5 * time.Second
+	}
+
+	m := &Master{
+		opts:           opts,
+		httpClient:     &http.Client{Timeout: opts.CallbackTimeout},
+		state:          StateReady,
+		faults:         make(map[string]*Fault),
+		callbackFaults: make(map[string]*Fault),
+		closed:         make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(EndpointSwarm, m.handle(EndpointSwarm, http.MethodPost, m.swarm))
+	mux.HandleFunc(EndpointStop, m.handle(EndpointStop, http.MethodGet, m.stop))
+	mux.HandleFunc(EndpointStats, m.handle(EndpointStats, http.MethodGet, m.statsRequests))
+	mux.HandleFunc(EndpointFailures, m.handle(EndpointFailures, http.MethodGet, m.statsFailures))
+	mux.HandleFunc(EndpointExceptions, m.handle(EndpointExceptions, http.MethodGet, m.exceptions))
+	mux.HandleFunc(EndpointSetContext, m.handle(EndpointSetContext, http.MethodPost, m.setContext))
+	mux.HandleFunc(EndpointLoadScript, m.handle(EndpointLoadScript, http.MethodPost, m.loadScript))
+	mux.HandleFunc(EndpointGetContext, m.handle(EndpointGetContext, http.MethodGet, m.getContext))
+	m.server = httptest.NewServer(mux)
+
+	return m
+}
+
+// URL returns the base URL of the fake master, to register it as a cluster's baseUrl
+func (m *Master) URL() string {
+	return m.server.URL
+}
+
+// Close stops the running test's background callbacks, waits for callbacks in flight and shuts the server down
+func (m *Master) Close() {
+	m.closeOnce.Do(func() {
+		m.mu.Lock()
+		m.endTest()
+		m.mu.Unlock()
+
+		close(m.closed)
+		m.callbacks.Wait()
+		m.server.Close()
+	})
+}
+
+// State returns the runner state: "ready" before the first swarm, then "running" or "stopped"
+func (m *Master) State() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Users returns the user count and spawn rate of the last swarm
+func (m *Master) Users() (int, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.users, m.spawnRate
+}
+
+// Host returns the host of the last swarm that sent one
+func (m *Master) Host() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.host
+}
+
+// RunContext returns the run context the fake plugin holds
+func (m *Master) RunContext() RunContext {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.runContext
+}
+
+// SetRunContext sets the run context as if the control plane had called /controlplane/set-context,
+// e.g. to emulate a master that was running a test before the control plane restarted
+func (m *Master) SetRunContext(runCtx RunContext) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runContext = runCtx
+}
+
+// Script returns the revision ID and SHA-256 of the script loaded last
+func (m *Master) Script() (string, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.revisionID, m.scriptSHA256
+}
+
+// SetWorkerAck changes how a worker acknowledges the scripts loaded from now on
+func (m *Master) SetWorkerAck(worker string, ack WorkerAck) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ackOverrides == nil {
+		m.ackOverrides = make(map[string]WorkerAck)
+	}
+	m.ackOverrides[worker] = ack
+}
+
+// Calls returns the requests the fake master received on an endpoint, oldest first
+// An empty endpoint returns the requests of every endpoint
+func (m *Master) Calls(endpoint string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var calls []Call
+	for _, call := range m.calls {
+		if endpoint == "" || call.Endpoint == endpoint {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// handle wraps an endpoint handler with method checks, call recording and fault injection
+func (m *Master) handle(endpoint, method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			respond(w, http.StatusMethodNotAllowed, map[string]any{"success": false, "error": "method not allowed"})
+			return
+		}
+
+		call := Call{Endpoint: endpoint, Method: r.Method, At: time.Now()}
+		if method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			call.Body = body
+			if form, err := url.ParseQuery(string(body)); err == nil && endpoint == EndpointSwarm {
+				call.Form = form
+			}
+		}
+
+		m.mu.Lock()
+		m.calls = append(m.calls, call)
+		fault := m.takeFault(m.faults, endpoint)
+		m.mu.Unlock()
+
+		if fault != nil {
+			if !fault.wait(r.Context(), m.closed) {
+				return
+			}
+			if fault.Drop {
+				dropConnection(w)
+				return
+			}
+			if fault.Status != 0 {
+				respond(w, fault.Status, map[string]any{"success": false, "error": "injected fault"})
+				return
+			}
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(call.Body))
+		next(w, r)
+	}
+}
+
+// swarm starts the test, or changes the users of the running test, like Locust's /swarm
+func (m *Master) swarm(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respond(w, http.StatusBadRequest, map[string]any{"success": false, "message": err.Error()})
+		return
+	}
+	users, err := strconv.Atoi(r.PostForm.Get("user_count"))
+	if err != nil {
+		respond(w, http.StatusBadRequest, map[string]any{"success": false, "message": "invalid user_count"})
+		return
+	}
+	spawnRate, err := strconv.ParseFloat(r.PostForm.Get("spawn_rate"), 64)
+	if err != nil {
+		respond(w, http.StatusBadRequest, map[string]any{"success": false, "message": "invalid spawn_rate"})
+		return
+	}
+
+	m.mu.Lock()
+	m.users = users
+	m.spawnRate = spawnRate
+	if host := r.PostForm.Get("host"); host != "" {
+		m.host = host
+	}
+	starting := m.state != StateRunning
+	if starting {
+		m.state = StateRunning
+		m.autoStopped = false
+		m.startTest()
+	}
+	host := m.host
+	m.mu.Unlock()
+
+	if starting {
+		m.emitAsync(CallbackTestStart, false)
+	}
+
+	respond(w, http.StatusOK, map[string]any{"success": true, "message": "Swarming started", "host": host})
+}
+
+// stop stops the running test and sends the test-stop callback, like Locust's /stop with the plugin
+func (m *Master) stop(w http.ResponseWriter, r *http.Request) {
+	m.stopTest(false)
+	respond(w, http.StatusOK, map[string]any{"success": true, "message": "Test stopped"})
+}
+
+// statsRequests reports the configured stats in the format of Locust's /stats/requests
+func (m *Master) statsRequests(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	stats, state, users, workers := m.stats, m.state, m.users, m.opts.Workers
+	m.mu.Unlock()
+
+	if state != StateRunning && state != StateSpawning {
+		users = 0
+	}
+	respond(w, http.StatusOK, stats.locustResponse(state, users, workers))
+}
+
+// statsFailures reports the configured failures in the format of Locust's /stats/failures/csv
+func (m *Master) statsFailures(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	stats := m.stats
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+	w.Write(stats.failuresCSV())
+}
+
+// exceptions reports the configured exceptions in the format of Locust's /exceptions
+func (m *Master) exceptions(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	stats := m.stats
+	m.mu.Unlock()
+
+	respond(w, http.StatusOK, stats.exceptionsResponse())
+}
+
+// setContext stores the run context, like the plugin's /controlplane/set-context
+func (m *Master) setContext(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RunID           string `json:"runId"`
+		ShardID         string `json:"shardId"`
+		TenantID        string `json:"tenantId"`
+		EnvID           string `json:"envId"`
+		DurationSeconds *int   `json:"durationSeconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respond(w, http.StatusBadRequest, map[string]any{"success": false, "error": err.Error()})
+		return
+	}
+
+	runCtx := RunContext{
+		RunID:    payload.RunID,
+		ShardID:  payload.ShardID,
+		TenantID: payload.TenantID,
+		EnvID:    payload.EnvID,
+	}
+	if payload.DurationSeconds != nil {
+		runCtx.DurationSeconds = strconv.Itoa(*payload.DurationSeconds)
+	}
+
+	m.mu.Lock()
+	m.runContext = runCtx
+	m.mu.Unlock()
+
+	respond(w, http.StatusOK, map[string]any{
+		"success": true,
+		"message": "Run context set successfully",
+		"context": runCtx.plugin(),
+	})
+}
+
+// loadScript records the script revision and its hash, like the plugin's /controlplane/load-script
+// Its workers acknowledge the script right away, unless SetWorkerAck says otherwise
+func (m *Master) loadScript(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RevisionID    string `json:"revisionId"`
+		ScriptContent string `json:"scriptContent"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respond(w, http.StatusBadRequest, map[string]any{"success": false, "error": err.Error()})
+		return
+	}
+	script, err := base64.StdEncoding.DecodeString(payload.ScriptContent)
+	if err != nil {
+		respond(w, http.StatusBadRequest, map[string]any{"success": false, "error": err.Error()})
+		return
+	}
+	sum := sha256.Sum256(script)
+
+	m.mu.Lock()
+	m.revisionID = payload.RevisionID
+	m.scriptSHA256 = hex.EncodeToString(sum[:])
+	m.workerAcks = make(map[string]string, m.opts.Workers)
+	for i := 1; i <= m.opts.Workers; i++ {
+		ack := m.ackOverrides[WorkerID(i)]
+		switch {
+		case ack.Missing:
+		case ack.Failed:
+			m.workerAcks[WorkerID(i)] = ""
+		case ack.SHA256 != "":
+			m.workerAcks[WorkerID(i)] = ack.SHA256
+		default:
+			m.workerAcks[WorkerID(i)] = m.scriptSHA256
+		}
+	}
+	digest, workers := m.scriptSHA256, m.opts.Workers
+	m.mu.Unlock()
+
+	respond(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"revisionId": payload.RevisionID,
+		"sha256":     digest,
+		"workers":    workers,
+	})
+}
+
+// getContext reports the run context and script, like the plugin's /controlplane/get-context
+func (m *Master) getContext(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	runCtx, revisionID, digest := m.runContext, m.revisionID, m.scriptSHA256
+	workers := make([]string, 0, m.opts.Workers)
+	acks := make(map[string]string, len(m.workerAcks))
+	if revisionID != "" {
+		for i := 1; i <= m.opts.Workers; i++ {
+			workers = append(workers, WorkerID(i))
+		}
+		for worker, ack := range m.workerAcks {
+			acks[worker] = ack
+		}
+	}
+	m.mu.Unlock()
+
+	respond(w, http.StatusOK, map[string]any{
+		"success": true,
+		"context": runCtx.plugin(),
+		"script": map[string]any{
+			"revision_id": revisionID,
+			"sha256":      digest,
+			"workers":     workers,
+			"worker_acks": acks,
+		},
+		"pluginVersion": PluginVersion,
+	})
+}
+
+// startTest starts the running test's metrics pusher and duration timer; callers must hold m.mu
+func (m *Master) startTest() {
+	done := make(chan struct{})
+	m.stopTimers = done
+
+	if m.opts.MetricsInterval > 0 {
+		go m.pushMetrics(done, m.opts.MetricsInterval)
+	}
+	if seconds, err := strconv.Atoi(m.runContext.DurationSeconds); err == nil {
+		go m.stopAfter(done, time.Duration(seconds)*time.Second)
+	}
+}
+
+// endTest stops the running test's pusher and duration timer; callers must hold m.mu
+func (m *Master) endTest() {
+	if m.stopTimers != nil {
+		close(m.stopTimers)
+		m.stopTimers = nil
+	}
+}
+
+// stopTest stops the running test and sends the test-stop callback, if a test is running
+func (m *Master) stopTest(autoStopped bool) {
+	m.mu.Lock()
+	if m.state != StateRunning && m.state != StateSpawning {
+		m.mu.Unlock()
+		return
+	}
+	m.state = StateStopped
+	m.autoStopped = autoStopped
+	m.endTest()
+	m.mu.Unlock()
+
+	m.emitAsync(CallbackTestStop, autoStopped)
+}
+
+// pushMetrics sends a metrics callback every interval until the test stops
+func (m *Master) pushMetrics(done <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := m.EmitMetrics(); err != nil {
+				log.Printf("[Fake Locust] Failed to push metrics: %v", err)
+			}
+			m.mu.Lock()
+			sampled := len(m.stats.Samples) > 0
+			m.mu.Unlock()
+			if sampled {
+				if err := m.EmitRequests(); err != nil {
+					log.Printf("[Fake Locust] Failed to push request samples: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// stopAfter stops the test when its duration elapses, like the plugin's duration monitor
+func (m *Master) stopAfter(done <-chan struct{}, duration time.Duration) {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		m.stopTest(true)
+	}
+}
+
+// plugin returns the run context as the plugin reports it, keyed by its Python globals
+func (c RunContext) plugin() map[string]string {
+	return map[string]string{
+		"run_id":           c.RunID,
+		"shard_id":         c.ShardID,
+		"tenant_id":        c.TenantID,
+		"env_id":           c.EnvID,
+		"duration_seconds": c.DurationSeconds,
+	}
+}
+
+// dropConnection closes the connection of a request without writing a response
+func dropConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	conn.Close()
+}
+
+func respond(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("[Fake Locust] Error encoding JSON response: %v", err)
+	}
+}
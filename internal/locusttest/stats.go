@@ -0,0 +1,296 @@
+package locusttest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stat is the statistics of one endpoint, as Locust keeps them; response times are in milliseconds
+type Stat struct {
+	Method             string
+	Name               string
+	NumRequests        int64
+	NumFailures        int64
+	AvgResponseTime    float64
+	MinResponseTime    float64
+	MaxResponseTime    float64
+	MedianResponseTime float64
+	P90ResponseTime    float64
+	P95ResponseTime    float64
+	P99ResponseTime    float64
+	P999ResponseTime   float64
+	ResponseTimes      map[int64]int64 // Locust's response_times: rounded response time -> count; pushed by the plugin if set
+	CurrentRPS         float64
+	CurrentFailPerSec  float64
+}
+
+// Failure is a row of Locust's failures table: the failed requests of one endpoint with the same error
+type Failure struct {
+	Method      string
+	Name        string
+	Error       string
+	Occurrences int64
+}
+
+// Exception is a row of Locust's exceptions table: the exceptions raised in user code with the same traceback
+type Exception struct {
+	Message   string
+	Traceback string
+	Count     int64
+	Nodes     []string // Workers the exception was raised on
+}
+
+// Sample is a request the plugin sampled from Locust's request events
+type Sample struct {
+	Timestamp      time.Time
+	Method         string
+	Name           string
+	StatusCode     int
+	ResponseTimeMs float64
+	ResponseLength int64
+	Exception      string // Empty if the request succeeded
+}
+
+// Stats is what the fake master reports from /stats/requests and in its metrics and test-stop callbacks
+// The aggregated entry is derived from the endpoints; its P90 to P99.9 are reported as set
+// Failures and exceptions are also served from /stats/failures/csv and /exceptions
+// Samples are sent, then cleared, by the requests callback
+type Stats struct {
+	Entries        []Stat
+	P90ResponseMs  float64
+	P95ResponseMs  float64
+	P99ResponseMs  float64
+	P999ResponseMs float64
+	Failures       []Failure
+	Exceptions     []Exception
+	Samples        []Sample
+	SampleRate     float64
+}
+
+// SetStats replaces the statistics the fake master reports
+func (m *Master) SetStats(stats Stats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = stats
+}
+
+// aggregated sums the endpoints into Locust's "Aggregated" entry
+// The median is approximated by the request-weighted mean of the endpoints' medians; response time
+// histograms add up, if every endpoint has one
+func (s Stats) aggregated() Stat {
+	total := Stat{
+		Name:             "Aggregated",
+		P90ResponseTime:  s.P90ResponseMs,
+		P95ResponseTime:  s.P95ResponseMs,
+		P99ResponseTime:  s.P99ResponseMs,
+		P999ResponseTime: s.P999ResponseMs,
+	}
+
+	var sumAvg, sumMedian float64
+	for _, stat := range s.Entries {
+		if stat.NumRequests > 0 {
+			if total.NumRequests == 0 || stat.MinResponseTime < total.MinResponseTime {
+				total.MinResponseTime = stat.MinResponseTime
+			}
+			total.MaxResponseTime = math.Max(total.MaxResponseTime, stat.MaxResponseTime)
+		}
+		total.NumRequests += stat.NumRequests
+		total.NumFailures += stat.NumFailures
+		total.CurrentRPS += stat.CurrentRPS
+		total.CurrentFailPerSec += stat.CurrentFailPerSec
+		sumAvg += stat.AvgResponseTime * float64(stat.NumRequests)
+		sumMedian += stat.MedianResponseTime * float64(stat.NumRequests)
+	}
+
+	for i, stat := range s.Entries {
+		if stat.ResponseTimes == nil {
+			total.ResponseTimes = nil
+			break
+		}
+		if i == 0 {
+			total.ResponseTimes = make(map[int64]int64)
+		}
+		for responseTime, count := range stat.ResponseTimes {
+			total.ResponseTimes[responseTime] += count
+		}
+	}
+
+	if total.NumRequests > 0 {
+		total.AvgResponseTime = sumAvg / float64(total.NumRequests)
+		total.MedianResponseTime = sumMedian / float64(total.NumRequests)
+	}
+	return total
+}
+
+// locustResponse renders the stats as Locust's /stats/requests response
+func (s Stats) locustResponse(state string, users, workers int) map[string]any {
+	total := s.aggregated()
+
+	entries := make([]map[string]any, 0, len(s.Entries)+1)
+	for _, stat := range append(append([]Stat(nil), s.Entries...), total) {
+		// Like a master with PERCENTILES_TO_CHART set to 0.5, 0.9, 0.95, 0.99 and 0.999
+		entries = append(entries, map[string]any{
+			"method":                         stat.Method,
+			"name":                           stat.Name,
+			"num_requests":                   stat.NumRequests,
+			"num_failures":                   stat.NumFailures,
+			"avg_response_time":              stat.AvgResponseTime,
+			"min_response_time":              stat.MinResponseTime,
+			"max_response_time":              stat.MaxResponseTime,
+			"median_response_time":           stat.MedianResponseTime,
+			"ninetieth_response_time":        stat.P90ResponseTime,
+			"ninety_ninth_response_time":     stat.P99ResponseTime,
+			"response_time_percentile_0.5":   stat.MedianResponseTime,
+			"response_time_percentile_0.9":   stat.P90ResponseTime,
+			"response_time_percentile_0.95":  stat.P95ResponseTime,
+			"response_time_percentile_0.99":  stat.P99ResponseTime,
+			"response_time_percentile_0.999": stat.P999ResponseTime,
+			"current_rps":                    stat.CurrentRPS,
+			"current_fail_per_sec":           stat.CurrentFailPerSec,
+		})
+	}
+
+	failRatio := 0.0
+	if total.NumRequests > 0 {
+		failRatio = float64(total.NumFailures) / float64(total.NumRequests)
+	}
+
+	return map[string]any{
+		"stats":                   entries,
+		"total_rps":               total.CurrentRPS,
+		"fail_ratio":              failRatio,
+		"user_count":              users,
+		"state":                   state,
+		"worker_count":            workers,
+		"total_avg_response_time": total.AvgResponseTime,
+	}
+}
+
+// pluginMetrics renders the stats as the metrics the harness plugin pushes to the control plane
+func (s Stats) pluginMetrics(users int) map[string]any {
+	total := s.aggregated()
+
+	errorRate := 0.0
+	if total.NumRequests > 0 {
+		errorRate = float64(total.NumFailures) / float64(total.NumRequests) * 100
+	}
+
+	requestStats := make(map[string]any, len(s.Entries))
+	for _, stat := range s.Entries {
+		entry := map[string]any{
+			"method":             stat.Method,
+			"name":               stat.Name,
+			"numRequests":        stat.NumRequests,
+			"numFailures":        stat.NumFailures,
+			"avgResponseTime":    stat.AvgResponseTime,
+			"minResponseTime":    stat.MinResponseTime,
+			"maxResponseTime":    stat.MaxResponseTime,
+			"medianResponseTime": stat.MedianResponseTime,
+			"p50ResponseMs":      stat.MedianResponseTime,
+			"p90ResponseMs":      stat.P90ResponseTime,
+			"p95ResponseMs":      stat.P95ResponseTime,
+			"p99ResponseMs":      stat.P99ResponseTime,
+			"p999ResponseMs":     stat.P999ResponseTime,
+			"requestsPerSec":     stat.CurrentRPS,
+		}
+		if stat.ResponseTimes != nil {
+			entry["responseTimes"] = stat.ResponseTimes
+		}
+		requestStats[stat.Method+":"+stat.Name] = entry
+	}
+
+	metrics := map[string]any{
+		"timestamp":      time.Now().UTC().Format(time.RFC3339Nano),
+		"totalRps":       total.CurrentRPS,
+		"totalRequests":  total.NumRequests,
+		"totalFailures":  total.NumFailures,
+		"currentUsers":   users,
+		"errorRate":      errorRate,
+		"avgResponseMs":  total.AvgResponseTime,
+		"p50ResponseMs":  total.MedianResponseTime,
+		"p90ResponseMs":  total.P90ResponseTime,
+		"p95ResponseMs":  total.P95ResponseTime,
+		"p99ResponseMs":  total.P99ResponseTime,
+		"p999ResponseMs": total.P999ResponseTime,
+		"requestStats":   requestStats,
+	}
+	if total.ResponseTimes != nil {
+		metrics["responseTimes"] = total.ResponseTimes
+	}
+	if len(s.Failures) > 0 {
+		failures := make([]map[string]any, 0, len(s.Failures))
+		for _, failure := range s.Failures {
+			failures = append(failures, map[string]any{
+				"method":      failure.Method,
+				"name":        failure.Name,
+				"error":       failure.Error,
+				"occurrences": failure.Occurrences,
+			})
+		}
+		metrics["failures"] = failures
+	}
+	if len(s.Exceptions) > 0 {
+		exceptions := make([]map[string]any, 0, len(s.Exceptions))
+		for _, exception := range s.Exceptions {
+			exceptions = append(exceptions, map[string]any{
+				"msg":       exception.Message,
+				"traceback": exception.Traceback,
+				"count":     exception.Count,
+				"nodes":     exception.Nodes,
+			})
+		}
+		metrics["exceptions"] = exceptions
+	}
+	return metrics
+}
+
+// pluginSamples renders the samples as the batch the harness plugin pushes to the control plane
+func (s Stats) pluginSamples() []map[string]any {
+	samples := make([]map[string]any, 0, len(s.Samples))
+	for _, sample := range s.Samples {
+		entry := map[string]any{
+			"timestamp":      sample.Timestamp.UnixMilli(),
+			"method":         sample.Method,
+			"name":           sample.Name,
+			"statusCode":     sample.StatusCode,
+			"responseTimeMs": sample.ResponseTimeMs,
+			"responseLength": sample.ResponseLength,
+		}
+		if sample.Exception != "" {
+			entry["exception"] = sample.Exception
+		}
+		samples = append(samples, entry)
+	}
+	return samples
+}
+
+// failuresCSV renders the failures as Locust's /stats/failures/csv response
+func (s Stats) failuresCSV() []byte {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"Method", "Name", "Error", "Occurrences"})
+	for _, failure := range s.Failures {
+		writer.Write([]string{failure.Method, failure.Name, failure.Error, strconv.FormatInt(failure.Occurrences, 10)})
+	}
+	writer.Flush()
+	return buf.Bytes()
+}
+
+// exceptionsResponse renders the exceptions as Locust's /exceptions response, escaped for its web UI
+func (s Stats) exceptionsResponse() map[string]any {
+	exceptions := make([]map[string]any, 0, len(s.Exceptions))
+	for _, exception := range s.Exceptions {
+		exceptions = append(exceptions, map[string]any{
+			"count":     exception.Count,
+			"msg":       html.EscapeString(exception.Message),
+			"traceback": html.EscapeString(exception.Traceback),
+			"nodes":     strings.Join(exception.Nodes, ", "),
+		})
+	}
+	return map[string]any{"exceptions": exceptions, "total": len(exceptions)}
+}
@@ -162,27 +162,47 @@ This plugin is automatically injected by the Harness platform.
 """
 
 import os
+import sys
+import base64
+import hashlib
+import inspect
 import logging
+import tempfile
+import importlib.util
+import random
+import time
+from collections import deque
 import requests
 import gevent
 from typing import Optional
-from locust import events
+from locust import events, User
 from locust.env import Environment
+from locust.runners import MasterRunner, WorkerRunner, STATE_MISSING
+from locust.stats import StatsError
 
 logging.basicConfig(level=logging.INFO, format='%(asctime)s - %(name)s - %(levelname)s - %(message)s')
 logger = logging.getLogger(__name__)
 
+PLUGIN_VERSION = "1.6.1"
 CONTROL_PLANE_URL = os.getenv("CONTROL_PLANE_URL", "")
 CONTROL_PLANE_TOKEN = os.getenv("CONTROL_PLANE_TOKEN", "")
 METRICS_PUSH_INTERVAL = int(os.getenv("METRICS_PUSH_INTERVAL", "10"))
+REQUEST_SAMPLE_RATE = min(max(float(os.getenv("REQUEST_SAMPLE_RATE", "0.01")), 0.0), 1.0)
+SCRIPT_DIR = os.getenv("HARNESS_SCRIPT_DIR", tempfile.gettempdir())
 
 _run_context = {
     "run_id": os.getenv("RUN_ID", ""),
+    "shard_id": os.getenv("SHARD_ID", ""),
     "tenant_id": os.getenv("TENANT_ID", ""),
     "env_id": os.getenv("ENV_ID", ""),
     "duration_seconds": os.getenv("DURATION_SECONDS", ""),
 }
 
+_loaded_script = {"revision_id": "", "sha256": "", "workers": [], "worker_acks": {}}
+
+MAX_BUFFERED_SAMPLES = 1000
+_request_samples: deque = deque(maxlen=MAX_BUFFERED_SAMPLES)
+
 _metrics_greenlet: Optional[gevent.Greenlet] = None
 _duration_monitor_greenlet: Optional[gevent.Greenlet] = None
 _test_start_time: Optional[float] = None
@@ -198,12 +218,13 @@ def _is_control_plane_enabled():
 def on_test_start(environment: Environment, **kwargs):
     global _test_start_time
     _test_start_time = environment.runner.start_time
+    _request_samples.clear()
     if not _is_control_plane_enabled():
         return
     run_id = _run_context.get("run_id", "")
     logger.info(f"Test started, notifying control plane (RUN_ID={run_id})")
     try:
-        payload = {"runId": run_id, "tenantId": _run_context.get("tenant_id", ""), "envId": _run_context.get("env_id", "")}
+        payload = {"runId": run_id, "shardId": _run_context.get("shard_id", ""), "tenantId": _run_context.get("tenant_id", ""), "envId": _run_context.get("env_id", "")}
         url = f"{CONTROL_PLANE_URL}/v1/internal/locust/test-start"
         response = requests.post(url, json=payload, headers=_control_plane_headers(), timeout=10)
         response.raise_for_status()
@@ -222,8 +243,9 @@ def on_test_stop(environment: Environment, **kwargs):
     stop_reason = "auto" if _auto_stopped else "manual"
     logger.info(f"Test stopped ({stop_reason}), notifying control plane (RUN_ID={run_id})")
     try:
+        _push_request_samples()
         final_metrics = _collect_metrics(environment)
-        payload = {"runId": run_id, "tenantId": _run_context.get("tenant_id", ""), "envId": _run_context.get("env_id", ""), "finalMetrics": final_metrics, "autoStopped": _auto_stopped}
+        payload = {"runId": run_id, "shardId": _run_context.get("shard_id", ""), "tenantId": _run_context.get("tenant_id", ""), "envId": _run_context.get("env_id", ""), "finalMetrics": final_metrics, "autoStopped": _auto_stopped}
         url = f"{CONTROL_PLANE_URL}/v1/internal/locust/test-stop"
         response = requests.post(url, json=payload, headers=_control_plane_headers(), timeout=5)
         if response.status_code == 200:
@@ -235,6 +257,32 @@ def on_test_stop(environment: Environment, **kwargs):
         if _metrics_greenlet: gevent.kill(_metrics_greenlet)
         if _duration_monitor_greenlet: gevent.kill(_duration_monitor_greenlet)
 
+PERCENTILES = [(0.50, "p50ResponseMs"), (0.90, "p90ResponseMs"), (0.95, "p95ResponseMs"), (0.99, "p99ResponseMs"), (0.999, "p999ResponseMs")]
+
+def _percentiles(stat) -> dict:
+    result = {field: 0.0 for _, field in PERCENTILES}
+    if stat is None or not stat.num_requests: return result
+    try:
+        for fraction, field in PERCENTILES:
+            result[field] = float(stat.get_response_time_percentile(fraction) or 0)
+    except (TypeError, ValueError, AttributeError) as e:
+        logger.warning(f"Failed to get percentiles of {stat.name}: {e}")
+    return result
+
+def _response_times(stat) -> dict:
+    if stat is None or not getattr(stat, "response_times", None): return {}
+    return {str(int(response_time)): int(count) for response_time, count in stat.response_times.items()}
+
+MAX_FAILURE_GROUPS = 500
+
+def _failures(environment: Environment) -> list:
+    return [{"method": e.method or "", "name": e.name or "", "error": str(StatsError.parse_error(e.error)), "occurrences": int(e.occurrences)} for e in list(environment.stats.errors.values())[:MAX_FAILURE_GROUPS]]
+
+def _exceptions(environment: Environment) -> list:
+    runner = environment.runner
+    if runner is None or not getattr(runner, "exceptions", None): return []
+    return [{"msg": str(e.get("msg", "")), "traceback": str(e.get("traceback", "")), "count": int(e.get("count", 0)), "nodes": sorted(str(n) for n in e.get("nodes", ()))} for e in list(runner.exceptions.values())[:MAX_FAILURE_GROUPS]]
+
 def _collect_metrics(environment: Environment) -> dict:
     stats = environment.stats
     total_rps = stats.total.current_rps if stats.total else 0
@@ -242,12 +290,13 @@ def _collect_metrics(environment: Environment) -> dict:
     total_failures = stats.total.num_failures if stats.total else 0
     current_users = environment.runner.user_count if environment.runner else 0
     error_rate = (total_failures / total_requests * 100) if total_requests > 0 else 0
-    percentiles = stats.total.get_response_time_percentile([0.50, 0.95, 0.99]) if stats.total else {}
-    request_stats = []
+    request_stats = {}
     for stat in stats.entries.values():
         if stat.name != "Aggregated":
-            request_stats.append({"name": stat.name, "method": stat.method, "numRequests": stat.num_requests, "numFailures": stat.num_failures, "avgResponseTimeMs": stat.avg_response_time, "minResponseTimeMs": stat.min_response_time, "maxResponseTimeMs": stat.max_response_time})
-    return {"timestamp": int(environment.runner.start_time * 1000) if environment.runner else 0, "totalRps": total_rps, "totalRequests": total_requests, "totalFailures": total_failures, "currentUsers": current_users, "errorRate": error_rate, "p50ResponseMs": percentiles.get(0.50, 0), "p95ResponseMs": percentiles.get(0.95, 0), "p99ResponseMs": percentiles.get(0.99, 0), "requestStats": request_stats}
+            request_stats[f"{stat.method}:{stat.name}"] = {"name": stat.name, "method": stat.method, "numRequests": stat.num_requests, "numFailures": stat.num_failures, "avgResponseTime": stat.avg_response_time, "minResponseTime": stat.min_response_time or 0, "maxResponseTime": stat.max_response_time, "medianResponseTime": stat.median_response_time or 0, "requestsPerSec": stat.current_rps, **_percentiles(stat), "responseTimes": _response_times(stat)}
+    from datetime import datetime, timezone
+    timestamp = datetime.now(timezone.utc).isoformat().replace('+00:00', 'Z')
+    return {"timestamp": timestamp, "totalRps": total_rps, "totalRequests": total_requests, "totalFailures": total_failures, "currentUsers": current_users, "errorRate": error_rate, "avgResponseMs": stats.total.avg_response_time if stats.total else 0, **_percentiles(stats.total), "responseTimes": _response_times(stats.total), "requestStats": request_stats, "failures": _failures(environment), "exceptions": _exceptions(environment)}
 
 def _metrics_pusher(environment: Environment):
     if not _is_control_plane_enabled(): return
@@ -256,15 +305,44 @@ def _metrics_pusher(environment: Environment):
         try:
             gevent.sleep(METRICS_PUSH_INTERVAL)
             metrics = _collect_metrics(environment)
-            payload = {"runId": run_id, "metrics": metrics}
+            payload = {"runId": run_id, "shardId": _run_context.get("shard_id", ""), "metrics": metrics}
             url = f"{CONTROL_PLANE_URL}/v1/internal/locust/metrics"
             response = requests.post(url, json=payload, headers=_control_plane_headers(), timeout=5)
             response.raise_for_status()
+            _push_request_samples()
         except gevent.GreenletExit:
             break
         except Exception as e:
             logger.error(f"Error pushing metrics: {e}")
 
+@events.request.add_listener
+def on_request(request_type, name, response_time, response_length, response=None, exception=None, start_time=None, **kwargs):
+    if REQUEST_SAMPLE_RATE <= 0 or random.random() >= REQUEST_SAMPLE_RATE: return
+    response_time = float(response_time or 0)
+    if start_time is None: start_time = time.time() - response_time / 1000
+    _request_samples.append({"timestamp": int(start_time * 1000), "method": request_type or "", "name": name or "", "statusCode": int(getattr(response, "status_code", 0) or 0), "responseTimeMs": response_time, "responseLength": int(response_length or 0), "exception": str(StatsError.parse_error(exception)) if exception else ""})
+
+@events.report_to_master.add_listener
+def on_report_to_master(client_id, data, **kwargs):
+    data["harness_request_samples"] = list(_request_samples)
+    _request_samples.clear()
+
+@events.worker_report.add_listener
+def on_worker_report(client_id, data, **kwargs):
+    _request_samples.extend(data.get("harness_request_samples", []))
+
+def _push_request_samples():
+    if not _is_control_plane_enabled() or not _request_samples: return
+    samples = list(_request_samples)
+    _request_samples.clear()
+    payload = {"runId": _run_context.get("run_id", ""), "shardId": _run_context.get("shard_id", ""), "sampleRate": REQUEST_SAMPLE_RATE, "samples": samples}
+    try:
+        url = f"{CONTROL_PLANE_URL}/v1/internal/locust/requests"
+        response = requests.post(url, json=payload, headers=_control_plane_headers(), timeout=5)
+        response.raise_for_status()
+    except Exception as e:
+        logger.error(f"Error pushing request samples: {e}")
+
 def _duration_monitor(environment: Environment):
     global _auto_stopped
     duration_str = _run_context.get("duration_seconds", "")
@@ -288,6 +366,46 @@ def start_background_greenlets(environment: Environment, **kwargs):
     _metrics_greenlet = gevent.spawn(_metrics_pusher, environment)
     _duration_monitor_greenlet = gevent.spawn(_duration_monitor, environment)
 
+def _load_script(environment: Environment, revision_id: str, script_content: str) -> str:
+    source = base64.b64decode(script_content)
+    digest = hashlib.sha256(source).hexdigest()
+    module_name = "harness_locustfile_" + "".join(c if c.isalnum() else "_" for c in revision_id)
+    path = os.path.join(SCRIPT_DIR, f"{module_name}.py")
+    with open(path, "wb") as f: f.write(source)
+    spec = importlib.util.spec_from_file_location(module_name, path)
+    module = importlib.util.module_from_spec(spec)
+    sys.modules[module_name] = module
+    spec.loader.exec_module(module)
+    user_classes = [v for v in vars(module).values() if inspect.isclass(v) and issubclass(v, User) and v.__module__ == module_name and not getattr(v, "abstract", False)]
+    if not user_classes: raise ValueError(f"script revision {revision_id} does not define any User classes")
+    environment.user_classes = user_classes
+    environment.available_user_classes = {cls.__name__: cls for cls in user_classes}
+    _loaded_script.update({"revision_id": revision_id, "sha256": digest, "workers": [], "worker_acks": {}})
+    logger.info(f"Loaded script revision {revision_id} (sha256={digest})")
+    return digest
+
+def _on_worker_load_script(environment: Environment, msg, **kwargs):
+    revision_id = msg.data.get("revisionId", "")
+    try:
+        digest = _load_script(environment, revision_id, msg.data.get("scriptContent", ""))
+        environment.runner.send_message("harness_script_loaded", {"revisionId": revision_id, "sha256": digest})
+    except Exception as e:
+        logger.error(f"Failed to load script revision {revision_id} on worker: {e}")
+        environment.runner.send_message("harness_script_loaded", {"revisionId": revision_id, "error": str(e)})
+
+def _on_master_script_loaded(environment: Environment, msg, **kwargs):
+    if msg.data.get("revisionId") != _loaded_script["revision_id"]: return
+    _loaded_script["worker_acks"][msg.node_id] = msg.data.get("sha256", "")
+    if msg.data.get("sha256") != _loaded_script["sha256"]:
+        logger.error(f"Worker {msg.node_id} failed to load script revision: {msg.data.get('error', 'hash mismatch')}")
+
+@events.init.add_listener
+def register_script_messages(environment: Environment, **kwargs):
+    if isinstance(environment.runner, WorkerRunner):
+        environment.runner.register_message("harness_load_script", _on_worker_load_script)
+    elif isinstance(environment.runner, MasterRunner):
+        environment.runner.register_message("harness_script_loaded", _on_master_script_loaded)
+
 @events.init.add_listener
 def on_locust_init(environment: Environment, **kwargs):
     if not isinstance(environment.web_ui, object): return
@@ -298,6 +416,7 @@ def on_locust_init(environment: Environment, **kwargs):
         try:
             data = request.get_json()
             _run_context["run_id"] = data.get("runId", "")
+            _run_context["shard_id"] = data.get("shardId", "")
             _run_context["tenant_id"] = data.get("tenantId", "")
             _run_context["env_id"] = data.get("envId", "")
             _run_context["duration_seconds"] = str(data.get("durationSeconds", ""))
@@ -305,9 +424,22 @@ def on_locust_init(environment: Environment, **kwargs):
             return jsonify({"success": True, "context": _run_context}), 200
         except Exception as e:
             return jsonify({"success": False, "error": str(e)}), 400
+    @environment.web_ui.app.route("/controlplane/load-script", methods=["POST"])
+    def load_script():
+        try:
+            data = request.get_json()
+            revision_id = data.get("revisionId", "")
+            script_content = data.get("scriptContent", "")
+            digest = _load_script(environment, revision_id, script_content)
+            if isinstance(environment.runner, MasterRunner):
+                _loaded_script["workers"] = [c.id for c in environment.runner.clients.values() if c.state != STATE_MISSING]
+                environment.runner.send_message("harness_load_script", {"revisionId": revision_id, "scriptContent": script_content})
+            return jsonify({"success": True, "revisionId": revision_id, "sha256": digest, "workers": len(_loaded_script["workers"])}), 200
+        except Exception as e:
+            return jsonify({"success": False, "error": str(e)}), 400
     @environment.web_ui.app.route("/controlplane/get-context", methods=["GET"])
     def get_run_context():
-        return jsonify({"success": True, "context": _run_context}), 200
+        return jsonify({"success": True, "context": _run_context, "script": _loaded_script, "pluginVersion": PLUGIN_VERSION}), 200
     logger.info("Harness Control Plane plugin initialized")
 
 logger.info("Locust Harness Plugin loaded")
@@ -0,0 +1,25 @@
+package scriptprocessor
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// ScriptSHA256 returns the hex-encoded SHA-256 hash of a script
+// This is the same hash the Harness plugin reports after loading a script
+func ScriptSHA256(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// ScriptSHA256Base64 decodes a base64-encoded script and returns the hash of its content
+func ScriptSHA256Base64(encodedScript string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encodedScript)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode script: %w", err)
+	}
+
+	return ScriptSHA256(string(decoded)), nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// abortBreach tracks how long an abort rule has been breached by consecutive snapshots
+type abortBreach struct {
+	since     int64 // Unix milliseconds of the first breaching snapshot
+	snapshots int
+}
+
+// checkAbortRules advances the breach state of the run's abort rules with a new snapshot
+// and returns the trigger of the first rule that tripped, if any
+func (o *Orchestrator) checkAbortRules(run *domain.LoadTestRun, metrics *domain.MetricSnapshot) *domain.AbortTrigger {
+	if len(run.AbortRules) == 0 || metrics == nil {
+		return nil
+	}
+
+	timestamp := metrics.Timestamp
+	if timestamp == 0 {
+		timestamp = time.Now().UnixMilli()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	breaches := o.abortBreaches[run.ID]
+	if len(breaches) != len(run.AbortRules) {
+		breaches = make([]abortBreach, len(run.AbortRules))
+		o.abortBreaches[run.ID] = breaches
+	}
+
+	for i := range run.AbortRules {
+		rule := &run.AbortRules[i]
+
+		value, ok := liveMetric(rule.Metric, rule.Endpoint, metrics)
+		if !ok || !rule.Breached(value) {
+			breaches[i] = abortBreach{}
+			continue
+		}
+
+		if breaches[i].snapshots == 0 {
+			breaches[i].since = timestamp
+		}
+		breaches[i].snapshots++
+
+		if !abortRuleTripped(rule, breaches[i], timestamp) {
+			continue
+		}
+
+		return &domain.AbortTrigger{
+			Rule:          *rule,
+			Actual:        value,
+			BreachedSince: breaches[i].since,
+			Snapshots:     breaches[i].snapshots,
+			TriggeredAt:   time.Now().UnixMilli(),
+		}
+	}
+
+	return nil
+}
+
+// abortRuleTripped reports whether a breach has lasted long enough to abort the run
+func abortRuleTripped(rule *domain.AbortRule, breach abortBreach, timestamp int64) bool {
+	switch {
+	case rule.ForSeconds > 0:
+		return timestamp-breach.since >= int64(rule.ForSeconds)*1000
+	case rule.ConsecutiveSnapshots > 0:
+		return breach.snapshots >= rule.ConsecutiveSnapshots
+	default:
+		return true
+	}
+}
+
+// clearAbortBreaches forgets the breach state of a run
+func (o *Orchestrator) clearAbortBreaches(runID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.abortBreaches, runID)
+}
+
+// abortRun stops the swarm of a run whose abort rule tripped and marks it Aborted
+// Callers must hold the run lock
+func (o *Orchestrator) abortRun(run *domain.LoadTestRun, trigger *domain.AbortTrigger) error {
+	log.Printf("[Orchestrator] Aborting run %s: %s (actual %.2f)", run.ID, trigger.Rule.String(), trigger.Actual)
+
+	// Stop generating load before finalizing; a failed stop is logged but does not keep the run open
+	client, err := o.clientForRun(run)
+	if err != nil {
+		log.Printf("Warning: no Locust client to abort run %s: %v", run.ID, err)
+	} else {
+		ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
+		defer cancel()
+
+		if err := client.Stop(ctx); err != nil {
+			log.Printf("Warning: failed to stop Locust while aborting run %s: %v", run.ID, err)
+		}
+	}
+
+	run.AbortedBy = trigger
+	reason := "abort rule tripped: " + trigger.Rule.String()
+	if err := o.finalizeRun(run, domain.LoadTestRunStatusAborted, domain.RunActorControlPlane, reason); err != nil {
+		return fmt.Errorf("failed to abort test run: %w", err)
+	}
+
+	return nil
+}
+
+// liveMetric returns a metric from a pushed snapshot, from the endpoint's stats if one is given;
+// false if the snapshot does not carry it
+// Error rates and RPS cover the snapshot's interval, since its counters are cumulative; snapshots
+// without an interval fall back to the rates they reported
+func liveMetric(metric domain.ThresholdMetric, endpoint string, metrics *domain.MetricSnapshot) (float64, bool) {
+	if value, ok := intervalRate(metric, endpoint, metrics); ok {
+		return value, true
+	}
+
+	if endpoint == "" {
+		switch metric {
+		case domain.ThresholdMetricP50ResponseMs:
+			return metrics.P50ResponseMs, true
+		case domain.ThresholdMetricP95ResponseMs:
+			return metrics.P95ResponseMs, true
+		case domain.ThresholdMetricP99ResponseMs:
+			return metrics.P99ResponseMs, true
+		case domain.ThresholdMetricAvgResponseMs:
+			return metrics.AverageResponseMs, true
+		case domain.ThresholdMetricErrorRate:
+			return metrics.ErrorRate, true
+		case domain.ThresholdMetricRPS:
+			return metrics.TotalRPS, true
+		}
+		return 0, false
+	}
+
+	for _, stat := range metrics.RequestStats {
+		if stat == nil || (stat.Name != endpoint && stat.Method+" "+stat.Name != endpoint) {
+			continue
+		}
+
+		// Pushed stats carry Locust's raw fields, which are already in milliseconds
+		switch metric {
+		case domain.ThresholdMetricP50ResponseMs:
+			if stat.P50ResponseMs > 0 {
+				return stat.P50ResponseMs, true
+			}
+			return stat.MedianResponseTime, true
+		case domain.ThresholdMetricP95ResponseMs:
+			return stat.P95ResponseMs, true
+		case domain.ThresholdMetricP99ResponseMs:
+			return stat.P99ResponseMs, true
+		case domain.ThresholdMetricAvgResponseMs:
+			if stat.AvgResponseTimeMs > 0 {
+				return stat.AvgResponseTimeMs, true
+			}
+			return stat.AvgResponseTime, true
+		case domain.ThresholdMetricErrorRate:
+			if stat.NumRequests == 0 {
+				return 0, true
+			}
+			return float64(stat.NumFailures) / float64(stat.NumRequests) * 100, true
+		case domain.ThresholdMetricRPS:
+			return stat.RequestsPerSec, true
+		}
+		return 0, false
+	}
+
+	return 0, false
+}
+
+// intervalRate returns the error rate or RPS over a snapshot's interval, for the endpoint if one is given
+// false for other metrics, snapshots without an interval and an RPS over an interval of unknown length
+func intervalRate(metric domain.ThresholdMetric, endpoint string, metrics *domain.MetricSnapshot) (float64, bool) {
+	if metrics.Interval == nil || (metric != domain.ThresholdMetricErrorRate && metric != domain.ThresholdMetricRPS) {
+		return 0, false
+	}
+
+	requests, failures := metrics.Interval.Requests, metrics.Interval.Failures
+	if endpoint != "" {
+		found := false
+		for _, stat := range metrics.RequestStats {
+			if stat != nil && (stat.Name == endpoint || stat.Method+" "+stat.Name == endpoint) {
+				requests, failures = stat.IntervalRequests, stat.IntervalFailures
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+
+	if metric == domain.ThresholdMetricErrorRate {
+		if requests == 0 {
+			return 0, true
+		}
+		return float64(failures) / float64(requests) * 100, true
+	}
+
+	durationMs := metrics.Interval.DurationMs()
+	if durationMs == 0 {
+		return 0, false
+	}
+	return float64(requests) * 1000 / float64(durationMs), true
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"testing"
+	"time"
+)
+
+func TestCheckAbortRules(t *testing.T) {
+	endpointStats := func(p95 float64) map[string]*domain.ReqStat {
+		return map[string]*domain.ReqStat{"GET /slow": {Method: "GET", Name: "/slow", P95ResponseMs: p95}}
+	}
+
+	tests := []struct {
+		name      string
+		rule      domain.AbortRule
+		snapshots []*domain.MetricSnapshot
+		tripAt    int // Index of the snapshot that trips the rule, -1 if none does
+	}{
+		{
+			name:      "first breach trips",
+			rule:      domain.AbortRule{Metric: domain.ThresholdMetricP95ResponseMs, Operator: domain.ThresholdOperatorGreaterThan, Value: 500},
+			snapshots: []*domain.MetricSnapshot{{P95ResponseMs: 200}, {P95ResponseMs: 800}},
+			tripAt:    1,
+		},
+		{
+			name: "consecutive snapshots",
+			rule: domain.AbortRule{Metric: domain.ThresholdMetricP95ResponseMs, Operator: domain.ThresholdOperatorGreaterThan, Value: 500, ConsecutiveSnapshots: 2},
+			snapshots: []*domain.MetricSnapshot{
+				{P95ResponseMs: 800}, {P95ResponseMs: 200}, {P95ResponseMs: 800}, {P95ResponseMs: 900},
+			},
+			tripAt: 3,
+		},
+		{
+			name: "breach must last",
+			rule: domain.AbortRule{Metric: domain.ThresholdMetricP95ResponseMs, Operator: domain.ThresholdOperatorGreaterThan, Value: 500, ForSeconds: 60},
+			snapshots: []*domain.MetricSnapshot{
+				{Timestamp: 1_000, P95ResponseMs: 800}, {Timestamp: 31_000, P95ResponseMs: 800}, {Timestamp: 61_000, P95ResponseMs: 800},
+			},
+			tripAt: 2,
+		},
+		{
+			name: "recovery resets the breach",
+			rule: domain.AbortRule{Metric: domain.ThresholdMetricP95ResponseMs, Operator: domain.ThresholdOperatorGreaterThan, Value: 500, ForSeconds: 60},
+			snapshots: []*domain.MetricSnapshot{
+				{Timestamp: 1_000, P95ResponseMs: 800}, {Timestamp: 31_000, P95ResponseMs: 100}, {Timestamp: 61_000, P95ResponseMs: 800},
+			},
+			tripAt: -1,
+		},
+		{
+			name: "scoped to endpoint",
+			rule: domain.AbortRule{Metric: domain.ThresholdMetricP95ResponseMs, Operator: domain.ThresholdOperatorGreaterThan, Value: 500, Endpoint: "GET /slow"},
+			snapshots: []*domain.MetricSnapshot{
+				{P95ResponseMs: 900, RequestStats: endpointStats(100)}, {P95ResponseMs: 100, RequestStats: endpointStats(900)},
+			},
+			tripAt: 1,
+		},
+		{
+			name:      "endpoint missing from snapshot",
+			rule:      domain.AbortRule{Metric: domain.ThresholdMetricP95ResponseMs, Operator: domain.ThresholdOperatorGreaterThan, Value: 500, Endpoint: "GET /other"},
+			snapshots: []*domain.MetricSnapshot{{P95ResponseMs: 900, RequestStats: endpointStats(900)}},
+			tripAt:    -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestOrchestrator(t)
+			run := &domain.LoadTestRun{ID: "run-1", AbortRules: []domain.AbortRule{tt.rule}}
+
+			tripped := -1
+			for i, snapshot := range tt.snapshots {
+				if trigger := o.checkAbortRules(run, snapshot); trigger != nil {
+					tripped = i
+					break
+				}
+			}
+
+			if tripped != tt.tripAt {
+				t.Errorf("tripped at snapshot %d, want %d", tripped, tt.tripAt)
+			}
+		})
+	}
+}
+
+func TestAbortRuleAbortsRunningRun(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	req := createPendingRun(t, o, "run-1")
+	run, err := o.loadTestRunStore.Get("run-1")
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	run.AbortRules = []domain.AbortRule{{Metric: domain.ThresholdMetricErrorRate, Operator: domain.ThresholdOperatorGreaterThan, Value: 20}}
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+	if _, err := o.CreateTestRun(req); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+
+	now := time.Now()
+	healthy := &domain.MetricSnapshot{Timestamp: now.UnixMilli(), TotalRequests: 100, TotalFailures: 0}
+	if err := o.UpdateMetrics("run-1", "", healthy); err != nil {
+		t.Fatalf("UpdateMetrics: %v", err)
+	}
+	if run, _ := o.GetTestRun("run-1"); run.Status != domain.LoadTestRunStatusRunning {
+		t.Fatalf("status after a healthy push = %s, want %s", run.Status, domain.LoadTestRunStatusRunning)
+	}
+
+	// The counters are cumulative: 60 of the next 100 requests failed, although only 30% did overall
+	failing := &domain.MetricSnapshot{Timestamp: now.Add(time.Second).UnixMilli(), TotalRequests: 200, TotalFailures: 60, ErrorRate: 30}
+	if err := o.UpdateMetrics("run-1", "", failing); err != nil {
+		t.Fatalf("UpdateMetrics: %v", err)
+	}
+
+	run, err = o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusAborted {
+		t.Fatalf("status = %s, want %s", run.Status, domain.LoadTestRunStatusAborted)
+	}
+	if run.AbortedBy == nil || run.AbortedBy.Actual != 60 {
+		t.Errorf("aborted by %+v, want the error rate rule at 60%%", run.AbortedBy)
+	}
+	if state := master.State(); state != locusttest.StateStopped {
+		t.Errorf("master state = %s, want %s", state, locusttest.StateStopped)
+	}
+}
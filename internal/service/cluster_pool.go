@@ -0,0 +1,138 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"fmt"
+	"sort"
+)
+
+// poolKey identifies the pool of clusters serving an account, org, project and environment
+func poolKey(accountID, orgID, projectID, envID string) string {
+	return accountID + "/" + orgID + "/" + projectID + "/" + envID
+}
+
+// rankClusters returns the healthy clusters running the engine and serving the given scope ordered by preference:
+// idle clusters first, then those driving the fewest users
+// Clusters that tie take turns across calls
+func (o *Orchestrator) rankClusters(engineType domain.EngineType, accountID, orgID, projectID, envID string) ([]*domain.LocustCluster, error) {
+	var pool []*domain.LocustCluster
+	matched := 0
+	for _, cluster := range o.ListClusters() {
+		if !cluster.Runs(engineType) || !cluster.Matches(accountID, orgID, projectID, envID) {
+			continue
+		}
+		matched++
+		// Clusters that were never probed yet are given a chance
+		if cluster.Health != nil && !cluster.Health.Reachable {
+			continue
+		}
+		pool = append(pool, cluster)
+	}
+
+	if matched == 0 {
+		return nil, fmt.Errorf("no %s cluster found for account=%s, org=%s, project=%s, env=%s",
+			engineType.OrDefault(), accountID, orgID, projectID, envID)
+	}
+	if len(pool) == 0 {
+		return nil, fmt.Errorf("none of the %d %s clusters for account=%s, org=%s, project=%s, env=%s is reachable",
+			matched, engineType.OrDefault(), accountID, orgID, projectID, envID)
+	}
+
+	key := poolKey(accountID, orgID, projectID, envID)
+	holders := make(map[string]string)
+
+	o.mu.Lock()
+	offset := o.poolCursors[key] % len(pool)
+	o.poolCursors[key]++
+	for _, cluster := range pool {
+		if holder, busy := o.clusterHolders[cluster.ID]; busy {
+			holders[cluster.ID] = holder
+		}
+	}
+	o.mu.Unlock()
+
+	// Rotate the pool so ties are broken round-robin by the stable sort below
+	ranked := make([]*domain.LocustCluster, 0, len(pool))
+	ranked = append(ranked, pool[offset:]...)
+	ranked = append(ranked, pool[:offset]...)
+
+	users := make(map[string]int, len(holders))
+	for clusterID, holder := range holders {
+		users[clusterID] = o.activeUsers(holder)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		_, busyI := holders[ranked[i].ID]
+		_, busyJ := holders[ranked[j].ID]
+		if busyI != busyJ {
+			return !busyI
+		}
+		return users[ranked[i].ID] < users[ranked[j].ID]
+	})
+
+	return ranked, nil
+}
+
+// activeUsers returns the users a run drives on its cluster
+// Runs that did not report metrics yet count with their target users
+func (o *Orchestrator) activeUsers(runID string) int {
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		return 0
+	}
+	if run.LastMetrics != nil {
+		return run.LastMetrics.CurrentUsers
+	}
+	return run.TargetUsers
+}
+
+// admitRun picks the cluster of its pool a run starts on and holds it for the run
+// A run dispatched from a cluster's queue already holds that cluster and keeps it
+// Returns false with the least loaded cluster, on whose queue the run waits, if every cluster of the pool is busy
+func (o *Orchestrator) admitRun(run *domain.LoadTestRun, heldClusterID string) (*domain.LocustCluster, bool, error) {
+	if heldClusterID != "" {
+		cluster, err := o.GetCluster(heldClusterID)
+		if err != nil {
+			return nil, false, err
+		}
+		return cluster, o.acquireCluster(cluster.ID, run.ID), nil
+	}
+
+	candidates, err := o.rankClusters(run.Engine, run.AccountID, run.OrgID, run.ProjectID, run.EnvID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, cluster := range candidates {
+		if o.tryAcquireCluster(cluster.ID, run.ID) {
+			return cluster, true, nil
+		}
+	}
+
+	return candidates[0], o.acquireCluster(candidates[0].ID, run.ID), nil
+}
+
+// failoverCluster holds another idle, healthy cluster of the pool for a run its cluster failed to start
+// Clusters the run was already started on are skipped; returns nil if no cluster is left
+func (o *Orchestrator) failoverCluster(run *domain.LoadTestRun) *domain.LocustCluster {
+	candidates, err := o.rankClusters(run.Engine, run.AccountID, run.OrgID, run.ProjectID, run.EnvID)
+	if err != nil {
+		return nil
+	}
+
+	tried := make(map[string]bool, len(run.ClusterAttempts))
+	for _, attempt := range run.ClusterAttempts {
+		tried[attempt.ClusterID] = true
+	}
+
+	for _, cluster := range candidates {
+		if tried[cluster.ID] {
+			continue
+		}
+		if o.tryAcquireCluster(cluster.ID, run.ID) {
+			return cluster
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
+	"Load-manager-cli/internal/locustclient"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrInvalidCluster is returned when a cluster definition is incomplete
+	ErrInvalidCluster = errors.New("invalid cluster")
+	// ErrClusterNotFound is returned when the cluster is not registered
+	ErrClusterNotFound = errors.New("cluster not found")
+	// ErrClusterExists is returned when registering a cluster ID that is already taken
+	ErrClusterExists = errors.New("cluster already exists")
+	// ErrClusterInUse is returned when deleting a cluster that runs or queues a run
+	ErrClusterInUse = errors.New("cluster is in use")
+)
+
+// loadClusters seeds the registry with config clusters it does not know yet
+// and loads every registered cluster and its Locust client
+func (o *Orchestrator) loadClusters() {
+	if o.clusterStore == nil {
+		return
+	}
+
+	nowMillis := time.Now().UnixMilli()
+	for _, clusterCfg := range o.config.LocustClusters {
+		if _, err := o.clusterStore.Get(clusterCfg.ID); err == nil {
+			continue
+		}
+
+		cluster := &domain.LocustCluster{
+			ID:          clusterCfg.ID,
+			BaseURL:     clusterCfg.BaseURL,
+			Engine:      clusterCfg.Engine,
+			MetricsMode: clusterCfg.MetricsMode,
+			AccountID:   clusterCfg.AccountID,
+			OrgID:       clusterCfg.OrgID,
+			ProjectID:   clusterCfg.ProjectID,
+			EnvID:       clusterCfg.EnvID,
+			AuthToken:   clusterCfg.AuthToken,
+			Source:      domain.ClusterSourceConfig,
+			CreatedAt:   nowMillis,
+			CreatedBy:   "config",
+			UpdatedAt:   nowMillis,
+			UpdatedBy:   "config",
+		}
+		if err := o.clusterStore.Create(cluster); err != nil {
+			log.Printf("[Orchestrator] Failed to seed cluster %s from config: %v", cluster.ID, err)
+			continue
+		}
+		log.Printf("[Orchestrator] Seeded cluster %s from config", cluster.ID)
+	}
+
+	clusters, err := o.clusterStore.List()
+	if err != nil {
+		log.Printf("[Orchestrator] Failed to load clusters, using config clusters only: %v", err)
+		return
+	}
+
+	for _, cluster := range clusters {
+		o.setCluster(cluster)
+	}
+
+	log.Printf("[Orchestrator] Loaded %d Locust clusters", len(clusters))
+}
+
+// setCluster puts a cluster in the registry cache and (re)builds the executor of its engine
+// A cluster whose engine has no executor stays registered but cannot take runs
+func (o *Orchestrator) setCluster(cluster *domain.LocustCluster) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// A native generator lives in this process: replacing it would orphan the test it runs
+	if _, ok := o.clients[cluster.ID].(*engine.NativeExecutor); ok && cluster.Runs(domain.EngineNative) {
+		o.clusters[cluster.ID] = cluster
+		return
+	}
+
+	client, err := engine.New(cluster, o, o.locustClientOptions())
+	if err != nil {
+		log.Printf("[Orchestrator] Cluster %s cannot take runs: %v", cluster.ID, err)
+	}
+
+	o.clusters[cluster.ID] = cluster
+	if client != nil {
+		o.clients[cluster.ID] = client
+	} else {
+		delete(o.clients, cluster.ID)
+	}
+}
+
+// locustClientOptions returns the timeout, retry and circuit breaker options Locust masters are called with
+func (o *Orchestrator) locustClientOptions() locustclient.Options {
+	cfg := o.config.LocustClient
+	return locustclient.Options{
+		Timeout:          time.Duration(cfg.TimeoutSeconds) * time.Second,
+		MaxAttempts:      cfg.MaxAttempts,
+		InitialBackoff:   time.Duration(cfg.InitialBackoffMillis) * time.Millisecond,
+		MaxBackoff:       time.Duration(cfg.MaxBackoffMillis) * time.Millisecond,
+		BreakerThreshold: cfg.BreakerFailureThreshold,
+		BreakerOpen:      time.Duration(cfg.BreakerOpenSeconds) * time.Second,
+	}
+}
+
+// resolveCluster returns the cluster serving the given account, org, project and optional environment
+// A cluster dedicated to the environment is preferred over one serving the whole project
+// New runs pick a cluster of their pool with rankClusters instead
+func (o *Orchestrator) resolveCluster(accountID, orgID, projectID, envID string) (*domain.LocustCluster, error) {
+	var match *domain.LocustCluster
+	for _, cluster := range o.ListClusters() {
+		if !cluster.Matches(accountID, orgID, projectID, envID) {
+			continue
+		}
+		if envID != "" && cluster.EnvID == envID {
+			return cluster, nil
+		}
+		if match == nil {
+			match = cluster
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("no Locust cluster found for account=%s, org=%s, project=%s, env=%s", accountID, orgID, projectID, envID)
+	}
+	return match, nil
+}
+
+// ListClusters returns copies of all registered clusters ordered by ID
+func (o *Orchestrator) ListClusters() []*domain.LocustCluster {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	clusters := make([]*domain.LocustCluster, 0, len(o.clusters))
+	for _, cluster := range o.clusters {
+		clusters = append(clusters, copyCluster(cluster))
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].ID < clusters[j].ID
+	})
+
+	return clusters
+}
+
+// GetCluster returns a copy of a registered cluster
+func (o *Orchestrator) GetCluster(id string) (*domain.LocustCluster, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	cluster, ok := o.clusters[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClusterNotFound, id)
+	}
+	return copyCluster(cluster), nil
+}
+
+// RegisterCluster adds a cluster to the registry and starts using it right away
+func (o *Orchestrator) RegisterCluster(cluster *domain.LocustCluster) error {
+	if err := cluster.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCluster, err)
+	}
+	if _, err := o.GetCluster(cluster.ID); err == nil {
+		return fmt.Errorf("%w: %s", ErrClusterExists, cluster.ID)
+	}
+
+	if o.clusterStore != nil {
+		if err := o.clusterStore.Create(cluster); err != nil {
+			return fmt.Errorf("failed to store cluster: %w", err)
+		}
+	}
+	o.setCluster(copyCluster(cluster))
+
+	log.Printf("[Orchestrator] Registered cluster %s at %s", cluster.ID, cluster.BaseURL)
+
+	// Probe the new cluster now instead of waiting for the next health check round,
+	// then let it take over runs queued on the other clusters of its pool
+	go func(clusterID string) {
+		o.checkClusterHealth(clusterID)
+		o.dispatchNext(clusterID)
+	}(cluster.ID)
+	return nil
+}
+
+// UpdateCluster replaces a registered cluster's definition and rebuilds its Locust client
+// Runs already on the cluster keep running; the new definition applies to their next call to Locust
+func (o *Orchestrator) UpdateCluster(cluster *domain.LocustCluster) error {
+	if err := cluster.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCluster, err)
+	}
+	existing, err := o.GetCluster(cluster.ID)
+	if err != nil {
+		return err
+	}
+	cluster.Health = existing.Health
+
+	if o.clusterStore != nil {
+		if err := o.clusterStore.Update(cluster); err != nil {
+			return fmt.Errorf("failed to update cluster: %w", err)
+		}
+	}
+	o.setCluster(copyCluster(cluster))
+
+	log.Printf("[Orchestrator] Updated cluster %s", cluster.ID)
+	return nil
+}
+
+// DeleteCluster removes an idle cluster from the registry
+func (o *Orchestrator) DeleteCluster(id string) error {
+	o.mu.RLock()
+	_, exists := o.clusters[id]
+	holder, busy := o.clusterHolders[id]
+	queued := len(o.clusterQueues[id])
+	o.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrClusterNotFound, id)
+	}
+	if busy {
+		return fmt.Errorf("%w: run %s is using it", ErrClusterInUse, holder)
+	}
+	if queued > 0 {
+		return fmt.Errorf("%w: %d runs are queued on it", ErrClusterInUse, queued)
+	}
+
+	if o.clusterStore != nil {
+		if err := o.clusterStore.Delete(id); err != nil {
+			return fmt.Errorf("failed to delete cluster: %w", err)
+		}
+	}
+
+	o.mu.Lock()
+	delete(o.clusters, id)
+	delete(o.clients, id)
+	o.mu.Unlock()
+
+	log.Printf("[Orchestrator] Deleted cluster %s", id)
+	return nil
+}
+
+// runClusterHealthChecks probes every registered cluster periodically until the orchestrator is stopped
+func (o *Orchestrator) runClusterHealthChecks() {
+	interval := time.Duration(o.config.Orchestrator.ClusterHealthIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	o.checkAllClusters()
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-ticker.C:
+			o.checkAllClusters()
+		}
+	}
+}
+
+// checkAllClusters probes every registered cluster
+func (o *Orchestrator) checkAllClusters() {
+	for _, cluster := range o.ListClusters() {
+		o.checkClusterHealth(cluster.ID)
+	}
+}
+
+// checkClusterHealth probes a cluster's runner and plugin and records the result
+func (o *Orchestrator) checkClusterHealth(clusterID string) {
+	client, err := o.getClient(clusterID)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(o.ctx, 10*time.Second)
+	defer cancel()
+
+	nowMillis := time.Now().UnixMilli()
+	health := &domain.ClusterHealth{CheckedAt: nowMillis}
+
+	previous, err := o.GetCluster(clusterID)
+	if err != nil {
+		return
+	}
+	if previous.Health != nil {
+		health.LastSeenAt = previous.Health.LastSeenAt
+	}
+
+	stats, err := client.Stats(ctx)
+	if err != nil {
+		health.Error = err.Error()
+	} else {
+		health.Reachable = true
+		health.LastSeenAt = nowMillis
+		health.State = stats.RunnerState
+		health.WorkerCount = stats.WorkerCount
+
+		runCtx, err := client.State(ctx)
+		if err != nil {
+			health.Error = fmt.Sprintf("harness plugin not responding: %v", err)
+		} else {
+			health.PluginVersion = runCtx.AgentVersion
+		}
+	}
+
+	if guarded, ok := client.(engine.CircuitReporter); ok {
+		health.Circuit = guarded.Circuit()
+	}
+
+	if previous.Health != nil && previous.Health.Reachable != health.Reachable {
+		log.Printf("[Orchestrator] Cluster %s reachable changed to %v", clusterID, health.Reachable)
+	}
+
+	o.mu.Lock()
+	if cluster, ok := o.clusters[clusterID]; ok {
+		cluster.Health = health
+	}
+	o.mu.Unlock()
+
+	if o.clusterStore != nil {
+		if err := o.clusterStore.UpdateHealth(clusterID, health); err != nil {
+			log.Printf("Warning: failed to record health of cluster %s: %v", clusterID, err)
+		}
+	}
+}
+
+// copyCluster creates a copy of a cluster so the registry cache cannot be modified by callers
+func copyCluster(cluster *domain.LocustCluster) *domain.LocustCluster {
+	result := *cluster
+	if cluster.Health != nil {
+		health := *cluster.Health
+		if health.Circuit != nil {
+			circuit := *health.Circuit
+			health.Circuit = &circuit
+		}
+		result.Health = &health
+	}
+	return &result
+}
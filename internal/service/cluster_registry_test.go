@@ -0,0 +1,298 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryClusterStore is a ClusterRepository holding clusters in memory
+type memoryClusterStore struct {
+	mu       sync.Mutex
+	clusters map[string]*domain.LocustCluster
+}
+
+func newMemoryClusterStore(clusters ...*domain.LocustCluster) *memoryClusterStore {
+	s := &memoryClusterStore{clusters: make(map[string]*domain.LocustCluster)}
+	for _, cluster := range clusters {
+		s.clusters[cluster.ID] = cluster
+	}
+	return s
+}
+
+func (s *memoryClusterStore) Create(cluster *domain.LocustCluster) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clusters[cluster.ID]; ok {
+		return fmt.Errorf("cluster already exists: %s", cluster.ID)
+	}
+	s.clusters[cluster.ID] = copyCluster(cluster)
+	return nil
+}
+
+func (s *memoryClusterStore) Get(id string) (*domain.LocustCluster, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cluster, ok := s.clusters[id]
+	if !ok {
+		return nil, fmt.Errorf("cluster not found: %s", id)
+	}
+	return copyCluster(cluster), nil
+}
+
+func (s *memoryClusterStore) Update(cluster *domain.LocustCluster) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clusters[cluster.ID]; !ok {
+		return fmt.Errorf("cluster not found: %s", cluster.ID)
+	}
+	s.clusters[cluster.ID] = copyCluster(cluster)
+	return nil
+}
+
+func (s *memoryClusterStore) UpdateHealth(id string, health *domain.ClusterHealth) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cluster, ok := s.clusters[id]
+	if !ok {
+		return fmt.Errorf("cluster not found: %s", id)
+	}
+	copied := *health
+	cluster.Health = &copied
+	return nil
+}
+
+func (s *memoryClusterStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.clusters, id)
+	return nil
+}
+
+func (s *memoryClusterStore) List() ([]*domain.LocustCluster, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clusters := make([]*domain.LocustCluster, 0, len(s.clusters))
+	for _, cluster := range s.clusters {
+		clusters = append(clusters, copyCluster(cluster))
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].ID < clusters[j].ID
+	})
+	return clusters, nil
+}
+
+// testCluster returns a cluster of the test tenant served by a fake master
+func testCluster(id string, master *locusttest.Master) *domain.LocustCluster {
+	return &domain.LocustCluster{ID: id, BaseURL: master.URL(), AccountID: "acc", OrgID: "org", ProjectID: "proj", Source: domain.ClusterSourceAPI}
+}
+
+// waitForHealth polls a cluster until it has been probed, failing the test if it is not within a few seconds
+func waitForHealth(t *testing.T, o *Orchestrator, clusterID string) *domain.ClusterHealth {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cluster, err := o.GetCluster(clusterID)
+		if err != nil {
+			t.Fatalf("GetCluster: %v", err)
+		}
+		if cluster.Health != nil {
+			return cluster.Health
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cluster %s was never probed", clusterID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLoadClustersSeedsConfigClusters(t *testing.T) {
+	configured := newTestMaster(t, locusttest.Options{})
+	registered := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, configured)
+
+	clusters := newMemoryClusterStore(testCluster("registered", registered))
+	o.clusterStore = clusters
+
+	o.loadClusters()
+
+	seeded, err := clusters.Get("cluster-1")
+	if err != nil {
+		t.Fatalf("config cluster was not seeded: %v", err)
+	}
+	if seeded.Source != domain.ClusterSourceConfig || seeded.BaseURL != configured.URL() {
+		t.Errorf("seeded cluster = %+v, want cluster-1 from config", seeded)
+	}
+
+	var ids []string
+	for _, cluster := range o.ListClusters() {
+		ids = append(ids, cluster.ID)
+	}
+	if fmt.Sprint(ids) != "[cluster-1 registered]" {
+		t.Errorf("clusters = %v, want [cluster-1 registered]", ids)
+	}
+	if _, err := o.getClient("registered"); err != nil {
+		t.Errorf("registered cluster has no client: %v", err)
+	}
+
+	// A config cluster edited through the API keeps its stored definition
+	seeded.Name = "renamed"
+	if err := clusters.Update(seeded); err != nil {
+		t.Fatalf("failed to update cluster: %v", err)
+	}
+	o.loadClusters()
+	if cluster, _ := o.GetCluster("cluster-1"); cluster.Name != "renamed" {
+		t.Errorf("cluster-1 name = %q, want the stored renamed", cluster.Name)
+	}
+}
+
+func TestRegisterCluster(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{Workers: 3})
+	o := newTestOrchestrator(t)
+	clusters := newMemoryClusterStore()
+	o.clusterStore = clusters
+
+	if err := o.RegisterCluster(testCluster("new", master)); err != nil {
+		t.Fatalf("RegisterCluster: %v", err)
+	}
+	if _, err := clusters.Get("new"); err != nil {
+		t.Errorf("registered cluster was not stored: %v", err)
+	}
+
+	// The new cluster is probed right away
+	health := waitForHealth(t, o, "new")
+	if !health.Reachable || health.WorkerCount != 3 || health.State != locusttest.StateReady {
+		t.Errorf("health = %+v, want reachable and ready with 3 workers", health)
+	}
+
+	// And takes runs
+	run, err := o.CreateTestRun(createPendingRun(t, o, "run-1"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if run.ClusterID != "new" {
+		t.Errorf("run cluster = %s, want new", run.ClusterID)
+	}
+
+	tests := []struct {
+		name    string
+		cluster *domain.LocustCluster
+		want    error
+	}{
+		{name: "duplicate", cluster: testCluster("new", master), want: ErrClusterExists},
+		{name: "no base url", cluster: &domain.LocustCluster{ID: "bad", AccountID: "acc", OrgID: "org", ProjectID: "proj"}, want: ErrInvalidCluster},
+		{name: "no tenant", cluster: &domain.LocustCluster{ID: "bad", BaseURL: master.URL()}, want: ErrInvalidCluster},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := o.RegisterCluster(tt.cluster); !errors.Is(err, tt.want) {
+				t.Errorf("RegisterCluster error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateClusterKeepsHealth(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	moved := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t)
+	o.clusterStore = newMemoryClusterStore()
+
+	if err := o.RegisterCluster(testCluster("c1", master)); err != nil {
+		t.Fatalf("RegisterCluster: %v", err)
+	}
+	waitForHealth(t, o, "c1")
+
+	if err := o.UpdateCluster(testCluster("c1", moved)); err != nil {
+		t.Fatalf("UpdateCluster: %v", err)
+	}
+	cluster, err := o.GetCluster("c1")
+	if err != nil {
+		t.Fatalf("GetCluster: %v", err)
+	}
+	if cluster.BaseURL != moved.URL() || cluster.Health == nil {
+		t.Errorf("cluster = %+v, want the new base URL with the previous health", cluster)
+	}
+
+	// The rebuilt client calls the new master
+	o.checkClusterHealth("c1")
+	if len(moved.Calls(locusttest.EndpointStats)) == 0 {
+		t.Error("updated cluster was not probed at its new base URL")
+	}
+
+	if err := o.UpdateCluster(testCluster("missing", moved)); !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("UpdateCluster error = %v, want %v", err, ErrClusterNotFound)
+	}
+}
+
+func TestDeleteCluster(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+	clusters := newMemoryClusterStore()
+	o.clusterStore = clusters
+	o.loadClusters()
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if err := o.DeleteCluster("cluster-1"); !errors.Is(err, ErrClusterInUse) {
+		t.Fatalf("DeleteCluster of a busy cluster error = %v, want %v", err, ErrClusterInUse)
+	}
+
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+	if err := o.DeleteCluster("cluster-1"); err != nil {
+		t.Fatalf("DeleteCluster: %v", err)
+	}
+	if _, err := o.GetCluster("cluster-1"); !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("GetCluster error = %v, want %v", err, ErrClusterNotFound)
+	}
+	if _, err := clusters.Get("cluster-1"); err == nil {
+		t.Error("deleted cluster is still stored")
+	}
+	if err := o.DeleteCluster("cluster-1"); !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("second DeleteCluster error = %v, want %v", err, ErrClusterNotFound)
+	}
+}
+
+func TestCheckClusterHealth(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{Workers: 2})
+	o := newTestOrchestrator(t, master)
+	clusters := newMemoryClusterStore()
+	o.clusterStore = clusters
+	o.loadClusters()
+
+	o.checkClusterHealth("cluster-1")
+
+	cluster, err := o.GetCluster("cluster-1")
+	if err != nil {
+		t.Fatalf("GetCluster: %v", err)
+	}
+	health := cluster.Health
+	if health == nil || !health.Reachable || health.WorkerCount != 2 || health.PluginVersion != locusttest.PluginVersion || health.Error != "" {
+		t.Fatalf("health = %+v, want reachable with 2 workers and the plugin", health)
+	}
+	if stored, _ := clusters.Get("cluster-1"); stored.Health == nil || !stored.Health.Reachable {
+		t.Errorf("stored health = %+v, want it recorded", stored.Health)
+	}
+	lastSeen := health.LastSeenAt
+
+	master.Close()
+	o.checkClusterHealth("cluster-1")
+
+	cluster, _ = o.GetCluster("cluster-1")
+	health = cluster.Health
+	if health.Reachable || health.Error == "" {
+		t.Errorf("health = %+v, want unreachable with an error", health)
+	}
+	if health.LastSeenAt != lastSeen {
+		t.Errorf("last seen at %d, want the previous probe's %d", health.LastSeenAt, lastSeen)
+	}
+}
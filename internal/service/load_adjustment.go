@@ -0,0 +1,101 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	// ErrRunNotFound is returned when the run does not exist
+	ErrRunNotFound = errors.New("load test run not found")
+	// ErrRunNotRunning is returned when an operation requires a Running run
+	ErrRunNotRunning = errors.New("load test run is not running")
+)
+
+// AdjustLoadRequest changes the users and/or spawn rate of a running run
+type AdjustLoadRequest struct {
+	TargetUsers *int
+	SpawnRate   *float64
+	ChangedBy   string
+}
+
+// AdjustLoad re-issues the swarm of a running run with new users and/or spawn rate
+// and records the change on the run. A manual adjustment takes over from any load profile
+func (o *Orchestrator) AdjustLoad(runID string, req *AdjustLoadRequest) (*domain.LoadTestRun, error) {
+	if req.TargetUsers == nil && req.SpawnRate == nil {
+		return nil, fmt.Errorf("%w: targetUsers or spawnRate is required", ErrInvalidRunParameters)
+	}
+	if req.TargetUsers != nil && *req.TargetUsers < 0 {
+		return nil, fmt.Errorf("%w: targetUsers must not be negative", ErrInvalidRunParameters)
+	}
+	if req.SpawnRate != nil && *req.SpawnRate <= 0 {
+		return nil, fmt.Errorf("%w: spawnRate must be greater than zero", ErrInvalidRunParameters)
+	}
+
+	unlock := o.lockRun(runID)
+	defer unlock()
+
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRunNotFound, err)
+	}
+
+	if run.Status != domain.LoadTestRunStatusRunning {
+		return nil, fmt.Errorf("%w (current status: %s)", ErrRunNotRunning, run.Status)
+	}
+
+	change := domain.LoadChange{
+		PreviousUsers:     run.TargetUsers,
+		PreviousSpawnRate: run.SpawnRate,
+		TargetUsers:       run.TargetUsers,
+		SpawnRate:         run.SpawnRate,
+		ChangedBy:         req.ChangedBy,
+	}
+	if req.TargetUsers != nil {
+		change.TargetUsers = *req.TargetUsers
+	}
+	if req.SpawnRate != nil {
+		change.SpawnRate = *req.SpawnRate
+	}
+
+	client, err := o.clientForRun(run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Locust client: %w", err)
+	}
+
+	log.Printf("[Orchestrator] Adjusting load of run %s: users %d -> %d, spawnRate %.2f -> %.2f",
+		run.ID, change.PreviousUsers, change.TargetUsers, change.PreviousSpawnRate, change.SpawnRate)
+
+	ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
+	defer cancel()
+
+	if err := client.Scale(ctx, change.TargetUsers, change.SpawnRate); err != nil {
+		return nil, fmt.Errorf("failed to re-swarm on Locust: %w", err)
+	}
+
+	// The profile would otherwise override the new load at its next stage boundary, or when resumed after a restart
+	if run.LoadProfile != nil {
+		o.stopLoadProfile(run.ID)
+		run.ProfileOverride = true
+	}
+
+	nowMillis := time.Now().UnixMilli()
+	change.Timestamp = nowMillis
+	run.TargetUsers = change.TargetUsers
+	run.SpawnRate = change.SpawnRate
+	run.LoadChanges = append(run.LoadChanges, change)
+	run.UpdatedAt = nowMillis
+	if req.ChangedBy != "" {
+		run.UpdatedBy = req.ChangedBy
+	}
+
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		return nil, fmt.Errorf("failed to record load change: %w", err)
+	}
+
+	return run, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAdjustLoadReswarmsAndRecordsChange(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+
+	users := 25
+	run, err := o.AdjustLoad("run-1", &AdjustLoadRequest{TargetUsers: &users, ChangedBy: "alice"})
+	if err != nil {
+		t.Fatalf("AdjustLoad: %v", err)
+	}
+
+	// The spawn rate is kept when only the users change
+	if users, spawnRate := master.Users(); users != 25 || spawnRate != 2 {
+		t.Errorf("swarm users = %d at %g/s, want 25 at 2/s", users, spawnRate)
+	}
+	if run.TargetUsers != 25 || run.SpawnRate != 2 || run.UpdatedBy != "alice" {
+		t.Errorf("run has %d users at %g/s updated by %q, want 25 at 2/s by alice", run.TargetUsers, run.SpawnRate, run.UpdatedBy)
+	}
+	if len(run.LoadChanges) != 1 {
+		t.Fatalf("load changes = %+v, want 1", run.LoadChanges)
+	}
+	change := run.LoadChanges[0]
+	if change.PreviousUsers != 10 || change.TargetUsers != 25 || change.PreviousSpawnRate != 2 || change.SpawnRate != 2 {
+		t.Errorf("load change = %+v, want 10 -> 25 users at 2/s", change)
+	}
+	if change.ChangedBy != "alice" || change.Timestamp == 0 {
+		t.Errorf("load change by %q at %d, want alice with a timestamp", change.ChangedBy, change.Timestamp)
+	}
+
+	spawnRate := 5.0
+	run, err = o.AdjustLoad("run-1", &AdjustLoadRequest{SpawnRate: &spawnRate, ChangedBy: "bob"})
+	if err != nil {
+		t.Fatalf("AdjustLoad: %v", err)
+	}
+	if users, spawnRate := master.Users(); users != 25 || spawnRate != 5 {
+		t.Errorf("swarm users = %d at %g/s, want 25 at 5/s", users, spawnRate)
+	}
+	if len(run.LoadChanges) != 2 {
+		t.Errorf("load changes = %d, want 2", len(run.LoadChanges))
+	}
+}
+
+func TestAdjustLoadRejectsInvalidRequests(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	createPendingRun(t, o, "pending")
+
+	negative, zero, users := -1, 0.0, 5
+	tests := []struct {
+		name  string
+		runID string
+		req   *AdjustLoadRequest
+		want  error
+	}{
+		{name: "nothing to change", runID: "run-1", req: &AdjustLoadRequest{}, want: ErrInvalidRunParameters},
+		{name: "negative users", runID: "run-1", req: &AdjustLoadRequest{TargetUsers: &negative}, want: ErrInvalidRunParameters},
+		{name: "zero spawn rate", runID: "run-1", req: &AdjustLoadRequest{SpawnRate: &zero}, want: ErrInvalidRunParameters},
+		{name: "unknown run", runID: "missing", req: &AdjustLoadRequest{TargetUsers: &users}, want: ErrRunNotFound},
+		{name: "run not running", runID: "pending", req: &AdjustLoadRequest{TargetUsers: &users}, want: ErrRunNotRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := o.AdjustLoad(tt.runID, tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("AdjustLoad error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if swarms := len(master.Calls(locusttest.EndpointSwarm)); swarms != 1 {
+		t.Errorf("swarm calls = %d, want only the start's", swarms)
+	}
+}
+
+func TestAdjustLoadKeepsRunWhenSwarmFails(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	master.InjectFault(locusttest.EndpointSwarm, locusttest.Fault{Status: http.StatusInternalServerError})
+
+	users := 25
+	if _, err := o.AdjustLoad("run-1", &AdjustLoadRequest{TargetUsers: &users}); err == nil {
+		t.Fatal("AdjustLoad succeeded, want an error")
+	}
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.TargetUsers != 10 || len(run.LoadChanges) != 0 {
+		t.Errorf("run has %d users and %d load changes, want 10 and none", run.TargetUsers, len(run.LoadChanges))
+	}
+}
+
+func TestAdjustLoadTakesOverFromLoadProfile(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	startProfiledRun(t, o, "run-1",
+		domain.LoadStage{TargetUsers: 5, SpawnRate: 1, HoldSeconds: 1},
+		domain.LoadStage{TargetUsers: 20, SpawnRate: 4, HoldSeconds: 60},
+	)
+
+	users := 8
+	if _, err := o.AdjustLoad("run-1", &AdjustLoadRequest{TargetUsers: &users}); err != nil {
+		t.Fatalf("AdjustLoad: %v", err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.CurrentStage != 0 || run.TargetUsers != 8 {
+		t.Errorf("run at stage %d with %d users, want the manual 8 users at stage 0", run.CurrentStage, run.TargetUsers)
+	}
+	if users, _ := master.Users(); users != 8 {
+		t.Errorf("swarm users = %d, want 8", users)
+	}
+}
+
+func TestAdjustLoadStartsFromCurrentStage(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	startProfiledRun(t, o, "run-1",
+		domain.LoadStage{TargetUsers: 5, SpawnRate: 1, HoldSeconds: 1},
+		domain.LoadStage{TargetUsers: 20, SpawnRate: 4, HoldSeconds: 60},
+	)
+	waitForStage(t, o, "run-1", 1)
+
+	// Changing only the spawn rate keeps the users of the stage the run is at
+	spawnRate := 10.0
+	run, err := o.AdjustLoad("run-1", &AdjustLoadRequest{SpawnRate: &spawnRate})
+	if err != nil {
+		t.Fatalf("AdjustLoad: %v", err)
+	}
+
+	if users, rate := master.Users(); users != 20 || rate != 10 {
+		t.Errorf("swarm users = %d at %g/s, want 20 at 10/s", users, rate)
+	}
+	change := run.LoadChanges[len(run.LoadChanges)-1]
+	if change.PreviousUsers != 20 || change.PreviousSpawnRate != 4 || change.TargetUsers != 20 {
+		t.Errorf("load change = %+v, want 20 users at 4/s -> 20 users at 10/s", change)
+	}
+}
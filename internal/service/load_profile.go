@@ -0,0 +1,162 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
+	"context"
+	"log"
+	"time"
+)
+
+// profileCancel cancels one load profile goroutine; a pointer identifies the goroutine, so one that ends
+// doesn't remove the entry of a profile that replaced it
+type profileCancel struct {
+	cancel context.CancelFunc
+}
+
+// startLoadProfile spawns the goroutine that drives a run through its load profile stages
+func (o *Orchestrator) startLoadProfile(run *domain.LoadTestRun, client engine.Executor) {
+	ctx, cancel := context.WithCancel(o.ctx)
+	handle := &profileCancel{cancel: cancel}
+
+	o.mu.Lock()
+	if existing, ok := o.profileCancels[run.ID]; ok {
+		existing.cancel()
+	}
+	o.profileCancels[run.ID] = handle
+	o.mu.Unlock()
+
+	log.Printf("[Orchestrator] Starting load profile for run %s (%d stages, %ds total)",
+		run.ID, len(run.LoadProfile.Stages), run.LoadProfile.TotalDurationSeconds())
+
+	runID, profile, stage, stageStartedAt := run.ID, run.LoadProfile, run.CurrentStage, currentStageStartedAt(run)
+	go func() {
+		defer o.endLoadProfile(runID, handle)
+		o.runLoadProfile(ctx, runID, client, profile, stage, stageStartedAt)
+	}()
+}
+
+// currentStageStartedAt returns when a run entered its current load profile stage, in Unix milliseconds
+// A profile resumed after a restart only holds the stage for what remains of its hold time
+func currentStageStartedAt(run *domain.LoadTestRun) int64 {
+	for i := len(run.StageTransitions) - 1; i >= 0; i-- {
+		if run.StageTransitions[i].StageIndex == run.CurrentStage {
+			return run.StageTransitions[i].StartedAt
+		}
+	}
+	if run.StartedAt > 0 {
+		return run.StartedAt
+	}
+	return time.Now().UnixMilli()
+}
+
+// stopLoadProfile cancels the load profile goroutine of a run, if any
+func (o *Orchestrator) stopLoadProfile(runID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if handle, ok := o.profileCancels[runID]; ok {
+		handle.cancel()
+		delete(o.profileCancels, runID)
+	}
+}
+
+// endLoadProfile releases the entry of a load profile goroutine that returned, unless another replaced it
+func (o *Orchestrator) endLoadProfile(runID string, handle *profileCancel) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	handle.cancel()
+	if o.profileCancels[runID] == handle {
+		delete(o.profileCancels, runID)
+	}
+}
+
+// runLoadProfile waits out each stage's hold time and scales the run to the next stage
+// The hold of fromStage counts from stageStartedAt (Unix milliseconds), so a stage whose hold already
+// elapsed advances straight away. It exits when the profile completes, the run leaves the Running state,
+// or the context is cancelled
+func (o *Orchestrator) runLoadProfile(ctx context.Context, runID string, client engine.Executor, profile *domain.LoadProfile, fromStage int, stageStartedAt int64) {
+	for i := fromStage + 1; i < len(profile.Stages); i++ {
+		hold := time.Duration(profile.Stages[i-1].HoldSeconds) * time.Second
+		if i == fromStage+1 {
+			hold -= time.Since(time.UnixMilli(stageStartedAt))
+		}
+		if hold < 0 {
+			hold = 0
+		}
+		timer := time.NewTimer(hold)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !o.advanceLoadProfile(ctx, runID, client, profile, i) {
+			return
+		}
+	}
+
+	log.Printf("[Orchestrator] Load profile for run %s reached its final stage", runID)
+}
+
+// advanceLoadProfile moves a run to the given stage and records the transition
+// Returns false if the run is no longer running and the profile should stop
+func (o *Orchestrator) advanceLoadProfile(ctx context.Context, runID string, client engine.Executor, profile *domain.LoadProfile, stageIndex int) bool {
+	unlock := o.lockRun(runID)
+	defer unlock()
+
+	// The profile may have been cancelled (e.g. by a manual load adjustment) while waiting for the lock
+	if ctx.Err() != nil {
+		return false
+	}
+
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		log.Printf("[Orchestrator] Load profile for run %s aborted: %v", runID, err)
+		return false
+	}
+
+	if run.Status != domain.LoadTestRunStatusRunning {
+		log.Printf("[Orchestrator] Load profile for run %s stopped: run is %s", runID, run.Status)
+		return false
+	}
+
+	stage := profile.Stages[stageIndex]
+	log.Printf("[Orchestrator] Run %s entering stage %d (%s): users=%d, spawnRate=%.2f",
+		runID, stageIndex, stage.Name, stage.TargetUsers, stage.SpawnRate)
+
+	swarmCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	transition := domain.StageTransition{
+		StageIndex:  stageIndex,
+		Name:        stage.Name,
+		TargetUsers: stage.TargetUsers,
+		SpawnRate:   stage.SpawnRate,
+		StartedAt:   time.Now().UnixMilli(),
+	}
+
+	if err := client.Scale(swarmCtx, stage.TargetUsers, stage.SpawnRate); err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		log.Printf("[Orchestrator] Swarm for stage %d of run %s failed: %v", stageIndex, runID, err)
+		transition.Error = err.Error()
+	} else {
+		// The run's users and spawn rate are what the engine runs, which manual adjustments start from
+		run.TargetUsers = stage.TargetUsers
+		run.SpawnRate = stage.SpawnRate
+	}
+
+	run.CurrentStage = stageIndex
+	run.StageTransitions = append(run.StageTransitions, transition)
+	run.UpdatedAt = transition.StartedAt
+
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		log.Printf("[Orchestrator] Failed to record stage transition for run %s: %v", runID, err)
+	}
+
+	return true
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"testing"
+	"time"
+)
+
+// startProfiledRun starts a run of the test script that walks through the given load profile stages
+func startProfiledRun(t *testing.T, o *Orchestrator, runID string, stages ...domain.LoadStage) *domain.LoadTestRun {
+	t.Helper()
+
+	req := createPendingRun(t, o, runID)
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	run.LoadProfile = &domain.LoadProfile{Type: domain.LoadProfileTypeStep, Stages: stages}
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+	req.TargetUsers, req.SpawnRate = stages[0].TargetUsers, stages[0].SpawnRate
+
+	started, err := o.CreateTestRun(req)
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	return started
+}
+
+// waitForStage polls a run until it enters a load profile stage, failing the test if it does not within a few seconds
+func waitForStage(t *testing.T, o *Orchestrator, runID string, stage int) *domain.LoadTestRun {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		run, err := o.GetTestRun(runID)
+		if err != nil {
+			t.Fatalf("GetTestRun: %v", err)
+		}
+		if run.CurrentStage == stage {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run %s stage = %d, want %d", runID, run.CurrentStage, stage)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLoadProfileAdvancesStages(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	run := startProfiledRun(t, o, "run-1",
+		domain.LoadStage{Name: "warmup", TargetUsers: 5, SpawnRate: 1, HoldSeconds: 1},
+		domain.LoadStage{Name: "peak", TargetUsers: 20, SpawnRate: 4, HoldSeconds: 60},
+	)
+	if len(run.StageTransitions) != 1 || run.StageTransitions[0].Name != "warmup" {
+		t.Fatalf("stage transitions at start = %+v, want warmup only", run.StageTransitions)
+	}
+
+	run = waitForStage(t, o, "run-1", 1)
+
+	if len(run.StageTransitions) != 2 {
+		t.Fatalf("stage transitions = %+v, want 2", run.StageTransitions)
+	}
+	transition := run.StageTransitions[1]
+	if transition.Name != "peak" || transition.TargetUsers != 20 || transition.Error != "" {
+		t.Errorf("second transition = %+v, want a successful move to peak", transition)
+	}
+	if hold := transition.StartedAt - run.StageTransitions[0].StartedAt; hold < 1000 {
+		t.Errorf("warmup held %dms, want at least 1000ms", hold)
+	}
+	if users, spawnRate := master.Users(); users != 20 || spawnRate != 4 {
+		t.Errorf("swarm users = %d at %g/s, want 20 at 4/s", users, spawnRate)
+	}
+	if run.TargetUsers != 20 || run.SpawnRate != 4 {
+		t.Errorf("run users = %d at %g/s, want the peak stage's 20 at 4/s", run.TargetUsers, run.SpawnRate)
+	}
+}
+
+func TestLoadProfileStopsWithRun(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	startProfiledRun(t, o, "run-1",
+		domain.LoadStage{TargetUsers: 5, SpawnRate: 1, HoldSeconds: 1},
+		domain.LoadStage{TargetUsers: 20, SpawnRate: 4, HoldSeconds: 60},
+	)
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.CurrentStage != 0 || len(run.StageTransitions) != 1 {
+		t.Errorf("stopped run moved to stage %d (%d transitions), want it kept at stage 0", run.CurrentStage, len(run.StageTransitions))
+	}
+	if swarms := len(master.Calls(locusttest.EndpointSwarm)); swarms != 1 {
+		t.Errorf("swarm calls = %d, want only the start's", swarms)
+	}
+}
+
+func TestResumedLoadProfileHoldsOnlyRemainingTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		enteredAt time.Duration // How long before the resume the run entered its current stage
+		wantStage int
+	}{
+		{name: "hold elapsed", enteredAt: 2 * time.Minute, wantStage: 1},
+		{name: "hold remaining", enteredAt: 0, wantStage: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master := newTestMaster(t, locusttest.Options{})
+			o := newTestOrchestrator(t, master)
+
+			startProfiledRun(t, o, "run-1",
+				domain.LoadStage{TargetUsers: 5, SpawnRate: 1, HoldSeconds: 60},
+				domain.LoadStage{TargetUsers: 20, SpawnRate: 4, HoldSeconds: 60},
+			)
+
+			// A restart resumes the profile from the stored run, whose stage was entered a while ago
+			o.stopLoadProfile("run-1")
+			run, err := o.GetTestRun("run-1")
+			if err != nil {
+				t.Fatalf("GetTestRun: %v", err)
+			}
+			run.StageTransitions[0].StartedAt = time.Now().Add(-tt.enteredAt).UnixMilli()
+			if err := o.loadTestRunStore.Update(run); err != nil {
+				t.Fatalf("failed to update run: %v", err)
+			}
+			client, err := o.getClient(run.ClusterID)
+			if err != nil {
+				t.Fatalf("getClient: %v", err)
+			}
+			o.startLoadProfile(run, client)
+
+			if tt.wantStage == 1 {
+				waitForStage(t, o, run.ID, 1)
+				return
+			}
+
+			time.Sleep(300 * time.Millisecond)
+			if current, _ := o.GetTestRun(run.ID); current.CurrentStage != 0 {
+				t.Errorf("stage = %d, want the run kept at stage 0 until its hold elapses", current.CurrentStage)
+			}
+		})
+	}
+}
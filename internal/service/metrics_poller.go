@@ -0,0 +1,171 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
+	"context"
+	"log"
+	"time"
+)
+
+// metricsModeFor returns how a run's metrics reach the control plane: its cluster's mode, else the configured default
+// Native generators report in-process and always count as push; a distributed run polls if any of its clusters does
+func (o *Orchestrator) metricsModeFor(run *domain.LoadTestRun) domain.MetricsMode {
+	fallback := o.config.Orchestrator.MetricsMode.OrDefault(domain.MetricsModePush)
+
+	for _, clusterID := range run.ClusterIDs() {
+		cluster, err := o.GetCluster(clusterID)
+		if err != nil || cluster.Runs(domain.EngineNative) {
+			continue
+		}
+		if cluster.MetricsMode.OrDefault(fallback) == domain.MetricsModePoll {
+			return domain.MetricsModePoll
+		}
+	}
+	return domain.MetricsModePush
+}
+
+// startMetricsPoller spawns the goroutine polling the Locust stats of a run in poll mode
+func (o *Orchestrator) startMetricsPoller(run *domain.LoadTestRun) {
+	if run.MetricsMode != domain.MetricsModePoll {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(o.ctx)
+
+	o.mu.Lock()
+	if existing, ok := o.pollCancels[run.ID]; ok {
+		existing()
+	}
+	o.pollCancels[run.ID] = cancel
+	o.mu.Unlock()
+
+	interval := time.Duration(o.config.Orchestrator.MetricsPollIntervalSeconds) * time.Second
+	log.Printf("[Orchestrator] Polling metrics of run %s every %s", run.ID, interval)
+
+	go o.pollMetrics(ctx, run.ID, run.ClusterIDs(), run.IsSharded(), interval)
+}
+
+// stopMetricsPoller cancels the metrics poller of a run, if any
+func (o *Orchestrator) stopMetricsPoller(runID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if cancel, ok := o.pollCancels[runID]; ok {
+		cancel()
+		delete(o.pollCancels, runID)
+	}
+}
+
+// pollMetrics fetches the stats of each of a run's clusters every interval and feeds them through UpdateMetrics,
+// like a plugin push would. A cluster reporting "stopped" is handled like the plugin's test_stop callback once
+// it was seen running, or right away if the run (or its shard) is Running: a short run may already be over by
+// the first poll. Exits when the context is cancelled or every cluster stopped
+func (o *Orchestrator) pollMetrics(ctx context.Context, runID string, clusterIDs []string, sharded bool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seenRunning := make(map[string]bool, len(clusterIDs))
+	stopped := make(map[string]bool, len(clusterIDs))
+
+	for len(stopped) < len(clusterIDs) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, clusterID := range clusterIDs {
+			if stopped[clusterID] || ctx.Err() != nil {
+				continue
+			}
+
+			shardID := ""
+			if sharded {
+				shardID = clusterID
+			}
+
+			state, stoppedRun := o.pollCluster(ctx, runID, clusterID, shardID, seenRunning[clusterID])
+			switch state {
+			case "running", "spawning":
+				seenRunning[clusterID] = true
+			case "stopped":
+				if stoppedRun {
+					stopped[clusterID] = true
+				}
+			}
+		}
+	}
+
+	log.Printf("[Orchestrator] Metrics poller of run %s finished: every cluster stopped", runID)
+}
+
+// pollCluster fetches one cluster's stats for a run and records them, returning the runner state Locust reported
+// and whether the cluster's stop was handled. Stats of a running cluster count as a metrics push; a cluster that
+// stopped after it was seen running, or while the run still generates load on it, finalizes the run, or its shard
+func (o *Orchestrator) pollCluster(ctx context.Context, runID, clusterID, shardID string, seenRunning bool) (string, bool) {
+	client, err := o.getClient(clusterID)
+	if err != nil {
+		log.Printf("[Orchestrator] Cannot poll cluster %s for run %s: %v", clusterID, runID, err)
+		return "", false
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	stats, err := client.Stats(pollCtx)
+	if err != nil {
+		// The stale run reaper takes over if the cluster stays silent
+		log.Printf("[Orchestrator] Failed to poll metrics of run %s on cluster %s: %v", runID, clusterID, err)
+		return "", false
+	}
+
+	// Failure details only add to the stats, which are recorded without them if the cluster can't give them
+	if source, ok := client.(engine.FailureSource); ok {
+		if failures, exceptions, err := source.Failures(pollCtx); err != nil {
+			log.Printf("[Orchestrator] Failed to poll failures of run %s on cluster %s: %v", runID, clusterID, err)
+		} else {
+			stats.Failures, stats.Exceptions = failures, exceptions
+		}
+	}
+
+	switch stats.RunnerState {
+	case "running", "spawning":
+		if err := o.UpdateMetrics(runID, shardID, stats); err != nil {
+			log.Printf("[Orchestrator] Failed to record polled metrics of run %s: %v", runID, err)
+		}
+	case "stopped":
+		if !seenRunning && !o.generatingLoad(runID, shardID) {
+			return stats.RunnerState, false
+		}
+		log.Printf("[Orchestrator] Cluster %s reports run %s stopped", clusterID, runID)
+		if err := o.HandleTestStop(runID, shardID, stats, o.durationElapsed(runID)); err != nil {
+			log.Printf("[Orchestrator] Failed to finalize polled run %s: %v", runID, err)
+		}
+		return stats.RunnerState, true
+	}
+	return stats.RunnerState, false
+}
+
+// generatingLoad reports whether a run is Running, or for a shard of a distributed run, whether the shard is
+func (o *Orchestrator) generatingLoad(runID, shardID string) bool {
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		return false
+	}
+	if shardID != "" {
+		shard := run.Shard(shardID)
+		return shard != nil && shard.Status == domain.LoadTestRunStatusRunning
+	}
+	return run.Status == domain.LoadTestRunStatusRunning
+}
+
+// durationElapsed reports whether a run has been running for its whole duration, telling a stop
+// Locust made by itself at the end of the run from one made by hand
+func (o *Orchestrator) durationElapsed(runID string) bool {
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil || run.DurationSeconds == nil || run.StartedAt == 0 {
+		return false
+	}
+	return time.Since(time.UnixMilli(run.StartedAt)) >= time.Duration(*run.DurationSeconds)*time.Second
+}
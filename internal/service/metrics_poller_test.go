@@ -0,0 +1,85 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"testing"
+	"time"
+)
+
+// newPollingOrchestrator returns a test orchestrator polling the stats of its runs every second
+func newPollingOrchestrator(t *testing.T, masters ...*locusttest.Master) *Orchestrator {
+	t.Helper()
+	o := newTestOrchestrator(t, masters...)
+	o.config.Orchestrator.MetricsMode = domain.MetricsModePoll
+	o.config.Orchestrator.MetricsPollIntervalSeconds = 1
+	return o
+}
+
+func TestMetricsPollerRecordsRunningStats(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	master.SetStats(locusttest.Stats{Entries: []locusttest.Stat{
+		{Method: "GET", Name: "/", NumRequests: 40, NumFailures: 2, AvgResponseTime: 12, MedianResponseTime: 10},
+	}})
+	o := newPollingOrchestrator(t, master)
+
+	run, err := o.CreateTestRun(createPendingRun(t, o, "run-1"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if run.MetricsMode != domain.MetricsModePoll {
+		t.Fatalf("metrics mode = %s, want %s", run.MetricsMode, domain.MetricsModePoll)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		run, err = o.GetTestRun("run-1")
+		if err != nil {
+			t.Fatalf("GetTestRun: %v", err)
+		}
+		if run.LastMetrics != nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if run.LastMetrics == nil {
+		t.Fatal("no metrics were polled")
+	}
+	if run.LastMetrics.TotalRequests != 40 || run.LastMetrics.TotalFailures != 2 {
+		t.Errorf("polled requests = %d (%d failed), want 40 (2 failed)", run.LastMetrics.TotalRequests, run.LastMetrics.TotalFailures)
+	}
+	if run.LastHeartbeatAt == 0 {
+		t.Error("polled metrics did not count as a heartbeat")
+	}
+	if run.Status != domain.LoadTestRunStatusRunning {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusRunning)
+	}
+}
+
+func TestMetricsPollerFinalizesRunStoppedBeforeFirstPoll(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newPollingOrchestrator(t, master)
+
+	// Locust stops the run as soon as it starts: the first poll already sees it stopped
+	req := createPendingRun(t, o, "run-1")
+	run, _ := o.loadTestRunStore.Get("run-1")
+	duration := 0
+	run.DurationSeconds = &duration
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+	if _, err := o.CreateTestRun(req); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+
+	run = waitForStatus(t, o, "run-1", domain.LoadTestRunStatusFinished)
+
+	last := run.Timeline[len(run.Timeline)-1]
+	if last.Actor != domain.RunActorLocust {
+		t.Errorf("finalized by %s, want %s", last.Actor, domain.RunActorLocust)
+	}
+	if len(master.Calls(locusttest.EndpointStop)) != 0 {
+		t.Error("the control plane stopped a run Locust had already stopped")
+	}
+}
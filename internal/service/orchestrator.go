@@ -3,11 +3,14 @@ package service
 import (
 	"Load-manager-cli/internal/config"
 	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
 	"Load-manager-cli/internal/locustclient"
+	"Load-manager-cli/internal/scriptprocessor"
 	"Load-manager-cli/internal/store"
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,42 +19,93 @@ import (
 
 // Orchestrator manages the lifecycle of load test runs and coordinates with Locust clusters
 type Orchestrator struct {
-	config           *config.Config
-	loadTestStore    store.LoadTestRepository
-	loadTestRunStore store.LoadTestRunRepository
-	metricsStore     *store.MongoMetricsStore
-	clients          map[string]locustclient.Client // Map of clusterID -> client
-	mu               sync.RWMutex
-	ctx              context.Context
-	cancel           context.CancelFunc
+	config              *config.Config
+	loadTestStore       store.LoadTestRepository
+	loadTestRunStore    store.LoadTestRunRepository
+	scriptRevisionStore store.ScriptRevisionRepository
+	scheduleStore       store.ScheduleRepository
+	clusterStore        store.ClusterRepository
+	metricsStore        store.MetricsRepository
+	failureStore        store.FailureRepository
+	requestSampleStore  store.RequestSampleRepository
+	clusters            map[string]*domain.LocustCluster // Map of clusterID -> registered cluster
+	clients             map[string]engine.Executor       // Map of clusterID -> client
+	profileCancels      map[string]*profileCancel        // Map of runID -> cancel func of its load profile goroutine
+	pollCancels         map[string]context.CancelFunc    // Map of runID -> cancel func of its metrics poller, for runs in poll mode
+	runLocks            sync.Map                         // Map of runID -> *sync.Mutex serializing run updates
+	clusterHolders      map[string]string                // Map of clusterID -> runID currently using the cluster
+	clusterQueues       map[string][]string              // Map of clusterID -> FIFO of run IDs waiting for the cluster
+	durationTimers      map[string]*time.Timer           // Map of runID -> timer stopping the run when its duration elapses
+	abortBreaches       map[string][]abortBreach         // Map of runID -> breach state of each of its abort rules
+	poolCursors         map[string]int                   // Map of pool key -> round-robin turn of the pool's clusters
+	shardRounds         map[string]map[string]bool       // Map of runID -> shards that pushed metrics since the run's last merged point
+	mu                  sync.RWMutex
+	ctx                 context.Context
+	cancel              context.CancelFunc
 }
 
 // NewOrchestrator creates a new orchestrator instance
-func NewOrchestrator(cfg *config.Config, loadTestStore store.LoadTestRepository, loadTestRunStore store.LoadTestRunRepository, metricsStore *store.MongoMetricsStore) *Orchestrator {
+func NewOrchestrator(cfg *config.Config, loadTestStore store.LoadTestRepository, loadTestRunStore store.LoadTestRunRepository, scriptRevisionStore store.ScriptRevisionRepository, scheduleStore store.ScheduleRepository, clusterStore store.ClusterRepository, metricsStore store.MetricsRepository, failureStore store.FailureRepository, requestSampleStore store.RequestSampleRepository) *Orchestrator {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	o := &Orchestrator{
-		config:           cfg,
-		loadTestStore:    loadTestStore,
-		loadTestRunStore: loadTestRunStore,
-		metricsStore:     metricsStore,
-		clients:          make(map[string]locustclient.Client),
-		ctx:              ctx,
-		cancel:           cancel,
-	}
-
-	// Initialize Locust clients for each configured cluster
+		config:              cfg,
+		loadTestStore:       loadTestStore,
+		loadTestRunStore:    loadTestRunStore,
+		scriptRevisionStore: scriptRevisionStore,
+		scheduleStore:       scheduleStore,
+		clusterStore:        clusterStore,
+		metricsStore:        metricsStore,
+		failureStore:        failureStore,
+		requestSampleStore:  requestSampleStore,
+		clusters:            make(map[string]*domain.LocustCluster),
+		clients:             make(map[string]engine.Executor),
+		profileCancels:      make(map[string]*profileCancel),
+		pollCancels:         make(map[string]context.CancelFunc),
+		clusterHolders:      make(map[string]string),
+		clusterQueues:       make(map[string][]string),
+		durationTimers:      make(map[string]*time.Timer),
+		abortBreaches:       make(map[string][]abortBreach),
+		poolCursors:         make(map[string]int),
+		shardRounds:         make(map[string]map[string]bool),
+		ctx:                 ctx,
+		cancel:              cancel,
+	}
+
+	// Initialize Locust clients for each configured cluster; Start replaces them with the cluster registry
 	for _, clusterCfg := range cfg.LocustClusters {
-		client := locustclient.NewHTTPClient(clusterCfg.BaseURL, clusterCfg.AuthToken)
-		o.clients[clusterCfg.ID] = client
+		o.setCluster(&domain.LocustCluster{
+			ID:          clusterCfg.ID,
+			BaseURL:     clusterCfg.BaseURL,
+			Engine:      clusterCfg.Engine,
+			MetricsMode: clusterCfg.MetricsMode,
+			AccountID:   clusterCfg.AccountID,
+			OrgID:       clusterCfg.OrgID,
+			ProjectID:   clusterCfg.ProjectID,
+			EnvID:       clusterCfg.EnvID,
+			AuthToken:   clusterCfg.AuthToken,
+			Source:      domain.ClusterSourceConfig,
+		})
 	}
 
 	return o
 }
 
-// Start begins the orchestrator (no background tasks needed with push-based metrics)
+// Start begins the orchestrator, loads the cluster registry, reconciles the runs left in flight
+// by a previous process with their Locust clusters and starts the scheduler, stale run reaper
+// and cluster health check loops
 func (o *Orchestrator) Start() {
-	log.Println("Orchestrator started (push-based metrics mode)")
+	o.loadClusters()
+	o.reconcileRuns()
+
+	go o.runReaper()
+	go o.runClusterHealthChecks()
+
+	if o.scheduleStore != nil {
+		go o.runScheduler()
+	}
+
+	log.Printf("Orchestrator started (default metrics mode: %s)", o.config.Orchestrator.MetricsMode)
 }
 
 // Stop gracefully shuts down the orchestrator
@@ -61,18 +115,12 @@ func (o *Orchestrator) Stop() {
 }
 
 // CreateTestRun creates and starts a new load test run
+// The run starts on the least loaded healthy cluster of its pool and fails over to another idle cluster
+// of the pool if Locust rejects it. If every cluster is busy, the run stays Pending in a cluster's queue
+// and is started automatically once a cluster of the pool is released
 func (o *Orchestrator) CreateTestRun(req *CreateTestRunRequest) (*domain.LoadTestRun, error) {
-	log.Printf("[Orchestrator] Starting test run %s: account=%s, org=%s, project=%s, env=%s, users=%d, spawnRate=%.2f",
-		req.LoadTestRunID, req.AccountID, req.OrgID, req.ProjectID, req.EnvID, req.TargetUsers, req.SpawnRate)
-	
-	// Validate account/org/project and environment
-	cluster, err := o.config.GetLocustCluster(req.AccountID, req.OrgID, req.ProjectID, req.EnvID)
-	if err != nil {
-		log.Printf("[Orchestrator] Failed to resolve cluster: %v", err)
-		return nil, fmt.Errorf("failed to resolve cluster: %w", err)
-	}
-	
-	log.Printf("[Orchestrator] Resolved cluster: id=%s, url=%s", cluster.ID, cluster.BaseURL)
+	log.Printf("[Orchestrator] Starting test run %s: account=%s, org=%s, project=%s, env=%s, users=%d, spawnRate=%.2f, host=%s",
+		req.LoadTestRunID, req.AccountID, req.OrgID, req.ProjectID, req.EnvID, req.TargetUsers, req.SpawnRate, req.TargetURL)
 
 	// Get the existing test run (already created by the API handler)
 	run, err := o.loadTestRunStore.Get(req.LoadTestRunID)
@@ -80,47 +128,93 @@ func (o *Orchestrator) CreateTestRun(req *CreateTestRunRequest) (*domain.LoadTes
 		return nil, fmt.Errorf("failed to get test run: %w", err)
 	}
 
-	// Start the load test on Locust
-	client, err := o.getClient(cluster.ID)
-	if err != nil {
-		run.Status = domain.LoadTestRunStatusFailed
-		_ = o.loadTestRunStore.Update(run)
-		return nil, fmt.Errorf("failed to get Locust client: %w", err)
+	var client engine.Executor
+	if run.IsSharded() {
+		client, err = o.startShardedRun(run, req)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var admitted bool
+		client, admitted, err = o.startPooledRun(run, req)
+		if err != nil {
+			return nil, err
+		}
+		if !admitted {
+			return run, nil
+		}
 	}
 
-	log.Printf("[Orchestrator] Setting run context in Locust for test %s", run.ID)
+	// Free the clusters the run holds for the next queued runs if it does not get to Running
+	started := false
+	defer func() {
+		if !started {
+			o.releaseRunClusters(run)
+		}
+	}()
+
+	log.Printf("[Orchestrator] Swarm succeeded for test %s, updating status to Running", run.ID)
 	
-	ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
-	defer cancel()
+	// Locust's test_start callback may already have marked the run Running, or a stop may have
+	// cancelled it while it was starting, so continue from the stored status
+	unlock := o.lockRun(run.ID)
+	defer unlock()
 
-	// Set run context in Locust before starting the swarm
-	if err := client.SetRunContext(ctx, run.ID, run.AccountID, run.EnvID, run.DurationSeconds); err != nil {
-		log.Printf("[Orchestrator] Failed to set run context for test %s: %v", run.ID, err)
-		run.Status = domain.LoadTestRunStatusFailed
-		_ = o.loadTestRunStore.Update(run)
-		return nil, fmt.Errorf("failed to set run context in Locust: %w", err)
+	current, err := o.loadTestRunStore.Get(run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test run: %w", err)
 	}
+	run.Status = current.Status
+	run.Timeline = current.Timeline
+	run.StartedAt = current.StartedAt
 
-	log.Printf("[Orchestrator] Calling Locust swarm API for test %s", run.ID)
-
-	if err := client.Swarm(ctx, req.TargetUsers, req.SpawnRate); err != nil {
-		log.Printf("[Orchestrator] Swarm failed for test %s: %v", run.ID, err)
-		run.Status = domain.LoadTestRunStatusFailed
-		_ = o.loadTestRunStore.Update(run)
-		return nil, fmt.Errorf("failed to start swarm on Locust: %w", err)
+	switch run.Status {
+	case domain.LoadTestRunStatusPending:
+		nowMillis := time.Now().UnixMilli()
+		if err := run.Transition(domain.LoadTestRunStatusRunning, domain.RunActorControlPlane, "swarm started on Locust", nowMillis); err != nil {
+			return nil, err
+		}
+		run.StartedAt = nowMillis
+	case domain.LoadTestRunStatusRunning:
+	default:
+		log.Printf("[Orchestrator] Test run %s became %s while starting, stopping Locust", run.ID, run.Status)
+		ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
+		defer cancel()
+		if err := client.Stop(ctx); err != nil {
+			log.Printf("Warning: failed to stop Locust for test run %s: %v", run.ID, err)
+		}
+		return nil, fmt.Errorf("%w: test run became %s while starting", domain.ErrInvalidTransition, run.Status)
 	}
+	run.MetricsMode = o.metricsModeFor(run)
+	startedAtMillis := run.StartedAt
 
-	log.Printf("[Orchestrator] Swarm succeeded for test %s, updating status to Running", run.ID)
-	
-	// Update status to Running
-	startedAtMillis := time.Now().UnixMilli()
-	run.Status = domain.LoadTestRunStatusRunning
-	run.StartedAt = startedAtMillis
-	run.UpdatedAt = startedAtMillis
+	if run.LoadProfile != nil {
+		firstStage := run.LoadProfile.Stages[0]
+		run.CurrentStage = 0
+		run.StageTransitions = []domain.StageTransition{{
+			StageIndex:  0,
+			Name:        firstStage.Name,
+			TargetUsers: firstStage.TargetUsers,
+			SpawnRate:   firstStage.SpawnRate,
+			StartedAt:   startedAtMillis,
+		}}
+	}
 
 	if err := o.loadTestRunStore.Update(run); err != nil {
 		return nil, fmt.Errorf("failed to update test run status: %w", err)
 	}
+	started = true
+
+	// The control plane enforces the duration itself in case the plugin never reports the stop
+	o.scheduleDurationStop(run)
+
+	// Without plugin callbacks the control plane fetches the metrics itself
+	o.startMetricsPoller(run)
+
+	// Walk through the remaining load profile stages in the background
+	if run.LoadProfile != nil && len(run.LoadProfile.Stages) > 1 {
+		o.startLoadProfile(run, client)
+	}
 
 	// Add to recent runs immediately when test starts
 	if run.LoadTestID != "" {
@@ -135,6 +229,159 @@ func (o *Orchestrator) CreateTestRun(req *CreateTestRunRequest) (*domain.LoadTes
 	return run, nil
 }
 
+// startPooledRun admits a run to a cluster of its pool and starts it there, failing over to another idle
+// cluster of the pool if Locust rejects it. Returns false without an error if every cluster is busy and
+// the run was queued
+func (o *Orchestrator) startPooledRun(run *domain.LoadTestRun, req *CreateTestRunRequest) (engine.Executor, bool, error) {
+	// Only one run may drive a cluster at a time
+	cluster, admitted, err := o.admitRun(run, req.ClusterID)
+	if err != nil {
+		log.Printf("[Orchestrator] Failed to resolve cluster: %v", err)
+		return nil, false, fmt.Errorf("failed to resolve cluster: %w", err)
+	}
+	run.ClusterID = cluster.ID
+
+	if !admitted {
+		if err := o.queueRun(run); err != nil {
+			return nil, false, fmt.Errorf("failed to queue test run: %w", err)
+		}
+		log.Printf("[Orchestrator] All clusters of the pool are busy, queued test run %s on cluster %s at position %d",
+			run.ID, cluster.ID, o.QueuePosition(run.ID))
+		return nil, false, nil
+	}
+
+	log.Printf("[Orchestrator] Admitted test run %s to cluster: id=%s, url=%s", run.ID, cluster.ID, cluster.BaseURL)
+
+	// Start the load test on Locust, failing over to another cluster of the pool if needed
+	for {
+		client, startErr := o.startOnCluster(run, req)
+		attempt := domain.ClusterAttempt{ClusterID: run.ClusterID, At: time.Now().UnixMilli()}
+		if startErr == nil {
+			run.ClusterAttempts = append(run.ClusterAttempts, attempt)
+			log.Printf("[Orchestrator] Started test run %s on cluster %s", run.ID, run.ClusterID)
+			return client, true, nil
+		}
+		attempt.Error = startErr.Error()
+		run.ClusterAttempts = append(run.ClusterAttempts, attempt)
+
+		var next *domain.LocustCluster
+		if startErr.failover {
+			// Refresh the cluster's health so the next runs avoid it if it is down
+			go o.checkClusterHealth(run.ClusterID)
+			next = o.failoverCluster(run)
+		}
+
+		if next == nil {
+			o.failRunStart(run, startErr.reason, startErr.err)
+			o.releaseCluster(run.ClusterID, run.ID)
+			return nil, false, startErr
+		}
+
+		log.Printf("[Orchestrator] Failing over test run %s from cluster %s to cluster %s", run.ID, run.ClusterID, next.ID)
+		o.releaseCluster(run.ClusterID, run.ID)
+		run.ClusterID = next.ID
+	}
+}
+
+// runStartError is a failure of a Locust cluster to start a run
+type runStartError struct {
+	reason   string // Recorded as the run's failure reason
+	err      error
+	failover bool // Whether another cluster of the pool may start the run instead
+}
+
+func (e *runStartError) Error() string {
+	return fmt.Sprintf("%s: %v", e.reason, e.err)
+}
+
+func (e *runStartError) Unwrap() error {
+	return e.err
+}
+
+// startOnCluster delivers the run's script to the cluster the run holds, sets the run context and starts the swarm
+// Failures to set the run context or to swarm are worth a failover, and so is a cluster that is down or too slow
+// to take the script; a script the cluster answers it cannot load as stored is not
+func (o *Orchestrator) startOnCluster(run *domain.LoadTestRun, req *CreateTestRunRequest) (engine.Executor, *runStartError) {
+	client, err := o.getClient(run.ClusterID)
+	if err != nil {
+		return nil, &runStartError{reason: "failed to get Locust client", err: err}
+	}
+
+	ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
+	defer cancel()
+
+	// Push the exact script revision referenced by the run before anything else
+	if err := o.deliverScript(ctx, client, run); err != nil {
+		log.Printf("[Orchestrator] Failed to deliver script for test %s: %v", run.ID, err)
+		return nil, &runStartError{reason: "failed to deliver script to Locust", err: err, failover: locustclient.IsClusterFailure(err)}
+	}
+
+	log.Printf("[Orchestrator] Setting run context in Locust for test %s", run.ID)
+
+	// Set run context in Locust before starting the swarm
+	runCtx := engine.RunContext{RunID: run.ID, TenantID: run.AccountID, EnvID: run.EnvID, DurationSeconds: run.DurationSeconds}
+	if err := client.Attach(ctx, runCtx); err != nil {
+		log.Printf("[Orchestrator] Failed to set run context for test %s on cluster %s: %v", run.ID, run.ClusterID, err)
+		return nil, &runStartError{reason: "failed to set run context in Locust", err: err, failover: true}
+	}
+
+	log.Printf("[Orchestrator] Calling Locust swarm API for test %s", run.ID)
+
+	if err := client.Start(ctx, req.TargetUsers, req.SpawnRate, req.TargetURL); err != nil {
+		log.Printf("[Orchestrator] Swarm failed for test %s on cluster %s: %v", run.ID, run.ClusterID, err)
+		return nil, &runStartError{reason: "failed to start swarm on Locust", err: err, failover: true}
+	}
+
+	return client, nil
+}
+
+// deliverScript loads the run's script revision on the Locust cluster and verifies
+// that the script Locust reports as loaded matches the stored revision
+func (o *Orchestrator) deliverScript(ctx context.Context, client engine.Executor, run *domain.LoadTestRun) error {
+	if run.ScriptRevisionID == "" {
+		return fmt.Errorf("test run %s has no script revision", run.ID)
+	}
+
+	revision, err := o.scriptRevisionStore.Get(run.ScriptRevisionID)
+	if err != nil {
+		return fmt.Errorf("failed to get script revision: %w", err)
+	}
+
+	expectedHash, err := scriptprocessor.ScriptSHA256Base64(revision.ScriptContent)
+	if err != nil {
+		return fmt.Errorf("failed to hash script revision %s: %w", revision.ID, err)
+	}
+
+	log.Printf("[Orchestrator] Delivering script revision %s (#%d) for test %s",
+		revision.ID, revision.RevisionNumber, run.ID)
+
+	result, err := client.Prepare(ctx, engine.Script{RevisionID: revision.ID, Content: revision.ScriptContent})
+	if err != nil {
+		return err
+	}
+
+	if result.SHA256 != expectedHash {
+		return fmt.Errorf("loaded script hash mismatch for revision %s: expected %s, got %s",
+			revision.ID, expectedHash, result.SHA256)
+	}
+
+	// Every worker must run the same revision as the master, or part of the load would run another script
+	if len(result.MissingWorkers) > 0 {
+		return fmt.Errorf("script revision %s was not acknowledged by workers %s",
+			revision.ID, strings.Join(result.MissingWorkers, ", "))
+	}
+	for worker, hash := range result.WorkerAcks {
+		if hash != expectedHash {
+			return fmt.Errorf("loaded script hash mismatch for revision %s on worker %s: expected %s, got %q",
+				revision.ID, worker, expectedHash, hash)
+		}
+	}
+
+	run.ScriptHash = expectedHash
+	log.Printf("[Orchestrator] Script revision %s confirmed on Locust (workers=%d)", revision.ID, result.Workers)
+	return nil
+}
+
 // RegisterExternalTestRun registers a test that was started externally (e.g., from Locust UI)
 // This allows the control plane to track and poll metrics for UI-started tests
 func (o *Orchestrator) RegisterExternalTestRun(req *RegisterExternalTestRunRequest) (*domain.LoadTestRun, error) {
@@ -142,7 +389,7 @@ func (o *Orchestrator) RegisterExternalTestRun(req *RegisterExternalTestRunReque
 		req.AccountID, req.OrgID, req.ProjectID, req.EnvID, req.TargetUsers)
 	
 	// Validate account/org/project and environment
-	cluster, err := o.config.GetLocustCluster(req.AccountID, req.OrgID, req.ProjectID, req.EnvID)
+	cluster, err := o.resolveCluster(req.AccountID, req.OrgID, req.ProjectID, req.EnvID)
 	if err != nil {
 		log.Printf("[Orchestrator] Failed to resolve cluster for external test: %v", err)
 		return nil, fmt.Errorf("failed to resolve cluster: %w", err)
@@ -163,17 +410,18 @@ func (o *Orchestrator) RegisterExternalTestRun(req *RegisterExternalTestRunReque
 		TargetUsers:     req.TargetUsers,
 		SpawnRate:       req.SpawnRate,
 		DurationSeconds: req.DurationSeconds,
-		Status:          domain.LoadTestRunStatusRunning,
 		StartedAt:       nowMillis,
 		CreatedAt:       nowMillis,
 		CreatedBy:       "locust-ui",
 		UpdatedAt:       nowMillis,
 		UpdatedBy:       "locust-ui",
+		ClusterID:       cluster.ID,
 		Metadata: map[string]any{
-			"source": "locust-ui",
+			"source":       "locust-ui",
 			"registeredAt": time.Now().Format("2006-01-02T15:04:05Z07:00"),
 		},
 	}
+	run.InitTimeline(domain.LoadTestRunStatusRunning, "locust-ui", "started from the Locust UI", nowMillis)
 	
 	// Store the test run
 	if err := o.loadTestRunStore.Create(run); err != nil {
@@ -181,41 +429,60 @@ func (o *Orchestrator) RegisterExternalTestRun(req *RegisterExternalTestRunReque
 		return nil, fmt.Errorf("failed to store test run: %w", err)
 	}
 	
+	// An externally started run occupies the cluster like any other run
+	if !o.acquireCluster(cluster.ID, run.ID) {
+		log.Printf("Warning: external test run %s started on busy cluster %s", run.ID, cluster.ID)
+	}
+	o.scheduleDurationStop(run)
+
 	log.Printf("[Orchestrator] Registered external test run %s from Locust UI",
 		run.ID)
 	return run, nil
 }
 
-// StopTestRun stops a running load test
-func (o *Orchestrator) StopTestRun(runID string) error {
+// StopTestRun stops a running load test on behalf of an actor
+// A run still waiting in its cluster's queue is removed from the queue without contacting Locust
+func (o *Orchestrator) StopTestRun(runID, actor string) error {
+	unlock := o.lockRun(runID)
+	defer unlock()
+
 	run, err := o.loadTestRunStore.Get(runID)
 	if err != nil {
-		return fmt.Errorf("failed to get test run: %w", err)
+		return fmt.Errorf("%w: %v", ErrRunNotFound, err)
 	}
 
-	if run.Status != domain.LoadTestRunStatusRunning {
-		return fmt.Errorf("test run is not running (current status: %s)", run.Status)
+	if run.Status == domain.LoadTestRunStatusPending {
+		// A pending run that is not queued is being started right now and can be stopped once it runs
+		if !o.dequeueRun(run.ClusterID, run.ID) {
+			return fmt.Errorf("%w: test run is starting", ErrRunNotRunning)
+		}
+		if err := o.finalizeRun(run, domain.LoadTestRunStatusStopped, actor, "cancelled before it started"); err != nil {
+			return err
+		}
+		log.Printf("Cancelled pending test run %s", runID)
+		return nil
 	}
 
-	// Get cluster from config based on account/org/project/env
-	cluster, err := o.config.GetLocustCluster(run.AccountID, run.OrgID, run.ProjectID, run.EnvID)
-	if err != nil {
-		return fmt.Errorf("failed to get cluster: %w", err)
+	if run.Status != domain.LoadTestRunStatusRunning {
+		return fmt.Errorf("%w (current status: %s)", ErrRunNotRunning, run.Status)
 	}
 
-	// Get Locust client
-	client, err := o.getClient(cluster.ID)
+	// Get Locust client for the cluster the run was admitted to
+	client, err := o.clientForRun(run)
 	if err != nil {
 		return fmt.Errorf("failed to get Locust client: %w", err)
 	}
 
 	// Update status to Stopping
-	run.Status = domain.LoadTestRunStatusStopping
-	run.UpdatedAt = time.Now().UnixMilli()
+	if err := run.Transition(domain.LoadTestRunStatusStopping, actor, "stop requested", time.Now().UnixMilli()); err != nil {
+		return err
+	}
 	if err := o.loadTestRunStore.Update(run); err != nil {
 		return fmt.Errorf("failed to update test run status: %w", err)
 	}
 
+	o.stopLoadProfile(run.ID)
+
 	// Stop the load test on Locust
 	ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
 	defer cancel()
@@ -224,21 +491,9 @@ func (o *Orchestrator) StopTestRun(runID string) error {
 		return fmt.Errorf("failed to stop Locust test: %w", err)
 	}
 
-	// Mark as finished (will be updated by callback if configured)
-	nowMillis := time.Now().UnixMilli()
-	run.Status = domain.LoadTestRunStatusFinished
-	run.FinishedAt = nowMillis
-	run.UpdatedAt = nowMillis
-
-	if err := o.loadTestRunStore.Update(run); err != nil {
-		return fmt.Errorf("failed to update test run finish status: %w", err)
-	}
-
-	// Update the LoadTest's recent runs if this run has a LoadTestID
-	if run.LoadTestID != "" {
-		if err := o.updateRecentRuns(run); err != nil {
-			log.Printf("Warning: failed to update recent runs for LoadTest %s: %v", run.LoadTestID, err)
-		}
+	// Locust's test_stop callback arrives after this and only contributes the final metrics
+	if err := o.finalizeRun(run, domain.LoadTestRunStatusStopped, actor, "stopped on request"); err != nil {
+		return err
 	}
 
 	log.Printf("Stopped test run %s", runID)
@@ -255,13 +510,26 @@ func (o *Orchestrator) ListTestRuns(filter *store.LoadTestRunFilter) ([]*domain.
 	return o.loadTestRunStore.List(filter)
 }
 
-// UpdateMetrics updates the metrics for a test run (called by Locust push callbacks)
-func (o *Orchestrator) UpdateMetrics(runID string, metrics *domain.MetricSnapshot) error {
+// UpdateMetrics updates the metrics for a test run (called by Locust push callbacks, or the poller in poll mode)
+// shardID identifies the pushing shard of a distributed run and is empty for other runs
+func (o *Orchestrator) UpdateMetrics(runID, shardID string, metrics *domain.MetricSnapshot) error {
+	unlock := o.lockRun(runID)
+	defer unlock()
+
 	run, err := o.loadTestRunStore.Get(runID)
 	if err != nil {
 		return fmt.Errorf("failed to get test run: %w", err)
 	}
 
+	o.recordFailures(run, shardID, metrics)
+
+	if run.IsSharded() {
+		return o.updateShardMetrics(run, shardID, metrics)
+	}
+
+	// Locust's counters are cumulative, so each point also records what it adds to the previous one
+	metrics.SetInterval(run.LastMetrics, run.StartedAt)
+
 	// Store metrics in time-series collection for historical analysis
 	if o.metricsStore != nil {
 		storeCtx, storeCancel := context.WithTimeout(o.ctx, 5*time.Second)
@@ -273,9 +541,18 @@ func (o *Orchestrator) UpdateMetrics(runID string, metrics *domain.MetricSnapsho
 		}
 	}
 
-	// Update the run's latest metrics
+	// Update the run's latest metrics; every push counts as a heartbeat
+	nowMillis := time.Now().UnixMilli()
 	run.LastMetrics = metrics
-	run.UpdatedAt = time.Now().UnixMilli()
+	run.LastHeartbeatAt = nowMillis
+	run.UpdatedAt = nowMillis
+
+	// Abort rules are checked on every push while the run generates load
+	if run.Status == domain.LoadTestRunStatusRunning {
+		if trigger := o.checkAbortRules(run, metrics); trigger != nil {
+			return o.abortRun(run, trigger)
+		}
+	}
 
 	if err := o.loadTestRunStore.Update(run); err != nil {
 		return fmt.Errorf("failed to update test run metrics: %w", err)
@@ -289,71 +566,115 @@ func (o *Orchestrator) UpdateMetrics(runID string, metrics *domain.MetricSnapsho
 
 // HandleTestStart handles test_start callback from Locust
 func (o *Orchestrator) HandleTestStart(runID string) error {
+	unlock := o.lockRun(runID)
+	defer unlock()
+
 	run, err := o.loadTestRunStore.Get(runID)
 	if err != nil {
 		return fmt.Errorf("failed to get test run: %w", err)
 	}
 
-	if run.Status == domain.LoadTestRunStatusPending {
-		nowMillis := time.Now().UnixMilli()
-		run.Status = domain.LoadTestRunStatusRunning
-		run.StartedAt = nowMillis
-		run.UpdatedAt = nowMillis
+	// The control plane marks the run Running itself once the swarm call returns
+	if run.Status == domain.LoadTestRunStatusRunning {
+		return nil
+	}
 
-		if err := o.loadTestRunStore.Update(run); err != nil {
-			return fmt.Errorf("failed to update test run: %w", err)
-		}
+	nowMillis := time.Now().UnixMilli()
+	if err := run.Transition(domain.LoadTestRunStatusRunning, domain.RunActorLocust, "test_start callback", nowMillis); err != nil {
+		return fmt.Errorf("failed to start test run %s: %w", runID, err)
+	}
+	run.StartedAt = nowMillis
 
-		log.Printf("Test run %s started (via callback)", runID)
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		return fmt.Errorf("failed to update test run: %w", err)
 	}
 
+	o.scheduleDurationStop(run)
+
+	log.Printf("Test run %s started (via callback)", runID)
 	return nil
 }
 
 // HandleTestStop handles test_stop callback from Locust
-func (o *Orchestrator) HandleTestStop(runID string, finalMetrics *domain.MetricSnapshot, autoStopped bool) error {
+// shardID identifies the stopping shard of a distributed run and is empty for other runs
+func (o *Orchestrator) HandleTestStop(runID, shardID string, finalMetrics *domain.MetricSnapshot, autoStopped bool) error {
 	log.Printf("[Orchestrator] Handling test stop for runID: %s, autoStopped: %v", runID, autoStopped)
 	
+	unlock := o.lockRun(runID)
+	defer unlock()
+
 	run, err := o.loadTestRunStore.Get(runID)
 	if err != nil {
 		return fmt.Errorf("failed to get test run: %w", err)
 	}
 
+	o.recordFailures(run, shardID, finalMetrics)
+
+	if run.IsSharded() {
+		return o.handleShardStop(run, shardID, finalMetrics, autoStopped)
+	}
+
+	if finalMetrics != nil {
+		o.storeFinalMetrics(run, finalMetrics)
+	}
+
+	// The control plane may already have finalized the run (e.g. the duration watchdog stopped it),
+	// in which case the callback only contributes the final metrics, and the verdict is judged again with them
+	if run.Status.IsTerminal() {
+		log.Printf("[Orchestrator] Test run %s already %s, keeping status", runID, run.Status)
+		if finalMetrics != nil {
+			run.LastMetrics = finalMetrics
+			run.UpdatedAt = time.Now().UnixMilli()
+			o.evaluateVerdict(run)
+			if err := o.loadTestRunStore.Update(run); err != nil {
+				return fmt.Errorf("failed to update test run: %w", err)
+			}
+		}
+		return nil
+	}
+
 	// Set status based on how the test was stopped
 	var newStatus domain.LoadTestRunStatus
+	var reason string
 	if autoStopped {
 		newStatus = domain.LoadTestRunStatusFinished // Auto-completed by duration
+		reason = "duration elapsed in Locust"
 	} else {
 		newStatus = domain.LoadTestRunStatusStopped // Manually stopped
+		reason = "stopped in Locust"
 	}
 	
 	log.Printf("[Orchestrator] Current status: %s, changing to %s", run.Status, newStatus)
 	
-	nowMillis := time.Now().UnixMilli()
-	run.Status = newStatus
-	run.FinishedAt = nowMillis
-	run.UpdatedAt = nowMillis
 	run.LastMetrics = finalMetrics
 
 	log.Printf("[Orchestrator] Updating test run in database...")
-	if err := o.loadTestRunStore.Update(run); err != nil {
+	if err := o.finalizeRun(run, newStatus, domain.RunActorLocust, reason); err != nil {
 		log.Printf("[Orchestrator] Failed to update test run: %v", err)
-		return fmt.Errorf("failed to update test run: %w", err)
+		return err
 	}
 	log.Printf("[Orchestrator] Test run updated successfully in database")
 
-	// Update the LoadTest's recent runs if this run has a LoadTestID
-	if run.LoadTestID != "" {
-		log.Printf("[Orchestrator] Updating recent runs for LoadTest %s", run.LoadTestID)
-		if err := o.updateRecentRuns(run); err != nil {
-			log.Printf("Warning: failed to update recent runs for LoadTest %s: %v", run.LoadTestID, err)
-		}
-	}
-
 	log.Printf("[Orchestrator] Test run %s finished (via callback), status set to: %s", runID, run.Status)
 	return nil
 }
 
+// storeFinalMetrics adds the metrics a run reported when it stopped to its timeseries, so that the
+// intervals of its points add up to its final counters
+func (o *Orchestrator) storeFinalMetrics(run *domain.LoadTestRun, finalMetrics *domain.MetricSnapshot) {
+	finalMetrics.SetInterval(run.LastMetrics, run.StartedAt)
+	if o.metricsStore == nil {
+		return
+	}
+
+	storeCtx, storeCancel := context.WithTimeout(o.ctx, 5*time.Second)
+	defer storeCancel()
+
+	if err := o.metricsStore.StoreMetric(storeCtx, run.ID, run.AccountID, run.OrgID, run.ProjectID, run.EnvID, finalMetrics); err != nil {
+		log.Printf("[Orchestrator] Failed to store final metrics for run %s: %v", run.ID, err)
+	}
+}
+
 // updateRecentRuns updates the LoadTest's RecentRuns array to include the completed run
 // and maintains only the 10 most recent runs
 func (o *Orchestrator) updateRecentRuns(run *domain.LoadTestRun) error {
@@ -412,9 +733,16 @@ func (o *Orchestrator) updateRecentRuns(run *domain.LoadTestRun) error {
 	return nil
 }
 
+// lockRun serializes read-modify-write cycles on a single run across callbacks and background goroutines
+func (o *Orchestrator) lockRun(runID string) func() {
+	value, _ := o.runLocks.LoadOrStore(runID, &sync.Mutex{})
+	runMu := value.(*sync.Mutex)
+	runMu.Lock()
+	return runMu.Unlock
+}
 
 // getClient retrieves a Locust client for the given cluster ID
-func (o *Orchestrator) getClient(clusterID string) (locustclient.Client, error) {
+func (o *Orchestrator) getClient(clusterID string) (engine.Executor, error) {
 	o.mu.RLock()
 	defer o.mu.RUnlock()
 
@@ -428,15 +756,15 @@ func (o *Orchestrator) getClient(clusterID string) (locustclient.Client, error)
 
 // CreateTestRunRequest represents a request to create and start a test run
 type CreateTestRunRequest struct {
-	LoadTestRunID   string         `json:"loadTestRunId"`  // The run ID (already created)
+	LoadTestRunID   string         `json:"loadTestRunId"`       // The run ID (already created)
+	ClusterID       string         `json:"clusterId,omitempty"` // Cluster already holding the run when it is dispatched from a queue
 	LoadTestID      string         `json:"loadTestId,omitempty"`
 	Name            string         `json:"name,omitempty"`
 	AccountID       string         `json:"accountId"`
 	OrgID           string         `json:"orgId"`
 	ProjectID       string         `json:"projectId"`
 	EnvID           string         `json:"envId,omitempty"`
-	ScriptContent   string         `json:"scriptContent"`  // Base64 encoded Python script
-	TargetURL       string         `json:"targetUrl"`
+	TargetURL       string         `json:"targetUrl"` // Host passed to Locust for this run
 	TargetUsers     int            `json:"targetUsers"`
 	SpawnRate       float64        `json:"spawnRate"`
 	DurationSeconds *int           `json:"durationSeconds,omitempty"`
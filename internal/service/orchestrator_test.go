@@ -0,0 +1,344 @@
+package service
+
+import (
+	"Load-manager-cli/internal/config"
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"Load-manager-cli/internal/scriptprocessor"
+	"Load-manager-cli/internal/store"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testScript = "from locust import HttpUser, task\n\nclass User(HttpUser):\n    @task\n    def index(self):\n        self.client.get(\"/\")\n"
+
+// memoryScriptRevisionStore is a ScriptRevisionRepository holding revisions in memory
+type memoryScriptRevisionStore struct {
+	revisions map[string]*domain.ScriptRevision
+}
+
+func (s *memoryScriptRevisionStore) Create(revision *domain.ScriptRevision) error {
+	s.revisions[revision.ID] = revision
+	return nil
+}
+
+func (s *memoryScriptRevisionStore) Get(id string) (*domain.ScriptRevision, error) {
+	revision, ok := s.revisions[id]
+	if !ok {
+		return nil, fmt.Errorf("script revision %s not found", id)
+	}
+	return revision, nil
+}
+
+func (s *memoryScriptRevisionStore) GetLatestByLoadTestID(loadTestID string) (*domain.ScriptRevision, error) {
+	var latest *domain.ScriptRevision
+	for _, revision := range s.revisions {
+		if revision.LoadTestID == loadTestID && (latest == nil || revision.RevisionNumber > latest.RevisionNumber) {
+			latest = revision
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no script revision for load test %s", loadTestID)
+	}
+	return latest, nil
+}
+
+func (s *memoryScriptRevisionStore) ListByLoadTestID(loadTestID string, limit int) ([]*domain.ScriptRevision, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// memoryMetricsStore is a MetricsRepository holding the metric documents of runs in memory
+type memoryMetricsStore struct {
+	mu   sync.Mutex
+	docs []store.MetricsDocument
+}
+
+func (s *memoryMetricsStore) StoreMetric(ctx context.Context, loadTestRunID, accountID, orgID, projectID, envID string, metric *domain.MetricSnapshot) error {
+	return s.StoreShardMetric(ctx, loadTestRunID, "", accountID, orgID, projectID, envID, metric)
+}
+
+func (s *memoryMetricsStore) StoreShardMetric(ctx context.Context, loadTestRunID, shardID, accountID, orgID, projectID, envID string, metric *domain.MetricSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs = append(s.docs, store.NewMetricsDocument(loadTestRunID, shardID, accountID, orgID, projectID, envID, metric))
+	return nil
+}
+
+func (s *memoryMetricsStore) GetMetricsTimeseries(ctx context.Context, loadTestRunID string, fromTime, toTime int64) ([]store.MetricsDocument, error) {
+	return s.shardDocs(loadTestRunID, ""), nil
+}
+
+// shardDocs returns the documents stored for a run's shard, or for the run itself if shardID is empty
+func (s *memoryMetricsStore) shardDocs(loadTestRunID, shardID string) []store.MetricsDocument {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var docs []store.MetricsDocument
+	for _, doc := range s.docs {
+		if doc.LoadTestRunID == loadTestRunID && doc.ShardID == shardID {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// newTestOrchestrator returns an orchestrator with one cluster per fake master, named cluster-1, cluster-2, ...
+// in the same pool, and in-memory stores holding the test script as revision "rev-1" of load test "test-1"
+func newTestOrchestrator(t *testing.T, masters ...*locusttest.Master) *Orchestrator {
+	t.Helper()
+
+	cfg := &config.Config{
+		LocustClient: config.LocustClientConfig{TimeoutSeconds: 5, MaxAttempts: 1},
+	}
+	for i, master := range masters {
+		cfg.LocustClusters = append(cfg.LocustClusters, config.ClusterConfig{
+			ID:        fmt.Sprintf("cluster-%d", i+1),
+			BaseURL:   master.URL(),
+			AccountID: "acc",
+			OrgID:     "org",
+			ProjectID: "proj",
+		})
+	}
+
+	revisions := &memoryScriptRevisionStore{revisions: map[string]*domain.ScriptRevision{
+		"rev-1": {ID: "rev-1", LoadTestID: "test-1", RevisionNumber: 1, ScriptContent: base64.StdEncoding.EncodeToString([]byte(testScript))},
+	}}
+
+	loadTests := store.NewInMemoryLoadTestStore()
+	if err := loadTests.Create(&domain.LoadTest{ID: "test-1", AccountID: "acc", OrgID: "org", ProjectID: "proj", TargetURL: "http://target.example", DefaultUsers: 10, DefaultSpawnRate: 2}); err != nil {
+		t.Fatalf("failed to create load test: %v", err)
+	}
+
+	o := NewOrchestrator(cfg, loadTests, store.NewInMemoryLoadTestRunStore(), revisions, nil, nil, nil, nil, nil)
+	t.Cleanup(o.Stop)
+	return o
+}
+
+// newTestMaster starts a fake master that is closed when the test ends
+func newTestMaster(t *testing.T, opts locusttest.Options) *locusttest.Master {
+	t.Helper()
+	master := locusttest.NewMaster(opts)
+	t.Cleanup(master.Close)
+	return master
+}
+
+// createPendingRun stores a Pending run of the test script and returns the request starting it
+func createPendingRun(t *testing.T, o *Orchestrator, runID string) *CreateTestRunRequest {
+	t.Helper()
+
+	run := &domain.LoadTestRun{
+		ID:               runID,
+		AccountID:        "acc",
+		OrgID:            "org",
+		ProjectID:        "proj",
+		ScriptRevisionID: "rev-1",
+		TargetUsers:      10,
+		SpawnRate:        2,
+	}
+	run.InitTimeline(domain.LoadTestRunStatusPending, "tester", "created", time.Now().UnixMilli())
+	if err := o.loadTestRunStore.Create(run); err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+
+	return &CreateTestRunRequest{
+		LoadTestRunID: runID,
+		AccountID:     "acc",
+		OrgID:         "org",
+		ProjectID:     "proj",
+		TargetURL:     "http://target.example",
+		TargetUsers:   10,
+		SpawnRate:     2,
+	}
+}
+
+func TestCreateTestRunStartsSwarm(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{Workers: 2})
+	o := newTestOrchestrator(t, master)
+
+	run, err := o.CreateTestRun(createPendingRun(t, o, "run-1"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+
+	if run.Status != domain.LoadTestRunStatusRunning {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusRunning)
+	}
+	if run.ClusterID != "cluster-1" {
+		t.Errorf("cluster = %s, want cluster-1", run.ClusterID)
+	}
+	if users, spawnRate := master.Users(); users != 10 || spawnRate != 2 {
+		t.Errorf("swarm users = %d at %g/s, want 10 at 2/s", users, spawnRate)
+	}
+	if host := master.Host(); host != "http://target.example" {
+		t.Errorf("swarm host = %q, want http://target.example", host)
+	}
+	if runCtx := master.RunContext(); runCtx.RunID != "run-1" || runCtx.TenantID != "acc" {
+		t.Errorf("run context = %+v, want run-1 of tenant acc", runCtx)
+	}
+
+	revisionID, digest := master.Script()
+	if revisionID != "rev-1" || digest != scriptprocessor.ScriptSHA256(testScript) {
+		t.Errorf("loaded script = %s %s, want rev-1 %s", revisionID, digest, scriptprocessor.ScriptSHA256(testScript))
+	}
+	if run.ScriptHash != digest {
+		t.Errorf("run script hash = %s, want %s", run.ScriptHash, digest)
+	}
+
+	// The script is loaded and the context set before the swarm starts
+	calls := master.Calls("")
+	var order []string
+	for _, call := range calls {
+		switch call.Endpoint {
+		case locusttest.EndpointLoadScript, locusttest.EndpointSetContext, locusttest.EndpointSwarm:
+			order = append(order, call.Endpoint)
+		}
+	}
+	want := []string{locusttest.EndpointLoadScript, locusttest.EndpointSetContext, locusttest.EndpointSwarm}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", order, want)
+	}
+}
+
+func TestStopTestRunStopsLocustAndReleasesCluster(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusStopped {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusStopped)
+	}
+	if state := master.State(); state != locusttest.StateStopped {
+		t.Errorf("master state = %s, want %s", state, locusttest.StateStopped)
+	}
+	if len(master.Calls(locusttest.EndpointStop)) != 1 {
+		t.Errorf("stop calls = %d, want 1", len(master.Calls(locusttest.EndpointStop)))
+	}
+
+	// The cluster is free again: the next run starts on it instead of queueing
+	next, err := o.CreateTestRun(createPendingRun(t, o, "run-2"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if next.Status != domain.LoadTestRunStatusRunning {
+		t.Errorf("next run status = %s, want %s", next.Status, domain.LoadTestRunStatusRunning)
+	}
+}
+
+func TestStopTestRunRejectsFinishedRun(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+	if err := o.StopTestRun("run-1", "tester"); err == nil {
+		t.Error("stopping a stopped run succeeded, want an error")
+	}
+}
+
+func TestCreateTestRunFailsOverWhenSwarmFails(t *testing.T) {
+	broken := newTestMaster(t, locusttest.Options{})
+	healthy := newTestMaster(t, locusttest.Options{})
+	broken.InjectFault(locusttest.EndpointSwarm, locusttest.Fault{Status: http.StatusInternalServerError})
+	o := newTestOrchestrator(t, broken, healthy)
+
+	run, err := o.CreateTestRun(createPendingRun(t, o, "run-1"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+
+	if run.ClusterID != "cluster-2" {
+		t.Errorf("cluster = %s, want cluster-2", run.ClusterID)
+	}
+	if len(run.ClusterAttempts) != 2 {
+		t.Fatalf("cluster attempts = %+v, want 2", run.ClusterAttempts)
+	}
+	if attempt := run.ClusterAttempts[0]; attempt.ClusterID != "cluster-1" || attempt.Error == "" {
+		t.Errorf("first attempt = %+v, want a failure on cluster-1", attempt)
+	}
+	if attempt := run.ClusterAttempts[1]; attempt.ClusterID != "cluster-2" || attempt.Error != "" {
+		t.Errorf("second attempt = %+v, want a success on cluster-2", attempt)
+	}
+	if state := healthy.State(); state != locusttest.StateRunning {
+		t.Errorf("failover master state = %s, want %s", state, locusttest.StateRunning)
+	}
+	if runCtx := healthy.RunContext(); runCtx.RunID != "run-1" {
+		t.Errorf("failover master run context = %+v, want run-1", runCtx)
+	}
+}
+
+func TestCreateTestRunFailsWhenEveryClusterFails(t *testing.T) {
+	first := newTestMaster(t, locusttest.Options{})
+	second := newTestMaster(t, locusttest.Options{})
+	first.InjectFault(locusttest.EndpointSetContext, locusttest.Fault{Status: http.StatusInternalServerError})
+	second.InjectFault(locusttest.EndpointSwarm, locusttest.Fault{Status: http.StatusInternalServerError})
+	o := newTestOrchestrator(t, first, second)
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err == nil {
+		t.Fatal("CreateTestRun succeeded, want an error")
+	}
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusFailed {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusFailed)
+	}
+	if run.FailureReason == "" {
+		t.Error("failure reason is empty")
+	}
+}
+
+func TestCreateTestRunFailsOnWorkerScriptMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		ack  locusttest.WorkerAck
+	}{
+		{name: "different hash", ack: locusttest.WorkerAck{SHA256: "0000"}},
+		{name: "load failed", ack: locusttest.WorkerAck{Failed: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master := newTestMaster(t, locusttest.Options{Workers: 2})
+			master.SetWorkerAck(locusttest.WorkerID(2), tt.ack)
+			o := newTestOrchestrator(t, master)
+
+			_, err := o.CreateTestRun(createPendingRun(t, o, "run-1"))
+			if err == nil || !strings.Contains(err.Error(), locusttest.WorkerID(2)) {
+				t.Fatalf("CreateTestRun error = %v, want a mismatch on %s", err, locusttest.WorkerID(2))
+			}
+
+			run, err := o.GetTestRun("run-1")
+			if err != nil {
+				t.Fatalf("GetTestRun: %v", err)
+			}
+			if run.Status != domain.LoadTestRunStatusFailed {
+				t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusFailed)
+			}
+			if len(master.Calls(locusttest.EndpointSwarm)) != 0 {
+				t.Error("swarm was started despite the worker mismatch")
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"fmt"
+)
+
+// RecordRequestSamples adds a batch of sampled requests to a run's request log
+// shardID is the reporting shard of a distributed run; sampleRate is the fraction of requests sampled
+func (o *Orchestrator) RecordRequestSamples(runID, shardID string, samples []domain.RequestSample, sampleRate float64) error {
+	if _, err := o.loadTestRunStore.Get(runID); err != nil {
+		return fmt.Errorf("failed to get test run: %w", err)
+	}
+	if o.requestSampleStore == nil || len(samples) == 0 {
+		return nil
+	}
+
+	// Only the newest samples of an oversized batch would survive the run's cap anyway
+	if len(samples) > domain.MaxRequestSamples {
+		samples = samples[len(samples)-domain.MaxRequestSamples:]
+	}
+	for i := range samples {
+		samples[i].ShardID = shardID
+		samples[i].TruncateException()
+	}
+
+	if err := o.requestSampleStore.Append(runID, samples, sampleRate); err != nil {
+		return fmt.Errorf("failed to record request samples: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryRequestSampleStore is an in-memory RequestSampleRepository capping logs like the MongoDB store
+type memoryRequestSampleStore struct {
+	mu     sync.Mutex
+	logs   map[string]*domain.RequestSampleLog
+	err    error // Returned by Append when set
+	writes int
+}
+
+func (s *memoryRequestSampleStore) Append(runID string, samples []domain.RequestSample, sampleRate float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writes++
+	if s.err != nil {
+		return s.err
+	}
+	if s.logs == nil {
+		s.logs = make(map[string]*domain.RequestSampleLog)
+	}
+	log, ok := s.logs[runID]
+	if !ok {
+		log = &domain.RequestSampleLog{RunID: runID}
+		s.logs[runID] = log
+	}
+	log.SampleRate = sampleRate
+	log.Received += int64(len(samples))
+	log.Samples = append(log.Samples, samples...)
+	if len(log.Samples) > domain.MaxRequestSamples {
+		log.Samples = log.Samples[len(log.Samples)-domain.MaxRequestSamples:]
+	}
+	return nil
+}
+
+func (s *memoryRequestSampleStore) Get(runID string) (*domain.RequestSampleLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if log, ok := s.logs[runID]; ok {
+		return log, nil
+	}
+	return &domain.RequestSampleLog{RunID: runID}, nil
+}
+
+func TestRecordRequestSamples(t *testing.T) {
+	o := newTestOrchestrator(t, newTestMaster(t, locusttest.Options{}))
+	samples := &memoryRequestSampleStore{}
+	o.requestSampleStore = samples
+	createPendingRun(t, o, "run-1")
+
+	batch := []domain.RequestSample{
+		{Timestamp: 1000, Method: "GET", Name: "/", StatusCode: 200, ResponseTimeMs: 20},
+		{Timestamp: 1001, Method: "GET", Name: "/", ResponseTimeMs: 5000, Exception: strings.Repeat("x", 2*domain.MaxSampleExceptionBytes)},
+	}
+	if err := o.RecordRequestSamples("run-1", "cluster-2", batch, 0.25); err != nil {
+		t.Fatalf("RecordRequestSamples: %v", err)
+	}
+
+	log, err := samples.Get("run-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(log.Samples) != 2 || log.SampleRate != 0.25 {
+		t.Fatalf("log = %d samples at rate %g, want 2 at 0.25", len(log.Samples), log.SampleRate)
+	}
+	for _, sample := range log.Samples {
+		if sample.ShardID != "cluster-2" {
+			t.Errorf("sample shard = %q, want cluster-2", sample.ShardID)
+		}
+	}
+	if got := len(log.Samples[1].Exception); got != domain.MaxSampleExceptionBytes {
+		t.Errorf("exception length = %d, want %d", got, domain.MaxSampleExceptionBytes)
+	}
+}
+
+func TestRecordRequestSamplesKeepsNewestOfOversizedBatch(t *testing.T) {
+	o := newTestOrchestrator(t, newTestMaster(t, locusttest.Options{}))
+	samples := &memoryRequestSampleStore{}
+	o.requestSampleStore = samples
+	createPendingRun(t, o, "run-1")
+
+	batch := make([]domain.RequestSample, domain.MaxRequestSamples+5)
+	for i := range batch {
+		batch[i] = domain.RequestSample{Timestamp: int64(i), Method: "GET", Name: "/", StatusCode: 200}
+	}
+	if err := o.RecordRequestSamples("run-1", "", batch, 1); err != nil {
+		t.Fatalf("RecordRequestSamples: %v", err)
+	}
+
+	log, _ := samples.Get("run-1")
+	if len(log.Samples) != domain.MaxRequestSamples {
+		t.Fatalf("samples = %d, want %d", len(log.Samples), domain.MaxRequestSamples)
+	}
+	if first := log.Samples[0].Timestamp; first != 5 {
+		t.Errorf("oldest kept sample = %d, want 5", first)
+	}
+}
+
+func TestRecordRequestSamplesErrors(t *testing.T) {
+	o := newTestOrchestrator(t, newTestMaster(t, locusttest.Options{}))
+	samples := &memoryRequestSampleStore{}
+	o.requestSampleStore = samples
+	createPendingRun(t, o, "run-1")
+	batch := []domain.RequestSample{{Timestamp: 1000, Method: "GET", Name: "/", StatusCode: 200}}
+
+	if err := o.RecordRequestSamples("missing", "", batch, 1); err == nil {
+		t.Error("RecordRequestSamples of an unknown run succeeded")
+	}
+	if err := o.RecordRequestSamples("run-1", "", nil, 1); err != nil {
+		t.Errorf("RecordRequestSamples of an empty batch: %v", err)
+	}
+	if samples.writes != 0 {
+		t.Errorf("store writes = %d, want 0", samples.writes)
+	}
+
+	samples.err = errors.New("store down")
+	if err := o.RecordRequestSamples("run-1", "", batch, 1); err == nil {
+		t.Error("RecordRequestSamples succeeded though the store failed")
+	}
+}
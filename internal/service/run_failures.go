@@ -0,0 +1,33 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"log"
+	"time"
+)
+
+// recordFailures moves the failure groups and exceptions a snapshot carries to the run's failures,
+// keeping them out of the run's metrics; shardID is the reporting shard of a distributed run
+// Callers must hold the run lock
+func (o *Orchestrator) recordFailures(run *domain.LoadTestRun, shardID string, metrics *domain.MetricSnapshot) {
+	if metrics == nil || (len(metrics.Failures) == 0 && len(metrics.Exceptions) == 0) {
+		return
+	}
+	failures, exceptions := metrics.Failures, metrics.Exceptions
+	metrics.Failures, metrics.Exceptions = nil, nil
+
+	if o.failureStore == nil {
+		return
+	}
+	if err := o.failureStore.Record(run.ID, shardID, failures, exceptions, time.Now().UnixMilli()); err != nil {
+		log.Printf("[Orchestrator] Failed to record failures of run %s: %v", run.ID, err)
+	}
+}
+
+// GetRunFailures returns the failures and exceptions reported over a run
+func (o *Orchestrator) GetRunFailures(runID string) (*domain.RunFailures, error) {
+	if o.failureStore == nil {
+		return &domain.RunFailures{RunID: runID}, nil
+	}
+	return o.failureStore.Get(runID)
+}
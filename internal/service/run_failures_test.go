@@ -0,0 +1,145 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"sync"
+	"testing"
+)
+
+// memoryFailureStore is an in-memory FailureRepository adding reports up like the MongoDB store
+type memoryFailureStore struct {
+	mu   sync.Mutex
+	runs map[string]*domain.RunFailures
+}
+
+func (s *memoryFailureStore) Record(runID, shardID string, failures []domain.FailureGroup, exceptions []domain.ExceptionGroup, at int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.runs == nil {
+		s.runs = make(map[string]*domain.RunFailures)
+	}
+	run, ok := s.runs[runID]
+	if !ok {
+		run = &domain.RunFailures{RunID: runID}
+		s.runs[runID] = run
+	}
+	run.Record(shardID, failures, exceptions, at)
+	return nil
+}
+
+func (s *memoryFailureStore) Get(runID string) (*domain.RunFailures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if run, ok := s.runs[runID]; ok {
+		return run, nil
+	}
+	return &domain.RunFailures{RunID: runID}, nil
+}
+
+// failingSnapshot returns a snapshot carrying cumulative failure and exception counts
+func failingSnapshot(failures, exceptions int64) *domain.MetricSnapshot {
+	return &domain.MetricSnapshot{
+		TotalRequests: 100,
+		TotalFailures: failures,
+		Failures:      []domain.FailureGroup{{Method: "GET", Name: "/", Error: "timeout", Occurrences: failures}},
+		Exceptions:    []domain.ExceptionGroup{{Message: "boom", Traceback: "tb", Count: exceptions}},
+	}
+}
+
+func TestUpdateMetricsRecordsFailures(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+	failures := &memoryFailureStore{}
+	o.failureStore = failures
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	for _, snapshot := range []*domain.MetricSnapshot{failingSnapshot(3, 1), failingSnapshot(5, 2)} {
+		if err := o.UpdateMetrics("run-1", "", snapshot); err != nil {
+			t.Fatalf("UpdateMetrics: %v", err)
+		}
+	}
+	if err := o.HandleTestStop("run-1", "", failingSnapshot(6, 2), false); err != nil {
+		t.Fatalf("HandleTestStop: %v", err)
+	}
+
+	recorded, err := o.GetRunFailures("run-1")
+	if err != nil {
+		t.Fatalf("GetRunFailures: %v", err)
+	}
+	if total := recorded.TotalFailures(); total != 6 {
+		t.Errorf("total failures = %d, want 6", total)
+	}
+	if total := recorded.TotalExceptions(); total != 2 {
+		t.Errorf("total exceptions = %d, want 2", total)
+	}
+
+	// The groups are kept out of the run's metrics
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.LastMetrics == nil || run.LastMetrics.Failures != nil || run.LastMetrics.Exceptions != nil {
+		t.Errorf("last metrics = %+v, want them without failure groups", run.LastMetrics)
+	}
+	if run.LastMetrics != nil && run.LastMetrics.TotalFailures != 6 {
+		t.Errorf("total failures in metrics = %d, want 6", run.LastMetrics.TotalFailures)
+	}
+}
+
+func TestUpdateMetricsRecordsShardFailures(t *testing.T) {
+	first := newTestMaster(t, locusttest.Options{})
+	second := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, first, second)
+	failures := &memoryFailureStore{}
+	o.failureStore = failures
+
+	run := launchTwoShardRun(t, o)
+	pushes := []struct {
+		shardID  string
+		failures int64
+	}{
+		{shardID: "cluster-1", failures: 4},
+		{shardID: "cluster-2", failures: 2},
+		{shardID: "cluster-1", failures: 5},
+	}
+	for _, push := range pushes {
+		if err := o.UpdateMetrics(run.ID, push.shardID, failingSnapshot(push.failures, 0)); err != nil {
+			t.Fatalf("UpdateMetrics %s: %v", push.shardID, err)
+		}
+	}
+
+	recorded, err := o.GetRunFailures(run.ID)
+	if err != nil {
+		t.Fatalf("GetRunFailures: %v", err)
+	}
+	if len(recorded.Failures) != 1 {
+		t.Fatalf("failure groups = %d, want 1", len(recorded.Failures))
+	}
+	if group := recorded.Failures[0]; group.Occurrences != 7 {
+		t.Errorf("occurrences = %d, want 7 summed over the shards", group.Occurrences)
+	}
+}
+
+func TestGetRunFailuresWithoutStore(t *testing.T) {
+	o := newTestOrchestrator(t, newTestMaster(t, locusttest.Options{}))
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if err := o.UpdateMetrics("run-1", "", failingSnapshot(3, 1)); err != nil {
+		t.Fatalf("UpdateMetrics: %v", err)
+	}
+
+	recorded, err := o.GetRunFailures("run-1")
+	if err != nil {
+		t.Fatalf("GetRunFailures: %v", err)
+	}
+	if recorded.RunID != "run-1" || len(recorded.Failures) != 0 || len(recorded.Exceptions) != 0 {
+		t.Errorf("failures = %+v, want none for run-1", recorded)
+	}
+}
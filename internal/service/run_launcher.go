@@ -0,0 +1,215 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRunParameters is returned when the resolved run parameters cannot be executed
+	ErrInvalidRunParameters = errors.New("invalid run parameters")
+	// ErrNoScriptRevision is returned when the load test has no script to run
+	ErrNoScriptRevision = errors.New("no script found for this load test")
+	// ErrRunNotCreated is returned when the run could not be persisted
+	ErrRunNotCreated = errors.New("failed to create load test run")
+	// ErrRunNotStarted is returned when the run was persisted but could not be started
+	ErrRunNotStarted = errors.New("failed to start load test")
+	// ErrShardClustersBusy is returned when a cluster of a distributed run is running another run
+	ErrShardClustersBusy = errors.New("a cluster of the distributed run is busy")
+)
+
+// LaunchRunRequest holds the per-run overrides applied on top of a LoadTest's defaults
+type LaunchRunRequest struct {
+	Name            string
+	TargetURL       string
+	TargetUsers     *int
+	SpawnRate       *float64
+	DurationSeconds *int
+	LoadProfile     *domain.LoadProfile
+	Shards          []domain.ShardSpec // Distributes the run across several clusters when set
+	CreatedBy       string
+	Metadata        map[string]any
+}
+
+// LaunchLoadTestRun creates a run of a LoadTest from its latest script revision and starts it
+// This is the single path used by the API and the scheduler to create runs
+func (o *Orchestrator) LaunchLoadTestRun(loadTest *domain.LoadTest, req *LaunchRunRequest) (*domain.LoadTestRun, error) {
+	// Apply defaults from LoadTest, allow overrides
+	targetURL := loadTest.TargetURL
+	if req.TargetURL != "" {
+		targetURL = req.TargetURL
+	}
+
+	targetUsers := loadTest.DefaultUsers
+	if req.TargetUsers != nil {
+		targetUsers = *req.TargetUsers
+	}
+
+	spawnRate := loadTest.DefaultSpawnRate
+	if req.SpawnRate != nil {
+		spawnRate = *req.SpawnRate
+	}
+
+	var durationSeconds *int
+	if req.DurationSeconds != nil {
+		durationSeconds = req.DurationSeconds
+	} else if loadTest.DefaultDurationSec != nil {
+		durationSeconds = loadTest.DefaultDurationSec
+	}
+
+	// A load profile drives users and spawn rate stage by stage, starting with its first stage
+	loadProfile := loadTest.LoadProfile
+	if req.LoadProfile != nil {
+		loadProfile = req.LoadProfile
+	}
+
+	if loadProfile != nil {
+		if err := loadProfile.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: invalid load profile: %v", ErrInvalidRunParameters, err)
+		}
+
+		targetUsers = loadProfile.Stages[0].TargetUsers
+		spawnRate = loadProfile.Stages[0].SpawnRate
+
+		// Without an explicit duration the run ends when the last stage's hold time elapses
+		if durationSeconds == nil {
+			totalDuration := loadProfile.TotalDurationSeconds()
+			durationSeconds = &totalDuration
+		}
+	}
+
+	// A distributed run splits its users across its shards' clusters
+	var shards []domain.RunShard
+	if len(req.Shards) > 0 {
+		var err error
+		shards, err = o.planShards(loadTest, req.Shards, targetUsers, spawnRate, loadProfile != nil)
+		if err != nil {
+			return nil, err
+		}
+
+		targetUsers = 0
+		for _, shard := range shards {
+			targetUsers += shard.Users
+		}
+	}
+
+	// Validate against max duration if set
+	if loadTest.MaxDurationSec != nil && durationSeconds != nil && *durationSeconds > *loadTest.MaxDurationSec {
+		return nil, fmt.Errorf("%w: duration exceeds maximum allowed duration", ErrInvalidRunParameters)
+	}
+
+	// Get the latest script revision
+	latestRevision, err := o.scriptRevisionStore.GetLatestByLoadTestID(loadTest.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoScriptRevision, err)
+	}
+
+	nowMillis := time.Now().UnixMilli()
+	run := &domain.LoadTestRun{
+		ID:               uuid.New().String(),
+		LoadTestID:       loadTest.ID,
+		ScriptRevisionID: latestRevision.ID,
+		Name:             req.Name,
+		AccountID:        loadTest.AccountID,
+		OrgID:            loadTest.OrgID,
+		ProjectID:        loadTest.ProjectID,
+		EnvID:            loadTest.EnvID,
+		Engine:           loadTest.Engine.OrDefault(),
+		TargetURL:        targetURL,
+		TargetUsers:      targetUsers,
+		SpawnRate:        spawnRate,
+		DurationSeconds:  durationSeconds,
+		LoadProfile:      loadProfile,
+		Shards:           shards,
+		Thresholds:       loadTest.Thresholds,
+		AbortRules:       loadTest.AbortRules,
+		CreatedAt:        nowMillis,
+		CreatedBy:        req.CreatedBy,
+		UpdatedAt:        nowMillis,
+		UpdatedBy:        req.CreatedBy,
+		Metadata:         req.Metadata,
+	}
+	if len(shards) > 0 {
+		run.ClusterID = shards[0].ClusterID
+	}
+	run.InitTimeline(domain.LoadTestRunStatusPending, req.CreatedBy, "run created", nowMillis)
+
+	// Distributed runs are not queued: their clusters are held before the run is persisted,
+	// so a busy cluster rejects the run without leaving a failed run behind
+	if run.IsSharded() {
+		if err := o.reserveShardClusters(run); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.loadTestRunStore.Create(run); err != nil {
+		o.releaseRunClusters(run)
+		return nil, fmt.Errorf("%w: %v", ErrRunNotCreated, err)
+	}
+
+	// Start the actual test
+	startReq := &CreateTestRunRequest{
+		LoadTestRunID:   run.ID,
+		LoadTestID:      loadTest.ID,
+		Name:            run.Name,
+		AccountID:       loadTest.AccountID,
+		OrgID:           loadTest.OrgID,
+		ProjectID:       loadTest.ProjectID,
+		EnvID:           loadTest.EnvID,
+		TargetURL:       targetURL,
+		TargetUsers:     targetUsers,
+		SpawnRate:       spawnRate,
+		DurationSeconds: durationSeconds,
+		CreatedBy:       req.CreatedBy,
+		Metadata:        req.Metadata,
+	}
+
+	startedRun, err := o.CreateTestRun(startReq)
+	if err != nil {
+		// Test creation failed, update run status to Failed unless starting it got far enough to do so
+		if current, getErr := o.loadTestRunStore.Get(run.ID); getErr == nil {
+			run = current
+		}
+		if !run.Status.IsTerminal() {
+			o.failRunStart(run, "failed to start load test", err)
+		}
+
+		return run, fmt.Errorf("%w: %v", ErrRunNotStarted, err)
+	}
+
+	return startedRun, nil
+}
+
+// planShards splits a distributed run across the clusters its shards request
+// Every cluster must be registered, run the load test's engine and serve its account, org, project and environment
+func (o *Orchestrator) planShards(loadTest *domain.LoadTest, specs []domain.ShardSpec, targetUsers int, spawnRate float64, profiled bool) ([]domain.RunShard, error) {
+	shards, err := domain.PlanShards(specs, targetUsers, spawnRate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRunParameters, err)
+	}
+
+	// A load profile sets the run's users stage by stage, which only weights can follow
+	if profiled && specs[0].Users > 0 {
+		return nil, fmt.Errorf("%w: shards of a run with a load profile must set weights, not users", ErrInvalidRunParameters)
+	}
+
+	for _, shard := range shards {
+		cluster, err := o.GetCluster(shard.ClusterID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: shard cluster %s: %v", ErrInvalidRunParameters, shard.ClusterID, err)
+		}
+		if !cluster.Matches(loadTest.AccountID, loadTest.OrgID, loadTest.ProjectID, loadTest.EnvID) {
+			return nil, fmt.Errorf("%w: cluster %s does not serve this load test's account, org, project and environment",
+				ErrInvalidRunParameters, shard.ClusterID)
+		}
+		if !cluster.Runs(loadTest.Engine) {
+			return nil, fmt.Errorf("%w: cluster %s does not run the %s engine", ErrInvalidRunParameters, shard.ClusterID, loadTest.Engine.OrDefault())
+		}
+	}
+
+	return shards, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"testing"
+)
+
+// launchRun launches a run of load test "test-1" with the given overrides
+func launchRun(t *testing.T, o *Orchestrator, req *LaunchRunRequest) *domain.LoadTestRun {
+	t.Helper()
+
+	loadTest, err := o.loadTestStore.Get("test-1")
+	if err != nil {
+		t.Fatalf("failed to get load test: %v", err)
+	}
+	if req.CreatedBy == "" {
+		req.CreatedBy = "tester"
+	}
+	run, err := o.LaunchLoadTestRun(loadTest, req)
+	if err != nil {
+		t.Fatalf("LaunchLoadTestRun: %v", err)
+	}
+	return run
+}
+
+func TestLaunchLoadTestRunPassesTargetHostToLocust(t *testing.T) {
+	tests := []struct {
+		name      string
+		targetURL string
+		wantHost  string
+	}{
+		{name: "load test default", wantHost: "http://target.example"},
+		{name: "run override", targetURL: "http://override.example", wantHost: "http://override.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master := newTestMaster(t, locusttest.Options{})
+			o := newTestOrchestrator(t, master)
+
+			run := launchRun(t, o, &LaunchRunRequest{TargetURL: tt.targetURL})
+
+			if run.TargetURL != tt.wantHost {
+				t.Errorf("run target URL = %q, want %q", run.TargetURL, tt.wantHost)
+			}
+			if host := master.Host(); host != tt.wantHost {
+				t.Errorf("swarm host = %q, want %q", host, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestQueuedRunKeepsItsTargetHost(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	first := launchRun(t, o, &LaunchRunRequest{})
+	queued := launchRun(t, o, &LaunchRunRequest{TargetURL: "http://override.example"})
+	if queued.Status != domain.LoadTestRunStatusPending {
+		t.Fatalf("second run status = %s, want it queued", queued.Status)
+	}
+
+	if err := o.StopTestRun(first.ID, "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+	waitForStatus(t, o, queued.ID, domain.LoadTestRunStatusRunning)
+
+	if host := master.Host(); host != "http://override.example" {
+		t.Errorf("swarm host of the queued run = %q, want http://override.example", host)
+	}
+}
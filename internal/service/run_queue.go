@@ -0,0 +1,281 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/store"
+	"log"
+	"sort"
+	"time"
+)
+
+// acquireCluster admits a run to a cluster if no other run holds it
+// Returns false and appends the run to the cluster's FIFO queue if the cluster is busy
+func (o *Orchestrator) acquireCluster(clusterID, runID string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	holder, busy := o.clusterHolders[clusterID]
+	if !busy || holder == runID {
+		o.clusterHolders[clusterID] = runID
+		return true
+	}
+
+	for _, queuedID := range o.clusterQueues[clusterID] {
+		if queuedID == runID {
+			return false
+		}
+	}
+
+	o.clusterQueues[clusterID] = append(o.clusterQueues[clusterID], runID)
+	return false
+}
+
+// tryAcquireCluster admits a run to a cluster if no other run holds it, without queueing it otherwise
+func (o *Orchestrator) tryAcquireCluster(clusterID, runID string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if holder, busy := o.clusterHolders[clusterID]; busy && holder != runID {
+		return false
+	}
+
+	o.clusterHolders[clusterID] = runID
+	return true
+}
+
+// releaseCluster frees a cluster held by a run and starts the next queued run, if any
+func (o *Orchestrator) releaseCluster(clusterID, runID string) {
+	if clusterID == "" {
+		return
+	}
+
+	o.mu.Lock()
+	if holder, ok := o.clusterHolders[clusterID]; !ok || holder != runID {
+		o.mu.Unlock()
+		return
+	}
+	delete(o.clusterHolders, clusterID)
+	o.mu.Unlock()
+
+	log.Printf("[Orchestrator] Run %s released cluster %s", runID, clusterID)
+	o.dispatchNext(clusterID)
+}
+
+// dispatchNext hands a free cluster to the run at the head of its queue
+// A cluster with an empty queue takes over a run waiting for another cluster of its pool
+func (o *Orchestrator) dispatchNext(clusterID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, busy := o.clusterHolders[clusterID]; busy {
+		return
+	}
+
+	queue := o.clusterQueues[clusterID]
+	if len(queue) == 0 {
+		go o.stealQueuedRun(clusterID)
+		return
+	}
+
+	nextID := queue[0]
+	if len(queue) == 1 {
+		delete(o.clusterQueues, clusterID)
+	} else {
+		o.clusterQueues[clusterID] = queue[1:]
+	}
+	o.clusterHolders[clusterID] = nextID
+
+	go o.startQueuedRun(clusterID, nextID)
+}
+
+// stealQueuedRun hands an idle cluster the longest waiting run at the head of another cluster's queue
+// that the idle cluster can serve: its engine and scope must match the run's
+func (o *Orchestrator) stealQueuedRun(clusterID string) {
+	o.mu.RLock()
+	registered, ok := o.clusters[clusterID]
+	var cluster *domain.LocustCluster
+	if ok {
+		cluster = copyCluster(registered)
+	}
+	heads := make(map[string]string) // Map of runID -> clusterID the run is queued on
+	for queuedOn, queue := range o.clusterQueues {
+		if queuedOn != clusterID && len(queue) > 0 {
+			heads[queue[0]] = queuedOn
+		}
+	}
+	o.mu.RUnlock()
+
+	if cluster == nil || len(heads) == 0 {
+		return
+	}
+	if cluster.Health != nil && !cluster.Health.Reachable {
+		return
+	}
+
+	var next *domain.LoadTestRun
+	for runID := range heads {
+		run, err := o.loadTestRunStore.Get(runID)
+		if err != nil || !cluster.Runs(run.Engine) || !cluster.Matches(run.AccountID, run.OrgID, run.ProjectID, run.EnvID) {
+			continue
+		}
+		if next == nil || run.QueuedAt < next.QueuedAt {
+			next = run
+		}
+	}
+	if next == nil {
+		return
+	}
+	queuedOn := heads[next.ID]
+
+	o.mu.Lock()
+	queue := o.clusterQueues[queuedOn]
+	if _, busy := o.clusterHolders[clusterID]; busy || len(queue) == 0 || queue[0] != next.ID {
+		// The cluster or the queue changed meanwhile, whoever changed it dispatches again
+		o.mu.Unlock()
+		return
+	}
+	if len(queue) == 1 {
+		delete(o.clusterQueues, queuedOn)
+	} else {
+		o.clusterQueues[queuedOn] = queue[1:]
+	}
+	o.clusterHolders[clusterID] = next.ID
+	o.mu.Unlock()
+
+	log.Printf("[Orchestrator] Cluster %s is idle, taking over run %s queued on cluster %s", clusterID, next.ID, queuedOn)
+	o.startQueuedRun(clusterID, next.ID)
+}
+
+// startQueuedRun starts a run that was waiting for its cluster using the parameters persisted on the run
+func (o *Orchestrator) startQueuedRun(clusterID, runID string) {
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		log.Printf("[Orchestrator] Failed to load queued run %s: %v", runID, err)
+		o.releaseCluster(clusterID, runID)
+		return
+	}
+
+	if run.Status != domain.LoadTestRunStatusPending {
+		log.Printf("[Orchestrator] Skipping queued run %s: status is %s", runID, run.Status)
+		o.releaseCluster(clusterID, runID)
+		return
+	}
+
+	log.Printf("[Orchestrator] Cluster %s is free, starting queued run %s", clusterID, runID)
+
+	req := &CreateTestRunRequest{
+		LoadTestRunID:   run.ID,
+		ClusterID:       clusterID,
+		LoadTestID:      run.LoadTestID,
+		Name:            run.Name,
+		AccountID:       run.AccountID,
+		OrgID:           run.OrgID,
+		ProjectID:       run.ProjectID,
+		EnvID:           run.EnvID,
+		TargetURL:       run.TargetURL,
+		TargetUsers:     run.TargetUsers,
+		SpawnRate:       run.SpawnRate,
+		DurationSeconds: run.DurationSeconds,
+		CreatedBy:       run.CreatedBy,
+		Metadata:        run.Metadata,
+	}
+
+	if _, err := o.CreateTestRun(req); err != nil {
+		log.Printf("[Orchestrator] Failed to start queued run %s: %v", runID, err)
+	}
+}
+
+// dequeueRun removes a run from its cluster's queue; false if the run was not queued
+func (o *Orchestrator) dequeueRun(clusterID, runID string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	queue := o.clusterQueues[clusterID]
+	for i, queuedID := range queue {
+		if queuedID != runID {
+			continue
+		}
+		queue = append(queue[:i:i], queue[i+1:]...)
+		if len(queue) == 0 {
+			delete(o.clusterQueues, clusterID)
+		} else {
+			o.clusterQueues[clusterID] = queue
+		}
+		return true
+	}
+
+	return false
+}
+
+// QueuePosition returns the 1-based position of a run in its cluster's queue, or 0 if it is not queued
+func (o *Orchestrator) QueuePosition(runID string) int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	for _, queue := range o.clusterQueues {
+		for i, queuedID := range queue {
+			if queuedID == runID {
+				return i + 1
+			}
+		}
+	}
+
+	return 0
+}
+
+// restoreRunQueues rebuilds the cluster queues from persisted runs after a restart
+// Cluster holders are restored beforehand by reconcileRuns as it resumes running runs
+func (o *Orchestrator) restoreRunQueues() {
+	pendingStatus := domain.LoadTestRunStatusPending
+	pending, err := o.loadTestRunStore.List(&store.LoadTestRunFilter{Status: &pendingStatus})
+	if err != nil {
+		log.Printf("[Orchestrator] Failed to list pending runs while restoring queues: %v", err)
+		return
+	}
+
+	// Only runs that were explicitly queued are restored, in the order they were queued
+	var queued []*domain.LoadTestRun
+	for _, run := range pending {
+		if run.ClusterID != "" && run.QueuedAt > 0 {
+			queued = append(queued, run)
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].QueuedAt < queued[j].QueuedAt
+	})
+
+	o.mu.Lock()
+	for _, run := range queued {
+		o.clusterQueues[run.ClusterID] = append(o.clusterQueues[run.ClusterID], run.ID)
+	}
+	clusterIDs := make([]string, 0, len(o.clusterQueues))
+	for clusterID := range o.clusterQueues {
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	o.mu.Unlock()
+
+	if len(queued) == 0 {
+		return
+	}
+	log.Printf("[Orchestrator] Restored %d queued runs across %d clusters", len(queued), len(clusterIDs))
+
+	// Clusters that were freed while the control plane was down can start their next run right away,
+	// then idle clusters without a queue take over runs waiting for other clusters of their pool
+	for _, clusterID := range clusterIDs {
+		o.dispatchNext(clusterID)
+	}
+	for _, cluster := range o.ListClusters() {
+		o.dispatchNext(cluster.ID)
+	}
+}
+
+// queueRun marks a run as waiting for its cluster and persists it so the queue survives restarts
+func (o *Orchestrator) queueRun(run *domain.LoadTestRun) error {
+	nowMillis := time.Now().UnixMilli()
+	if run.QueuedAt == 0 {
+		run.QueuedAt = nowMillis
+	}
+	run.UpdatedAt = nowMillis
+
+	return o.loadTestRunStore.Update(run)
+}
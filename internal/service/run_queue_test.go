@@ -0,0 +1,161 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"testing"
+	"time"
+)
+
+// waitForStatus polls a run until it reaches a status, failing the test if it does not within a few seconds
+func waitForStatus(t *testing.T, o *Orchestrator, runID string, status domain.LoadTestRunStatus) *domain.LoadTestRun {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		run, err := o.GetTestRun(runID)
+		if err != nil {
+			t.Fatalf("GetTestRun: %v", err)
+		}
+		if run.Status == status {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run %s status = %s, want %s", runID, run.Status, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCreateTestRunQueuesWhenClusterBusy(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	queued, err := o.CreateTestRun(createPendingRun(t, o, "run-2"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if queued.Status != domain.LoadTestRunStatusPending {
+		t.Errorf("status = %s, want %s", queued.Status, domain.LoadTestRunStatusPending)
+	}
+	if queued.QueuedAt == 0 {
+		t.Error("queued run has no queue time")
+	}
+	if position := o.QueuePosition("run-2"); position != 1 {
+		t.Errorf("queue position = %d, want 1", position)
+	}
+	if len(master.Calls(locusttest.EndpointSwarm)) != 1 {
+		t.Errorf("swarm calls = %d, want 1", len(master.Calls(locusttest.EndpointSwarm)))
+	}
+}
+
+func TestStoppedRunDispatchesQueuedRuns(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	for _, runID := range []string{"run-1", "run-2", "run-3"} {
+		if _, err := o.CreateTestRun(createPendingRun(t, o, runID)); err != nil {
+			t.Fatalf("CreateTestRun %s: %v", runID, err)
+		}
+	}
+	if position := o.QueuePosition("run-3"); position != 2 {
+		t.Fatalf("run-3 queue position = %d, want 2", position)
+	}
+
+	// Stopping the holder hands the cluster to the head of the queue
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+	next := waitForStatus(t, o, "run-2", domain.LoadTestRunStatusRunning)
+	if next.ClusterID != "cluster-1" {
+		t.Errorf("run-2 cluster = %s, want cluster-1", next.ClusterID)
+	}
+	if runCtx := master.RunContext(); runCtx.RunID != "run-2" {
+		t.Errorf("run context = %+v, want run-2", runCtx)
+	}
+	if position := o.QueuePosition("run-3"); position != 1 {
+		t.Errorf("run-3 queue position = %d, want 1", position)
+	}
+
+	if err := o.StopTestRun("run-2", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+	waitForStatus(t, o, "run-3", domain.LoadTestRunStatusRunning)
+	if len(master.Calls(locusttest.EndpointSwarm)) != 3 {
+		t.Errorf("swarm calls = %d, want 3", len(master.Calls(locusttest.EndpointSwarm)))
+	}
+}
+
+func TestStopTestRunCancelsQueuedRun(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	for _, runID := range []string{"run-1", "run-2", "run-3"} {
+		if _, err := o.CreateTestRun(createPendingRun(t, o, runID)); err != nil {
+			t.Fatalf("CreateTestRun %s: %v", runID, err)
+		}
+	}
+
+	if err := o.StopTestRun("run-2", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+	cancelled, err := o.GetTestRun("run-2")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if cancelled.Status != domain.LoadTestRunStatusStopped {
+		t.Errorf("status = %s, want %s", cancelled.Status, domain.LoadTestRunStatusStopped)
+	}
+	if position := o.QueuePosition("run-2"); position != 0 {
+		t.Errorf("cancelled run queue position = %d, want 0", position)
+	}
+	if position := o.QueuePosition("run-3"); position != 1 {
+		t.Errorf("run-3 queue position = %d, want 1", position)
+	}
+	if len(master.Calls(locusttest.EndpointStop)) != 0 {
+		t.Error("cancelling a queued run called Locust")
+	}
+
+	// The cancelled run is skipped when the cluster frees up
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+	waitForStatus(t, o, "run-3", domain.LoadTestRunStatusRunning)
+	if run, _ := o.GetTestRun("run-2"); run.Status != domain.LoadTestRunStatusStopped {
+		t.Errorf("cancelled run status = %s, want %s", run.Status, domain.LoadTestRunStatusStopped)
+	}
+}
+
+func TestIdleClusterTakesOverOnlyRunsOfItsEngine(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-1")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if _, err := o.CreateTestRun(createPendingRun(t, o, "run-2")); err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+
+	// An idle native generator cluster of the same pool leaves the queued Locust run alone
+	o.setCluster(&domain.LocustCluster{ID: "native-1", Engine: domain.EngineNative, AccountID: "acc", OrgID: "org", ProjectID: "proj"})
+	o.stealQueuedRun("native-1")
+	if position := o.QueuePosition("run-2"); position != 1 {
+		t.Errorf("run-2 queue position = %d, want 1", position)
+	}
+	if run, _ := o.GetTestRun("run-2"); run.Status != domain.LoadTestRunStatusPending {
+		t.Errorf("run-2 status = %s, want %s", run.Status, domain.LoadTestRunStatusPending)
+	}
+
+	// A new Locust cluster takes it over
+	second := newTestMaster(t, locusttest.Options{})
+	if err := o.RegisterCluster(testCluster("cluster-2", second)); err != nil {
+		t.Fatalf("RegisterCluster: %v", err)
+	}
+	if run := waitForStatus(t, o, "run-2", domain.LoadTestRunStatusRunning); run.ClusterID != "cluster-2" {
+		t.Errorf("run-2 cluster = %s, want cluster-2", run.ClusterID)
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
+	"Load-manager-cli/internal/store"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// runReaper periodically checks running runs for missing heartbeats until the orchestrator is stopped
+func (o *Orchestrator) runReaper() {
+	interval := time.Duration(o.config.Orchestrator.ReaperIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-ticker.C:
+			o.reapStaleRuns()
+		}
+	}
+}
+
+// reapStaleRuns probes every running run that has been silent for longer than the heartbeat timeout,
+// and retries the stop of every run that has been stopping for longer than it
+func (o *Orchestrator) reapStaleRuns() {
+	timeout := time.Duration(o.config.Orchestrator.HeartbeatTimeoutSeconds) * time.Second
+
+	running := domain.LoadTestRunStatusRunning
+	runs, err := o.loadTestRunStore.List(&store.LoadTestRunFilter{Status: &running})
+	if err != nil {
+		log.Printf("[Reaper] Failed to list running runs: %v", err)
+	} else {
+		now := time.Now()
+		for _, run := range runs {
+			if now.Sub(time.UnixMilli(lastHeartbeat(run))) < timeout {
+				continue
+			}
+			o.probeStaleRun(run.ID, timeout)
+		}
+	}
+
+	stopping := domain.LoadTestRunStatusStopping
+	runs, err = o.loadTestRunStore.List(&store.LoadTestRunFilter{Status: &stopping})
+	if err != nil {
+		log.Printf("[Reaper] Failed to list stopping runs: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, run := range runs {
+		if now.Sub(time.UnixMilli(stoppingSince(run))) < timeout {
+			continue
+		}
+		o.reapStoppingRun(run.ID, timeout)
+	}
+}
+
+// probeStaleRun asks the run's Locust cluster whether it is still generating load
+// A running cluster gets the run context re-sent so metric pushes resume; otherwise the run is failed
+func (o *Orchestrator) probeStaleRun(runID string, timeout time.Duration) {
+	unlock := o.lockRun(runID)
+	defer unlock()
+
+	// Re-read under the lock: a heartbeat or stop may have arrived since the run was listed
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		log.Printf("[Reaper] Failed to get run %s: %v", runID, err)
+		return
+	}
+	silence := time.Since(time.UnixMilli(lastHeartbeat(run)))
+	if run.Status != domain.LoadTestRunStatusRunning || silence < timeout {
+		return
+	}
+
+	log.Printf("[Reaper] Run %s has sent no metrics for %s, probing its Locust cluster", run.ID, silence.Round(time.Second))
+
+	client, err := o.clientForRun(run)
+	if err != nil {
+		o.failStaleRun(run, fmt.Sprintf("no metrics for %s and no Locust client: %v", silence.Round(time.Second), err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(o.ctx, 10*time.Second)
+	defer cancel()
+
+	stats, err := client.Stats(ctx)
+	if err != nil {
+		o.failStaleRun(run, fmt.Sprintf("no metrics for %s and Locust master unreachable: %v", silence.Round(time.Second), err))
+		return
+	}
+
+	if stats.RunnerState != "running" && stats.RunnerState != "spawning" {
+		o.failStaleRun(run, fmt.Sprintf("no metrics for %s and Locust runner is %q", silence.Round(time.Second), stats.RunnerState))
+		return
+	}
+
+	// Locust is still running: point the plugin back at this run with whatever duration remains
+	durationSeconds := run.DurationSeconds
+	if durationSeconds != nil && run.StartedAt > 0 {
+		remaining := *durationSeconds - int(time.Since(time.UnixMilli(run.StartedAt)).Seconds())
+		if remaining < 1 {
+			remaining = 1
+		}
+		durationSeconds = &remaining
+	}
+
+	runCtx := engine.RunContext{RunID: run.ID, TenantID: run.AccountID, EnvID: run.EnvID, DurationSeconds: durationSeconds}
+	if err := client.Attach(ctx, runCtx); err != nil {
+		log.Printf("Warning: failed to re-send run context for run %s: %v", run.ID, err)
+	}
+
+	// Only the heartbeat moves: the probed snapshot was never stored, so it must not become the
+	// baseline the next pushed snapshot's interval is computed against
+	nowMillis := time.Now().UnixMilli()
+	run.LastHeartbeatAt = nowMillis
+	run.UpdatedAt = nowMillis
+
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		log.Printf("[Reaper] Failed to re-attach run %s: %v", run.ID, err)
+		return
+	}
+
+	log.Printf("[Reaper] Re-attached run %s: Locust is %s with %d users", run.ID, stats.RunnerState, stats.CurrentUsers)
+}
+
+// reapStoppingRun retries the stop of a run left Stopping, e.g. because Locust could not be reached
+// when the stop was requested; the run is Stopped once Locust confirms, otherwise it is failed
+func (o *Orchestrator) reapStoppingRun(runID string, timeout time.Duration) {
+	unlock := o.lockRun(runID)
+	defer unlock()
+
+	// Re-read under the lock: the stop may have completed since the run was listed
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		log.Printf("[Reaper] Failed to get run %s: %v", runID, err)
+		return
+	}
+	stuck := time.Since(time.UnixMilli(stoppingSince(run)))
+	if run.Status != domain.LoadTestRunStatusStopping || stuck < timeout {
+		return
+	}
+
+	log.Printf("[Reaper] Run %s has been stopping for %s, retrying the stop", run.ID, stuck.Round(time.Second))
+
+	client, err := o.clientForRun(run)
+	if err != nil {
+		o.failStaleRun(run, fmt.Sprintf("stopping for %s and no Locust client: %v", stuck.Round(time.Second), err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
+	defer cancel()
+
+	if err := client.Stop(ctx); err != nil {
+		o.failStaleRun(run, fmt.Sprintf("stopping for %s and Locust stop failed: %v", stuck.Round(time.Second), err))
+		return
+	}
+
+	if err := o.finalizeRun(run, domain.LoadTestRunStatusStopped, domain.RunActorControlPlane, "stop completed by the reaper"); err != nil {
+		log.Printf("[Reaper] Failed to mark run %s as stopped: %v", run.ID, err)
+		return
+	}
+
+	log.Printf("[Reaper] Stopped run %s", run.ID)
+}
+
+// failStaleRun marks a silent or stuck run as Failed with the given reason
+// Callers must hold the run lock
+func (o *Orchestrator) failStaleRun(run *domain.LoadTestRun, reason string) {
+	log.Printf("[Reaper] Failing run %s: %s", run.ID, reason)
+
+	run.FailureReason = reason
+	if err := o.finalizeRun(run, domain.LoadTestRunStatusFailed, domain.RunActorControlPlane, reason); err != nil {
+		log.Printf("[Reaper] Failed to mark run %s as failed: %v", run.ID, err)
+	}
+}
+
+// stoppingSince returns when a run entered Stopping in Unix milliseconds
+func stoppingSince(run *domain.LoadTestRun) int64 {
+	for i := len(run.Timeline) - 1; i >= 0; i-- {
+		if run.Timeline[i].To == domain.LoadTestRunStatusStopping {
+			return run.Timeline[i].At
+		}
+	}
+	return run.UpdatedAt
+}
+
+// lastHeartbeat returns the last time a run was known to be alive in Unix milliseconds
+func lastHeartbeat(run *domain.LoadTestRun) int64 {
+	if run.LastHeartbeatAt > run.StartedAt {
+		return run.LastHeartbeatAt
+	}
+	return run.StartedAt
+}
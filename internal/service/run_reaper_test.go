@@ -0,0 +1,147 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newReapingOrchestrator returns a test orchestrator whose reaper treats runs silent for a minute as stale
+func newReapingOrchestrator(t *testing.T, masters ...*locusttest.Master) *Orchestrator {
+	t.Helper()
+	o := newTestOrchestrator(t, masters...)
+	o.config.Orchestrator.HeartbeatTimeoutSeconds = 60
+	return o
+}
+
+// silenceRun moves a run's start and last heartbeat back by silence, as if it stopped reporting then
+func silenceRun(t *testing.T, o *Orchestrator, runID string, silence time.Duration) {
+	t.Helper()
+
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	run.StartedAt = time.Now().Add(-silence).UnixMilli()
+	run.LastHeartbeatAt = run.StartedAt
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+}
+
+func TestReaperReattachesSilentRunStillRunning(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newReapingOrchestrator(t, master)
+
+	startTimedRun(t, o, "run-1", 600)
+
+	// A run that reported recently is left alone
+	o.reapStaleRuns()
+	if probes := len(master.Calls(locusttest.EndpointStats)); probes != 0 {
+		t.Fatalf("stats calls = %d, want 0 for a live run", probes)
+	}
+
+	// The plugin lost the run context, e.g. because Locust restarted, and the run went silent
+	master.SetRunContext(locusttest.RunContext{})
+	silenceRun(t, o, "run-1", 2*time.Minute)
+
+	o.reapStaleRuns()
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusRunning {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusRunning)
+	}
+	if silence := time.Since(time.UnixMilli(run.LastHeartbeatAt)); silence > 10*time.Second {
+		t.Errorf("heartbeat %s ago, want it moved to the probe", silence)
+	}
+
+	runCtx := master.RunContext()
+	if runCtx.RunID != "run-1" {
+		t.Fatalf("run context = %+v, want run-1 re-sent", runCtx)
+	}
+	if remaining, err := strconv.Atoi(runCtx.DurationSeconds); err != nil || remaining <= 0 || remaining > 480 {
+		t.Errorf("re-sent duration = %q, want what remains of 600s after 2 minutes", runCtx.DurationSeconds)
+	}
+}
+
+func TestReaperFailsSilentRunLocustIsNotRunning(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		lose   func(t *testing.T, master *locusttest.Master) // Ends the run behind the control plane's back
+	}{
+		{name: "runner stopped", reason: `runner is "stopped"`, lose: func(t *testing.T, master *locusttest.Master) {
+			resp, err := http.Get(master.URL() + locusttest.EndpointStop)
+			if err != nil {
+				t.Fatalf("failed to stop master: %v", err)
+			}
+			resp.Body.Close()
+		}},
+		{name: "master unreachable", reason: "unreachable", lose: func(t *testing.T, master *locusttest.Master) {
+			master.Close()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master := newTestMaster(t, locusttest.Options{})
+			o := newReapingOrchestrator(t, master)
+
+			startTimedRun(t, o, "run-1", 600)
+			tt.lose(t, master)
+			silenceRun(t, o, "run-1", 2*time.Minute)
+
+			o.reapStaleRuns()
+
+			run, err := o.GetTestRun("run-1")
+			if err != nil {
+				t.Fatalf("GetTestRun: %v", err)
+			}
+			if run.Status != domain.LoadTestRunStatusFailed {
+				t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusFailed)
+			}
+			if !strings.Contains(run.FailureReason, tt.reason) {
+				t.Errorf("failure reason = %q, want it to mention %q", run.FailureReason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestReaperCompletesStuckStop(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newReapingOrchestrator(t, master)
+
+	startTimedRun(t, o, "run-1", 600)
+
+	// The stop was requested two minutes ago, but Locust never confirmed it
+	run, err := o.loadTestRunStore.Get("run-1")
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	if err := run.Transition(domain.LoadTestRunStatusStopping, "tester", "stop requested", time.Now().Add(-2*time.Minute).UnixMilli()); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+
+	o.reapStaleRuns()
+
+	run, err = o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusStopped {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusStopped)
+	}
+	if state := master.State(); state != locusttest.StateStopped {
+		t.Errorf("master state = %s, want %s", state, locusttest.StateStopped)
+	}
+}
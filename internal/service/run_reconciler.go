@@ -0,0 +1,223 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
+	"Load-manager-cli/internal/store"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// clusterState is what a Locust cluster reported during startup reconciliation
+type clusterState struct {
+	stats  *domain.MetricSnapshot
+	runCtx *engine.State
+	err    error
+}
+
+// generating reports whether the cluster's runner is generating load
+func (s *clusterState) generating() bool {
+	return s.err == nil && (s.stats.RunnerState == "running" || s.stats.RunnerState == "spawning")
+}
+
+// runsOn reports whether the plugin's run context points at the given run
+func (s *clusterState) runsOn(runID string) bool {
+	return s.err == nil && s.runCtx.RunID == runID
+}
+
+// reconcileRuns checks every run a previous control plane process left Pending, Running or Stopping
+// against its Locust cluster and resumes, finalizes or fails it, then restores the run queues
+func (o *Orchestrator) reconcileRuns() {
+	states := make(map[string]*clusterState)
+	reconciled := 0
+
+	for _, status := range []domain.LoadTestRunStatus{
+		domain.LoadTestRunStatusRunning,
+		domain.LoadTestRunStatusStopping,
+		domain.LoadTestRunStatusPending,
+	} {
+		runs, err := o.loadTestRunStore.List(&store.LoadTestRunFilter{Status: &status})
+		if err != nil {
+			log.Printf("[Reconciler] Failed to list %s runs: %v", status, err)
+			continue
+		}
+
+		for _, run := range runs {
+			o.reconcileRun(run.ID, states)
+			reconciled++
+		}
+	}
+
+	if reconciled > 0 {
+		log.Printf("[Reconciler] Reconciled %d in-flight runs against %d Locust clusters", reconciled, len(states))
+	}
+
+	o.restoreRunQueues()
+}
+
+// reconcileRun brings a single non-terminal run in line with what its Locust cluster reports
+func (o *Orchestrator) reconcileRun(runID string, states map[string]*clusterState) {
+	unlock := o.lockRun(runID)
+	defer unlock()
+
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		log.Printf("[Reconciler] Failed to get run %s: %v", runID, err)
+		return
+	}
+	if run.Status.IsTerminal() {
+		return
+	}
+
+	// Queued runs never reached Locust unless the restart interrupted their start
+	queued := run.Status == domain.LoadTestRunStatusPending && run.QueuedAt > 0
+
+	if run.ClusterID == "" {
+		cluster, err := o.resolveCluster(run.AccountID, run.OrgID, run.ProjectID, run.EnvID)
+		if err != nil {
+			o.failReconciledRun(run, fmt.Sprintf("no Locust cluster after control plane restart: %v", err))
+			return
+		}
+		run.ClusterID = cluster.ID
+	}
+
+	client, err := o.clientForRun(run)
+	if err != nil {
+		o.failReconciledRun(run, fmt.Sprintf("no Locust client after control plane restart: %v", err))
+		return
+	}
+
+	// A distributed run is reconciled against the merged state of all of its clusters
+	stateKey := strings.Join(run.ClusterIDs(), ",")
+	state, ok := states[stateKey]
+	if !ok {
+		state = o.queryClusterState(client)
+		states[stateKey] = state
+	}
+
+	switch {
+	case state.err != nil:
+		switch run.Status {
+		case domain.LoadTestRunStatusRunning:
+			// The reaper fails the run if its heartbeats do not come back
+			log.Printf("[Reconciler] Locust cluster %s unreachable, resuming run %s: %v", run.ClusterID, run.ID, state.err)
+			o.resumeRun(run, client)
+		case domain.LoadTestRunStatusStopping:
+			o.finalizeReconciledRun(run, domain.LoadTestRunStatusStopped, "stop requested before control plane restart; Locust unreachable")
+		default:
+			if !queued {
+				o.failReconciledRun(run, fmt.Sprintf("control plane restarted while the run was starting and Locust is unreachable: %v", state.err))
+			}
+		}
+
+	case state.runsOn(run.ID) && state.generating():
+		switch run.Status {
+		case domain.LoadTestRunStatusPending:
+			nowMillis := time.Now().UnixMilli()
+			if err := run.Transition(domain.LoadTestRunStatusRunning, domain.RunActorControlPlane, "Locust was running the run when the control plane restarted", nowMillis); err != nil {
+				log.Printf("[Reconciler] Failed to resume run %s: %v", run.ID, err)
+				return
+			}
+			run.StartedAt = nowMillis
+			if err := o.loadTestRunStore.Update(run); err != nil {
+				log.Printf("[Reconciler] Failed to update run %s: %v", run.ID, err)
+				return
+			}
+			o.resumeRun(run, client)
+		case domain.LoadTestRunStatusRunning:
+			o.resumeRun(run, client)
+		case domain.LoadTestRunStatusStopping:
+			// The restart interrupted the stop, finish it
+			ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
+			defer cancel()
+			if err := client.Stop(ctx); err != nil {
+				log.Printf("Warning: failed to stop Locust for run %s: %v", run.ID, err)
+			}
+			o.finalizeReconciledRun(run, domain.LoadTestRunStatusStopped, "stop completed after control plane restart")
+		}
+
+	case state.runsOn(run.ID) && run.Status != domain.LoadTestRunStatusPending:
+		// Locust ended the run while the control plane was down and its test_stop callback was lost
+		run.LastMetrics = state.stats
+		status := domain.LoadTestRunStatusStopped
+		if run.Status == domain.LoadTestRunStatusRunning && durationElapsed(run) {
+			status = domain.LoadTestRunStatusFinished
+		}
+		o.finalizeReconciledRun(run, status, fmt.Sprintf("Locust runner was %q after control plane restart", state.stats.RunnerState))
+
+	default:
+		if queued {
+			return
+		}
+		o.failReconciledRun(run, fmt.Sprintf("Locust cluster %s no longer runs this run after control plane restart (current run: %q)",
+			run.ClusterID, state.runCtx.RunID))
+	}
+}
+
+// queryClusterState fetches the runner state and plugin run context of a Locust cluster
+func (o *Orchestrator) queryClusterState(client engine.Executor) *clusterState {
+	ctx, cancel := context.WithTimeout(o.ctx, 10*time.Second)
+	defer cancel()
+
+	stats, err := client.Stats(ctx)
+	if err != nil {
+		return &clusterState{err: fmt.Errorf("failed to get stats: %w", err)}
+	}
+
+	runCtx, err := client.State(ctx)
+	if err != nil {
+		return &clusterState{err: fmt.Errorf("failed to get run context: %w", err)}
+	}
+
+	return &clusterState{stats: stats, runCtx: runCtx}
+}
+
+// resumeRun takes back ownership of a run that is still running after a restart:
+// it holds the run's clusters again, re-arms the duration timer and continues the load profile
+// Callers must hold the run lock
+func (o *Orchestrator) resumeRun(run *domain.LoadTestRun, client engine.Executor) {
+	o.mu.Lock()
+	for _, clusterID := range run.ClusterIDs() {
+		o.clusterHolders[clusterID] = run.ID
+	}
+	o.mu.Unlock()
+
+	o.scheduleDurationStop(run)
+	o.startMetricsPoller(run)
+
+	// The current stage only holds for what remains of its hold time since the run entered it;
+	// a profile a live adjustment took over from stays stopped
+	if run.LoadProfile != nil && !run.ProfileOverride && run.CurrentStage < len(run.LoadProfile.Stages)-1 {
+		o.startLoadProfile(run, client)
+	}
+
+	log.Printf("[Reconciler] Resumed run %s on cluster %s", run.ID, run.ClusterID)
+}
+
+// finalizeReconciledRun moves a run to a terminal status on behalf of the reconciler
+// Callers must hold the run lock
+func (o *Orchestrator) finalizeReconciledRun(run *domain.LoadTestRun, status domain.LoadTestRunStatus, reason string) {
+	log.Printf("[Reconciler] Marking run %s as %s: %s", run.ID, status, reason)
+
+	if err := o.finalizeRun(run, status, domain.RunActorControlPlane, reason); err != nil {
+		log.Printf("[Reconciler] Failed to finalize run %s: %v", run.ID, err)
+	}
+}
+
+// failReconciledRun marks a run that cannot be recovered after a restart as Failed
+// Callers must hold the run lock
+func (o *Orchestrator) failReconciledRun(run *domain.LoadTestRun, reason string) {
+	run.FailureReason = reason
+	o.finalizeReconciledRun(run, domain.LoadTestRunStatusFailed, reason)
+}
+
+// durationElapsed reports whether a run has been running for at least its duration
+func durationElapsed(run *domain.LoadTestRun) bool {
+	if run.DurationSeconds == nil || run.StartedAt == 0 {
+		return false
+	}
+	return time.Since(time.UnixMilli(run.StartedAt)) >= time.Duration(*run.DurationSeconds)*time.Second
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// restartOrchestrator stops an orchestrator and reconciles its runs in a new one sharing its config and stores,
+// as a control plane restart would
+func restartOrchestrator(t *testing.T, o *Orchestrator) *Orchestrator {
+	t.Helper()
+	o.Stop()
+
+	restarted := NewOrchestrator(o.config, o.loadTestStore, o.loadTestRunStore, o.scriptRevisionStore, o.scheduleStore,
+		o.clusterStore, o.metricsStore, o.failureStore, o.requestSampleStore)
+	t.Cleanup(restarted.Stop)
+
+	restarted.reconcileRuns()
+	return restarted
+}
+
+// updateStoredRun applies a change to a stored run
+func updateStoredRun(t *testing.T, o *Orchestrator, runID string, change func(run *domain.LoadTestRun)) {
+	t.Helper()
+
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	change(run)
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+}
+
+// stopMaster stops a fake master's test behind the control plane's back
+func stopMaster(t *testing.T, master *locusttest.Master) {
+	t.Helper()
+
+	resp, err := http.Get(master.URL() + locusttest.EndpointStop)
+	if err != nil {
+		t.Fatalf("failed to stop master: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestReconcilerResumesRunStillRunning(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	startTimedRun(t, o, "run-1", 600)
+
+	o = restartOrchestrator(t, o)
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusRunning {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusRunning)
+	}
+
+	o.mu.Lock()
+	holder, timer := o.clusterHolders["cluster-1"], o.durationTimers["run-1"]
+	o.mu.Unlock()
+	if holder != "run-1" {
+		t.Errorf("cluster-1 holder = %q, want run-1", holder)
+	}
+	if timer == nil {
+		t.Error("duration watchdog was not re-armed")
+	}
+
+	// The cluster is still held: another run waits for it
+	queued, err := o.CreateTestRun(createPendingRun(t, o, "run-2"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if queued.Status != domain.LoadTestRunStatusPending {
+		t.Errorf("next run status = %s, want %s", queued.Status, domain.LoadTestRunStatusPending)
+	}
+}
+
+func TestReconcilerFinalizesRunsLocustEndedDuringRestart(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, o *Orchestrator, master *locusttest.Master)
+		want    domain.LoadTestRunStatus
+	}{
+		{
+			name: "stopped before its duration",
+			prepare: func(t *testing.T, o *Orchestrator, master *locusttest.Master) {
+				stopMaster(t, master)
+			},
+			want: domain.LoadTestRunStatusStopped,
+		},
+		{
+			name: "duration elapsed",
+			prepare: func(t *testing.T, o *Orchestrator, master *locusttest.Master) {
+				stopMaster(t, master)
+				updateStoredRun(t, o, "run-1", func(run *domain.LoadTestRun) {
+					run.StartedAt = time.Now().Add(-11 * time.Minute).UnixMilli()
+				})
+			},
+			want: domain.LoadTestRunStatusFinished,
+		},
+		{
+			name: "stop interrupted by the restart",
+			prepare: func(t *testing.T, o *Orchestrator, master *locusttest.Master) {
+				updateStoredRun(t, o, "run-1", func(run *domain.LoadTestRun) {
+					if err := run.Transition(domain.LoadTestRunStatusStopping, "tester", "stop requested", time.Now().UnixMilli()); err != nil {
+						t.Fatalf("Transition: %v", err)
+					}
+				})
+			},
+			want: domain.LoadTestRunStatusStopped,
+		},
+		{
+			name: "cluster moved on to another run",
+			prepare: func(t *testing.T, o *Orchestrator, master *locusttest.Master) {
+				master.SetRunContext(locusttest.RunContext{RunID: "other-run"})
+			},
+			want: domain.LoadTestRunStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master := newTestMaster(t, locusttest.Options{})
+			o := newTestOrchestrator(t, master)
+
+			startTimedRun(t, o, "run-1", 600)
+			tt.prepare(t, o, master)
+
+			o = restartOrchestrator(t, o)
+
+			run, err := o.GetTestRun("run-1")
+			if err != nil {
+				t.Fatalf("GetTestRun: %v", err)
+			}
+			if run.Status != tt.want {
+				t.Errorf("status = %s, want %s", run.Status, tt.want)
+			}
+			if state := master.State(); tt.want != domain.LoadTestRunStatusFailed && state != locusttest.StateStopped {
+				t.Errorf("master state = %s, want %s", state, locusttest.StateStopped)
+			}
+
+			o.mu.Lock()
+			holder := o.clusterHolders["cluster-1"]
+			o.mu.Unlock()
+			if holder != "" {
+				t.Errorf("cluster-1 is held by %q, want it free", holder)
+			}
+		})
+	}
+}
+
+func TestReconcilerResumesRunInterruptedWhileStarting(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	// The swarm started but the restart came before the run was marked Running
+	startTimedRun(t, o, "run-1", 600)
+	updateStoredRun(t, o, "run-1", func(run *domain.LoadTestRun) {
+		run.Status = domain.LoadTestRunStatusPending
+		run.StartedAt = 0
+	})
+
+	o = restartOrchestrator(t, o)
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusRunning || run.StartedAt == 0 {
+		t.Errorf("status = %s started at %d, want %s with a start time", run.Status, run.StartedAt, domain.LoadTestRunStatusRunning)
+	}
+}
+
+func TestReconcilerRestoresRunQueues(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	// Queues are restored in the order runs were queued, which is only recorded to the millisecond
+	for _, runID := range []string{"run-1", "run-2", "run-3"} {
+		if _, err := o.CreateTestRun(createPendingRun(t, o, runID)); err != nil {
+			t.Fatalf("CreateTestRun %s: %v", runID, err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	o = restartOrchestrator(t, o)
+
+	for runID, want := range map[string]int{"run-2": 1, "run-3": 2} {
+		if position := o.QueuePosition(runID); position != want {
+			t.Errorf("%s queue position = %d, want %d", runID, position, want)
+		}
+		if run, _ := o.GetTestRun(runID); run.Status != domain.LoadTestRunStatusPending {
+			t.Errorf("%s status = %s, want %s", runID, run.Status, domain.LoadTestRunStatusPending)
+		}
+	}
+
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+	waitForStatus(t, o, "run-2", domain.LoadTestRunStatusRunning)
+}
+
+func TestReconcilerKeepsLoadProfileStoppedAfterAdjustment(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	startProfiledRun(t, o, "run-1",
+		domain.LoadStage{TargetUsers: 5, SpawnRate: 1, HoldSeconds: 1},
+		domain.LoadStage{TargetUsers: 20, SpawnRate: 4, HoldSeconds: 60},
+	)
+	users := 8
+	if _, err := o.AdjustLoad("run-1", &AdjustLoadRequest{TargetUsers: &users}); err != nil {
+		t.Fatalf("AdjustLoad: %v", err)
+	}
+
+	o = restartOrchestrator(t, o)
+
+	o.mu.Lock()
+	_, profiled := o.profileCancels["run-1"]
+	o.mu.Unlock()
+	if profiled {
+		t.Error("load profile resumed after a manual adjustment took over from it")
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusRunning || run.CurrentStage != 0 || run.TargetUsers != 8 {
+		t.Errorf("run %s at stage %d with %d users, want Running with the manual 8 users at stage 0",
+			run.Status, run.CurrentStage, run.TargetUsers)
+	}
+	if users, _ := master.Users(); users != 8 {
+		t.Errorf("swarm users = %d, want 8", users)
+	}
+}
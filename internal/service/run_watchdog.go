@@ -0,0 +1,161 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// scheduleDurationStop arms a timer that stops a run once its duration (plus the grace period) has elapsed
+// The timer is measured from the run's StartedAt so it can be re-armed after a restart
+func (o *Orchestrator) scheduleDurationStop(run *domain.LoadTestRun) {
+	if run.DurationSeconds == nil || *run.DurationSeconds <= 0 || run.StartedAt == 0 {
+		return
+	}
+
+	grace := time.Duration(o.config.Orchestrator.DurationGraceSeconds) * time.Second
+	deadline := time.UnixMilli(run.StartedAt).Add(time.Duration(*run.DurationSeconds)*time.Second + grace)
+	remaining := time.Until(deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	runID := run.ID
+	timer := time.AfterFunc(remaining, func() {
+		o.enforceDuration(runID)
+	})
+
+	o.mu.Lock()
+	if existing, ok := o.durationTimers[runID]; ok {
+		existing.Stop()
+	}
+	o.durationTimers[runID] = timer
+	o.mu.Unlock()
+
+	log.Printf("[Orchestrator] Duration watchdog for run %s fires in %s", runID, remaining.Round(time.Second))
+}
+
+// cancelDurationStop disarms the duration timer of a run, if any
+func (o *Orchestrator) cancelDurationStop(runID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if timer, ok := o.durationTimers[runID]; ok {
+		timer.Stop()
+		delete(o.durationTimers, runID)
+	}
+}
+
+// enforceDuration stops a run whose duration has elapsed without Locust reporting the stop
+func (o *Orchestrator) enforceDuration(runID string) {
+	o.mu.Lock()
+	delete(o.durationTimers, runID)
+	o.mu.Unlock()
+
+	if o.ctx.Err() != nil {
+		return
+	}
+
+	unlock := o.lockRun(runID)
+	defer unlock()
+
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		log.Printf("[Orchestrator] Duration watchdog could not load run %s: %v", runID, err)
+		return
+	}
+
+	if run.Status != domain.LoadTestRunStatusRunning && run.Status != domain.LoadTestRunStatusStopping {
+		return
+	}
+
+	log.Printf("[Orchestrator] Run %s exceeded its duration of %ds, stopping it from the control plane",
+		run.ID, *run.DurationSeconds)
+
+	// Stop generating load before finalizing; a failed stop is logged but does not keep the run open
+	client, err := o.clientForRun(run)
+	if err != nil {
+		log.Printf("Warning: duration watchdog has no Locust client for run %s: %v", run.ID, err)
+	} else {
+		ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
+		defer cancel()
+
+		if err := client.Stop(ctx); err != nil {
+			log.Printf("Warning: duration watchdog failed to stop Locust for run %s: %v", run.ID, err)
+		}
+	}
+
+	reason := fmt.Sprintf("duration of %ds elapsed", *run.DurationSeconds)
+	if err := o.finalizeRun(run, domain.LoadTestRunStatusFinished, domain.RunActorControlPlane, reason); err != nil {
+		log.Printf("[Orchestrator] Duration watchdog failed to finalize run %s: %v", run.ID, err)
+	}
+}
+
+// finalizeRun moves a run to a terminal status and releases everything the orchestrator holds for it
+// Callers must hold the run lock
+func (o *Orchestrator) finalizeRun(run *domain.LoadTestRun, status domain.LoadTestRunStatus, actor, reason string) error {
+	nowMillis := time.Now().UnixMilli()
+	if err := run.Transition(status, actor, reason, nowMillis); err != nil {
+		return err
+	}
+	run.FinishedAt = nowMillis
+
+	o.stopLoadProfile(run.ID)
+	o.stopMetricsPoller(run.ID)
+	o.cancelDurationStop(run.ID)
+	o.clearAbortBreaches(run.ID)
+	o.clearShardRounds(run.ID)
+	finalizeShards(run, status, nowMillis)
+
+	// Judge the run against its thresholds using everything stored up to now
+	o.evaluateVerdict(run)
+
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		return fmt.Errorf("failed to update test run finish status: %w", err)
+	}
+
+	// Update the LoadTest's recent runs if this run has a LoadTestID
+	if run.LoadTestID != "" {
+		if err := o.updateRecentRuns(run); err != nil {
+			log.Printf("Warning: failed to update recent runs for LoadTest %s: %v", run.LoadTestID, err)
+		}
+	}
+
+	o.releaseRunClusters(run)
+	return nil
+}
+
+// failRunStart marks a run that could not be started on Locust as Failed
+func (o *Orchestrator) failRunStart(run *domain.LoadTestRun, reason string, cause error) {
+	run.FailureReason = fmt.Sprintf("%s: %v", reason, cause)
+	if err := run.Transition(domain.LoadTestRunStatusFailed, domain.RunActorControlPlane, run.FailureReason, time.Now().UnixMilli()); err != nil {
+		log.Printf("Warning: failed to mark run %s as failed: %v", run.ID, err)
+		return
+	}
+
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		log.Printf("Warning: failed to mark run %s as failed: %v", run.ID, err)
+	}
+}
+
+// clientForRun returns the Locust client of the cluster a run was admitted to
+// Distributed runs get a client driving all of their shards at once
+// Runs created before clusters were recorded fall back to resolving the cluster from the registry
+func (o *Orchestrator) clientForRun(run *domain.LoadTestRun) (engine.Executor, error) {
+	if run.IsSharded() {
+		return o.newShardedClient(run)
+	}
+	if run.ClusterID != "" {
+		return o.getClient(run.ClusterID)
+	}
+
+	cluster, err := o.resolveCluster(run.AccountID, run.OrgID, run.ProjectID, run.EnvID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	return o.getClient(cluster.ID)
+}
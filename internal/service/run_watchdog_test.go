@@ -0,0 +1,100 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"testing"
+	"time"
+)
+
+// startTimedRun starts a run that should last durationSeconds
+func startTimedRun(t *testing.T, o *Orchestrator, runID string, durationSeconds int) *domain.LoadTestRun {
+	t.Helper()
+
+	req := createPendingRun(t, o, runID)
+	run, err := o.loadTestRunStore.Get(runID)
+	if err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	run.DurationSeconds = &durationSeconds
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+	req.DurationSeconds = &durationSeconds
+
+	started, err := o.CreateTestRun(req)
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	return started
+}
+
+func TestDurationWatchdogFinishesRunLocustNeverStopped(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	startTimedRun(t, o, "run-1", 1)
+
+	run := waitForStatus(t, o, "run-1", domain.LoadTestRunStatusFinished)
+	if last := run.Timeline[len(run.Timeline)-1]; last.Actor != domain.RunActorControlPlane {
+		t.Errorf("finished by %s, want %s", last.Actor, domain.RunActorControlPlane)
+	}
+	if run.FinishedAt == 0 {
+		t.Error("finished run has no finish time")
+	}
+	if state := master.State(); state != locusttest.StateStopped {
+		t.Errorf("master state = %s, want %s", state, locusttest.StateStopped)
+	}
+
+	// The cluster is free again
+	next, err := o.CreateTestRun(createPendingRun(t, o, "run-2"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if next.Status != domain.LoadTestRunStatusRunning {
+		t.Errorf("next run status = %s, want %s", next.Status, domain.LoadTestRunStatusRunning)
+	}
+}
+
+func TestDurationWatchdogIsDisarmedByStop(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	startTimedRun(t, o, "run-1", 1)
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+
+	o.mu.Lock()
+	armed := len(o.durationTimers)
+	o.mu.Unlock()
+	if armed != 0 {
+		t.Errorf("armed duration timers = %d, want 0", armed)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusStopped {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusStopped)
+	}
+	if stops := len(master.Calls(locusttest.EndpointStop)); stops != 1 {
+		t.Errorf("stop calls = %d, want 1", stops)
+	}
+}
+
+func TestDurationWatchdogFiresRightAwayForOverdueRun(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	run := startTimedRun(t, o, "run-1", 3600)
+	o.cancelDurationStop(run.ID)
+
+	// A run re-armed after a restart is measured from when it started
+	run.StartedAt = time.Now().Add(-2 * time.Hour).UnixMilli()
+	o.scheduleDurationStop(run)
+
+	waitForStatus(t, o, "run-1", domain.LoadTestRunStatusFinished)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// schedulerTickInterval is how often the scheduler looks for due schedules
+const schedulerTickInterval = 15 * time.Second
+
+// NextScheduleFire returns the first time after the given instant at which a cron expression fires
+// The expression is evaluated in the given IANA timezone (UTC if empty)
+func NextScheduleFire(cronExpression, timezone string, after time.Time) (time.Time, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+
+	cronSchedule, err := cron.ParseStandard(cronExpression)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression %q: %w", cronExpression, err)
+	}
+
+	next := cronSchedule.Next(after.In(location))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron expression %q never fires", cronExpression)
+	}
+
+	return next, nil
+}
+
+// runScheduler fires due schedules until the orchestrator is stopped
+func (o *Orchestrator) runScheduler() {
+	ticker := time.NewTicker(schedulerTickInterval)
+	defer ticker.Stop()
+
+	for {
+		o.fireDueSchedules()
+
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fireDueSchedules creates a run for every enabled schedule whose next fire time has passed
+// Firings missed while the control plane was down are collapsed into a single run
+func (o *Orchestrator) fireDueSchedules() {
+	now := time.Now()
+
+	schedules, err := o.scheduleStore.ListDue(now.UnixMilli())
+	if err != nil {
+		log.Printf("[Scheduler] Failed to list due schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		o.fireSchedule(schedule, now)
+	}
+}
+
+// fireSchedule claims a schedule's firing by saving its next fire time, then launches a run and records the firing
+// A firing that can't be claimed is not launched, so neither a failed save nor a crash during the launch
+// fires the schedule twice; the next tick retries an unclaimed firing
+func (o *Orchestrator) fireSchedule(schedule *domain.Schedule, now time.Time) {
+	log.Printf("[Scheduler] Firing schedule %s for LoadTest %s (%s %s)",
+		schedule.ID, schedule.LoadTestID, schedule.CronExpression, schedule.Timezone)
+
+	next, err := NextScheduleFire(schedule.CronExpression, schedule.Timezone, now)
+	if err != nil {
+		// The expression was valid when saved; pause the schedule rather than retrying every tick
+		log.Printf("[Scheduler] Pausing schedule %s: %v", schedule.ID, err)
+		schedule.Enabled = false
+		schedule.NextFireAt = 0
+	} else {
+		schedule.NextFireAt = next.UnixMilli()
+	}
+	schedule.UpdatedAt = now.UnixMilli()
+
+	if err := o.scheduleStore.Update(schedule); err != nil {
+		log.Printf("[Scheduler] Failed to claim firing of schedule %s, not launching it: %v", schedule.ID, err)
+		return
+	}
+
+	firing := domain.ScheduleFiring{FiredAt: now.UnixMilli()}
+
+	run, err := o.launchScheduledRun(schedule)
+	if run != nil {
+		firing.RunID = run.ID
+	}
+	if err != nil {
+		log.Printf("[Scheduler] Schedule %s failed to launch a run: %v", schedule.ID, err)
+		firing.Error = err.Error()
+	} else {
+		log.Printf("[Scheduler] Schedule %s launched run %s (status: %s)", schedule.ID, run.ID, run.Status)
+	}
+
+	schedule.RecordFiring(firing)
+	schedule.UpdatedAt = time.Now().UnixMilli()
+
+	if err := o.scheduleStore.Update(schedule); err != nil {
+		log.Printf("[Scheduler] Failed to record firing of schedule %s: %v", schedule.ID, err)
+	}
+}
+
+// launchScheduledRun creates a run of the schedule's LoadTest with the schedule's overrides
+func (o *Orchestrator) launchScheduledRun(schedule *domain.Schedule) (*domain.LoadTestRun, error) {
+	loadTest, err := o.loadTestStore.Get(schedule.LoadTestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get load test: %w", err)
+	}
+
+	req := &LaunchRunRequest{
+		CreatedBy: "scheduler",
+		Metadata:  map[string]any{},
+	}
+
+	if overrides := schedule.Overrides; overrides != nil {
+		req.Name = overrides.Name
+		req.TargetURL = overrides.TargetURL
+		req.TargetUsers = overrides.TargetUsers
+		req.SpawnRate = overrides.SpawnRate
+		req.DurationSeconds = overrides.DurationSeconds
+		req.LoadProfile = overrides.LoadProfile
+		for k, v := range overrides.Metadata {
+			req.Metadata[k] = v
+		}
+	}
+
+	if req.Name == "" && schedule.Name != "" {
+		req.Name = schedule.Name
+	}
+	req.Metadata["source"] = "schedule"
+	req.Metadata["scheduleId"] = schedule.ID
+
+	return o.LaunchLoadTestRun(loadTest, req)
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryScheduleStore is a ScheduleRepository holding schedules in memory
+type memoryScheduleStore struct {
+	mu        sync.Mutex
+	schedules map[string]*domain.Schedule
+	updates   []domain.Schedule // Every schedule saved by Update, in order
+	updateErr error             // Returned by Update when set
+}
+
+func newMemoryScheduleStore(schedules ...*domain.Schedule) *memoryScheduleStore {
+	s := &memoryScheduleStore{schedules: make(map[string]*domain.Schedule)}
+	for _, schedule := range schedules {
+		s.schedules[schedule.ID] = schedule
+	}
+	return s
+}
+
+func (s *memoryScheduleStore) Create(schedule *domain.Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (s *memoryScheduleStore) Get(id string) (*domain.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return nil, fmt.Errorf("schedule not found: %s", id)
+	}
+	copied := *schedule
+	return &copied, nil
+}
+
+func (s *memoryScheduleStore) Update(schedule *domain.Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.updateErr != nil {
+		return s.updateErr
+	}
+	s.updates = append(s.updates, *schedule)
+	copied := *schedule
+	s.schedules[schedule.ID] = &copied
+	return nil
+}
+
+func (s *memoryScheduleStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.schedules, id)
+	return nil
+}
+
+func (s *memoryScheduleStore) ListByLoadTestID(loadTestID string) ([]*domain.Schedule, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (s *memoryScheduleStore) ListDue(before int64) ([]*domain.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*domain.Schedule
+	for _, schedule := range s.schedules {
+		if schedule.Enabled && schedule.NextFireAt > 0 && schedule.NextFireAt <= before {
+			copied := *schedule
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
+
+func TestNextScheduleFire(t *testing.T) {
+	after := time.Date(2026, 3, 10, 7, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		expression string
+		timezone   string
+		want       time.Time
+	}{
+		{name: "utc by default", expression: "0 8 * * *", want: time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)},
+		{name: "evaluated in timezone", expression: "0 8 * * *", timezone: "Asia/Kolkata", want: time.Date(2026, 3, 11, 2, 30, 0, 0, time.UTC)},
+		{name: "every 15 minutes", expression: "*/15 * * * *", want: time.Date(2026, 3, 10, 7, 45, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := NextScheduleFire(tt.expression, tt.timezone, after)
+			if err != nil {
+				t.Fatalf("NextScheduleFire: %v", err)
+			}
+			if !next.Equal(tt.want) {
+				t.Errorf("next fire = %s, want %s", next.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNextScheduleFireRejectsInvalidSchedules(t *testing.T) {
+	if _, err := NextScheduleFire("not a cron", "", time.Now()); err == nil {
+		t.Error("invalid cron expression was accepted")
+	}
+	if _, err := NextScheduleFire("0 8 * * *", "Mars/Olympus", time.Now()); err == nil {
+		t.Error("invalid timezone was accepted")
+	}
+}
+
+func TestFireDueSchedulesLaunchesRuns(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	users := 4
+	now := time.Now()
+	schedules := newMemoryScheduleStore(
+		&domain.Schedule{
+			ID: "due", LoadTestID: "test-1", Name: "nightly", CronExpression: "0 2 * * *", Enabled: true,
+			NextFireAt: now.Add(-time.Minute).UnixMilli(),
+			Overrides:  &domain.ScheduleRunOverrides{TargetUsers: &users},
+		},
+		&domain.Schedule{ID: "later", LoadTestID: "test-1", CronExpression: "0 2 * * *", Enabled: true, NextFireAt: now.Add(time.Hour).UnixMilli()},
+		&domain.Schedule{ID: "paused", LoadTestID: "test-1", CronExpression: "0 2 * * *", NextFireAt: now.Add(-time.Minute).UnixMilli()},
+	)
+	o.scheduleStore = schedules
+
+	o.fireDueSchedules()
+
+	fired, _ := schedules.Get("due")
+	if len(fired.Firings) != 1 || fired.Firings[0].Error != "" || fired.LastRunID == "" {
+		t.Fatalf("firings = %+v, want one successful firing", fired.Firings)
+	}
+	if fired.NextFireAt <= now.UnixMilli() {
+		t.Errorf("next fire at %d, want it moved past %d", fired.NextFireAt, now.UnixMilli())
+	}
+
+	run, err := o.GetTestRun(fired.LastRunID)
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusRunning || run.LoadTestID != "test-1" {
+		t.Errorf("run is %s of %s, want Running of test-1", run.Status, run.LoadTestID)
+	}
+	if run.Name != "nightly" || run.TargetUsers != 4 || run.CreatedBy != "scheduler" {
+		t.Errorf("run %q with %d users by %s, want nightly with 4 users by scheduler", run.Name, run.TargetUsers, run.CreatedBy)
+	}
+	if run.Metadata["source"] != "schedule" || run.Metadata["scheduleId"] != "due" {
+		t.Errorf("run metadata = %v, want source schedule and scheduleId due", run.Metadata)
+	}
+
+	for _, id := range []string{"later", "paused"} {
+		if schedule, _ := schedules.Get(id); len(schedule.Firings) != 0 {
+			t.Errorf("schedule %s fired, want it left alone", id)
+		}
+	}
+	if swarms := len(master.Calls(locusttest.EndpointSwarm)); swarms != 1 {
+		t.Errorf("swarm calls = %d, want 1", swarms)
+	}
+}
+
+func TestFireScheduleRecordsLaunchFailure(t *testing.T) {
+	o := newTestOrchestrator(t, newTestMaster(t, locusttest.Options{}))
+
+	now := time.Now()
+	schedules := newMemoryScheduleStore(&domain.Schedule{
+		ID: "orphan", LoadTestID: "deleted-test", CronExpression: "0 2 * * *", Enabled: true, NextFireAt: now.Add(-time.Minute).UnixMilli(),
+	})
+	o.scheduleStore = schedules
+
+	o.fireDueSchedules()
+
+	schedule, _ := schedules.Get("orphan")
+	if len(schedule.Firings) != 1 || schedule.Firings[0].Error == "" || schedule.Firings[0].RunID != "" {
+		t.Fatalf("firings = %+v, want one failed firing without a run", schedule.Firings)
+	}
+	if !schedule.Enabled || schedule.NextFireAt <= now.UnixMilli() {
+		t.Errorf("schedule enabled=%v next=%d, want it kept enabled for its next fire time", schedule.Enabled, schedule.NextFireAt)
+	}
+}
+
+func TestFireScheduleClaimsFiringBeforeLaunch(t *testing.T) {
+	o := newTestOrchestrator(t, newTestMaster(t, locusttest.Options{}))
+
+	now := time.Now()
+	schedules := newMemoryScheduleStore(&domain.Schedule{
+		ID: "due", LoadTestID: "test-1", CronExpression: "0 2 * * *", Enabled: true, NextFireAt: now.Add(-time.Minute).UnixMilli(),
+	})
+	o.scheduleStore = schedules
+
+	o.fireDueSchedules()
+
+	if len(schedules.updates) != 2 {
+		t.Fatalf("schedule updates = %d, want a claim then the firing", len(schedules.updates))
+	}
+	claim, recorded := schedules.updates[0], schedules.updates[1]
+	if claim.NextFireAt <= now.UnixMilli() || len(claim.Firings) != 0 {
+		t.Errorf("claim next=%d firings=%d, want the next fire time saved before any firing", claim.NextFireAt, len(claim.Firings))
+	}
+	if len(recorded.Firings) != 1 || recorded.Firings[0].RunID == "" || recorded.NextFireAt != claim.NextFireAt {
+		t.Errorf("recorded firings = %+v next=%d, want the launched run at the claimed next fire time", recorded.Firings, recorded.NextFireAt)
+	}
+}
+
+func TestFireScheduleSkipsLaunchWhenClaimFails(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+
+	now := time.Now()
+	schedules := newMemoryScheduleStore(&domain.Schedule{
+		ID: "due", LoadTestID: "test-1", CronExpression: "0 2 * * *", Enabled: true, NextFireAt: now.Add(-time.Minute).UnixMilli(),
+	})
+	schedules.updateErr = errors.New("store down")
+	o.scheduleStore = schedules
+
+	o.fireDueSchedules()
+
+	if swarms := len(master.Calls(locusttest.EndpointSwarm)); swarms != 0 {
+		t.Errorf("swarm calls = %d, want no run launched for an unclaimed firing", swarms)
+	}
+	runs, err := o.ListTestRuns(nil)
+	if err != nil {
+		t.Fatalf("ListTestRuns: %v", err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("runs = %d, want 0", len(runs))
+	}
+
+	// The firing is retried once the store recovers, launching a single run
+	schedules.updateErr = nil
+	o.fireDueSchedules()
+	o.fireDueSchedules()
+
+	if swarms := len(master.Calls(locusttest.EndpointSwarm)); swarms != 1 {
+		t.Errorf("swarm calls = %d, want 1", swarms)
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// shardedClient drives the shards of a distributed run as if they were a single cluster
+// Every call fans out to the shards' clusters; load is split in proportion to each shard's users at start
+type shardedClient struct {
+	shards []shardClient
+}
+
+// shardClient is the executor of one shard of a distributed run
+type shardClient struct {
+	clusterID string
+	client    engine.Executor
+	users     int
+}
+
+// newShardedClient builds the client driving all shards of a distributed run
+func (o *Orchestrator) newShardedClient(run *domain.LoadTestRun) (*shardedClient, error) {
+	shards := make([]shardClient, len(run.Shards))
+	for i, shard := range run.Shards {
+		client, err := o.getClient(shard.ClusterID)
+		if err != nil {
+			return nil, fmt.Errorf("shard %s: %w", shard.ClusterID, err)
+		}
+		shards[i] = shardClient{clusterID: shard.ClusterID, client: client, users: shard.Users}
+	}
+	return &shardedClient{shards: shards}, nil
+}
+
+// each calls fn for every shard concurrently and joins the errors of the shards that failed
+func (c *shardedClient) each(fn func(i int, shard shardClient) error) error {
+	errs := make([]error, len(c.shards))
+
+	var wg sync.WaitGroup
+	for i, shard := range c.shards {
+		wg.Add(1)
+		go func(i int, shard shardClient) {
+			defer wg.Done()
+			if err := fn(i, shard); err != nil {
+				errs[i] = fmt.Errorf("shard %s: %w", shard.clusterID, err)
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Prepare loads the script on every shard and checks that they all report the same hash
+// The shards' worker acks and missing workers are merged under the shard's cluster ID, as "cluster/worker"
+func (c *shardedClient) Prepare(ctx context.Context, script engine.Script) (*engine.Prepared, error) {
+	results := make([]*engine.Prepared, len(c.shards))
+	err := c.each(func(i int, shard shardClient) error {
+		result, err := shard.client.Prepare(ctx, script)
+		results[i] = result
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &engine.Prepared{RevisionID: script.RevisionID, SHA256: results[0].SHA256}
+	for i, result := range results {
+		clusterID := c.shards[i].clusterID
+		if result.SHA256 != merged.SHA256 {
+			return nil, fmt.Errorf("shard %s loaded script %s, shard %s loaded %s",
+				c.shards[0].clusterID, merged.SHA256, clusterID, result.SHA256)
+		}
+		merged.Workers += result.Workers
+
+		for worker, sha := range result.WorkerAcks {
+			if merged.WorkerAcks == nil {
+				merged.WorkerAcks = make(map[string]string)
+			}
+			merged.WorkerAcks[clusterID+"/"+worker] = sha
+		}
+		for _, worker := range result.MissingWorkers {
+			merged.MissingWorkers = append(merged.MissingWorkers, clusterID+"/"+worker)
+		}
+	}
+
+	return merged, nil
+}
+
+// Attach points every shard at the run, each under its own shard ID
+func (c *shardedClient) Attach(ctx context.Context, run engine.RunContext) error {
+	return c.each(func(_ int, shard shardClient) error {
+		shardRun := run
+		shardRun.ShardID = shard.clusterID
+		return shard.client.Attach(ctx, shardRun)
+	})
+}
+
+// Start splits users and spawn rate across the shards and starts them together
+func (c *shardedClient) Start(ctx context.Context, users int, spawnRate float64, host string) error {
+	split, rates := c.split(users, spawnRate)
+	return c.each(func(i int, shard shardClient) error {
+		return shard.client.Start(ctx, split[i], rates[i], host)
+	})
+}
+
+// Scale splits the new load across the shards in the proportions the run started with
+func (c *shardedClient) Scale(ctx context.Context, users int, spawnRate float64) error {
+	split, rates := c.split(users, spawnRate)
+	return c.each(func(i int, shard shardClient) error {
+		return shard.client.Scale(ctx, split[i], rates[i])
+	})
+}
+
+// split divides users and spawn rate across the shards in proportion to each shard's users at start
+func (c *shardedClient) split(users int, spawnRate float64) ([]int, []float64) {
+	weights := make([]float64, len(c.shards))
+	for i, shard := range c.shards {
+		weights[i] = float64(shard.users)
+	}
+	split := domain.SplitUsers(users, weights)
+	return split, domain.SplitSpawnRate(spawnRate, split)
+}
+
+// Stop stops every shard
+func (c *shardedClient) Stop(ctx context.Context) error {
+	return c.each(func(_ int, shard shardClient) error {
+		return shard.client.Stop(ctx)
+	})
+}
+
+// Stats polls every shard and merges their stats into stats of the whole run
+func (c *shardedClient) Stats(ctx context.Context) (*domain.MetricSnapshot, error) {
+	snapshots := make([]*domain.MetricSnapshot, len(c.shards))
+	err := c.each(func(i int, shard shardClient) error {
+		stats, err := shard.client.Stats(ctx)
+		snapshots[i] = stats
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.MergeMetricSnapshots(snapshots), nil
+}
+
+// State returns the run and script held by the first shard
+func (c *shardedClient) State(ctx context.Context) (*engine.State, error) {
+	return c.shards[0].client.State(ctx)
+}
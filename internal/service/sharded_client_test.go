@@ -0,0 +1,128 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
+	"Load-manager-cli/internal/locusttest"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unackedExecutor reports one of its workers as never acknowledging the script it loaded
+type unackedExecutor struct {
+	engine.Executor
+	worker string
+}
+
+func (e *unackedExecutor) Prepare(ctx context.Context, script engine.Script) (*engine.Prepared, error) {
+	prepared, err := e.Executor.Prepare(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	delete(prepared.WorkerAcks, e.worker)
+	prepared.MissingWorkers = append(prepared.MissingWorkers, e.worker)
+	return prepared, nil
+}
+
+// createShardedRun stores a Pending run of the test script split evenly across clusters and returns the request starting it
+func createShardedRun(t *testing.T, o *Orchestrator, runID string, clusterIDs ...string) *CreateTestRunRequest {
+	t.Helper()
+
+	specs := make([]domain.ShardSpec, len(clusterIDs))
+	for i, clusterID := range clusterIDs {
+		specs[i] = domain.ShardSpec{ClusterID: clusterID, Weight: 1}
+	}
+	shards, err := domain.PlanShards(specs, 10, 2)
+	if err != nil {
+		t.Fatalf("PlanShards: %v", err)
+	}
+
+	run := &domain.LoadTestRun{
+		ID:               runID,
+		AccountID:        "acc",
+		OrgID:            "org",
+		ProjectID:        "proj",
+		ScriptRevisionID: "rev-1",
+		TargetUsers:      10,
+		SpawnRate:        2,
+		ClusterID:        shards[0].ClusterID,
+		Shards:           shards,
+	}
+	run.InitTimeline(domain.LoadTestRunStatusPending, "tester", "created", time.Now().UnixMilli())
+	if err := o.loadTestRunStore.Create(run); err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+
+	return &CreateTestRunRequest{
+		LoadTestRunID: runID,
+		AccountID:     "acc",
+		OrgID:         "org",
+		ProjectID:     "proj",
+		TargetURL:     "http://target.example",
+		TargetUsers:   10,
+		SpawnRate:     2,
+	}
+}
+
+func TestShardedPrepareMergesWorkerAcksPerShard(t *testing.T) {
+	first := newTestMaster(t, locusttest.Options{Workers: 2})
+	second := newTestMaster(t, locusttest.Options{Workers: 1})
+	o := newTestOrchestrator(t, first, second)
+
+	run := &domain.LoadTestRun{ID: "run-1", Shards: []domain.RunShard{{ClusterID: "cluster-1"}, {ClusterID: "cluster-2"}}}
+	client, err := o.newShardedClient(run)
+	if err != nil {
+		t.Fatalf("newShardedClient: %v", err)
+	}
+
+	revision, _ := o.scriptRevisionStore.Get("rev-1")
+	prepared, err := client.Prepare(context.Background(), engine.Script{RevisionID: revision.ID, Content: revision.ScriptContent})
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+
+	if prepared.Workers != 3 {
+		t.Errorf("workers = %d, want 3", prepared.Workers)
+	}
+	for _, worker := range []string{"cluster-1/" + locusttest.WorkerID(1), "cluster-1/" + locusttest.WorkerID(2), "cluster-2/" + locusttest.WorkerID(1)} {
+		if prepared.WorkerAcks[worker] != prepared.SHA256 {
+			t.Errorf("ack of %s = %q, want %s", worker, prepared.WorkerAcks[worker], prepared.SHA256)
+		}
+	}
+	if len(prepared.MissingWorkers) != 0 {
+		t.Errorf("missing workers = %v, want none", prepared.MissingWorkers)
+	}
+}
+
+func TestShardedRunRejectsWorkerThatNeverAcks(t *testing.T) {
+	first := newTestMaster(t, locusttest.Options{Workers: 2})
+	second := newTestMaster(t, locusttest.Options{Workers: 2})
+	o := newTestOrchestrator(t, first, second)
+
+	inner, err := o.getClient("cluster-2")
+	if err != nil {
+		t.Fatalf("getClient: %v", err)
+	}
+	o.clients["cluster-2"] = &unackedExecutor{Executor: inner, worker: locusttest.WorkerID(2)}
+
+	_, err = o.CreateTestRun(createShardedRun(t, o, "run-1", "cluster-1", "cluster-2"))
+	missing := "cluster-2/" + locusttest.WorkerID(2)
+	if err == nil || !strings.Contains(err.Error(), missing) {
+		t.Fatalf("CreateTestRun error = %v, want %s reported as missing", err, missing)
+	}
+
+	run, err := o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusFailed {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusFailed)
+	}
+	for i, master := range []*locusttest.Master{first, second} {
+		if len(master.Calls(locusttest.EndpointSwarm)) != 0 {
+			t.Errorf("shard %d was swarmed despite the missing ack", i+1)
+		}
+	}
+}
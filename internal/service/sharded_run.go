@@ -0,0 +1,305 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/engine"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// startShardedRun holds every cluster of a distributed run and starts all its shards
+// Distributed runs are not queued: they start only if all of their clusters are idle or already
+// reserved for them, and fail as a whole if any shard fails to start
+func (o *Orchestrator) startShardedRun(run *domain.LoadTestRun, req *CreateTestRunRequest) (engine.Executor, error) {
+	if err := o.reserveShardClusters(run); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Orchestrator] Starting distributed test run %s on %d clusters", run.ID, len(run.Shards))
+
+	client, err := o.newShardedClient(run)
+	if err != nil {
+		o.failShardedStart(run, nil, "failed to get Locust clients", err)
+		return nil, fmt.Errorf("failed to get Locust clients: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
+	defer cancel()
+
+	// Every shard loads the exact script revision referenced by the run
+	if err := o.deliverScript(ctx, client, run); err != nil {
+		o.failShardedStart(run, nil, "failed to deliver script to Locust", err)
+		return nil, fmt.Errorf("failed to deliver script to Locust: %w", err)
+	}
+
+	// Shards are started together, each with its own share of the load
+	errs := make([]error, len(run.Shards))
+	var wg sync.WaitGroup
+	for i := range run.Shards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			shard := run.Shards[i]
+			shardClient := client.shards[i].client
+
+			runCtx := engine.RunContext{RunID: run.ID, ShardID: shard.ClusterID, TenantID: run.AccountID, EnvID: run.EnvID, DurationSeconds: run.DurationSeconds}
+			if err := shardClient.Attach(ctx, runCtx); err != nil {
+				errs[i] = fmt.Errorf("failed to set run context: %w", err)
+				return
+			}
+			if err := shardClient.Start(ctx, shard.Users, shard.SpawnRate, req.TargetURL); err != nil {
+				errs[i] = fmt.Errorf("failed to start swarm: %w", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	nowMillis := time.Now().UnixMilli()
+	for i := range run.Shards {
+		if errs[i] != nil {
+			run.Shards[i].Status = domain.LoadTestRunStatusFailed
+			run.Shards[i].Error = errs[i].Error()
+			continue
+		}
+		run.Shards[i].Status = domain.LoadTestRunStatusRunning
+		run.Shards[i].StartedAt = nowMillis
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		log.Printf("[Orchestrator] Failed to start shards of test run %s: %v", run.ID, err)
+		o.failShardedStart(run, client, "failed to start shards on Locust", err)
+		return nil, fmt.Errorf("failed to start shards on Locust: %w", err)
+	}
+
+	return client, nil
+}
+
+// reserveShardClusters holds every cluster of a distributed run for it, or none of them if any is
+// running another run
+func (o *Orchestrator) reserveShardClusters(run *domain.LoadTestRun) error {
+	for i, shard := range run.Shards {
+		if !o.tryAcquireCluster(shard.ClusterID, run.ID) {
+			for _, held := range run.Shards[:i] {
+				o.releaseCluster(held.ClusterID, run.ID)
+			}
+			return fmt.Errorf("%w: cluster %s of shard %d is running another run", ErrShardClustersBusy, shard.ClusterID, i)
+		}
+	}
+	return nil
+}
+
+// failShardedStart stops whatever shards of a distributed run did start, marks the run as Failed
+// and releases its clusters
+func (o *Orchestrator) failShardedStart(run *domain.LoadTestRun, client *shardedClient, reason string, cause error) {
+	if client != nil {
+		ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second)
+		defer cancel()
+		if err := client.Stop(ctx); err != nil {
+			log.Printf("Warning: failed to stop shards of test run %s: %v", run.ID, err)
+		}
+	}
+
+	o.failRunStart(run, reason, cause)
+	o.releaseRunClusters(run)
+}
+
+// releaseRunClusters frees every cluster a run holds
+func (o *Orchestrator) releaseRunClusters(run *domain.LoadTestRun) {
+	for _, clusterID := range run.ClusterIDs() {
+		o.releaseCluster(clusterID, run.ID)
+	}
+}
+
+// updateShardMetrics records a snapshot pushed by one shard of a distributed run
+// The run's metrics are the merge of its shards' latest snapshots; a merged point is added to the
+// run's timeseries once per push round and is what abort rules are checked against. The shards'
+// intervals are accumulated until then, so the merged point's interval covers every push since the last one
+// Callers must hold the run lock
+func (o *Orchestrator) updateShardMetrics(run *domain.LoadTestRun, shardID string, metrics *domain.MetricSnapshot) error {
+	shard := run.Shard(shardID)
+	if shard == nil {
+		return fmt.Errorf("test run %s has no shard %q", run.ID, shardID)
+	}
+
+	nowMillis := time.Now().UnixMilli()
+	metrics.SetInterval(shard.LastMetrics, run.StartedAt)
+	o.addPendingInterval(run, metrics)
+	shard.LastMetrics = metrics
+	shard.LastHeartbeatAt = nowMillis
+	run.LastMetrics = run.MergedShardMetrics()
+	run.LastHeartbeatAt = nowMillis
+	run.UpdatedAt = nowMillis
+
+	roundComplete := o.completeShardRound(run, shardID)
+
+	if o.metricsStore != nil {
+		storeCtx, storeCancel := context.WithTimeout(o.ctx, 5*time.Second)
+		defer storeCancel()
+
+		if err := o.metricsStore.StoreShardMetric(storeCtx, run.ID, shardID, run.AccountID, run.OrgID, run.ProjectID, run.EnvID, metrics); err != nil {
+			log.Printf("Warning: failed to store metrics of shard %s for run %s: %v", shardID, run.ID, err)
+		}
+	}
+	if roundComplete {
+		o.storeMergedMetrics(run)
+	}
+
+	if roundComplete && run.Status == domain.LoadTestRunStatusRunning {
+		if trigger := o.checkAbortRules(run, run.LastMetrics); trigger != nil {
+			return o.abortRun(run, trigger)
+		}
+	}
+
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		return fmt.Errorf("failed to update test run metrics: %w", err)
+	}
+
+	return nil
+}
+
+// completeShardRound records that a shard pushed metrics and reports whether a merged point is due:
+// either every running shard pushed since the last merged point, or this shard pushes again before
+// the others did, in which case it starts the next round
+func (o *Orchestrator) completeShardRound(run *domain.LoadTestRun, shardID string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	round := o.shardRounds[run.ID]
+	if round[shardID] {
+		o.shardRounds[run.ID] = map[string]bool{shardID: true}
+		return true
+	}
+	if round == nil {
+		round = make(map[string]bool)
+		o.shardRounds[run.ID] = round
+	}
+	round[shardID] = true
+
+	for _, shard := range run.Shards {
+		if shard.Status == domain.LoadTestRunStatusRunning && !round[shard.ClusterID] {
+			return false
+		}
+	}
+
+	delete(o.shardRounds, run.ID)
+	return true
+}
+
+// clearShardRounds forgets which shards of a run pushed metrics since its last merged point
+func (o *Orchestrator) clearShardRounds(runID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.shardRounds, runID)
+}
+
+// handleShardStop records that one shard of a distributed run stopped
+// The run ends once all of its shards stopped: Finished if they all ran for the run's duration, Stopped otherwise
+// Callers must hold the run lock
+func (o *Orchestrator) handleShardStop(run *domain.LoadTestRun, shardID string, finalMetrics *domain.MetricSnapshot, autoStopped bool) error {
+	shard := run.Shard(shardID)
+	if shard == nil {
+		return fmt.Errorf("test run %s has no shard %q", run.ID, shardID)
+	}
+
+	nowMillis := time.Now().UnixMilli()
+	if finalMetrics != nil {
+		finalMetrics.SetInterval(shard.LastMetrics, run.StartedAt)
+		o.addPendingInterval(run, finalMetrics)
+		shard.LastMetrics = finalMetrics
+		run.LastMetrics = run.MergedShardMetrics()
+	}
+	if !shard.Status.IsTerminal() {
+		shard.Status = domain.LoadTestRunStatusStopped
+		if autoStopped {
+			shard.Status = domain.LoadTestRunStatusFinished
+		}
+		shard.FinishedAt = nowMillis
+	}
+	run.UpdatedAt = nowMillis
+
+	log.Printf("[Orchestrator] Shard %s of test run %s is %s", shardID, run.ID, shard.Status)
+
+	remaining := 0
+	finished := true
+	for _, other := range run.Shards {
+		if !other.Status.IsTerminal() {
+			remaining++
+		}
+		if other.Status != domain.LoadTestRunStatusFinished {
+			finished = false
+		}
+	}
+
+	// The last shard to stop closes the run's timeseries with what the shards pushed since its last merged point
+	closed := false
+	if remaining == 0 && run.PendingInterval != nil && !run.PendingInterval.Empty() {
+		o.storeMergedMetrics(run)
+		closed = true
+	}
+
+	// The control plane may already have finalized the run, in which case its verdict is judged again with
+	// the closing point, or other shards are still running
+	if run.Status.IsTerminal() || remaining > 0 {
+		if run.Status.IsTerminal() && closed {
+			o.evaluateVerdict(run)
+		}
+		if err := o.loadTestRunStore.Update(run); err != nil {
+			return fmt.Errorf("failed to update test run: %w", err)
+		}
+		return nil
+	}
+
+	if finished {
+		return o.finalizeRun(run, domain.LoadTestRunStatusFinished, domain.RunActorLocust, "duration elapsed on every shard")
+	}
+	return o.finalizeRun(run, domain.LoadTestRunStatusStopped, domain.RunActorLocust, "every shard stopped in Locust")
+}
+
+// addPendingInterval accumulates a shard snapshot's interval until the run's next merged point
+func (o *Orchestrator) addPendingInterval(run *domain.LoadTestRun, metrics *domain.MetricSnapshot) {
+	if run.PendingInterval == nil {
+		run.PendingInterval = domain.NewPendingInterval(run.StartedAt)
+	}
+	run.PendingInterval.Add(metrics)
+}
+
+// storeMergedMetrics adds the run's merged metrics to its timeseries with the shard intervals accumulated
+// since the previous merged point, and starts accumulating the next ones
+// Callers must hold the run lock
+func (o *Orchestrator) storeMergedMetrics(run *domain.LoadTestRun) {
+	if run.LastMetrics == nil {
+		return
+	}
+	if run.PendingInterval == nil {
+		run.PendingInterval = domain.NewPendingInterval(run.StartedAt)
+	}
+	run.PendingInterval.Apply(run.LastMetrics)
+	run.PendingInterval = domain.NewPendingInterval(run.LastMetrics.Timestamp)
+
+	if o.metricsStore == nil {
+		return
+	}
+
+	storeCtx, storeCancel := context.WithTimeout(o.ctx, 5*time.Second)
+	defer storeCancel()
+
+	if err := o.metricsStore.StoreMetric(storeCtx, run.ID, run.AccountID, run.OrgID, run.ProjectID, run.EnvID, run.LastMetrics); err != nil {
+		log.Printf("Warning: failed to store merged metrics for run %s: %v", run.ID, err)
+	}
+}
+
+// finalizeShards moves the shards still generating load to the run's terminal status
+func finalizeShards(run *domain.LoadTestRun, status domain.LoadTestRunStatus, at int64) {
+	for i := range run.Shards {
+		if run.Shards[i].Status.IsTerminal() {
+			continue
+		}
+		run.Shards[i].Status = status
+		run.Shards[i].FinishedAt = at
+	}
+}
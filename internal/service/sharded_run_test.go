@@ -0,0 +1,272 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// launchShardedRun launches a run of load test "test-1" split evenly across the given clusters
+func launchShardedRun(o *Orchestrator, clusterIDs ...string) (*domain.LoadTestRun, error) {
+	loadTest, err := o.loadTestStore.Get("test-1")
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]domain.ShardSpec, len(clusterIDs))
+	for i, clusterID := range clusterIDs {
+		specs[i] = domain.ShardSpec{ClusterID: clusterID, Weight: 1}
+	}
+	return o.LaunchLoadTestRun(loadTest, &LaunchRunRequest{Shards: specs, CreatedBy: "tester"})
+}
+
+func TestLaunchShardedRunRejectsBusyClusterWithoutCreatingRun(t *testing.T) {
+	first := newTestMaster(t, locusttest.Options{})
+	second := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, first, second)
+
+	busy, err := o.CreateTestRun(createPendingRun(t, o, "run-1"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	idle := "cluster-1"
+	if busy.ClusterID == idle {
+		idle = "cluster-2"
+	}
+
+	if _, err := launchShardedRun(o, "cluster-1", "cluster-2"); !errors.Is(err, ErrShardClustersBusy) {
+		t.Fatalf("LaunchLoadTestRun error = %v, want %v", err, ErrShardClustersBusy)
+	}
+
+	runs, err := o.loadTestRunStore.List(nil)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != "run-1" {
+		t.Errorf("stored runs = %d, want only run-1", len(runs))
+	}
+	for _, master := range []*locusttest.Master{first, second} {
+		if len(master.Calls(locusttest.EndpointLoadScript)) > 1 {
+			t.Error("a shard was prepared for the rejected run")
+		}
+	}
+
+	// The idle cluster was not left reserved: the next run starts on it instead of queueing
+	next, err := o.CreateTestRun(createPendingRun(t, o, "run-2"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if next.Status != domain.LoadTestRunStatusRunning || next.ClusterID != idle {
+		t.Errorf("next run is %s on %s, want Running on %s", next.Status, next.ClusterID, idle)
+	}
+}
+
+// launchTwoShardRun launches a run split evenly across cluster-1 and cluster-2 of the orchestrator
+func launchTwoShardRun(t *testing.T, o *Orchestrator) *domain.LoadTestRun {
+	t.Helper()
+
+	run, err := launchShardedRun(o, "cluster-1", "cluster-2")
+	if err != nil {
+		t.Fatalf("LaunchLoadTestRun: %v", err)
+	}
+	return run
+}
+
+func TestShardedRunStartsEveryShard(t *testing.T) {
+	first := newTestMaster(t, locusttest.Options{})
+	second := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, first, second)
+
+	run := launchTwoShardRun(t, o)
+
+	if run.Status != domain.LoadTestRunStatusRunning || run.TargetUsers != 10 {
+		t.Errorf("run is %s with %d users, want Running with 10", run.Status, run.TargetUsers)
+	}
+	for i, master := range []*locusttest.Master{first, second} {
+		shard := run.Shards[i]
+		if shard.Status != domain.LoadTestRunStatusRunning || shard.StartedAt == 0 {
+			t.Errorf("shard %s is %s, want Running", shard.ClusterID, shard.Status)
+		}
+		if users, spawnRate := master.Users(); users != 5 || spawnRate != 1 {
+			t.Errorf("shard %s swarm users = %d at %g/s, want 5 at 1/s", shard.ClusterID, users, spawnRate)
+		}
+		if runCtx := master.RunContext(); runCtx.RunID != run.ID || runCtx.ShardID != shard.ClusterID {
+			t.Errorf("shard %s run context = %+v, want run %s as shard %s", shard.ClusterID, runCtx, run.ID, shard.ClusterID)
+		}
+	}
+
+	// Both clusters are held: a regular run has to wait
+	queued, err := o.CreateTestRun(createPendingRun(t, o, "run-2"))
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+	if queued.Status != domain.LoadTestRunStatusPending {
+		t.Errorf("next run status = %s, want %s", queued.Status, domain.LoadTestRunStatusPending)
+	}
+}
+
+func TestShardedRunFailsWhenAShardFailsToStart(t *testing.T) {
+	first := newTestMaster(t, locusttest.Options{})
+	second := newTestMaster(t, locusttest.Options{})
+	second.InjectFault(locusttest.EndpointSwarm, locusttest.Fault{Status: http.StatusInternalServerError})
+	o := newTestOrchestrator(t, first, second)
+
+	run, err := launchShardedRun(o, "cluster-1", "cluster-2")
+	if !errors.Is(err, ErrRunNotStarted) {
+		t.Fatalf("LaunchLoadTestRun error = %v, want %v", err, ErrRunNotStarted)
+	}
+
+	run, err = o.GetTestRun(run.ID)
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusFailed {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusFailed)
+	}
+	if shard := run.Shard("cluster-2"); shard == nil || shard.Error == "" {
+		t.Errorf("shard cluster-2 = %+v, want its start error", shard)
+	}
+
+	// The shard that did start was stopped, and both clusters were released
+	if state := first.State(); state != locusttest.StateStopped {
+		t.Errorf("cluster-1 master state = %s, want %s", state, locusttest.StateStopped)
+	}
+	o.mu.Lock()
+	held := len(o.clusterHolders)
+	o.mu.Unlock()
+	if held != 0 {
+		t.Errorf("held clusters = %d, want 0", held)
+	}
+}
+
+func TestStopShardedRunStopsEveryShard(t *testing.T) {
+	first := newTestMaster(t, locusttest.Options{})
+	second := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, first, second)
+
+	run := launchTwoShardRun(t, o)
+	if err := o.StopTestRun(run.ID, "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+
+	run, err := o.GetTestRun(run.ID)
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusStopped {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusStopped)
+	}
+	for i, master := range []*locusttest.Master{first, second} {
+		if state := master.State(); state != locusttest.StateStopped {
+			t.Errorf("shard %s master state = %s, want %s", run.Shards[i].ClusterID, state, locusttest.StateStopped)
+		}
+		if !run.Shards[i].Status.IsTerminal() {
+			t.Errorf("shard %s is %s, want it terminal", run.Shards[i].ClusterID, run.Shards[i].Status)
+		}
+	}
+
+	o.mu.Lock()
+	held := len(o.clusterHolders)
+	o.mu.Unlock()
+	if held != 0 {
+		t.Errorf("held clusters = %d, want 0", held)
+	}
+}
+
+func TestShardMetricsMergeIntoRunTimeseries(t *testing.T) {
+	o := newTestOrchestrator(t, newTestMaster(t, locusttest.Options{}), newTestMaster(t, locusttest.Options{}))
+	metrics := &memoryMetricsStore{}
+	o.metricsStore = metrics
+
+	run := launchTwoShardRun(t, o)
+	now := time.Now()
+
+	push := func(shardID string, at time.Time, requests int64, users int) {
+		t.Helper()
+		snapshot := &domain.MetricSnapshot{Timestamp: at.UnixMilli(), TotalRequests: requests, CurrentUsers: users}
+		if err := o.UpdateMetrics(run.ID, shardID, snapshot); err != nil {
+			t.Fatalf("UpdateMetrics %s: %v", shardID, err)
+		}
+	}
+
+	// A merged point is only added once every running shard pushed
+	push("cluster-1", now, 100, 5)
+	if docs := metrics.shardDocs(run.ID, ""); len(docs) != 0 {
+		t.Fatalf("merged points after one shard pushed = %d, want 0", len(docs))
+	}
+	push("cluster-2", now.Add(100*time.Millisecond), 300, 5)
+
+	docs := metrics.shardDocs(run.ID, "")
+	if len(docs) != 1 {
+		t.Fatalf("merged points = %d, want 1", len(docs))
+	}
+	if merged := docs[0]; merged.TotalRequests != 400 || merged.CurrentUsers != 10 {
+		t.Errorf("merged point has %d requests and %d users, want 400 and 10", merged.TotalRequests, merged.CurrentUsers)
+	}
+	for _, shardID := range []string{"cluster-1", "cluster-2"} {
+		if docs := metrics.shardDocs(run.ID, shardID); len(docs) != 1 {
+			t.Errorf("points of shard %s = %d, want 1", shardID, len(docs))
+		}
+	}
+
+	// A shard pushing twice before the other closes the round on its own
+	push("cluster-1", now.Add(time.Second), 200, 5)
+	push("cluster-1", now.Add(2*time.Second), 300, 5)
+	if docs := metrics.shardDocs(run.ID, ""); len(docs) != 2 {
+		t.Errorf("merged points = %d, want 2", len(docs))
+	}
+
+	stored, err := o.GetTestRun(run.ID)
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if stored.LastMetrics == nil || stored.LastMetrics.TotalRequests != 600 {
+		t.Errorf("run metrics = %+v, want the merge of both shards' latest 600 requests", stored.LastMetrics)
+	}
+}
+
+func TestShardStopsFinalizeRunOnceAllStopped(t *testing.T) {
+	tests := []struct {
+		name        string
+		autoStopped []bool
+		want        domain.LoadTestRunStatus
+	}{
+		{name: "every shard ran its duration", autoStopped: []bool{true, true}, want: domain.LoadTestRunStatusFinished},
+		{name: "a shard stopped early", autoStopped: []bool{true, false}, want: domain.LoadTestRunStatusStopped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestOrchestrator(t, newTestMaster(t, locusttest.Options{}), newTestMaster(t, locusttest.Options{}))
+			run := launchTwoShardRun(t, o)
+
+			if err := o.HandleTestStop(run.ID, "cluster-1", nil, tt.autoStopped[0]); err != nil {
+				t.Fatalf("HandleTestStop: %v", err)
+			}
+			stored, err := o.GetTestRun(run.ID)
+			if err != nil {
+				t.Fatalf("GetTestRun: %v", err)
+			}
+			if stored.Status != domain.LoadTestRunStatusRunning {
+				t.Fatalf("status with a shard left = %s, want %s", stored.Status, domain.LoadTestRunStatusRunning)
+			}
+			if shard := stored.Shard("cluster-1"); !shard.Status.IsTerminal() {
+				t.Errorf("stopped shard is %s, want it terminal", shard.Status)
+			}
+
+			if err := o.HandleTestStop(run.ID, "cluster-2", nil, tt.autoStopped[1]); err != nil {
+				t.Fatalf("HandleTestStop: %v", err)
+			}
+			stored, err = o.GetTestRun(run.ID)
+			if err != nil {
+				t.Fatalf("GetTestRun: %v", err)
+			}
+			if stored.Status != tt.want {
+				t.Errorf("status = %s, want %s", stored.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/store"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// evaluateVerdict evaluates the run's thresholds against its stored timeseries and records the verdict on the run
+func (o *Orchestrator) evaluateVerdict(run *domain.LoadTestRun) {
+	if len(run.Thresholds) == 0 || o.metricsStore == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(o.ctx, 15*time.Second)
+	defer cancel()
+
+	docs, err := o.metricsStore.GetMetricsTimeseries(ctx, run.ID, 0, 0)
+	if err != nil {
+		log.Printf("Warning: failed to load timeseries for verdict of run %s: %v", run.ID, err)
+		return
+	}
+
+	run.Verdict = EvaluateThresholds(run.Thresholds, docs)
+	log.Printf("[Orchestrator] Verdict for run %s: passed=%v (%d thresholds, %d snapshots)",
+		run.ID, run.Verdict.Passed, len(run.Thresholds), len(docs))
+}
+
+// EvaluateThresholds computes a verdict for a set of thresholds over a run's metric snapshots
+// A threshold without any data to evaluate fails
+func EvaluateThresholds(thresholds []domain.Threshold, docs []store.MetricsDocument) *domain.Verdict {
+	verdict := &domain.Verdict{
+		Passed:      true,
+		EvaluatedAt: time.Now().UnixMilli(),
+		Results:     make([]domain.ThresholdResult, 0, len(thresholds)),
+	}
+	windows := store.PointHistograms(docs)
+
+	for _, threshold := range thresholds {
+		result := domain.ThresholdResult{Threshold: threshold}
+
+		actual, samples := evaluateThreshold(&threshold, docs, windows)
+		result.Samples = samples
+
+		if samples == 0 {
+			result.Message = "no metrics available to evaluate threshold"
+		} else {
+			result.Actual = actual
+			result.Passed = threshold.Compare(result.Actual)
+			if !result.Passed {
+				result.Message = fmt.Sprintf("%s: actual %.2f", threshold.String(), result.Actual)
+			}
+		}
+
+		if !result.Passed {
+			verdict.Passed = false
+		}
+		verdict.Results = append(verdict.Results, result)
+	}
+
+	return verdict
+}
+
+// evaluateThreshold reduces a run's snapshots to the value a threshold is compared against
+// Returns the number of snapshots the value was computed from, 0 if there was no data
+func evaluateThreshold(threshold *domain.Threshold, docs []store.MetricsDocument, windows []*store.ResponseTimeHistograms) (float64, int) {
+	if fraction, ok := percentileFractions[threshold.Metric]; ok {
+		return percentileThreshold(threshold, fraction, docs, windows)
+	}
+	if threshold.Metric == domain.ThresholdMetricErrorRate || threshold.Metric == domain.ThresholdMetricRPS {
+		if actual, samples := rateThreshold(threshold, docs); samples > 0 {
+			return actual, samples
+		}
+	}
+
+	values := thresholdSeries(threshold, docs)
+	if len(values) == 0 {
+		return 0, 0
+	}
+	return aggregate(values, threshold.Aggregation), len(values)
+}
+
+// percentileFractions maps the percentile threshold metrics to the fraction of responses they measure
+var percentileFractions = map[domain.ThresholdMetric]float64{
+	domain.ThresholdMetricP50ResponseMs: 0.50,
+	domain.ThresholdMetricP95ResponseMs: 0.95,
+	domain.ThresholdMetricP99ResponseMs: 0.99,
+}
+
+// percentileThreshold evaluates a percentile threshold on the response time histograms of the snapshots
+// avg and last give the whole run's percentile, computed from the merged histograms; min and max the lowest or
+// highest percentile of the responses a single snapshot recorded. Snapshots without histograms fall back to the
+// percentiles they reported, which cover the test so far: the last one stands for the whole run
+func percentileThreshold(threshold *domain.Threshold, fraction float64, docs []store.MetricsDocument, windows []*store.ResponseTimeHistograms) (float64, int) {
+	merged := make(domain.Histogram)
+	var series []float64
+	for _, window := range windows {
+		histogram := window.Total
+		if threshold.Endpoint != "" {
+			histogram = endpointHistogram(threshold.Endpoint, window)
+		}
+		if histogram.Count() == 0 {
+			continue
+		}
+		merged.Merge(histogram)
+		series = append(series, histogram.Percentile(fraction))
+	}
+
+	if len(series) == 0 {
+		values := thresholdSeries(threshold, docs)
+		switch {
+		case len(values) == 0:
+			return 0, 0
+		case threshold.Aggregation == domain.ThresholdAggregationMin, threshold.Aggregation == domain.ThresholdAggregationMax:
+			return aggregate(values, threshold.Aggregation), len(values)
+		default:
+			return values[len(values)-1], len(values)
+		}
+	}
+
+	switch threshold.Aggregation {
+	case domain.ThresholdAggregationMin, domain.ThresholdAggregationMax:
+		return aggregate(series, threshold.Aggregation), len(series)
+	default:
+		return merged.Percentile(fraction), len(series)
+	}
+}
+
+// rateThreshold evaluates an error rate or RPS threshold on the snapshots' intervals, since their counters are cumulative
+// avg gives the whole run's rate from the summed intervals; min, max and last the rate over a single interval.
+// Intervals without requests carry no error rate and intervals of unknown length no RPS.
+// Returns 0 samples if the snapshots predate intervals, which then fall back to the rates they reported
+func rateThreshold(threshold *domain.Threshold, docs []store.MetricsDocument) (float64, int) {
+	var requests, failures, durationMs int64
+	var series []float64
+	for i := range docs {
+		doc := &docs[i]
+		if doc.Interval == nil {
+			continue
+		}
+
+		intervalRequests, intervalFailures := doc.Interval.Requests, doc.Interval.Failures
+		if threshold.Endpoint != "" {
+			stat := endpointStat(threshold.Endpoint, doc)
+			if stat == nil {
+				continue
+			}
+			intervalRequests, intervalFailures = stat.IntervalRequests, stat.IntervalFailures
+		}
+
+		switch threshold.Metric {
+		case domain.ThresholdMetricErrorRate:
+			if intervalRequests == 0 {
+				continue
+			}
+			series = append(series, float64(intervalFailures)/float64(intervalRequests)*100)
+			requests += intervalRequests
+			failures += intervalFailures
+		case domain.ThresholdMetricRPS:
+			intervalMs := doc.Interval.DurationMs()
+			if intervalMs == 0 {
+				continue
+			}
+			series = append(series, float64(intervalRequests)*1000/float64(intervalMs))
+			requests += intervalRequests
+			durationMs += intervalMs
+		}
+	}
+
+	if len(series) == 0 {
+		return 0, 0
+	}
+
+	switch threshold.Aggregation {
+	case domain.ThresholdAggregationMin, domain.ThresholdAggregationMax, domain.ThresholdAggregationLast:
+		return aggregate(series, threshold.Aggregation), len(series)
+	}
+	if threshold.Metric == domain.ThresholdMetricErrorRate {
+		return float64(failures) / float64(requests) * 100, len(series)
+	}
+	return float64(requests) * 1000 / float64(durationMs), len(series)
+}
+
+// endpointStat returns the stats of the endpoint a threshold is scoped to from a snapshot, nil if it has none
+func endpointStat(endpoint string, doc *store.MetricsDocument) *store.RequestStatDocument {
+	for i := range doc.RequestStats {
+		stat := &doc.RequestStats[i]
+		if stat.Name == endpoint || stat.Method+" "+stat.Name == endpoint {
+			return stat
+		}
+	}
+	return nil
+}
+
+// endpointHistogram returns the responses of a snapshot's window for the endpoint a threshold is scoped to,
+// merged across methods if the threshold names the endpoint without one; nil if the endpoint has none
+func endpointHistogram(endpoint string, window *store.ResponseTimeHistograms) domain.Histogram {
+	var histogram domain.Histogram
+	for key, candidate := range window.Endpoints {
+		method, name, _ := strings.Cut(key, ":")
+		if name != endpoint && method+" "+name != endpoint {
+			continue
+		}
+		if histogram == nil {
+			histogram = make(domain.Histogram)
+		}
+		histogram.Merge(candidate)
+	}
+	return histogram
+}
+
+// thresholdSeries extracts the values of a threshold's metric from every snapshot,
+// from the endpoint's request stats if the threshold is scoped to one
+func thresholdSeries(threshold *domain.Threshold, docs []store.MetricsDocument) []float64 {
+	values := make([]float64, 0, len(docs))
+
+	for _, doc := range docs {
+		if threshold.Endpoint == "" {
+			values = append(values, snapshotMetric(threshold.Metric, &doc))
+			continue
+		}
+
+		if stat := endpointStat(threshold.Endpoint, &doc); stat != nil {
+			if value, ok := endpointMetric(threshold.Metric, stat); ok {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// snapshotMetric returns a run-wide metric from a snapshot
+func snapshotMetric(metric domain.ThresholdMetric, doc *store.MetricsDocument) float64 {
+	switch metric {
+	case domain.ThresholdMetricP50ResponseMs:
+		return doc.P50ResponseMs
+	case domain.ThresholdMetricP95ResponseMs:
+		return doc.P95ResponseMs
+	case domain.ThresholdMetricP99ResponseMs:
+		return doc.P99ResponseMs
+	case domain.ThresholdMetricAvgResponseMs:
+		return doc.AvgResponseMs
+	case domain.ThresholdMetricErrorRate:
+		return doc.ErrorRate
+	case domain.ThresholdMetricRPS:
+		return doc.TotalRPS
+	}
+	return 0
+}
+
+// endpointMetric returns a metric from an endpoint's stats; false if the endpoint stats do not carry it
+func endpointMetric(metric domain.ThresholdMetric, stat *store.RequestStatDocument) (float64, bool) {
+	switch metric {
+	case domain.ThresholdMetricP50ResponseMs:
+		return stat.P50ResponseMs, true
+	case domain.ThresholdMetricP95ResponseMs:
+		return stat.P95ResponseMs, true
+	case domain.ThresholdMetricP99ResponseMs:
+		return stat.P99ResponseMs, true
+	case domain.ThresholdMetricAvgResponseMs:
+		return stat.AvgResponseTimeMs, true
+	case domain.ThresholdMetricErrorRate:
+		if stat.NumRequests == 0 {
+			return 0, true
+		}
+		return float64(stat.NumFailures) / float64(stat.NumRequests) * 100, true
+	case domain.ThresholdMetricRPS:
+		return stat.RequestsPerSec, true
+	}
+	return 0, false
+}
+
+// aggregate reduces a non-empty series to a single value
+func aggregate(values []float64, aggregation domain.ThresholdAggregation) float64 {
+	switch aggregation {
+	case domain.ThresholdAggregationMin:
+		min := math.Inf(1)
+		for _, v := range values {
+			min = math.Min(min, v)
+		}
+		return min
+	case domain.ThresholdAggregationMax:
+		max := math.Inf(-1)
+		for _, v := range values {
+			max = math.Max(max, v)
+		}
+		return max
+	case domain.ThresholdAggregationLast:
+		return values[len(values)-1]
+	default:
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	}
+}
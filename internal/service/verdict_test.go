@@ -0,0 +1,300 @@
+package service
+
+import (
+	"Load-manager-cli/internal/domain"
+	"Load-manager-cli/internal/locusttest"
+	"Load-manager-cli/internal/store"
+	"math"
+	"testing"
+)
+
+// intervalDocs returns two snapshots carrying intervals and interval histograms:
+// 100 requests over 10s without failures, then 100 requests over 5s with 10 failures
+func intervalDocs() []store.MetricsDocument {
+	return []store.MetricsDocument{
+		{
+			Interval: &store.IntervalDocument{StartMs: 0, EndMs: 10000, Requests: 100, ResponseTimes: domain.Histogram{10: 90, 100: 10}},
+			RequestStats: []store.RequestStatDocument{
+				{Method: "GET", Name: "/a", IntervalRequests: 100, IntervalResponseTimes: domain.Histogram{10: 90, 100: 10}},
+			},
+		},
+		{
+			Interval: &store.IntervalDocument{StartMs: 10000, EndMs: 15000, Requests: 100, Failures: 10, ResponseTimes: domain.Histogram{20: 50, 500: 50}},
+			RequestStats: []store.RequestStatDocument{
+				{Method: "GET", Name: "/a", IntervalRequests: 60, IntervalFailures: 10, IntervalResponseTimes: domain.Histogram{20: 50, 500: 10}},
+				{Method: "POST", Name: "/b", IntervalRequests: 40, IntervalResponseTimes: domain.Histogram{500: 40}},
+			},
+		},
+	}
+}
+
+// legacyDocs returns two snapshots stored before intervals and histograms, reporting values since the test started
+func legacyDocs() []store.MetricsDocument {
+	return []store.MetricsDocument{
+		{P50ResponseMs: 100, ErrorRate: 2, TotalRPS: 10},
+		{P50ResponseMs: 200, ErrorRate: 4, TotalRPS: 30},
+	}
+}
+
+func TestEvaluateThresholds(t *testing.T) {
+	tests := []struct {
+		name        string
+		threshold   domain.Threshold
+		docs        []store.MetricsDocument
+		wantActual  float64
+		wantSamples int
+	}{
+		{
+			// The whole run's median, not the average of the snapshots' medians (10 and 500)
+			name:        "percentile from merged histograms",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricP50ResponseMs},
+			docs:        intervalDocs(),
+			wantActual:  20,
+			wantSamples: 2,
+		},
+		{
+			name:        "lowest snapshot percentile",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricP50ResponseMs, Aggregation: domain.ThresholdAggregationMin},
+			docs:        intervalDocs(),
+			wantActual:  10,
+			wantSamples: 2,
+		},
+		{
+			name:        "highest snapshot percentile",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricP50ResponseMs, Aggregation: domain.ThresholdAggregationMax},
+			docs:        intervalDocs(),
+			wantActual:  500,
+			wantSamples: 2,
+		},
+		{
+			// Only GET /a's responses: the run's median including POST /b is 20
+			name:        "endpoint percentile",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricP50ResponseMs, Endpoint: "GET /a"},
+			docs:        intervalDocs(),
+			wantActual:  10,
+			wantSamples: 2,
+		},
+		{
+			name:        "error rate over the whole run",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricErrorRate},
+			docs:        intervalDocs(),
+			wantActual:  5,
+			wantSamples: 2,
+		},
+		{
+			name:        "highest interval error rate",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricErrorRate, Aggregation: domain.ThresholdAggregationMax},
+			docs:        intervalDocs(),
+			wantActual:  10,
+			wantSamples: 2,
+		},
+		{
+			name:        "endpoint error rate",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricErrorRate, Endpoint: "/a"},
+			docs:        intervalDocs(),
+			wantActual:  6.25,
+			wantSamples: 2,
+		},
+		{
+			name:        "RPS over the whole run",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricRPS},
+			docs:        intervalDocs(),
+			wantActual:  200.0 * 1000 / 15000,
+			wantSamples: 2,
+		},
+		{
+			name:        "lowest interval RPS",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricRPS, Aggregation: domain.ThresholdAggregationMin},
+			docs:        intervalDocs(),
+			wantActual:  10,
+			wantSamples: 2,
+		},
+		{
+			name:        "last interval RPS",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricRPS, Aggregation: domain.ThresholdAggregationLast},
+			docs:        intervalDocs(),
+			wantActual:  20,
+			wantSamples: 2,
+		},
+		{
+			// Reported percentiles cover the test so far: the last one stands for the whole run
+			name:        "legacy percentile",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricP50ResponseMs},
+			docs:        legacyDocs(),
+			wantActual:  200,
+			wantSamples: 2,
+		},
+		{
+			name:        "legacy lowest percentile",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricP50ResponseMs, Aggregation: domain.ThresholdAggregationMin},
+			docs:        legacyDocs(),
+			wantActual:  100,
+			wantSamples: 2,
+		},
+		{
+			name:        "legacy error rate",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricErrorRate},
+			docs:        legacyDocs(),
+			wantActual:  3,
+			wantSamples: 2,
+		},
+		{
+			name:        "legacy RPS",
+			threshold:   domain.Threshold{Metric: domain.ThresholdMetricRPS, Aggregation: domain.ThresholdAggregationMax},
+			docs:        legacyDocs(),
+			wantActual:  30,
+			wantSamples: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.threshold.Operator = domain.ThresholdOperatorLessOrEqual
+			tt.threshold.Value = tt.wantActual
+
+			verdict := EvaluateThresholds([]domain.Threshold{tt.threshold}, tt.docs)
+			result := verdict.Results[0]
+			if math.Abs(result.Actual-tt.wantActual) > 1e-9 {
+				t.Errorf("actual = %g, want %g", result.Actual, tt.wantActual)
+			}
+			if result.Samples != tt.wantSamples {
+				t.Errorf("samples = %d, want %d", result.Samples, tt.wantSamples)
+			}
+			if !result.Passed || !verdict.Passed {
+				t.Errorf("verdict failed: %s", result.Message)
+			}
+		})
+	}
+}
+
+func TestEvaluateThresholdsFailsWithoutData(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold domain.Threshold
+		docs      []store.MetricsDocument
+	}{
+		{
+			name:      "no snapshots",
+			threshold: domain.Threshold{Metric: domain.ThresholdMetricP95ResponseMs, Operator: domain.ThresholdOperatorLessThan, Value: 300},
+		},
+		{
+			name:      "endpoint never requested",
+			threshold: domain.Threshold{Metric: domain.ThresholdMetricErrorRate, Operator: domain.ThresholdOperatorLessThan, Value: 1, Endpoint: "/missing"},
+			docs:      intervalDocs(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := EvaluateThresholds([]domain.Threshold{tt.threshold}, tt.docs)
+			if verdict.Passed {
+				t.Error("verdict passed without data")
+			}
+			result := verdict.Results[0]
+			if result.Passed || result.Samples != 0 || result.Message != "no metrics available to evaluate threshold" {
+				t.Errorf("result = %+v, want a failure without samples", result)
+			}
+		})
+	}
+}
+
+func TestEvaluateThresholdsFailsOnAnyThreshold(t *testing.T) {
+	thresholds := []domain.Threshold{
+		{Metric: domain.ThresholdMetricErrorRate, Operator: domain.ThresholdOperatorLessThan, Value: 10},
+		{Metric: domain.ThresholdMetricP50ResponseMs, Operator: domain.ThresholdOperatorLessThan, Value: 15},
+	}
+
+	verdict := EvaluateThresholds(thresholds, intervalDocs())
+	if verdict.Passed {
+		t.Error("verdict passed with a failed threshold")
+	}
+	if !verdict.Results[0].Passed {
+		t.Errorf("error rate threshold failed: %s", verdict.Results[0].Message)
+	}
+	if result := verdict.Results[1]; result.Passed || result.Message != "avg(p50ResponseMs) < 15: actual 20.00" {
+		t.Errorf("p50 result = %+v, want a failure at 20", result)
+	}
+}
+
+func TestFinalMetricsReevaluateVerdictOfFinalizedRun(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+	o.metricsStore = &memoryMetricsStore{}
+
+	req := createPendingRun(t, o, "run-1")
+	run, _ := o.loadTestRunStore.Get("run-1")
+	run.Thresholds = []domain.Threshold{{Metric: domain.ThresholdMetricErrorRate, Operator: domain.ThresholdOperatorLessThan, Value: 5}}
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+	run, err := o.CreateTestRun(req)
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+
+	if err := o.UpdateMetrics("run-1", "", &domain.MetricSnapshot{Timestamp: run.StartedAt + 5000, TotalRequests: 100}); err != nil {
+		t.Fatalf("UpdateMetrics: %v", err)
+	}
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+	if run, _ := o.GetTestRun("run-1"); run.Verdict == nil || !run.Verdict.Passed {
+		t.Fatalf("verdict at stop = %+v, want passed", run.Verdict)
+	}
+
+	// The last interval fails 50 of its 100 requests: 25% over the whole run
+	final := &domain.MetricSnapshot{Timestamp: run.StartedAt + 10000, TotalRequests: 200, TotalFailures: 50}
+	if err := o.HandleTestStop("run-1", "", final, false); err != nil {
+		t.Fatalf("HandleTestStop: %v", err)
+	}
+
+	run, err = o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Status != domain.LoadTestRunStatusStopped {
+		t.Errorf("status = %s, want %s", run.Status, domain.LoadTestRunStatusStopped)
+	}
+	if run.Verdict == nil || run.Verdict.Passed {
+		t.Fatalf("verdict after final metrics = %+v, want failed", run.Verdict)
+	}
+	if actual := run.Verdict.Results[0].Actual; math.Abs(actual-25) > 1e-9 {
+		t.Errorf("error rate = %g, want 25", actual)
+	}
+}
+
+func TestVerdictJudgesPushedAverageResponseTime(t *testing.T) {
+	master := newTestMaster(t, locusttest.Options{})
+	o := newTestOrchestrator(t, master)
+	o.metricsStore = &memoryMetricsStore{}
+
+	req := createPendingRun(t, o, "run-1")
+	run, _ := o.loadTestRunStore.Get("run-1")
+	run.Thresholds = []domain.Threshold{{Metric: domain.ThresholdMetricAvgResponseMs, Operator: domain.ThresholdOperatorLessThan, Value: 200}}
+	if err := o.loadTestRunStore.Update(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+	run, err := o.CreateTestRun(req)
+	if err != nil {
+		t.Fatalf("CreateTestRun: %v", err)
+	}
+
+	// Locust pushes and polled stats only set AverageResponseMs, not its AvgResponseMs alias
+	if err := o.UpdateMetrics("run-1", "", &domain.MetricSnapshot{Timestamp: run.StartedAt + 5000, TotalRequests: 100, AverageResponseMs: 500}); err != nil {
+		t.Fatalf("UpdateMetrics: %v", err)
+	}
+	if err := o.StopTestRun("run-1", "tester"); err != nil {
+		t.Fatalf("StopTestRun: %v", err)
+	}
+
+	run, err = o.GetTestRun("run-1")
+	if err != nil {
+		t.Fatalf("GetTestRun: %v", err)
+	}
+	if run.Verdict == nil || run.Verdict.Passed {
+		t.Fatalf("verdict = %+v, want failed", run.Verdict)
+	}
+	if actual := run.Verdict.Results[0].Actual; actual != 500 {
+		t.Errorf("average response time = %g, want 500", actual)
+	}
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"Load-manager-cli/internal/domain"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ClusterRepository defines the interface for Locust cluster storage
+type ClusterRepository interface {
+	Create(cluster *domain.LocustCluster) error
+	Get(id string) (*domain.LocustCluster, error)
+	Update(cluster *domain.LocustCluster) error
+	UpdateHealth(id string, health *domain.ClusterHealth) error
+	Delete(id string) error
+	List() ([]*domain.LocustCluster, error)
+}
+
+// MongoClusterStore implements ClusterRepository using MongoDB
+type MongoClusterStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoClusterStore creates a new MongoDB-backed cluster store
+func NewMongoClusterStore(db *mongo.Database) (*MongoClusterStore, error) {
+	collection := db.Collection("clusters")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("id_unique_idx"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "accountId", Value: 1},
+				{Key: "orgId", Value: 1},
+				{Key: "projectId", Value: 1},
+			},
+			Options: options.Index().SetName("tenant_idx"),
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create cluster indexes: %w", err)
+	}
+
+	return &MongoClusterStore{collection: collection}, nil
+}
+
+// Create stores a new cluster
+func (s *MongoClusterStore) Create(cluster *domain.LocustCluster) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := s.collection.InsertOne(ctx, cluster); err != nil {
+		return fmt.Errorf("failed to create cluster: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a cluster by ID
+func (s *MongoClusterStore) Get(id string) (*domain.LocustCluster, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var cluster domain.LocustCluster
+	err := s.collection.FindOne(ctx, bson.M{"id": id}).Decode(&cluster)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("cluster not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	return &cluster, nil
+}
+
+// Update replaces an existing cluster
+func (s *MongoClusterStore) Update(cluster *domain.LocustCluster) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := s.collection.ReplaceOne(ctx, bson.M{"id": cluster.ID}, cluster)
+	if err != nil {
+		return fmt.Errorf("failed to update cluster: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("cluster not found: %s", cluster.ID)
+	}
+
+	return nil
+}
+
+// UpdateHealth records the latest health probe of a cluster without touching its configuration
+func (s *MongoClusterStore) UpdateHealth(id string, health *domain.ClusterHealth) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := s.collection.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": bson.M{"health": health}})
+	if err != nil {
+		return fmt.Errorf("failed to update cluster health: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("cluster not found: %s", id)
+	}
+
+	return nil
+}
+
+// Delete deletes a cluster by ID
+func (s *MongoClusterStore) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := s.collection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete cluster: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("cluster not found: %s", id)
+	}
+
+	return nil
+}
+
+// List retrieves all clusters ordered by ID
+func (s *MongoClusterStore) List() ([]*domain.LocustCluster, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+
+	cursor, err := s.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	clusters := []*domain.LocustCluster{}
+	if err := cursor.All(ctx, &clusters); err != nil {
+		return nil, fmt.Errorf("failed to decode clusters: %w", err)
+	}
+
+	return clusters, nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"Load-manager-cli/internal/domain"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FailureRepository defines the interface for storing the failures and exceptions of runs
+type FailureRepository interface {
+	// Record adds the cumulative failure groups and exceptions reported by a run, or by one of its shards
+	Record(runID, shardID string, failures []domain.FailureGroup, exceptions []domain.ExceptionGroup, at int64) error
+	// Get returns a run's failures, empty if none were reported
+	Get(runID string) (*domain.RunFailures, error)
+}
+
+// MongoFailureStore implements FailureRepository using MongoDB, with one document per run
+// Callers serialize the reports of a run, as the orchestrator does under its run lock
+type MongoFailureStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoFailureStore creates a new MongoDB-backed failure store
+func NewMongoFailureStore(db *mongo.Database) (*MongoFailureStore, error) {
+	collection := db.Collection("run_failures")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "runId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("run_id_unique_idx"),
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create failure indexes: %w", err)
+	}
+
+	return &MongoFailureStore{collection: collection}, nil
+}
+
+// Record adds a report to the run's failures
+func (s *MongoFailureStore) Record(runID, shardID string, failures []domain.FailureGroup, exceptions []domain.ExceptionGroup, at int64) error {
+	runFailures, err := s.Get(runID)
+	if err != nil {
+		return err
+	}
+	runFailures.Record(shardID, failures, exceptions, at)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := s.collection.ReplaceOne(ctx, bson.M{"runId": runID}, runFailures, opts); err != nil {
+		return fmt.Errorf("failed to store run failures: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a run's failures
+func (s *MongoFailureStore) Get(runID string) (*domain.RunFailures, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var runFailures domain.RunFailures
+	err := s.collection.FindOne(ctx, bson.M{"runId": runID}).Decode(&runFailures)
+	if err == mongo.ErrNoDocuments {
+		return &domain.RunFailures{RunID: runID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run failures: %w", err)
+	}
+
+	return &runFailures, nil
+}
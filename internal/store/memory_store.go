@@ -285,23 +285,24 @@ func copyLoadTest(test *domain.LoadTest) *domain.LoadTest {
 	}
 	
 	result := &domain.LoadTest{
-		ID:                 test.ID,
-		Name:               test.Name,
-		Description:        test.Description,
-		AccountID:          test.AccountID,
-		OrgID:              test.OrgID,
-		ProjectID:          test.ProjectID,
-		EnvID:              test.EnvID,
-		LocustClusterID:    test.LocustClusterID,
-		TargetURL:          test.TargetURL,
-		LatestRevisionID:   test.LatestRevisionID,
-		ScenarioID:         test.ScenarioID,
-		DefaultUsers:       test.DefaultUsers,
-		DefaultSpawnRate:   test.DefaultSpawnRate,
-		CreatedAt:          test.CreatedAt,
-		CreatedBy:          test.CreatedBy,
-		UpdatedAt:          test.UpdatedAt,
-		UpdatedBy:          test.UpdatedBy,
+		ID:               test.ID,
+		Name:             test.Name,
+		Description:      test.Description,
+		AccountID:        test.AccountID,
+		OrgID:            test.OrgID,
+		ProjectID:        test.ProjectID,
+		EnvID:            test.EnvID,
+		LocustClusterID:  test.LocustClusterID,
+		Engine:           test.Engine,
+		TargetURL:        test.TargetURL,
+		LatestRevisionID: test.LatestRevisionID,
+		ScenarioID:       test.ScenarioID,
+		DefaultUsers:     test.DefaultUsers,
+		DefaultSpawnRate: test.DefaultSpawnRate,
+		CreatedAt:        test.CreatedAt,
+		CreatedBy:        test.CreatedBy,
+		UpdatedAt:        test.UpdatedAt,
+		UpdatedBy:        test.UpdatedBy,
 	}
 	
 	if test.Tags != nil {
@@ -324,6 +325,10 @@ func copyLoadTest(test *domain.LoadTest) *domain.LoadTest {
 		result.MaxDurationSec = &val
 	}
 	
+	result.LoadProfile = copyLoadProfile(test.LoadProfile)
+	result.Thresholds = copyThresholds(test.Thresholds)
+	result.AbortRules = copyAbortRules(test.AbortRules)
+
 	if test.RecentRuns != nil {
 		result.RecentRuns = make([]domain.RecentRun, len(test.RecentRuns))
 		copy(result.RecentRuns, test.RecentRuns)
@@ -349,16 +354,24 @@ func copyLoadTestRun(run *domain.LoadTestRun) *domain.LoadTestRun {
 		ID:               run.ID,
 		LoadTestID:       run.LoadTestID,
 		ScriptRevisionID: run.ScriptRevisionID,
+		ScriptHash:       run.ScriptHash,
 		Name:             run.Name,
 		AccountID:        run.AccountID,
 		OrgID:            run.OrgID,
 		ProjectID:        run.ProjectID,
 		EnvID:            run.EnvID,
+		ClusterID:        run.ClusterID,
+		Engine:           run.Engine,
+		MetricsMode:      run.MetricsMode,
+		TargetURL:        run.TargetURL,
 		TargetUsers:      run.TargetUsers,
 		SpawnRate:        run.SpawnRate,
 		Status:           run.Status,
+		QueuedAt:         run.QueuedAt,
 		StartedAt:        run.StartedAt,
 		FinishedAt:       run.FinishedAt,
+		LastHeartbeatAt:  run.LastHeartbeatAt,
+		FailureReason:    run.FailureReason,
 		CreatedAt:        run.CreatedAt,
 		CreatedBy:        run.CreatedBy,
 		UpdatedAt:        run.UpdatedAt,
@@ -370,6 +383,50 @@ func copyLoadTestRun(run *domain.LoadTestRun) *domain.LoadTestRun {
 		result.DurationSeconds = &val
 	}
 	
+	result.LoadProfile = copyLoadProfile(run.LoadProfile)
+	result.CurrentStage = run.CurrentStage
+	result.ProfileOverride = run.ProfileOverride
+	result.Thresholds = copyThresholds(run.Thresholds)
+
+	if run.Verdict != nil {
+		verdict := *run.Verdict
+		verdict.Results = make([]domain.ThresholdResult, len(run.Verdict.Results))
+		copy(verdict.Results, run.Verdict.Results)
+		result.Verdict = &verdict
+	}
+
+	result.AbortRules = copyAbortRules(run.AbortRules)
+
+	if run.Timeline != nil {
+		result.Timeline = make([]domain.StatusTransition, len(run.Timeline))
+		copy(result.Timeline, run.Timeline)
+	}
+	if run.AbortedBy != nil {
+		abortedBy := *run.AbortedBy
+		result.AbortedBy = &abortedBy
+	}
+	if run.ClusterAttempts != nil {
+		result.ClusterAttempts = make([]domain.ClusterAttempt, len(run.ClusterAttempts))
+		copy(result.ClusterAttempts, run.ClusterAttempts)
+	}
+	if run.Shards != nil {
+		result.Shards = make([]domain.RunShard, len(run.Shards))
+		for i, shard := range run.Shards {
+			result.Shards[i] = shard
+			result.Shards[i].LastMetrics = copyMetricSnapshot(shard.LastMetrics)
+		}
+	}
+
+	if run.StageTransitions != nil {
+		result.StageTransitions = make([]domain.StageTransition, len(run.StageTransitions))
+		copy(result.StageTransitions, run.StageTransitions)
+	}
+
+	if run.LoadChanges != nil {
+		result.LoadChanges = make([]domain.LoadChange, len(run.LoadChanges))
+		copy(result.LoadChanges, run.LoadChanges)
+	}
+
 	if run.Metadata != nil {
 		result.Metadata = make(map[string]any)
 		for k, v := range run.Metadata {
@@ -381,6 +438,62 @@ func copyLoadTestRun(run *domain.LoadTestRun) *domain.LoadTestRun {
 		result.LastMetrics = copyMetricSnapshot(run.LastMetrics)
 	}
 	
+	result.PendingInterval = copyPendingInterval(run.PendingInterval)
+
+	return result
+}
+
+// copyPendingInterval creates a deep copy of a PendingInterval
+func copyPendingInterval(pending *domain.PendingInterval) *domain.PendingInterval {
+	if pending == nil {
+		return nil
+	}
+
+	result := *pending
+	result.ResponseTimes = pending.ResponseTimes.Clone()
+	result.Endpoints = make(map[string]*domain.EndpointInterval, len(pending.Endpoints))
+	for k, v := range pending.Endpoints {
+		endpoint := *v
+		endpoint.ResponseTimes = v.ResponseTimes.Clone()
+		result.Endpoints[k] = &endpoint
+	}
+	return &result
+}
+
+// copyLoadProfile creates a deep copy of a LoadProfile
+func copyLoadProfile(profile *domain.LoadProfile) *domain.LoadProfile {
+	if profile == nil {
+		return nil
+	}
+
+	result := &domain.LoadProfile{
+		Type:   profile.Type,
+		Stages: make([]domain.LoadStage, len(profile.Stages)),
+	}
+	copy(result.Stages, profile.Stages)
+
+	return result
+}
+
+// copyAbortRules creates a copy of an abort rule list
+func copyAbortRules(rules []domain.AbortRule) []domain.AbortRule {
+	if rules == nil {
+		return nil
+	}
+
+	result := make([]domain.AbortRule, len(rules))
+	copy(result, rules)
+	return result
+}
+
+// copyThresholds creates a copy of a threshold list
+func copyThresholds(thresholds []domain.Threshold) []domain.Threshold {
+	if thresholds == nil {
+		return nil
+	}
+
+	result := make([]domain.Threshold, len(thresholds))
+	copy(result, thresholds)
 	return result
 }
 
@@ -401,9 +514,25 @@ func copyMetricSnapshot(metrics *domain.MetricSnapshot) *domain.MetricSnapshot {
 		MaxResponseMs:     metrics.MaxResponseMs,
 		AvgResponseMs:     metrics.AvgResponseMs,
 		P50ResponseMs:     metrics.P50ResponseMs,
+		P90ResponseMs:     metrics.P90ResponseMs,
 		P95ResponseMs:     metrics.P95ResponseMs,
 		P99ResponseMs:     metrics.P99ResponseMs,
+		P999ResponseMs:    metrics.P999ResponseMs,
 		CurrentUsers:      metrics.CurrentUsers,
+		RunnerState:       metrics.RunnerState,
+		WorkerCount:       metrics.WorkerCount,
+		ResponseTimes:     metrics.ResponseTimes.Clone(),
+	}
+	if metrics.Interval != nil {
+		interval := *metrics.Interval
+		interval.ResponseTimes = metrics.Interval.ResponseTimes.Clone()
+		copy.Interval = &interval
+	}
+	if metrics.Failures != nil {
+		copy.Failures = append([]domain.FailureGroup(nil), metrics.Failures...)
+	}
+	if metrics.Exceptions != nil {
+		copy.Exceptions = append([]domain.ExceptionGroup(nil), metrics.Exceptions...)
 	}
 	
 	if metrics.RequestStats != nil {
@@ -411,20 +540,27 @@ func copyMetricSnapshot(metrics *domain.MetricSnapshot) *domain.MetricSnapshot {
 		for k, v := range metrics.RequestStats {
 			if v != nil {
 				copy.RequestStats[k] = &domain.ReqStat{
-					Method:             v.Method,
-					Name:               v.Name,
-					NumRequests:        v.NumRequests,
-					NumFailures:        v.NumFailures,
-					AvgResponseTime:    v.AvgResponseTime,
-					AvgResponseTimeMs:  v.AvgResponseTimeMs,
-					MinResponseTime:    v.MinResponseTime,
-					MinResponseTimeMs:  v.MinResponseTimeMs,
-					MaxResponseTime:    v.MaxResponseTime,
-					MaxResponseTimeMs:  v.MaxResponseTimeMs,
-					MedianResponseTime: v.MedianResponseTime,
-					P50ResponseMs:      v.P50ResponseMs,
-					P95ResponseMs:      v.P95ResponseMs,
-					RequestsPerSec:     v.RequestsPerSec,
+					Method:                v.Method,
+					Name:                  v.Name,
+					NumRequests:           v.NumRequests,
+					NumFailures:           v.NumFailures,
+					AvgResponseTime:       v.AvgResponseTime,
+					AvgResponseTimeMs:     v.AvgResponseTimeMs,
+					MinResponseTime:       v.MinResponseTime,
+					MinResponseTimeMs:     v.MinResponseTimeMs,
+					MaxResponseTime:       v.MaxResponseTime,
+					MaxResponseTimeMs:     v.MaxResponseTimeMs,
+					MedianResponseTime:    v.MedianResponseTime,
+					P50ResponseMs:         v.P50ResponseMs,
+					P90ResponseMs:         v.P90ResponseMs,
+					P95ResponseMs:         v.P95ResponseMs,
+					P99ResponseMs:         v.P99ResponseMs,
+					P999ResponseMs:        v.P999ResponseMs,
+					ResponseTimes:         v.ResponseTimes.Clone(),
+					RequestsPerSec:        v.RequestsPerSec,
+					IntervalRequests:      v.IntervalRequests,
+					IntervalFailures:      v.IntervalFailures,
+					IntervalResponseTimes: v.IntervalResponseTimes.Clone(),
 				}
 			}
 		}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"Load-manager-cli/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ResponseTimeHistograms holds the responses recorded over a series of metric points
+type ResponseTimeHistograms struct {
+	Total     domain.Histogram
+	Endpoints map[string]domain.Histogram // Keyed by "method:name"
+}
+
+// PointHistograms returns the responses each of a series of points recorded since the previous one, oldest first
+// Points store the histograms of their intervals. Points stored before that carry cumulative histograms instead,
+// which are diffed against the previous point's with Histogram.Since, so the first of them covers the test so far.
+// Total is nil for a point without histograms and endpoints without one are left out
+func PointHistograms(points []MetricsDocument) []*ResponseTimeHistograms {
+	histograms := make([]*ResponseTimeHistograms, len(points))
+
+	var previousTotal domain.Histogram
+	previousEndpoints := make(map[string]domain.Histogram)
+	for i, point := range points {
+		histograms[i] = &ResponseTimeHistograms{Endpoints: make(map[string]domain.Histogram)}
+
+		switch {
+		case point.Interval != nil && point.Interval.ResponseTimes != nil:
+			histograms[i].Total = point.Interval.ResponseTimes
+		case point.ResponseTimes != nil:
+			histograms[i].Total = point.ResponseTimes.Since(previousTotal)
+			previousTotal = point.ResponseTimes
+		}
+
+		for _, stat := range point.RequestStats {
+			key := stat.Method + ":" + stat.Name
+			switch {
+			case stat.IntervalResponseTimes != nil:
+				histograms[i].Endpoints[key] = stat.IntervalResponseTimes
+			case stat.ResponseTimes != nil:
+				histograms[i].Endpoints[key] = stat.ResponseTimes.Since(previousEndpoints[key])
+				previousEndpoints[key] = stat.ResponseTimes
+			}
+		}
+	}
+
+	return histograms
+}
+
+// FoldHistograms merges the responses recorded over a series of points, oldest first
+// Points without histograms are skipped
+func FoldHistograms(points []MetricsDocument) *ResponseTimeHistograms {
+	folded := &ResponseTimeHistograms{
+		Total:     make(domain.Histogram),
+		Endpoints: make(map[string]domain.Histogram),
+	}
+
+	for _, point := range PointHistograms(points) {
+		folded.Total.Merge(point.Total)
+		for key, histogram := range point.Endpoints {
+			if folded.Endpoints[key] == nil {
+				folded.Endpoints[key] = make(domain.Histogram)
+			}
+			folded.Endpoints[key].Merge(histogram)
+		}
+	}
+
+	return folded
+}
+
+// GetResponseTimeHistograms returns the responses recorded over a run's merged timeseries
+// Only the histograms of the points are fetched
+func (s *MongoMetricsStore) GetResponseTimeHistograms(ctx context.Context, loadTestRunID string) (*ResponseTimeHistograms, error) {
+	filter := bson.M{
+		"loadTestRunId": loadTestRunID,
+		"shardId":       shardFilter(""),
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}}).
+		SetProjection(bson.M{
+			"timestamp":                          1,
+			"responseTimes":                      1,
+			"interval.responseTimes":             1,
+			"requestStats.method":                1,
+			"requestStats.name":                  1,
+			"requestStats.responseTimes":         1,
+			"requestStats.intervalResponseTimes": 1,
+		})
+
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find metrics: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var points []MetricsDocument
+	if err := cursor.All(ctx, &points); err != nil {
+		return nil, fmt.Errorf("failed to decode metrics: %w", err)
+	}
+
+	return FoldHistograms(points), nil
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"Load-manager-cli/internal/domain"
+	"reflect"
+	"testing"
+)
+
+func TestPointHistograms(t *testing.T) {
+	points := []MetricsDocument{
+		// Legacy points: cumulative histograms only
+		{
+			ResponseTimes: domain.Histogram{10: 4},
+			RequestStats: []RequestStatDocument{
+				{Method: "GET", Name: "/a", ResponseTimes: domain.Histogram{10: 4}},
+			},
+		},
+		{
+			ResponseTimes: domain.Histogram{10: 6, 20: 3},
+			RequestStats: []RequestStatDocument{
+				{Method: "GET", Name: "/a", ResponseTimes: domain.Histogram{10: 6, 20: 3}},
+			},
+		},
+		// Point without histograms
+		{},
+		// Points with interval histograms
+		{
+			Interval: &IntervalDocument{ResponseTimes: domain.Histogram{30: 2}},
+			RequestStats: []RequestStatDocument{
+				{Method: "GET", Name: "/a", IntervalResponseTimes: domain.Histogram{30: 1}},
+				{Method: "POST", Name: "/a", IntervalResponseTimes: domain.Histogram{30: 1}},
+			},
+		},
+	}
+
+	want := []*ResponseTimeHistograms{
+		{Total: domain.Histogram{10: 4}, Endpoints: map[string]domain.Histogram{"GET:/a": {10: 4}}},
+		{Total: domain.Histogram{10: 2, 20: 3}, Endpoints: map[string]domain.Histogram{"GET:/a": {10: 2, 20: 3}}},
+		{Endpoints: map[string]domain.Histogram{}},
+		{Total: domain.Histogram{30: 2}, Endpoints: map[string]domain.Histogram{"GET:/a": {30: 1}, "POST:/a": {30: 1}}},
+	}
+
+	got := PointHistograms(points)
+	if len(got) != len(want) {
+		t.Fatalf("got %d points, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	folded := FoldHistograms(points)
+	if wantTotal := (domain.Histogram{10: 6, 20: 3, 30: 2}); !reflect.DeepEqual(folded.Total, wantTotal) {
+		t.Errorf("folded total = %v, want %v", folded.Total, wantTotal)
+	}
+	if wantEndpoint := (domain.Histogram{10: 6, 20: 3, 30: 1}); !reflect.DeepEqual(folded.Endpoints["GET:/a"], wantEndpoint) {
+		t.Errorf("folded GET:/a = %v, want %v", folded.Endpoints["GET:/a"], wantEndpoint)
+	}
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"Load-manager-cli/internal/domain"
@@ -17,39 +18,81 @@ const (
 
 // MetricsDocument represents a time-series metrics document
 type MetricsDocument struct {
-	Timestamp       time.Time              `bson:"timestamp"` // BSON DateTime for time-series collection
-	LoadTestRunID   string                 `bson:"loadTestRunId"`
-	AccountID       string                 `bson:"accountId"`
-	OrgID           string                 `bson:"orgId"`
-	ProjectID       string                 `bson:"projectId"`
-	EnvID           string                 `bson:"envId,omitempty"`
-	TotalRPS        float64                `bson:"totalRps"`
-	TotalRequests   int64                  `bson:"totalRequests"`
-	TotalFailures   int64                  `bson:"totalFailures"`
-	ErrorRate       float64                `bson:"errorRate"`
-	CurrentUsers    int                    `bson:"currentUsers"`
-	P50ResponseMs   float64                `bson:"p50ResponseMs"`
-	P95ResponseMs   float64                `bson:"p95ResponseMs"`
-	P99ResponseMs   float64                `bson:"p99ResponseMs"`
-	MinResponseMs   float64                `bson:"minResponseMs"`
-	MaxResponseMs   float64                `bson:"maxResponseMs"`
-	AvgResponseMs   float64                `bson:"avgResponseMs"`
-	RequestStats    []RequestStatDocument  `bson:"requestStats"`
-	Metadata        map[string]interface{} `bson:"metadata,omitempty"`
+	Timestamp      time.Time              `bson:"timestamp"` // BSON DateTime for time-series collection
+	LoadTestRunID  string                 `bson:"loadTestRunId"`
+	ShardID        string                 `bson:"shardId,omitempty"` // Set on a shard's own points of a distributed run
+	AccountID      string                 `bson:"accountId"`
+	OrgID          string                 `bson:"orgId"`
+	ProjectID      string                 `bson:"projectId"`
+	EnvID          string                 `bson:"envId,omitempty"`
+	TotalRPS       float64                `bson:"totalRps"`
+	TotalRequests  int64                  `bson:"totalRequests"`
+	TotalFailures  int64                  `bson:"totalFailures"`
+	ErrorRate      float64                `bson:"errorRate"`
+	CurrentUsers   int                    `bson:"currentUsers"`
+	P50ResponseMs  float64                `bson:"p50ResponseMs"`
+	P90ResponseMs  float64                `bson:"p90ResponseMs"`
+	P95ResponseMs  float64                `bson:"p95ResponseMs"`
+	P99ResponseMs  float64                `bson:"p99ResponseMs"`
+	P999ResponseMs float64                `bson:"p999ResponseMs"`
+	MinResponseMs  float64                `bson:"minResponseMs"`
+	MaxResponseMs  float64                `bson:"maxResponseMs"`
+	AvgResponseMs  float64                `bson:"avgResponseMs"`
+	ResponseTimes  domain.Histogram       `bson:"responseTimes,omitempty"` // Cumulative response time histogram; only on points stored before interval histograms were
+	Interval       *IntervalDocument      `bson:"interval,omitempty"`      // Requests since the previous point; missing on points stored before intervals were
+	RequestStats   []RequestStatDocument  `bson:"requestStats"`
+	Metadata       map[string]interface{} `bson:"metadata,omitempty"`
 }
 
 // RequestStatDocument represents per-endpoint stats
 type RequestStatDocument struct {
-	Method            string  `bson:"method"`
-	Name              string  `bson:"name"`
-	NumRequests       int64   `bson:"numRequests"`
-	NumFailures       int64   `bson:"numFailures"`
-	AvgResponseTimeMs float64 `bson:"avgResponseTimeMs"`
-	MinResponseTimeMs float64 `bson:"minResponseTimeMs"`
-	MaxResponseTimeMs float64 `bson:"maxResponseTimeMs"`
-	P50ResponseMs     float64 `bson:"p50ResponseMs"`
-	P95ResponseMs     float64 `bson:"p95ResponseMs"`
-	RequestsPerSec    float64 `bson:"requestsPerSec"`
+	Method            string           `bson:"method"`
+	Name              string           `bson:"name"`
+	NumRequests       int64            `bson:"numRequests"`
+	NumFailures       int64            `bson:"numFailures"`
+	AvgResponseTimeMs float64          `bson:"avgResponseTimeMs"`
+	MinResponseTimeMs float64          `bson:"minResponseTimeMs"`
+	MaxResponseTimeMs float64          `bson:"maxResponseTimeMs"`
+	P50ResponseMs     float64          `bson:"p50ResponseMs"`
+	P90ResponseMs     float64          `bson:"p90ResponseMs"`
+	P95ResponseMs     float64          `bson:"p95ResponseMs"`
+	P99ResponseMs     float64          `bson:"p99ResponseMs"`
+	P999ResponseMs    float64          `bson:"p999ResponseMs"`
+	ResponseTimes     domain.Histogram `bson:"responseTimes,omitempty"` // Cumulative response time histogram; only on points stored before interval histograms were
+	RequestsPerSec    float64          `bson:"requestsPerSec"`
+	IntervalRequests  int64            `bson:"intervalRequests,omitempty"` // Requests since the previous point, if the point has an interval
+	IntervalFailures  int64            `bson:"intervalFailures,omitempty"`
+	// Response times since the previous point, if the source reports response time histograms
+	IntervalResponseTimes domain.Histogram `bson:"intervalResponseTimes,omitempty"`
+}
+
+// IntervalDocument represents the requests a point adds to the previous one
+type IntervalDocument struct {
+	StartMs  int64 `bson:"startMs"` // Unix milliseconds
+	EndMs    int64 `bson:"endMs"`   // Unix milliseconds
+	Requests int64 `bson:"requests"`
+	Failures int64 `bson:"failures"`
+	Reset    bool  `bson:"reset,omitempty"`
+	// Response times since the previous point, if the source reports response time histograms
+	ResponseTimes domain.Histogram `bson:"responseTimes,omitempty"`
+}
+
+// DurationMs returns the length of the interval, 0 if its bounds are out of order
+func (d *IntervalDocument) DurationMs() int64 {
+	if d.EndMs <= d.StartMs {
+		return 0
+	}
+	return d.EndMs - d.StartMs
+}
+
+// MetricsRepository stores the metric snapshots of runs as a timeseries
+type MetricsRepository interface {
+	// StoreMetric stores a snapshot of a run
+	StoreMetric(ctx context.Context, loadTestRunID, accountID, orgID, projectID, envID string, metric *domain.MetricSnapshot) error
+	// StoreShardMetric stores a snapshot pushed by one shard of a distributed run
+	StoreShardMetric(ctx context.Context, loadTestRunID, shardID, accountID, orgID, projectID, envID string, metric *domain.MetricSnapshot) error
+	// GetMetricsTimeseries returns the snapshots of a run, not of its shards, oldest first
+	GetMetricsTimeseries(ctx context.Context, loadTestRunID string, fromTime, toTime int64) ([]MetricsDocument, error)
 }
 
 // MongoMetricsStore handles time-series metrics storage
@@ -141,61 +184,112 @@ func (s *MongoMetricsStore) createIndexes() error {
 	return nil
 }
 
-// StoreMetric stores a metric snapshot
+// StoreMetric stores a metric snapshot of a run
+// For distributed runs this is the merged snapshot of all shards
 func (s *MongoMetricsStore) StoreMetric(ctx context.Context, loadTestRunID, accountID, orgID, projectID, envID string, metric *domain.MetricSnapshot) error {
+	return s.storeMetric(ctx, loadTestRunID, "", accountID, orgID, projectID, envID, metric)
+}
+
+// StoreShardMetric stores a metric snapshot pushed by one shard of a distributed run
+// Shard points are kept apart from the run's merged timeseries for per-shard drill-down
+func (s *MongoMetricsStore) StoreShardMetric(ctx context.Context, loadTestRunID, shardID, accountID, orgID, projectID, envID string, metric *domain.MetricSnapshot) error {
+	return s.storeMetric(ctx, loadTestRunID, shardID, accountID, orgID, projectID, envID, metric)
+}
+
+// storeMetric stores a metric snapshot of a run, or of one of its shards if shardID is set
+func (s *MongoMetricsStore) storeMetric(ctx context.Context, loadTestRunID, shardID, accountID, orgID, projectID, envID string, metric *domain.MetricSnapshot) error {
+	doc := NewMetricsDocument(loadTestRunID, shardID, accountID, orgID, projectID, envID, metric)
+
+	_, err := s.collection.InsertOne(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("failed to insert metric: %w", err)
+	}
+
+	return nil
+}
+
+// NewMetricsDocument returns the document storing a metric snapshot of a run, or of one of its shards if shardID is set
+// Only the interval's response time histograms are stored: cumulative ones would grow every point with the run
+func NewMetricsDocument(loadTestRunID, shardID, accountID, orgID, projectID, envID string, metric *domain.MetricSnapshot) MetricsDocument {
 	// Convert Unix milliseconds to time.Time for MongoDB time-series collection
 	timestamp := time.UnixMilli(metric.Timestamp)
 	
 	doc := MetricsDocument{
-		Timestamp:     timestamp,
-		LoadTestRunID: loadTestRunID,
-		AccountID:     accountID,
-		OrgID:         orgID,
-		ProjectID:     projectID,
-		EnvID:         envID,
-		TotalRPS:      metric.TotalRPS,
-		TotalRequests: metric.TotalRequests,
-		TotalFailures: metric.TotalFailures,
-		ErrorRate:     metric.ErrorRate,
-		CurrentUsers:  metric.CurrentUsers,
-		P50ResponseMs: metric.P50ResponseMs,
-		P95ResponseMs: metric.P95ResponseMs,
-		P99ResponseMs: metric.P99ResponseMs,
-		MinResponseMs: metric.MinResponseMs,
-		MaxResponseMs: metric.MaxResponseMs,
-		AvgResponseMs: metric.AvgResponseMs,
-		RequestStats:  make([]RequestStatDocument, 0, len(metric.RequestStats)),
+		Timestamp:      timestamp,
+		LoadTestRunID:  loadTestRunID,
+		ShardID:        shardID,
+		AccountID:      accountID,
+		OrgID:          orgID,
+		ProjectID:      projectID,
+		EnvID:          envID,
+		TotalRPS:       metric.TotalRPS,
+		TotalRequests:  metric.TotalRequests,
+		TotalFailures:  metric.TotalFailures,
+		ErrorRate:      metric.ErrorRate,
+		CurrentUsers:   metric.CurrentUsers,
+		P50ResponseMs:  metric.P50ResponseMs,
+		P90ResponseMs:  metric.P90ResponseMs,
+		P95ResponseMs:  metric.P95ResponseMs,
+		P99ResponseMs:  metric.P99ResponseMs,
+		P999ResponseMs: metric.P999ResponseMs,
+		MinResponseMs:  metric.MinResponseMs,
+		MaxResponseMs:  metric.MaxResponseMs,
+		AvgResponseMs:  metric.AverageResponseMs, // The canonical average: only some sources set the AvgResponseMs alias
+		RequestStats:   make([]RequestStatDocument, 0, len(metric.RequestStats)),
+	}
+	if metric.Interval != nil {
+		doc.Interval = &IntervalDocument{
+			StartMs:       metric.Interval.StartMs,
+			EndMs:         metric.Interval.EndMs,
+			Requests:      metric.Interval.Requests,
+			Failures:      metric.Interval.Failures,
+			Reset:         metric.Interval.Reset,
+			ResponseTimes: metric.Interval.ResponseTimes,
+		}
 	}
 
 	for _, stat := range metric.RequestStats {
 		if stat != nil {
 			doc.RequestStats = append(doc.RequestStats, RequestStatDocument{
-				Method:            stat.Method,
-				Name:              stat.Name,
-				NumRequests:       stat.NumRequests,
-				NumFailures:       stat.NumFailures,
-				AvgResponseTimeMs: stat.AvgResponseTimeMs,
-				MinResponseTimeMs: stat.MinResponseTimeMs,
-				MaxResponseTimeMs: stat.MaxResponseTimeMs,
-				P50ResponseMs:     stat.P50ResponseMs,
-				P95ResponseMs:     stat.P95ResponseMs,
-				RequestsPerSec:    stat.RequestsPerSec,
+				Method:                stat.Method,
+				Name:                  stat.Name,
+				NumRequests:           stat.NumRequests,
+				NumFailures:           stat.NumFailures,
+				AvgResponseTimeMs:     stat.AvgResponseTimeMs,
+				MinResponseTimeMs:     stat.MinResponseTimeMs,
+				MaxResponseTimeMs:     stat.MaxResponseTimeMs,
+				P50ResponseMs:         stat.P50ResponseMs,
+				P90ResponseMs:         stat.P90ResponseMs,
+				P95ResponseMs:         stat.P95ResponseMs,
+				P99ResponseMs:         stat.P99ResponseMs,
+				P999ResponseMs:        stat.P999ResponseMs,
+				RequestsPerSec:        stat.RequestsPerSec,
+				IntervalRequests:      stat.IntervalRequests,
+				IntervalFailures:      stat.IntervalFailures,
+				IntervalResponseTimes: stat.IntervalResponseTimes,
 			})
 		}
 	}
 
-	_, err := s.collection.InsertOne(ctx, doc)
-	if err != nil {
-		return fmt.Errorf("failed to insert metric: %w", err)
-	}
-
-	return nil
+	return doc
 }
 
 // GetMetricsTimeseries retrieves time-series data for charts
+// Distributed runs return their merged timeseries
 func (s *MongoMetricsStore) GetMetricsTimeseries(ctx context.Context, loadTestRunID string, fromTime, toTime int64) ([]MetricsDocument, error) {
+	return s.findMetrics(ctx, loadTestRunID, "", fromTime, toTime)
+}
+
+// GetShardMetricsTimeseries retrieves the time-series data pushed by one shard of a distributed run
+func (s *MongoMetricsStore) GetShardMetricsTimeseries(ctx context.Context, loadTestRunID, shardID string, fromTime, toTime int64) ([]MetricsDocument, error) {
+	return s.findMetrics(ctx, loadTestRunID, shardID, fromTime, toTime)
+}
+
+// findMetrics retrieves the points of a run's timeseries, or of one of its shards if shardID is set
+func (s *MongoMetricsStore) findMetrics(ctx context.Context, loadTestRunID, shardID string, fromTime, toTime int64) ([]MetricsDocument, error) {
 	filter := bson.M{
 		"loadTestRunId": loadTestRunID,
+		"shardId":       shardFilter(shardID),
 	}
 
 	if fromTime > 0 || toTime > 0 {
@@ -226,21 +320,42 @@ func (s *MongoMetricsStore) GetMetricsTimeseries(ctx context.Context, loadTestRu
 }
 
 // GetAggregatedMetrics retrieves aggregated metrics for a test run
+// Totals and rates add up the intervals of the points, since their counters are cumulative. Runs whose
+// points predate intervals fall back to the counters of their last point and the rates Locust reported
 func (s *MongoMetricsStore) GetAggregatedMetrics(ctx context.Context, loadTestRunID string) (*AggregatedMetrics, error) {
+	intervalMs := bson.M{"$subtract": bson.A{"$interval.endMs", "$interval.startMs"}}
+	intervalRPS := bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{intervalMs, 0}},
+		bson.M{"$divide": bson.A{bson.M{"$multiply": bson.A{"$interval.requests", 1000}}, intervalMs}},
+		nil,
+	}}
+
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{"loadTestRunId": loadTestRunID}}},
+		{{Key: "$match", Value: bson.M{"loadTestRunId": loadTestRunID, "shardId": shardFilter("")}}},
+		{{Key: "$sort", Value: bson.D{{Key: "timestamp", Value: 1}}}},
 		{{Key: "$group", Value: bson.M{
-			"_id":           nil,
-			"avgRPS":        bson.M{"$avg": "$totalRps"},
-			"maxRPS":        bson.M{"$max": "$totalRps"},
-			"minRPS":        bson.M{"$min": "$totalRps"},
-			"avgP50":        bson.M{"$avg": "$p50ResponseMs"},
-			"avgP95":        bson.M{"$avg": "$p95ResponseMs"},
-			"avgP99":        bson.M{"$avg": "$p99ResponseMs"},
-			"maxP95":        bson.M{"$max": "$p95ResponseMs"},
-			"totalRequests": bson.M{"$sum": "$totalRequests"},
-			"totalFailures": bson.M{"$sum": "$totalFailures"},
-			"dataPoints":    bson.M{"$sum": 1},
+			"_id":              nil,
+			"avgRPS":           bson.M{"$avg": "$totalRps"},
+			"maxRPS":           bson.M{"$max": "$totalRps"},
+			"minRPS":           bson.M{"$min": "$totalRps"},
+			"avgP50":           bson.M{"$avg": "$p50ResponseMs"},
+			"avgP95":           bson.M{"$avg": "$p95ResponseMs"},
+			"avgP99":           bson.M{"$avg": "$p99ResponseMs"},
+			"maxP95":           bson.M{"$max": "$p95ResponseMs"},
+			"p50":              bson.M{"$last": "$p50ResponseMs"},
+			"p90":              bson.M{"$last": "$p90ResponseMs"},
+			"p95":              bson.M{"$last": "$p95ResponseMs"},
+			"p99":              bson.M{"$last": "$p99ResponseMs"},
+			"p999":             bson.M{"$last": "$p999ResponseMs"},
+			"totalRequests":    bson.M{"$last": "$totalRequests"},
+			"totalFailures":    bson.M{"$last": "$totalFailures"},
+			"intervalPoints":   bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{"$interval", false}}, 1, 0}}},
+			"intervalRequests": bson.M{"$sum": "$interval.requests"},
+			"intervalFailures": bson.M{"$sum": "$interval.failures"},
+			"intervalMs":       bson.M{"$sum": intervalMs},
+			"maxIntervalRPS":   bson.M{"$max": intervalRPS},
+			"minIntervalRPS":   bson.M{"$min": intervalRPS},
+			"dataPoints":       bson.M{"$sum": 1},
 		}}},
 	}
 
@@ -259,19 +374,67 @@ func (s *MongoMetricsStore) GetAggregatedMetrics(ctx context.Context, loadTestRu
 		return nil, fmt.Errorf("no metrics found for test run")
 	}
 
-	return &results[0], nil
+	aggregated := &results[0]
+	if aggregated.IntervalPoints > 0 {
+		aggregated.TotalRequests = aggregated.IntervalRequests
+		aggregated.TotalFailures = aggregated.IntervalFailures
+		if aggregated.IntervalMs > 0 {
+			aggregated.AvgRPS = float64(aggregated.IntervalRequests) * 1000 / float64(aggregated.IntervalMs)
+			aggregated.MaxRPS = aggregated.MaxIntervalRPS
+			aggregated.MinRPS = aggregated.MinIntervalRPS
+		}
+	}
+
+	// Averaging the percentiles of the points means nothing; the merged histograms give the real ones,
+	// and without histograms the last point's percentiles, which cover the test so far, are the closest
+	histograms, err := s.GetResponseTimeHistograms(ctx, loadTestRunID)
+	if err != nil {
+		log.Printf("[MongoStore] Failed to merge response time histograms of run %s: %v", loadTestRunID, err)
+	} else if histograms.Total.Count() > 0 {
+		aggregated.P50 = histograms.Total.Percentile(0.50)
+		aggregated.P90 = histograms.Total.Percentile(0.90)
+		aggregated.P95 = histograms.Total.Percentile(0.95)
+		aggregated.P99 = histograms.Total.Percentile(0.99)
+		aggregated.P999 = histograms.Total.Percentile(0.999)
+	}
+
+	return aggregated, nil
+}
+
+// shardFilter matches a shard's points, or the run's own points if shardID is empty
+func shardFilter(shardID string) interface{} {
+	if shardID == "" {
+		return bson.M{"$exists": false}
+	}
+	return shardID
 }
 
 // AggregatedMetrics holds aggregated statistics
+// P50 to P999 are the whole run's percentiles, from the merged response time histograms, or the last point's
+// if the points carry no histograms. MaxP95 is the highest percentile a point reported.
+// TotalRequests, TotalFailures and the RPS fields come from the points' intervals when they have some
 type AggregatedMetrics struct {
-	AvgRPS        float64 `bson:"avgRPS"`
-	MaxRPS        float64 `bson:"maxRPS"`
-	MinRPS        float64 `bson:"minRPS"`
+	AvgRPS float64 `bson:"avgRPS"`
+	MaxRPS float64 `bson:"maxRPS"`
+	MinRPS float64 `bson:"minRPS"`
+	// Deprecated: averages of the points' cumulative percentiles, which are no percentile of the run; use P50, P95 and P99
 	AvgP50        float64 `bson:"avgP50"`
-	AvgP95        float64 `bson:"avgP95"`
-	AvgP99        float64 `bson:"avgP99"`
+	AvgP95        float64 `bson:"avgP95"` // Deprecated: see AvgP50
+	AvgP99        float64 `bson:"avgP99"` // Deprecated: see AvgP50
 	MaxP95        float64 `bson:"maxP95"`
+	P50           float64 `bson:"p50"`
+	P90           float64 `bson:"p90"`
+	P95           float64 `bson:"p95"`
+	P99           float64 `bson:"p99"`
+	P999          float64 `bson:"p999"`
 	TotalRequests int64   `bson:"totalRequests"`
 	TotalFailures int64   `bson:"totalFailures"`
 	DataPoints    int     `bson:"dataPoints"`
+	// Sums over the points' intervals, from which the totals and rates above are derived
+	IntervalPoints   int     `bson:"intervalPoints"`
+	IntervalRequests int64   `bson:"intervalRequests"`
+	IntervalFailures int64   `bson:"intervalFailures"`
+	IntervalMs       int64   `bson:"intervalMs"`
+	MaxIntervalRPS   float64 `bson:"maxIntervalRPS"`
+	MinIntervalRPS   float64 `bson:"minIntervalRPS"`
 }
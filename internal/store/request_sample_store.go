@@ -0,0 +1,93 @@
+package store
+
+import (
+	"Load-manager-cli/internal/domain"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RequestSampleRepository defines the interface for storing the request samples of runs
+type RequestSampleRepository interface {
+	// Append adds samples to a run's log, dropping its oldest samples past domain.MaxRequestSamples
+	Append(runID string, samples []domain.RequestSample, sampleRate float64) error
+	// Get returns a run's request samples, empty if none were reported
+	Get(runID string) (*domain.RequestSampleLog, error)
+}
+
+// MongoRequestSampleStore implements RequestSampleRepository using MongoDB, with one capped document per run
+type MongoRequestSampleStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRequestSampleStore creates a new MongoDB-backed request sample store
+func NewMongoRequestSampleStore(db *mongo.Database) (*MongoRequestSampleStore, error) {
+	collection := db.Collection("request_samples")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "runId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("run_id_unique_idx"),
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create request sample indexes: %w", err)
+	}
+
+	return &MongoRequestSampleStore{collection: collection}, nil
+}
+
+// Append pushes samples onto the run's log in one atomic update, so shards can report concurrently
+func (s *MongoRequestSampleStore) Append(runID string, samples []domain.RequestSample, sampleRate float64) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$push": bson.M{
+			"samples": bson.M{
+				"$each":  samples,
+				"$slice": -domain.MaxRequestSamples,
+			},
+		},
+		"$inc": bson.M{"received": int64(len(samples))},
+		"$set": bson.M{
+			"sampleRate": sampleRate,
+			"updatedAt":  time.Now().UnixMilli(),
+		},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	if _, err := s.collection.UpdateOne(ctx, bson.M{"runId": runID}, update, opts); err != nil {
+		return fmt.Errorf("failed to store request samples: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a run's request samples
+func (s *MongoRequestSampleStore) Get(runID string) (*domain.RequestSampleLog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var log domain.RequestSampleLog
+	err := s.collection.FindOne(ctx, bson.M{"runId": runID}).Decode(&log)
+	if err == mongo.ErrNoDocuments {
+		return &domain.RequestSampleLog{RunID: runID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request samples: %w", err)
+	}
+
+	return &log, nil
+}
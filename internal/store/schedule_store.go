@@ -0,0 +1,167 @@
+package store
+
+import (
+	"Load-manager-cli/internal/domain"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ScheduleRepository defines the interface for schedule storage
+type ScheduleRepository interface {
+	Create(schedule *domain.Schedule) error
+	Get(id string) (*domain.Schedule, error)
+	Update(schedule *domain.Schedule) error
+	Delete(id string) error
+	ListByLoadTestID(loadTestID string) ([]*domain.Schedule, error)
+	ListDue(before int64) ([]*domain.Schedule, error)
+}
+
+// MongoScheduleStore implements ScheduleRepository using MongoDB
+type MongoScheduleStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoScheduleStore creates a new MongoDB-backed schedule store
+func NewMongoScheduleStore(db *mongo.Database) (*MongoScheduleStore, error) {
+	collection := db.Collection("schedules")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("id_unique_idx"),
+		},
+		{
+			Keys:    bson.D{{Key: "loadTestId", Value: 1}},
+			Options: options.Index().SetName("loadtest_idx"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "enabled", Value: 1},
+				{Key: "nextFireAt", Value: 1},
+			},
+			Options: options.Index().SetName("enabled_next_fire_idx"),
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create schedule indexes: %w", err)
+	}
+
+	return &MongoScheduleStore{collection: collection}, nil
+}
+
+// Create stores a new schedule
+func (s *MongoScheduleStore) Create(schedule *domain.Schedule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := s.collection.InsertOne(ctx, schedule); err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a schedule by ID
+func (s *MongoScheduleStore) Get(id string) (*domain.Schedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var schedule domain.Schedule
+	err := s.collection.FindOne(ctx, bson.M{"id": id}).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("schedule not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+// Update replaces an existing schedule
+func (s *MongoScheduleStore) Update(schedule *domain.Schedule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := s.collection.ReplaceOne(ctx, bson.M{"id": schedule.ID}, schedule)
+	if err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("schedule not found: %s", schedule.ID)
+	}
+
+	return nil
+}
+
+// Delete deletes a schedule by ID
+func (s *MongoScheduleStore) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := s.collection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("schedule not found: %s", id)
+	}
+
+	return nil
+}
+
+// ListByLoadTestID retrieves all schedules of a load test (oldest first)
+func (s *MongoScheduleStore) ListByLoadTestID(loadTestID string) ([]*domain.Schedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	cursor, err := s.collection.Find(ctx, bson.M{"loadTestId": loadTestID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	schedules := []*domain.Schedule{}
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, fmt.Errorf("failed to decode schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// ListDue retrieves enabled schedules whose next fire time is at or before the given Unix milliseconds
+func (s *MongoScheduleStore) ListDue(before int64) ([]*domain.Schedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"enabled":    true,
+		"nextFireAt": bson.M{"$gt": 0, "$lte": before},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "nextFireAt", Value: 1}})
+
+	cursor, err := s.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due schedules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var schedules []*domain.Schedule
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, fmt.Errorf("failed to decode schedules: %w", err)
+	}
+
+	return schedules, nil
+}
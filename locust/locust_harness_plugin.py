@@ -7,22 +7,37 @@ Users should NOT modify this file - it's automatically injected into test runs.
 Features:
 - Automatic test start/stop notifications to control plane
 - Real-time metrics pushing during test execution
+- Sampled request log: a fraction of individual requests, batched to the control plane
 - Duration-based auto-stop functionality
 - Dynamic run context management via custom web endpoints
+- Script revision delivery from the control plane (master and workers)
 
 Environment Variables Required:
 - CONTROL_PLANE_URL: URL of the control plane (e.g., http://localhost:8080)
 - CONTROL_PLANE_TOKEN: Authentication token for control plane API
 - METRICS_PUSH_INTERVAL: Interval in seconds for pushing metrics (default: 10)
+- REQUEST_SAMPLE_RATE: Fraction of requests sampled for the request log, 0 to disable (default: 0.01);
+  set it on workers too, since they are the ones sending requests
 """
 
 import os
+import sys
+import base64
+import hashlib
+import inspect
 import logging
+import tempfile
+import importlib.util
+import random
+import time
+from collections import deque
 import requests
 import gevent
 from typing import Optional
-from locust import events
+from locust import events, User
 from locust.env import Environment
+from locust.runners import MasterRunner, WorkerRunner, STATE_MISSING
+from locust.stats import StatsError
 
 # Setup logging
 logging.basicConfig(
@@ -31,19 +46,38 @@ logging.basicConfig(
 )
 logger = logging.getLogger(__name__)
 
+# Version of this plugin, reported to the control plane's cluster health checks
+PLUGIN_VERSION = "1.6.1"
+
 # Control plane configuration from environment variables
 CONTROL_PLANE_URL = os.getenv("CONTROL_PLANE_URL", "")
 CONTROL_PLANE_TOKEN = os.getenv("CONTROL_PLANE_TOKEN", "")
 METRICS_PUSH_INTERVAL = int(os.getenv("METRICS_PUSH_INTERVAL", "10"))
+REQUEST_SAMPLE_RATE = min(max(float(os.getenv("REQUEST_SAMPLE_RATE", "0.01")), 0.0), 1.0)
+SCRIPT_DIR = os.getenv("HARNESS_SCRIPT_DIR", tempfile.gettempdir())
 
 # Global state for current test run (set dynamically per test)
 _run_context = {
     "run_id": os.getenv("RUN_ID", ""),
+    "shard_id": os.getenv("SHARD_ID", ""),  # Set when this master drives one shard of a distributed run
     "tenant_id": os.getenv("TENANT_ID", ""),
     "env_id": os.getenv("ENV_ID", ""),
     "duration_seconds": os.getenv("DURATION_SECONDS", ""),
 }
 
+# Script revision currently loaded (set by the control plane via /controlplane/load-script)
+_loaded_script = {
+    "revision_id": "",
+    "sha256": "",
+    "workers": [],  # worker client_ids the master forwarded the revision to
+    "worker_acks": {},  # worker client_id -> sha256 reported by that worker ("" if it failed to load)
+}
+
+# Requests sampled since the last batch pushed to the control plane; the oldest are dropped when full.
+# Workers hand theirs to the master with each report, and the master pushes them with its metrics.
+MAX_BUFFERED_SAMPLES = 1000
+_request_samples: deque = deque(maxlen=MAX_BUFFERED_SAMPLES)
+
 # Global greenlet references
 _metrics_greenlet: Optional[gevent.Greenlet] = None
 _duration_monitor_greenlet: Optional[gevent.Greenlet] = None
@@ -68,8 +102,8 @@ def _is_control_plane_enabled():
 def on_test_start(environment: Environment, **kwargs):
     """Event handler called when a load test starts."""
     global _test_start_time
-    import time
     _test_start_time = time.time()
+    _request_samples.clear()
     
     if not _is_control_plane_enabled():
         logger.warning("Control plane integration not configured, skipping test_start callback")
@@ -81,6 +115,7 @@ def on_test_start(environment: Environment, **kwargs):
     try:
         payload = {
             "runId": run_id,
+            "shardId": _run_context.get("shard_id", ""),
             "tenantId": _run_context.get("tenant_id", ""),
             "envId": _run_context.get("env_id", ""),
         }
@@ -119,10 +154,12 @@ def on_test_stop(environment: Environment, **kwargs):
     logger.info(f"Test stopped ({stop_reason}), notifying control plane with final metrics (RUN_ID={run_id})")
     
     try:
+        _push_request_samples()
         final_metrics = _collect_metrics(environment)
         
         payload = {
             "runId": run_id,
+            "shardId": _run_context.get("shard_id", ""),
             "tenantId": _run_context.get("tenant_id", ""),
             "envId": _run_context.get("env_id", ""),
             "finalMetrics": final_metrics,
@@ -165,6 +202,73 @@ def on_test_stop(environment: Environment, **kwargs):
             _duration_monitor_greenlet = None
 
 
+# Response time percentiles pushed for the whole run and for each endpoint, as (fraction, payload field)
+PERCENTILES = [
+    (0.50, "p50ResponseMs"),
+    (0.90, "p90ResponseMs"),
+    (0.95, "p95ResponseMs"),
+    (0.99, "p99ResponseMs"),
+    (0.999, "p999ResponseMs"),
+]
+
+
+def _percentiles(stat) -> dict:
+    """Returns the response time percentiles of a stats entry, keyed by payload field."""
+    result = {field: 0.0 for _, field in PERCENTILES}
+    if stat is None or not stat.num_requests:
+        return result
+    try:
+        # Locust's get_response_time_percentile expects a single float, not a list
+        for fraction, field in PERCENTILES:
+            result[field] = float(stat.get_response_time_percentile(fraction) or 0)
+    except (TypeError, ValueError, AttributeError) as e:
+        logger.warning(f"Failed to get percentiles of {stat.name}: {e}")
+    return result
+
+
+def _response_times(stat) -> dict:
+    """Returns Locust's response time histogram of a stats entry: rounded response time (ms) -> count.
+
+    The control plane merges these across shards, endpoints and time windows for exact percentiles.
+    """
+    if stat is None or not getattr(stat, "response_times", None):
+        return {}
+    return {str(int(response_time)): int(count) for response_time, count in stat.response_times.items()}
+
+
+# Cap on the failure groups and exceptions pushed with each snapshot, as the control plane keeps per run
+MAX_FAILURE_GROUPS = 500
+
+
+def _failures(environment: Environment) -> list:
+    """Returns Locust's failures table: one group per endpoint and error, with cumulative occurrences."""
+    failures = []
+    for error in list(environment.stats.errors.values())[:MAX_FAILURE_GROUPS]:
+        failures.append({
+            "method": error.method or "",
+            "name": error.name or "",
+            "error": str(StatsError.parse_error(error.error)),
+            "occurrences": int(error.occurrences),
+        })
+    return failures
+
+
+def _exceptions(environment: Environment) -> list:
+    """Returns the exceptions raised in user code, grouped by traceback, with cumulative counts."""
+    runner = environment.runner
+    if runner is None or not getattr(runner, "exceptions", None):
+        return []
+    exceptions = []
+    for exception in list(runner.exceptions.values())[:MAX_FAILURE_GROUPS]:
+        exceptions.append({
+            "msg": str(exception.get("msg", "")),
+            "traceback": str(exception.get("traceback", "")),
+            "count": int(exception.get("count", 0)),
+            "nodes": sorted(str(node) for node in exception.get("nodes", ())),
+        })
+    return exceptions
+
+
 def _collect_metrics(environment: Environment) -> dict:
     """Collects current metrics from Locust environment."""
     stats = environment.stats
@@ -177,18 +281,8 @@ def _collect_metrics(environment: Environment) -> dict:
     
     error_rate = (total_failures / total_requests * 100) if total_requests > 0 else 0
     
-    # Get percentiles - call separately for each percentile value
-    p50 = 0.0
-    p95 = 0.0
-    p99 = 0.0
-    if stats.total:
-        try:
-            # Locust's get_response_time_percentile expects a single float, not a list
-            p50 = float(stats.total.get_response_time_percentile(0.50) or 0)
-            p95 = float(stats.total.get_response_time_percentile(0.95) or 0)
-            p99 = float(stats.total.get_response_time_percentile(0.99) or 0)
-        except (TypeError, ValueError, AttributeError) as e:
-            logger.warning(f"Failed to get percentiles: {e}")
+    # Real percentiles from Locust's response time histograms
+    total_percentiles = _percentiles(stats.total)
     
     # Build request stats as a map (dict) with key as "method:name"
     request_stats_map = {}
@@ -207,6 +301,8 @@ def _collect_metrics(environment: Environment) -> dict:
                 "maxResponseTime": float(stat.max_response_time) if stat.max_response_time else 0.0,
                 "medianResponseTime": float(stat.median_response_time) if hasattr(stat, 'median_response_time') and stat.median_response_time else 0.0,
                 "requestsPerSec": float(stat.current_rps) if hasattr(stat, 'current_rps') and stat.current_rps else 0.0,
+                **_percentiles(stat),
+                "responseTimes": _response_times(stat),
             }
     
     # Get average response time from total stats
@@ -225,10 +321,11 @@ def _collect_metrics(environment: Environment) -> dict:
         "currentUsers": int(current_users) if current_users else 0,
         "errorRate": float(error_rate) if error_rate else 0.0,
         "avgResponseMs": avg_response_time,  # Added missing field
-        "p50ResponseMs": float(p50) if p50 else 0.0,
-        "p95ResponseMs": float(p95) if p95 else 0.0,
-        "p99ResponseMs": float(p99) if p99 else 0.0,
+        **total_percentiles,
+        "responseTimes": _response_times(stats.total),
         "requestStats": request_stats_map,  # Map/dict, not array
+        "failures": _failures(environment),
+        "exceptions": _exceptions(environment),
     }
 
 
@@ -250,6 +347,7 @@ def _metrics_pusher(environment: Environment):
             
             payload = {
                 "runId": run_id,
+                "shardId": _run_context.get("shard_id", ""),
                 "metrics": metrics,
             }
             
@@ -265,6 +363,8 @@ def _metrics_pusher(environment: Environment):
             response.raise_for_status()
             
             logger.info(f"✓ Metrics pushed successfully (Status: {response.status_code})")
+            
+            _push_request_samples()
         
         except gevent.GreenletExit:
             logger.info("Metrics pusher greenlet killed")
@@ -273,6 +373,60 @@ def _metrics_pusher(environment: Environment):
             logger.error(f"Error pushing metrics to control plane: {e}", exc_info=True)
 
 
+@events.request.add_listener
+def on_request(request_type, name, response_time, response_length, response=None, exception=None, start_time=None, **kwargs):
+    """Samples individual requests for the control plane's request log, at REQUEST_SAMPLE_RATE."""
+    if REQUEST_SAMPLE_RATE <= 0 or random.random() >= REQUEST_SAMPLE_RATE:
+        return
+    response_time = float(response_time or 0)
+    if start_time is None:
+        start_time = time.time() - response_time / 1000
+    _request_samples.append({
+        "timestamp": int(start_time * 1000),
+        "method": request_type or "",
+        "name": name or "",
+        "statusCode": int(getattr(response, "status_code", 0) or 0),
+        "responseTimeMs": response_time,
+        "responseLength": int(response_length or 0),
+        "exception": str(StatsError.parse_error(exception)) if exception else "",
+    })
+
+
+@events.report_to_master.add_listener
+def on_report_to_master(client_id, data, **kwargs):
+    """Hands the requests a worker sampled to the master with its stats report."""
+    data["harness_request_samples"] = list(_request_samples)
+    _request_samples.clear()
+
+
+@events.worker_report.add_listener
+def on_worker_report(client_id, data, **kwargs):
+    """Collects the requests sampled by a worker, pushed with the master's next batch."""
+    _request_samples.extend(data.get("harness_request_samples", []))
+
+
+def _push_request_samples():
+    """Pushes the requests sampled since the previous batch to the control plane."""
+    if not _is_control_plane_enabled() or not _request_samples:
+        return
+    samples = list(_request_samples)
+    _request_samples.clear()
+    
+    payload = {
+        "runId": _run_context.get("run_id", ""),
+        "shardId": _run_context.get("shard_id", ""),
+        "sampleRate": REQUEST_SAMPLE_RATE,
+        "samples": samples,
+    }
+    try:
+        url = f"{CONTROL_PLANE_URL}/v1/internal/locust/requests"
+        response = requests.post(url, json=payload, headers=_control_plane_headers(), timeout=5)
+        response.raise_for_status()
+        logger.info(f"Pushed {len(samples)} request samples")
+    except Exception as e:
+        logger.error(f"Error pushing request samples to control plane: {e}")
+
+
 def _duration_monitor(environment: Environment):
     """Background task that monitors test duration and stops the test when duration elapses."""
     global _auto_stopped
@@ -322,6 +476,78 @@ def start_background_greenlets(environment: Environment, **kwargs):
     logger.info(f"Duration monitor greenlet spawned for run {run_id}")
 
 
+def _load_script(environment: Environment, revision_id: str, script_content: str) -> str:
+    """
+    Loads a base64 encoded locustfile revision and replaces the environment's user classes.
+    Returns the SHA-256 of the decoded script so the control plane can verify what was loaded.
+    """
+    source = base64.b64decode(script_content)
+    digest = hashlib.sha256(source).hexdigest()
+
+    module_name = "harness_locustfile_" + "".join(c if c.isalnum() else "_" for c in revision_id)
+    path = os.path.join(SCRIPT_DIR, f"{module_name}.py")
+    with open(path, "wb") as f:
+        f.write(source)
+
+    spec = importlib.util.spec_from_file_location(module_name, path)
+    module = importlib.util.module_from_spec(spec)
+    sys.modules[module_name] = module
+    spec.loader.exec_module(module)
+
+    user_classes = [
+        value for value in vars(module).values()
+        if inspect.isclass(value) and issubclass(value, User)
+        and value.__module__ == module_name
+        and not getattr(value, "abstract", False)
+    ]
+    if not user_classes:
+        raise ValueError(f"script revision {revision_id} does not define any User classes")
+
+    environment.user_classes = user_classes
+    environment.available_user_classes = {cls.__name__: cls for cls in user_classes}
+
+    _loaded_script["revision_id"] = revision_id
+    _loaded_script["sha256"] = digest
+    _loaded_script["workers"] = []
+    _loaded_script["worker_acks"] = {}
+
+    logger.info(f"Loaded script revision {revision_id} (sha256={digest}, users={[c.__name__ for c in user_classes]})")
+    return digest
+
+
+def _on_worker_load_script(environment: Environment, msg, **kwargs):
+    """Worker-side handler for a script revision forwarded by the master."""
+    revision_id = msg.data.get("revisionId", "")
+    try:
+        digest = _load_script(environment, revision_id, msg.data.get("scriptContent", ""))
+        environment.runner.send_message("harness_script_loaded", {"revisionId": revision_id, "sha256": digest})
+    except Exception as e:
+        logger.error(f"Failed to load script revision {revision_id} on worker: {e}")
+        environment.runner.send_message("harness_script_loaded", {"revisionId": revision_id, "error": str(e)})
+
+
+def _on_master_script_loaded(environment: Environment, msg, **kwargs):
+    """Master-side handler for workers acknowledging a script revision."""
+    if msg.data.get("revisionId") != _loaded_script["revision_id"]:
+        return
+    if msg.data.get("error"):
+        logger.error(f"Worker {msg.node_id} failed to load script: {msg.data['error']}")
+        _loaded_script["worker_acks"][msg.node_id] = ""
+        return
+    _loaded_script["worker_acks"][msg.node_id] = msg.data.get("sha256", "")
+    if msg.data.get("sha256") != _loaded_script["sha256"]:
+        logger.error(f"Worker {msg.node_id} loaded script with mismatching hash {msg.data.get('sha256')}")
+
+
+@events.init.add_listener
+def register_script_messages(environment: Environment, **kwargs):
+    """Registers runner messages used to forward script revisions from the master to workers."""
+    if isinstance(environment.runner, WorkerRunner):
+        environment.runner.register_message("harness_load_script", _on_worker_load_script)
+    elif isinstance(environment.runner, MasterRunner):
+        environment.runner.register_message("harness_script_loaded", _on_master_script_loaded)
+
+
 @events.init.add_listener
 def on_locust_init(environment: Environment, **kwargs):
     """
@@ -345,11 +571,13 @@ def on_locust_init(environment: Environment, **kwargs):
             data = request.get_json()
             
             _run_context["run_id"] = data.get("runId", "")
+            _run_context["shard_id"] = data.get("shardId", "")
             _run_context["tenant_id"] = data.get("tenantId", "")
             _run_context["env_id"] = data.get("envId", "")
             _run_context["duration_seconds"] = str(data.get("durationSeconds", ""))
             
             logger.info(f"Run context updated: runId={_run_context['run_id']}, "
+                       f"shardId={_run_context['shard_id']}, "
                        f"tenantId={_run_context['tenant_id']}, "
                        f"envId={_run_context['env_id']}, "
                        f"duration={_run_context['duration_seconds']}")
@@ -367,12 +595,57 @@ def on_locust_init(environment: Environment, **kwargs):
                 "error": str(e)
             }), 400
     
+    @environment.web_ui.app.route("/controlplane/load-script", methods=["POST"])
+    def load_script():
+        """
+        Custom endpoint to load a script revision before starting a test.
+        Called by the control plane orchestrator before /controlplane/set-context and /swarm.
+        The revision is loaded on the master and forwarded to all connected workers. Workers acknowledge
+        it asynchronously; the control plane polls /controlplane/get-context until every worker listed in
+        "workers" has an ack in "worker_acks".
+        """
+        try:
+            data = request.get_json()
+            revision_id = data.get("revisionId", "")
+            script_content = data.get("scriptContent", "")
+
+            digest = _load_script(environment, revision_id, script_content)
+
+            if isinstance(environment.runner, MasterRunner):
+                _loaded_script["workers"] = [
+                    client.id for client in environment.runner.clients.values()
+                    if client.state != STATE_MISSING
+                ]
+                environment.runner.send_message("harness_load_script", {
+                    "revisionId": revision_id,
+                    "scriptContent": script_content,
+                })
+
+            return jsonify({
+                "success": True,
+                "revisionId": revision_id,
+                "sha256": digest,
+                "workers": len(_loaded_script["workers"]),
+            }), 200
+
+        except Exception as e:
+            logger.error(f"Failed to load script: {e}")
+            return jsonify({
+                "success": False,
+                "error": str(e)
+            }), 400
+
     @environment.web_ui.app.route("/controlplane/get-context", methods=["GET"])
     def get_run_context():
-        """Get current run context (for debugging)."""
+        """
+        Get current run context and loaded script.
+        Called by the control plane on startup to reconcile runs that were in flight.
+        """
         return jsonify({
             "success": True,
-            "context": _run_context
+            "context": _run_context,
+            "script": _loaded_script,
+            "pluginVersion": PLUGIN_VERSION,
         }), 200
     
     logger.info("Harness Control Plane plugin initialized: custom endpoints registered")